@@ -0,0 +1,348 @@
+package sgl
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Animation is a function that takes a time delta and returns whether or
+// not the animation has completed.
+type Animation func(float32) bool
+
+// AnimationMap holds animations keyed by some name. Animations have no
+// state here; use an Animator to pause them, follow their progress, or be
+// told when they complete.
+type AnimationMap map[string]Animation
+
+// Update every animation in the map, deleting those that are completed.
+func (am AnimationMap) Update(dt float32) {
+	for name, ani := range am {
+		done := ani(dt)
+		if done {
+			delete(am, name)
+		}
+	}
+}
+
+// Has checks the animation map for an animation of the given name.
+func (am AnimationMap) Has(name string) bool {
+	_, has := am[name]
+	return has
+}
+
+// Cancel removes the animation, leaving the animated value as it is.
+func (am AnimationMap) Cancel(name string) {
+	delete(am, name)
+}
+
+// AnimationState is info about the progress of an animation in an
+// Animator. See Animator.State().
+type AnimationState struct {
+	Duration   float32 // seconds, or 0 if unknown
+	Elapsed    float32 // seconds, not counting time while paused
+	Paused     bool
+	OnComplete func() // optional, called when the animation completes
+}
+
+// animatorEntry is an animation in an Animator, along with its state.
+type animatorEntry struct {
+	animate Animation
+	state   AnimationState
+}
+
+// Animator holds animations keyed by some name, like an AnimationMap, and
+// keeps the state of each, so they can be paused and cancelled, their
+// progress followed, and something done when they complete.
+type Animator struct {
+	animations map[string]*animatorEntry
+}
+
+// NewAnimator creates an empty Animator.
+func NewAnimator() *Animator {
+	return &Animator{animations: make(map[string]*animatorEntry)}
+}
+
+// Update every animation in the animator, deleting those that are
+// completed. OnComplete is called after the animation is deleted, so it may
+// start another animation with the same name.
+func (an *Animator) Update(dt float32) {
+	for name, entry := range an.animations {
+		if entry.state.Paused {
+			continue
+		}
+		entry.state.Elapsed += dt
+		done := entry.animate(dt)
+		if done {
+			delete(an.animations, name)
+			if entry.state.OnComplete != nil {
+				entry.state.OnComplete()
+			}
+		}
+	}
+}
+
+// Add inserts an animation with "name", replacing any existing one with
+// the same name. durationSec is used only by Progress(), and may be 0 if
+// unknown. onComplete is optional.
+func (an *Animator) Add(name string, ani Animation, durationSec float32, onComplete ...func()) {
+	an.animations[name] = &animatorEntry{
+		animate: ani,
+		state: AnimationState{
+			Duration:   durationSec,
+			OnComplete: combineFuncs(onComplete),
+		},
+	}
+}
+
+// Has checks the animator for an animation of the given name.
+func (an *Animator) Has(name string) bool {
+	_, has := an.animations[name]
+	return has
+}
+
+// State gets the state of the animation with name. ok is false if there is
+// no such animation.
+func (an *Animator) State(name string) (state AnimationState, ok bool) {
+	entry, ok := an.animations[name]
+	if !ok {
+		return AnimationState{}, false
+	}
+	return entry.state, true
+}
+
+// Cancel removes the animation without calling its OnComplete, leaving the
+// animated value as it is.
+func (an *Animator) Cancel(name string) {
+	delete(an.animations, name)
+}
+
+// Pause stops the animation from updating until Resume() is called.
+func (an *Animator) Pause(name string) {
+	if entry, ok := an.animations[name]; ok {
+		entry.state.Paused = true
+	}
+}
+
+// Resume continues a paused animation.
+func (an *Animator) Resume(name string) {
+	if entry, ok := an.animations[name]; ok {
+		entry.state.Paused = false
+	}
+}
+
+// Progress gets the fraction (0 to 1) of the animation's duration that has
+// elapsed. Returns 0 if there is no such animation or its duration is unknown.
+func (an *Animator) Progress(name string) float32 {
+	entry, ok := an.animations[name]
+	if !ok || entry.state.Duration <= 0 {
+		return 0
+	}
+	return mgl32.Clamp(entry.state.Elapsed/entry.state.Duration, 0, 1)
+}
+
+// Lerpable is the set of types that can be animated by AnimateValue().
+type Lerpable interface {
+	float32 | mgl32.Vec2 | mgl32.Vec3 | mgl32.Vec4 | mgl32.Quat | mgl32.Mat4 | Color
+}
+
+// AnimateValue inserts a new animation with "name" into an which animates the
+// value from "from" to "to" over "durationSec" seconds. Quaternions are
+// interpolated with slerp, and everything else linearly. onComplete is optional.
+// (This isn't a method of Animator since methods can't have type parameters.)
+func AnimateValue[T Lerpable](an *Animator, name string, value *T, durationSec float32, from, to T, onComplete ...func()) {
+	an.Add(name, lerpAnimation(value, durationSec, from, to), durationSec, onComplete...)
+}
+
+// lerpAnimation makes an animation of value from "from" to "to" over
+// "durationSec" seconds.
+func lerpAnimation[T Lerpable](value *T, durationSec float32, from, to T) Animation {
+	var elapsed float32
+	return func(dt float32) (done bool) {
+		elapsed += dt
+		t := mgl32.Clamp(elapsed/durationSec, 0, 1)
+		*value = Lerp(t, from, to)
+		return elapsed > durationSec
+	}
+}
+
+// Lerp interpolates between from and to at point t (0 to 1). Quaternions
+// are interpolated with slerp, and everything else linearly.
+func Lerp[T Lerpable](t float32, from, to T) T {
+	var result interface{}
+	switch a := interface{}(from).(type) {
+	case float32:
+		result = lerp(t, a, interface{}(to).(float32))
+	case mgl32.Vec2:
+		b := interface{}(to).(mgl32.Vec2)
+		result = a.Add(b.Sub(a).Mul(t))
+	case mgl32.Vec3:
+		b := interface{}(to).(mgl32.Vec3)
+		result = a.Add(b.Sub(a).Mul(t))
+	case mgl32.Vec4:
+		b := interface{}(to).(mgl32.Vec4)
+		result = a.Add(b.Sub(a).Mul(t))
+	case mgl32.Quat:
+		result = mgl32.QuatSlerp(a, interface{}(to).(mgl32.Quat), t)
+	case mgl32.Mat4:
+		b := interface{}(to).(mgl32.Mat4)
+		result = a.Add(b.Sub(a).Mul(t))
+	case Color:
+		b := interface{}(to).(Color)
+		result = Color{
+			R: lerp(t, a.R, b.R),
+			G: lerp(t, a.G, b.G),
+			B: lerp(t, a.B, b.B),
+			A: lerp(t, a.A, b.A),
+		}
+	}
+	return result.(T)
+}
+
+// Float32 inserts a new animation with "name" which animates the value from "from" to "to" over
+// "durationSec" seconds.
+func (am AnimationMap) Float32(name string, value *float32, durationSec, from, to float32) {
+	am[name] = lerpAnimation(value, durationSec, from, to)
+}
+
+// Vec3f inserts a new animation with "name" which animates the value from "from" to "to" over
+// "durationSec" seconds.
+func (am AnimationMap) Vec3f(name string, value *mgl32.Vec3, durationSec float32, from, to mgl32.Vec3) {
+	am[name] = lerpAnimation(value, durationSec, from, to)
+}
+
+// Float32 inserts a new animation with "name" which animates the value from "from" to "to" over
+// "durationSec" seconds. onComplete is optional.
+func (an *Animator) Float32(name string, value *float32, durationSec, from, to float32, onComplete ...func()) {
+	AnimateValue(an, name, value, durationSec, from, to, onComplete...)
+}
+
+// Vec3f inserts a new animation with "name" which animates the value from "from" to "to" over
+// "durationSec" seconds. onComplete is optional.
+func (an *Animator) Vec3f(name string, value *mgl32.Vec3, durationSec float32, from, to mgl32.Vec3, onComplete ...func()) {
+	AnimateValue(an, name, value, durationSec, from, to, onComplete...)
+}
+
+// Springable is the set of types that can be animated by a Spring.
+type Springable interface {
+	float32 | mgl32.Vec2 | mgl32.Vec3 | mgl32.Vec4 | Color
+}
+
+// Spring moves Value toward Target with damped harmonic motion. Unlike
+// fixed-duration animations, Target may change at any time and the motion
+// stays smooth, which suits things like camera follow and UI "pop".
+type Spring[T Springable] struct {
+	Value     T
+	Target    T
+	Velocity  T
+	Stiffness float32 // strength of the pull toward Target
+	Damping   float32 // resistance to motion. 2*sqrt(Stiffness) is critically damped.
+}
+
+// NewSpring creates a spring at rest at value. Damping is set relative to
+// critical damping, so dampingRatio 1 reaches the target quickly without
+// overshooting, and smaller values oscillate (bounce) more.
+func NewSpring[T Springable](value T, stiffness, dampingRatio float32) *Spring[T] {
+	return &Spring[T]{
+		Value:     value,
+		Target:    value,
+		Stiffness: stiffness,
+		Damping:   dampingRatio * 2 * float32(math.Sqrt(float64(stiffness))),
+	}
+}
+
+// maximum time step for Spring integration, for stability with stiff springs.
+const maxSpringStep = 1.0 / 240
+
+// Update advances the spring by dt seconds.
+func (s *Spring[T]) Update(dt float32) {
+	x, v, target := toVec4(s.Value), toVec4(s.Velocity), toVec4(s.Target)
+	for dt > 0 {
+		step := dt
+		if step > maxSpringStep {
+			step = maxSpringStep
+		}
+		dt -= step
+
+		// semi-implicit euler
+		accel := target.Sub(x).Mul(s.Stiffness).Sub(v.Mul(s.Damping))
+		v = v.Add(accel.Mul(step))
+		x = x.Add(v.Mul(step))
+	}
+	s.Value, s.Velocity = fromVec4[T](x), fromVec4[T](v)
+}
+
+// AtRest returns true if the spring is within epsilon of its target and
+// moving slower than epsilon.
+func (s *Spring[T]) AtRest(epsilon float32) bool {
+	x, v, target := toVec4(s.Value), toVec4(s.Velocity), toVec4(s.Target)
+	return target.Sub(x).Len() < epsilon && v.Len() < epsilon
+}
+
+// AnimateSpring inserts an animation with "name" into an which updates the
+// spring and copies its Value to value. The animation completes when the
+// spring is at rest (see Spring.AtRest()) within epsilon. onComplete is optional.
+func AnimateSpring[T Springable](an *Animator, name string, value *T, spring *Spring[T], epsilon float32, onComplete ...func()) {
+	an.Add(name, func(dt float32) (done bool) {
+		spring.Update(dt)
+		if spring.AtRest(epsilon) {
+			spring.Value = spring.Target
+			spring.Velocity = *new(T)
+		}
+		*value = spring.Value
+		return spring.AtRest(epsilon)
+	}, 0, onComplete...)
+}
+
+// toVec4 converts any springable value to a Vec4, padding with zeros.
+func toVec4[T Springable](value T) mgl32.Vec4 {
+	switch v := interface{}(value).(type) {
+	case float32:
+		return mgl32.Vec4{v}
+	case mgl32.Vec2:
+		return v.Vec4(0, 0)
+	case mgl32.Vec3:
+		return v.Vec4(0)
+	case mgl32.Vec4:
+		return v
+	case Color:
+		return v.Vec4()
+	}
+	return mgl32.Vec4{}
+}
+
+// fromVec4 is the inverse of toVec4.
+func fromVec4[T Springable](v mgl32.Vec4) T {
+	var result interface{}
+	switch interface{}(*new(T)).(type) {
+	case float32:
+		result = v[0]
+	case mgl32.Vec2:
+		result = v.Vec2()
+	case mgl32.Vec3:
+		result = v.Vec3()
+	case mgl32.Vec4:
+		result = v
+	case Color:
+		result = Color{v[0], v[1], v[2], v[3]}
+	}
+	return result.(T)
+}
+
+// combineFuncs makes a single func calling each of fns, or nil if there are none.
+func combineFuncs(fns []func()) func() {
+	switch len(fns) {
+	case 0:
+		return nil
+	case 1:
+		return fns[0]
+	default:
+		return func() {
+			for _, fn := range fns {
+				if fn != nil {
+					fn()
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,146 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// App is a simple application driven by Window.Run().
+type App interface {
+	Init()             // called once before the first frame
+	Update(dt float64) // called each frame with the (scaled) time delta
+	Draw()             // called each frame after the buffers are cleared
+	GUI()              // called each frame to build the imgui interface, if the window uses imgui
+	Cleanup()          // called once after the loop ends, before the window is disposed
+}
+
+// Run runs the render loop for app until the window is closed, then disposes
+// the window. Cleanup() and Dispose() are called even if app panics, though
+// the panic is not recovered. An error is returned if OpenGL reports an error
+// after app.Init(). Draw() and GUI() are not called while the window is
+// minimized. A typical program:
+//
+//	sgl.Init()
+//	defer sgl.Destroy()
+//	win, err := sgl.NewWindow("app", sgl.WindowMetric{W: 800, H: 600}, sgl.UseImgui(nil))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := win.Run(&myApp{}); err != nil {
+//		log.Fatal(err)
+//	}
+func (platform *Window) Run(app App) error {
+	defer platform.Dispose()
+
+	app.Init()
+	defer app.Cleanup()
+	if err := CheckError(); err != nil {
+		return fmt.Errorf("opengl error during app init: %w", err)
+	}
+
+	platform.SetFrameFunc(func(dt float64) {
+		app.Update(dt)
+		if platform.Minimized() {
+			return
+		}
+		platform.ClearBuffers()
+		app.Draw()
+		if platform.CanUseGui() {
+			platform.RenderImgui(app.GUI)
+		}
+	})
+	platform.Loop()
+	return nil
+}
+
+// SetFrameFunc registers frame to be called once per frame by Loop() or
+// Frame(), with the (scaled) time delta. It should clear the buffers, draw,
+// and render imgui, but not begin or end the frame. It is still called while
+// the window is minimized, so it can update state, but should skip drawing.
+func (platform *Window) SetFrameFunc(frame func(dt float64)) {
+	platform.frameFunc = frame
+}
+
+// Loop runs frames with the function set by SetFrameFunc() until the window
+// is closed. It's the same as the loop:
+//
+//	platform.InitLoop()
+//	for platform.Frame() {
+//	}
+//
+// Platforms which must drive the loop themselves, such as a browser with
+// requestAnimationFrame, instead call InitLoop() once and then Frame() from
+// their callback until it returns false.
+func (platform *Window) Loop() {
+	platform.InitLoop()
+	for platform.Frame() {
+	}
+}
+
+// Frame runs a single frame: BeginFrame(), the function set by
+// SetFrameFunc(), and EndFrame(). Buffers aren't swapped while the window is
+// minimized. It returns false, without calling the frame function, once the
+// window should close.
+func (platform *Window) Frame() (continueRendering bool) {
+	if !platform.BeginFrame() {
+		return false
+	}
+	if platform.frameFunc != nil {
+		platform.frameFunc(platform.Clock.DeltaT)
+	}
+	if platform.Minimized() {
+		platform.inFrame = false // nothing to swap
+		return true
+	}
+	platform.EndFrame()
+	return true
+}
+
+// RunWithContext runs frame each frame, as with SetFrameFunc() and Loop(),
+// until the window is closed or ctx is cancelled, then disposes the window.
+// frame is still called while the window is minimized. It returns ctx.Err()
+// if ctx was cancelled. Destroy() should be called after it returns:
+//
+//	sgl.Init()
+//	defer sgl.Destroy()
+//	ctx, stop := sgl.InterruptContext(context.Background())
+//	defer stop()
+//	win, _ := sgl.NewWindow("daemon", sgl.WindowMetric{W: 800, H: 600})
+//	err := win.RunWithContext(ctx, draw)
+func (platform *Window) RunWithContext(ctx context.Context, frame func()) error {
+	// wake the loop, even if it's waiting for events
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-ctx.Done():
+			platform.GlfwWindow.SetShouldClose(true)
+			glfw.PostEmptyEvent()
+		case <-done:
+		}
+	}()
+
+	platform.SetFrameFunc(func(float64) { frame() })
+	platform.Loop()
+
+	// the window can't be destroyed while the goroutine may still use it
+	close(done)
+	<-exited
+	platform.Dispose()
+	return ctx.Err()
+}
+
+// InterruptContext gets a context which is cancelled on SIGINT (ctrl-c) or
+// SIGTERM, for use with RunWithContext(). stop should be deferred to restore
+// the default signal handling.
+func InterruptContext(parent context.Context) (ctx context.Context, stop context.CancelFunc) {
+	return signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+}
@@ -0,0 +1,123 @@
+package sgl
+
+import (
+	"embed"
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+
+	"golang.org/x/image/font/basicfont"
+)
+
+// the files in assets are compiled into the package.
+//
+//go:embed assets/*.png
+var defaultAssets embed.FS
+
+// DefaultAssets gets the files compiled into the package: white.png,
+// checker.png, and sky.png (a horizontal cross cubemap). The stock shaders'
+// sources are Go constants, so they aren't among them.
+func DefaultAssets() fs.FS {
+	sub, _ := fs.Sub(defaultAssets, "assets")
+	return sub
+}
+
+// shared defaults, created on first use.
+var (
+	defaultWhite   *Texture2D
+	defaultChecker *Texture2D
+	defaultSky     *Skybox
+	defaultFont    *CharacterDict
+)
+
+// loadDefaultImage decodes one of the DefaultAssets() images.
+func loadDefaultImage(name string) (*image.RGBA, error) {
+	file, err := DefaultAssets().Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not load default asset: %w", err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode default asset %s: %w", name, err)
+	}
+	return imageToRGBA(img), nil
+}
+
+// loadDefaultTexture creates a texture from one of the DefaultAssets()
+// images, if *tex isn't created already.
+func loadDefaultTexture(tex **Texture2D, name string) (*Texture2D, error) {
+	if *tex != nil {
+		return *tex, nil
+	}
+	img, err := loadDefaultImage(name)
+	if err != nil {
+		return nil, err
+	}
+	if *tex, err = NewTexture2D(img); err != nil {
+		return nil, fmt.Errorf("couldn't create default texture %s: %w", name, err)
+	}
+	return *tex, nil
+}
+
+// DefaultWhiteTexture gets a 1x1 white texture, such as to bind where a
+// shader samples a texture but none is given. It's shared, so don't delete
+// it; see DeleteDefaults().
+func DefaultWhiteTexture() (*Texture2D, error) {
+	return loadDefaultTexture(&defaultWhite, "white.png")
+}
+
+// DefaultChecker gets a 64x64 gray checkerboard texture with 8 pixel
+// squares, such as to stand in for a texture which failed to load. It's
+// shared, so don't delete it; see DeleteDefaults().
+func DefaultChecker() (*Texture2D, error) {
+	return loadDefaultTexture(&defaultChecker, "checker.png")
+}
+
+// DefaultSkybox gets a skybox of a plain blue sky fading to gray ground at
+// the horizon. It's shared, so don't delete it; see DeleteDefaults().
+func DefaultSkybox() (*Skybox, error) {
+	if defaultSky != nil {
+		return defaultSky, nil
+	}
+	img, err := loadDefaultImage("sky.png")
+	if err != nil {
+		return nil, err
+	}
+	if defaultSky, err = NewSkyboxImage(img); err != nil {
+		return nil, fmt.Errorf("couldn't create default skybox: %w", err)
+	}
+	return defaultSky, nil
+}
+
+// DefaultFont gets a CharacterDict of basicfont.Face7x13, a 7x13 pixel font
+// from golang.org/x/image. It isn't one of DefaultAssets(). It's shared, so
+// don't delete it; see DeleteDefaults().
+func DefaultFont() *CharacterDict {
+	if defaultFont == nil {
+		defaultFont = NewCharacterDict(basicfont.Face7x13)
+	}
+	return defaultFont
+}
+
+// DeleteDefaults deletes the defaults which have been created, such as
+// before destroying the window. They're created again if used afterwards.
+func DeleteDefaults() {
+	if defaultWhite != nil {
+		defaultWhite.Delete()
+		defaultWhite = nil
+	}
+	if defaultChecker != nil {
+		defaultChecker.Delete()
+		defaultChecker = nil
+	}
+	if defaultSky != nil {
+		defaultSky.Delete()
+		defaultSky = nil
+	}
+	if defaultFont != nil {
+		defaultFont.Delete()
+		defaultFont = nil
+	}
+}
@@ -0,0 +1,322 @@
+package sgl
+
+import (
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Backend is the set of opengl calls used by Buffer, Vao, Texture2D,
+// Texture3D, Program, Fbo, GBuffer, ShapeBatch, VectorField,
+// LightClusters, and RenderState. The default backend calls go-gl; another may be set with
+// SetBackend(), such as a MockBackend to test size, offset, and layout logic
+// without a GPU.
+//
+// Methods mirror the gl functions of the same name, except that objects are
+// created and deleted one at a time, strings are Go strings, and compiling
+// and linking report success and the info log directly.
+type Backend interface {
+	GetError() uint32
+
+	// state
+	Enable(capability uint32)
+	Disable(capability uint32)
+	IsEnabled(capability uint32) bool
+	GetIntegerv(pname uint32, data *int32)
+	GetFloatv(pname uint32, data *float32)
+	GetBooleanv(pname uint32, data *bool)
+	Viewport(x, y, width, height int32)
+	Scissor(x, y, width, height int32)
+	ClearColor(red, green, blue, alpha float32)
+	BlendFuncSeparate(srcRGB, dstRGB, srcAlpha, dstAlpha uint32)
+	DepthMask(flag bool)
+	ColorMask(red, green, blue, alpha bool)
+	StencilFunc(fn uint32, ref int32, mask uint32)
+	StencilMask(mask uint32)
+	StencilOp(fail, depthFail, pass uint32)
+
+	// buffers and vertex arrays
+	GenBuffer() uint32
+	DeleteBuffer(buffer uint32)
+	BindBuffer(target, buffer uint32)
+	BufferData(target uint32, size int, data unsafe.Pointer, usage uint32)
+	BufferSubData(target uint32, offset, size int, data unsafe.Pointer)
+	GetBufferSubData(target uint32, offset, size int, data unsafe.Pointer)
+	GenVertexArray() uint32
+	DeleteVertexArray(array uint32)
+	BindVertexArray(array uint32)
+	EnableVertexAttribArray(index uint32)
+	VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset int)
+	VertexAttribDivisor(index, divisor uint32)
+	DrawArrays(mode uint32, first, count int32)
+	DrawArraysInstanced(mode uint32, first, count, instances int32)
+	DrawElements(mode uint32, count int32, xtype uint32, offset int)
+
+	// textures
+	GenTexture() uint32
+	DeleteTexture(texture uint32)
+	ActiveTexture(unit uint32)
+	BindTexture(target, texture uint32)
+	TexParameteri(target, pname uint32, param int32)
+	TexParameteriv(target, pname uint32, params *int32)
+	TexParameterfv(target, pname uint32, params *float32)
+	PixelStorei(pname uint32, param int32)
+	TexImage2D(target uint32, level, internalFormat, width, height int32, format, xtype uint32, pixels unsafe.Pointer)
+	TexSubImage2D(target uint32, level, x, y, width, height int32, format, xtype uint32, pixels unsafe.Pointer)
+	GetTexImage(target uint32, level int32, format, xtype uint32, pixels unsafe.Pointer)
+	TexImage3D(target uint32, level, internalFormat, width, height, depth int32, format, xtype uint32, pixels unsafe.Pointer)
+	TexSubImage3D(target uint32, level, x, y, z, width, height, depth int32, format, xtype uint32, pixels unsafe.Pointer)
+	TexBuffer(target, internalFormat, buffer uint32)
+
+	// shaders and programs
+	CreateShader(shaderType uint32) uint32
+	CompileShader(shader uint32, source string) (ok bool, log string)
+	DeleteShader(shader uint32)
+	CreateProgram() uint32
+	AttachShader(program, shader uint32)
+	LinkProgram(program uint32) (ok bool, log string)
+	UseProgram(program uint32)
+	DeleteProgram(program uint32)
+	GetAttribLocation(program uint32, name string) int32
+	GetUniformLocation(program uint32, name string) int32
+	Uniform1iv(location, count int32, value *int32)
+	Uniform3iv(location, count int32, value *int32)
+	Uniform1fv(location, count int32, value *float32)
+	Uniform2fv(location, count int32, value *float32)
+	Uniform3fv(location, count int32, value *float32)
+	Uniform4fv(location, count int32, value *float32)
+	UniformMatrix4fv(location, count int32, transpose bool, value *float32)
+
+	// framebuffers
+	GenFramebuffer() uint32
+	DeleteFramebuffer(framebuffer uint32)
+	BindFramebuffer(target, framebuffer uint32)
+	FramebufferTexture2D(target, attachment, textarget, texture uint32, level int32)
+	CheckFramebufferStatus(target uint32) uint32
+	DrawBuffers(buffers []uint32)
+	ClearBufferfv(buffer uint32, drawBuffer int32, value *float32)
+	GenRenderbuffer() uint32
+	DeleteRenderbuffer(renderbuffer uint32)
+	BindRenderbuffer(target, renderbuffer uint32)
+	RenderbufferStorage(target, internalFormat uint32, width, height int32)
+	FramebufferRenderbuffer(target, attachment, renderbufferTarget, renderbuffer uint32)
+}
+
+// backend is used by the wrappers for all the calls in Backend.
+var backend Backend = GLBackend{}
+
+// SetBackend replaces the backend used by sgl, returning the previous one.
+// It must be called before any resources are created, since objects made
+// with one backend can't be used with another. For example, in a test:
+//
+//	mock := sgl.NewMockBackend()
+//	defer sgl.SetBackend(sgl.SetBackend(mock))
+func SetBackend(b Backend) (previous Backend) {
+	previous, backend = backend, b
+	return
+}
+
+// GLBackend is the default Backend, which calls opengl through go-gl.
+type GLBackend struct{}
+
+func (GLBackend) GetError() uint32 { return gl.GetError() }
+
+func (GLBackend) Enable(capability uint32)              { gl.Enable(capability) }
+func (GLBackend) Disable(capability uint32)             { gl.Disable(capability) }
+func (GLBackend) IsEnabled(capability uint32) bool      { return gl.IsEnabled(capability) }
+func (GLBackend) GetIntegerv(pname uint32, data *int32) { gl.GetIntegerv(pname, data) }
+func (GLBackend) GetFloatv(pname uint32, data *float32) { gl.GetFloatv(pname, data) }
+func (GLBackend) GetBooleanv(pname uint32, data *bool)  { gl.GetBooleanv(pname, data) }
+func (GLBackend) Viewport(x, y, width, height int32)    { gl.Viewport(x, y, width, height) }
+func (GLBackend) Scissor(x, y, width, height int32)     { gl.Scissor(x, y, width, height) }
+func (GLBackend) ClearColor(red, green, blue, alpha float32) {
+	gl.ClearColor(red, green, blue, alpha)
+}
+func (GLBackend) BlendFuncSeparate(srcRGB, dstRGB, srcAlpha, dstAlpha uint32) {
+	gl.BlendFuncSeparate(srcRGB, dstRGB, srcAlpha, dstAlpha)
+}
+func (GLBackend) DepthMask(flag bool) { gl.DepthMask(flag) }
+func (GLBackend) ColorMask(red, green, blue, alpha bool) {
+	gl.ColorMask(red, green, blue, alpha)
+}
+func (GLBackend) StencilFunc(fn uint32, ref int32, mask uint32) { gl.StencilFunc(fn, ref, mask) }
+func (GLBackend) StencilMask(mask uint32)                       { gl.StencilMask(mask) }
+func (GLBackend) StencilOp(fail, depthFail, pass uint32)        { gl.StencilOp(fail, depthFail, pass) }
+
+func (GLBackend) GenBuffer() (id uint32)                     { gl.GenBuffers(1, &id); return }
+func (GLBackend) DeleteBuffer(buffer uint32)                 { gl.DeleteBuffers(1, &buffer) }
+func (GLBackend) BindBuffer(target, buffer uint32)           { gl.BindBuffer(target, buffer) }
+func (GLBackend) GenVertexArray() (id uint32)                { gl.GenVertexArrays(1, &id); return }
+func (GLBackend) DeleteVertexArray(array uint32)             { gl.DeleteVertexArrays(1, &array) }
+func (GLBackend) BindVertexArray(array uint32)               { gl.BindVertexArray(array) }
+func (GLBackend) EnableVertexAttribArray(i uint32)           { gl.EnableVertexAttribArray(i) }
+func (GLBackend) VertexAttribDivisor(i, divisor uint32)      { gl.VertexAttribDivisor(i, divisor) }
+func (GLBackend) DrawArrays(mode uint32, first, count int32) { gl.DrawArrays(mode, first, count) }
+
+func (GLBackend) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	gl.BufferData(target, size, data, usage)
+}
+
+func (GLBackend) BufferSubData(target uint32, offset, size int, data unsafe.Pointer) {
+	gl.BufferSubData(target, offset, size, data)
+}
+
+func (GLBackend) GetBufferSubData(target uint32, offset, size int, data unsafe.Pointer) {
+	gl.GetBufferSubData(target, offset, size, data)
+}
+
+func (GLBackend) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset int) {
+	gl.VertexAttribPointer(index, size, xtype, normalized, stride, gl.PtrOffset(offset))
+}
+
+func (GLBackend) DrawElements(mode uint32, count int32, xtype uint32, offset int) {
+	gl.DrawElements(mode, count, xtype, gl.PtrOffset(offset))
+}
+
+func (GLBackend) DrawArraysInstanced(mode uint32, first, count, instances int32) {
+	gl.DrawArraysInstanced(mode, first, count, instances)
+}
+
+func (GLBackend) GenTexture() (id uint32)               { gl.GenTextures(1, &id); return }
+func (GLBackend) DeleteTexture(texture uint32)          { gl.DeleteTextures(1, &texture) }
+func (GLBackend) ActiveTexture(unit uint32)             { gl.ActiveTexture(unit) }
+func (GLBackend) BindTexture(target, texture uint32)    { gl.BindTexture(target, texture) }
+func (GLBackend) PixelStorei(pname uint32, param int32) { gl.PixelStorei(pname, param) }
+
+func (GLBackend) TexParameteri(target, pname uint32, param int32) {
+	gl.TexParameteri(target, pname, param)
+}
+
+func (GLBackend) TexParameteriv(target, pname uint32, params *int32) {
+	gl.TexParameteriv(target, pname, params)
+}
+
+func (GLBackend) TexParameterfv(target, pname uint32, params *float32) {
+	gl.TexParameterfv(target, pname, params)
+}
+
+func (GLBackend) TexImage2D(target uint32, level, internalFormat, width, height int32, format, xtype uint32, pixels unsafe.Pointer) {
+	gl.TexImage2D(target, level, internalFormat, width, height, 0, format, xtype, pixels)
+}
+
+func (GLBackend) TexSubImage2D(target uint32, level, x, y, width, height int32, format, xtype uint32, pixels unsafe.Pointer) {
+	gl.TexSubImage2D(target, level, x, y, width, height, format, xtype, pixels)
+}
+
+func (GLBackend) GetTexImage(target uint32, level int32, format, xtype uint32, pixels unsafe.Pointer) {
+	gl.GetTexImage(target, level, format, xtype, pixels)
+}
+
+func (GLBackend) TexImage3D(target uint32, level, internalFormat, width, height, depth int32, format, xtype uint32, pixels unsafe.Pointer) {
+	gl.TexImage3D(target, level, internalFormat, width, height, depth, 0, format, xtype, pixels)
+}
+
+func (GLBackend) TexSubImage3D(target uint32, level, x, y, z, width, height, depth int32, format, xtype uint32, pixels unsafe.Pointer) {
+	gl.TexSubImage3D(target, level, x, y, z, width, height, depth, format, xtype, pixels)
+}
+
+func (GLBackend) TexBuffer(target, internalFormat, buffer uint32) {
+	gl.TexBuffer(target, internalFormat, buffer)
+}
+
+func (GLBackend) CreateShader(shaderType uint32) uint32 { return gl.CreateShader(shaderType) }
+func (GLBackend) DeleteShader(shader uint32)            { gl.DeleteShader(shader) }
+func (GLBackend) CreateProgram() uint32                 { return gl.CreateProgram() }
+func (GLBackend) AttachShader(program, shader uint32)   { gl.AttachShader(program, shader) }
+func (GLBackend) UseProgram(program uint32)             { gl.UseProgram(program) }
+func (GLBackend) DeleteProgram(program uint32)          { gl.DeleteProgram(program) }
+
+func (GLBackend) CompileShader(shader uint32, source string) (ok bool, log string) {
+	csources, free := gl.Strs(source + "\x00") // gl.Strs() lies about null termination
+	defer free()
+	gl.ShaderSource(shader, 1, csources, nil)
+	gl.CompileShader(shader)
+
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+		log = strings.Repeat("\x00", int(logLength+1))
+		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+		return false, log
+	}
+	return true, ""
+}
+
+func (GLBackend) LinkProgram(program uint32) (ok bool, log string) {
+	gl.LinkProgram(program)
+
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+		log = strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+		return false, log
+	}
+	return true, ""
+}
+
+func (GLBackend) GetAttribLocation(program uint32, name string) int32 {
+	return gl.GetAttribLocation(program, gl.Str(name+"\x00"))
+}
+
+func (GLBackend) GetUniformLocation(program uint32, name string) int32 {
+	return gl.GetUniformLocation(program, gl.Str(name+"\x00"))
+}
+
+func (GLBackend) Uniform1iv(location, count int32, value *int32) {
+	gl.Uniform1iv(location, count, value)
+}
+func (GLBackend) Uniform3iv(location, count int32, value *int32) {
+	gl.Uniform3iv(location, count, value)
+}
+func (GLBackend) Uniform1fv(location, count int32, value *float32) {
+	gl.Uniform1fv(location, count, value)
+}
+func (GLBackend) Uniform2fv(location, count int32, value *float32) {
+	gl.Uniform2fv(location, count, value)
+}
+func (GLBackend) Uniform3fv(location, count int32, value *float32) {
+	gl.Uniform3fv(location, count, value)
+}
+func (GLBackend) Uniform4fv(location, count int32, value *float32) {
+	gl.Uniform4fv(location, count, value)
+}
+
+func (GLBackend) UniformMatrix4fv(location, count int32, transpose bool, value *float32) {
+	gl.UniformMatrix4fv(location, count, transpose, value)
+}
+
+func (GLBackend) GenFramebuffer() (id uint32)                { gl.GenFramebuffers(1, &id); return }
+func (GLBackend) DeleteFramebuffer(framebuffer uint32)       { gl.DeleteFramebuffers(1, &framebuffer) }
+func (GLBackend) BindFramebuffer(target, framebuffer uint32) { gl.BindFramebuffer(target, framebuffer) }
+func (GLBackend) CheckFramebufferStatus(target uint32) uint32 {
+	return gl.CheckFramebufferStatus(target)
+}
+func (GLBackend) DrawBuffers(buffers []uint32) {
+	gl.DrawBuffers(int32(len(buffers)), &buffers[0])
+}
+func (GLBackend) ClearBufferfv(buffer uint32, drawBuffer int32, value *float32) {
+	gl.ClearBufferfv(buffer, drawBuffer, value)
+}
+func (GLBackend) GenRenderbuffer() (id uint32)           { gl.GenRenderbuffers(1, &id); return }
+func (GLBackend) DeleteRenderbuffer(renderbuffer uint32) { gl.DeleteRenderbuffers(1, &renderbuffer) }
+func (GLBackend) BindRenderbuffer(target, renderbuffer uint32) {
+	gl.BindRenderbuffer(target, renderbuffer)
+}
+
+func (GLBackend) FramebufferTexture2D(target, attachment, textarget, texture uint32, level int32) {
+	gl.FramebufferTexture2D(target, attachment, textarget, texture, level)
+}
+
+func (GLBackend) RenderbufferStorage(target, internalFormat uint32, width, height int32) {
+	gl.RenderbufferStorage(target, internalFormat, width, height)
+}
+
+func (GLBackend) FramebufferRenderbuffer(target, attachment, renderbufferTarget, renderbuffer uint32) {
+	gl.FramebufferRenderbuffer(target, attachment, renderbufferTarget, renderbuffer)
+}
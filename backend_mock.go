@@ -0,0 +1,440 @@
+package sgl
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// MockBackend is a Backend which needs no GPU or opengl context, for unit
+// tests. It keeps the contents of buffers and the size and parameters of
+// textures so tests can check what was uploaded, keeps the state set by
+// calls such as Viewport and ClearColor so it can be read back, and records
+// the name of every call made. Shaders always compile and link, and framebuffers are
+// always complete.
+type MockBackend struct {
+	Calls     []string                // names of the calls made, in order
+	Buffers   map[uint32][]byte       // buffer contents, by id
+	Textures  map[uint32]*MockTexture // textures, by id
+	Attribs   map[uint32]MockAttrib   // vertex attributes, by index
+	Errors    []uint32                // errors to report from GetError, in order
+	Enabled   map[uint32]bool         // capabilities, from Enable and Disable
+	Viewports [][4]int32              // from Viewport, in order
+	Integers  map[uint32][]int32      // state read by GetIntegerv, by pname
+	Floats    map[uint32][]float32    // state read by GetFloatv, by pname
+	Booleans  map[uint32][]bool       // state read by GetBooleanv, by pname
+	Draws     []uint32                // draw buffers of the bound framebuffer, from DrawBuffers
+
+	nextID    uint32
+	bound     map[uint32]uint32 // bound object, by target
+	locations map[string]int32  // attribute and uniform locations, by name
+}
+
+// MockTexture is the state of a texture in a MockBackend.
+type MockTexture struct {
+	Width, Height  int32
+	Depth          int32 // of 3d textures
+	InternalFormat int32
+	Buffer         uint32           // of buffer textures, from TexBuffer
+	Params         map[uint32]int32 // from TexParameteri
+	Uploads        int              // calls to TexImage2D and TexSubImage2D with data
+}
+
+// MockAttrib is a vertex attribute layout set in a MockBackend.
+type MockAttrib struct {
+	Buffer  uint32 // the buffer bound when the layout was set
+	Size    int32
+	Type    uint32
+	Stride  int32
+	Offset  int
+	Divisor uint32
+	Enabled bool
+}
+
+// NewMockBackend creates an empty MockBackend.
+func NewMockBackend() *MockBackend {
+	return &MockBackend{
+		Buffers:   make(map[uint32][]byte),
+		Textures:  make(map[uint32]*MockTexture),
+		Attribs:   make(map[uint32]MockAttrib),
+		Enabled:   make(map[uint32]bool),
+		Integers:  make(map[uint32][]int32),
+		Floats:    make(map[uint32][]float32),
+		Booleans:  make(map[uint32][]bool),
+		bound:     make(map[uint32]uint32),
+		locations: make(map[string]int32),
+	}
+}
+
+// Bound gets the object bound to target, eg gl.ARRAY_BUFFER.
+func (m *MockBackend) Bound(target uint32) uint32 { return m.bound[target] }
+
+func (m *MockBackend) call(name string) { m.Calls = append(m.Calls, name) }
+
+func (m *MockBackend) gen(name string) uint32 {
+	m.call(name)
+	m.nextID++
+	return m.nextID
+}
+
+func (m *MockBackend) bind(name string, target, id uint32) {
+	m.call(name)
+	m.bound[target] = id
+}
+
+func (m *MockBackend) location(name string) int32 {
+	if loc, ok := m.locations[name]; ok {
+		return loc
+	}
+	loc := int32(len(m.locations))
+	m.locations[name] = loc
+	return loc
+}
+
+// texture gets the texture bound to target, which must exist.
+func (m *MockBackend) texture(target uint32) *MockTexture {
+	tex, ok := m.Textures[m.bound[target]]
+	if !ok {
+		panic(fmt.Sprintf("mock: no texture bound to 0x%x", target))
+	}
+	return tex
+}
+
+// buffer gets the contents of the buffer bound to target, which must exist.
+func (m *MockBackend) buffer(target uint32) []byte {
+	buf, ok := m.Buffers[m.bound[target]]
+	if !ok {
+		panic(fmt.Sprintf("mock: no buffer bound to 0x%x", target))
+	}
+	return buf
+}
+
+func (m *MockBackend) GetError() uint32 {
+	if len(m.Errors) == 0 {
+		return gl.NO_ERROR
+	}
+	err := m.Errors[0]
+	m.Errors = m.Errors[1:]
+	return err
+}
+
+func (m *MockBackend) Enable(capability uint32) {
+	m.call("Enable")
+	m.Enabled[capability] = true
+}
+
+func (m *MockBackend) Disable(capability uint32) {
+	m.call("Disable")
+	m.Enabled[capability] = false
+}
+
+func (m *MockBackend) IsEnabled(capability uint32) bool {
+	m.call("IsEnabled")
+	return m.Enabled[capability]
+}
+
+// GetIntegerv reads from Integers, except for FRAMEBUFFER_BINDING, which
+// is the framebuffer bound.
+func (m *MockBackend) GetIntegerv(pname uint32, data *int32) {
+	m.call("GetIntegerv")
+	if pname == gl.FRAMEBUFFER_BINDING {
+		*data = int32(m.bound[gl.FRAMEBUFFER])
+		return
+	}
+	values := m.Integers[pname]
+	copy(unsafe.Slice(data, len(values)), values)
+}
+
+func (m *MockBackend) GetFloatv(pname uint32, data *float32) {
+	m.call("GetFloatv")
+	values := m.Floats[pname]
+	copy(unsafe.Slice(data, len(values)), values)
+}
+
+func (m *MockBackend) GetBooleanv(pname uint32, data *bool) {
+	m.call("GetBooleanv")
+	values := m.Booleans[pname]
+	copy(unsafe.Slice(data, len(values)), values)
+}
+
+func (m *MockBackend) Viewport(x, y, width, height int32) {
+	m.call("Viewport")
+	m.Viewports = append(m.Viewports, [4]int32{x, y, width, height})
+	m.Integers[gl.VIEWPORT] = []int32{x, y, width, height}
+}
+
+func (m *MockBackend) Scissor(x, y, width, height int32) {
+	m.call("Scissor")
+	m.Integers[gl.SCISSOR_BOX] = []int32{x, y, width, height}
+}
+
+func (m *MockBackend) ClearColor(red, green, blue, alpha float32) {
+	m.call("ClearColor")
+	m.Floats[gl.COLOR_CLEAR_VALUE] = []float32{red, green, blue, alpha}
+}
+
+func (m *MockBackend) BlendFuncSeparate(srcRGB, dstRGB, srcAlpha, dstAlpha uint32) {
+	m.call("BlendFuncSeparate")
+	m.Integers[gl.BLEND_SRC_RGB] = []int32{int32(srcRGB)}
+	m.Integers[gl.BLEND_DST_RGB] = []int32{int32(dstRGB)}
+	m.Integers[gl.BLEND_SRC_ALPHA] = []int32{int32(srcAlpha)}
+	m.Integers[gl.BLEND_DST_ALPHA] = []int32{int32(dstAlpha)}
+}
+
+func (m *MockBackend) DepthMask(flag bool) {
+	m.call("DepthMask")
+	m.Booleans[gl.DEPTH_WRITEMASK] = []bool{flag}
+}
+
+func (m *MockBackend) ColorMask(red, green, blue, alpha bool) {
+	m.call("ColorMask")
+	m.Booleans[gl.COLOR_WRITEMASK] = []bool{red, green, blue, alpha}
+}
+
+func (m *MockBackend) StencilFunc(fn uint32, ref int32, mask uint32) {
+	m.call("StencilFunc")
+	m.Integers[gl.STENCIL_FUNC] = []int32{int32(fn)}
+	m.Integers[gl.STENCIL_REF] = []int32{ref}
+	m.Integers[gl.STENCIL_VALUE_MASK] = []int32{int32(mask)}
+}
+
+func (m *MockBackend) StencilMask(mask uint32) {
+	m.call("StencilMask")
+	m.Integers[gl.STENCIL_WRITEMASK] = []int32{int32(mask)}
+}
+
+func (m *MockBackend) StencilOp(fail, depthFail, pass uint32) {
+	m.call("StencilOp")
+	m.Integers[gl.STENCIL_FAIL] = []int32{int32(fail)}
+	m.Integers[gl.STENCIL_PASS_DEPTH_FAIL] = []int32{int32(depthFail)}
+	m.Integers[gl.STENCIL_PASS_DEPTH_PASS] = []int32{int32(pass)}
+}
+
+func (m *MockBackend) GenBuffer() uint32 {
+	id := m.gen("GenBuffer")
+	m.Buffers[id] = nil
+	return id
+}
+
+func (m *MockBackend) DeleteBuffer(buffer uint32) {
+	m.call("DeleteBuffer")
+	delete(m.Buffers, buffer)
+}
+
+func (m *MockBackend) BindBuffer(target, buffer uint32) { m.bind("BindBuffer", target, buffer) }
+
+func (m *MockBackend) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	m.call("BufferData")
+	m.buffer(target) // must exist
+	buf := make([]byte, size)
+	if data != nil {
+		copy(buf, unsafe.Slice((*byte)(data), size))
+	}
+	m.Buffers[m.bound[target]] = buf
+}
+
+func (m *MockBackend) BufferSubData(target uint32, offset, size int, data unsafe.Pointer) {
+	m.call("BufferSubData")
+	buf := m.buffer(target)
+	if offset < 0 || offset+size > len(buf) {
+		panic(fmt.Sprintf("mock: BufferSubData of %d bytes at %d overflows %d byte buffer", size, offset, len(buf)))
+	}
+	copy(buf[offset:], unsafe.Slice((*byte)(data), size))
+}
+
+func (m *MockBackend) GetBufferSubData(target uint32, offset, size int, data unsafe.Pointer) {
+	m.call("GetBufferSubData")
+	buf := m.buffer(target)
+	if offset < 0 || offset+size > len(buf) {
+		panic(fmt.Sprintf("mock: GetBufferSubData of %d bytes at %d overflows %d byte buffer", size, offset, len(buf)))
+	}
+	copy(unsafe.Slice((*byte)(data), size), buf[offset:])
+}
+
+func (m *MockBackend) GenVertexArray() uint32         { return m.gen("GenVertexArray") }
+func (m *MockBackend) DeleteVertexArray(array uint32) { m.call("DeleteVertexArray") }
+func (m *MockBackend) BindVertexArray(array uint32)   { m.bind("BindVertexArray", 0, array) }
+
+func (m *MockBackend) EnableVertexAttribArray(index uint32) {
+	m.call("EnableVertexAttribArray")
+	a := m.Attribs[index]
+	a.Enabled = true
+	m.Attribs[index] = a
+}
+
+func (m *MockBackend) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset int) {
+	m.call("VertexAttribPointer")
+	a := m.Attribs[index]
+	a.Buffer, a.Size, a.Type, a.Stride, a.Offset = m.bound[gl.ARRAY_BUFFER], size, xtype, stride, offset
+	m.Attribs[index] = a
+}
+
+func (m *MockBackend) VertexAttribDivisor(index, divisor uint32) {
+	m.call("VertexAttribDivisor")
+	a := m.Attribs[index]
+	a.Divisor = divisor
+	m.Attribs[index] = a
+}
+
+func (m *MockBackend) DrawArrays(mode uint32, first, count int32) { m.call("DrawArrays") }
+func (m *MockBackend) DrawArraysInstanced(mode uint32, first, count, instances int32) {
+	m.call("DrawArraysInstanced")
+}
+func (m *MockBackend) DrawElements(mode uint32, count int32, xtype uint32, offset int) {
+	m.call("DrawElements")
+}
+
+func (m *MockBackend) GenTexture() uint32 {
+	id := m.gen("GenTexture")
+	m.Textures[id] = &MockTexture{Params: make(map[uint32]int32)}
+	return id
+}
+
+func (m *MockBackend) DeleteTexture(texture uint32) {
+	m.call("DeleteTexture")
+	delete(m.Textures, texture)
+}
+
+func (m *MockBackend) ActiveTexture(unit uint32)             { m.call("ActiveTexture") }
+func (m *MockBackend) BindTexture(target, texture uint32)    { m.bind("BindTexture", target, texture) }
+func (m *MockBackend) PixelStorei(pname uint32, param int32) { m.call("PixelStorei") }
+
+func (m *MockBackend) TexParameteri(target, pname uint32, param int32) {
+	m.call("TexParameteri")
+	m.texture(target).Params[pname] = param
+}
+
+func (m *MockBackend) TexParameteriv(target, pname uint32, params *int32) {
+	m.call("TexParameteriv")
+	m.texture(target).Params[pname] = *params
+}
+
+func (m *MockBackend) TexParameterfv(target, pname uint32, params *float32) {
+	m.call("TexParameterfv")
+	m.texture(target)
+}
+
+func (m *MockBackend) TexImage2D(target uint32, level, internalFormat, width, height int32, format, xtype uint32, pixels unsafe.Pointer) {
+	m.call("TexImage2D")
+	tex := m.texture(target)
+	tex.Width, tex.Height, tex.InternalFormat = width, height, internalFormat
+	if pixels != nil {
+		tex.Uploads++
+	}
+}
+
+func (m *MockBackend) TexSubImage2D(target uint32, level, x, y, width, height int32, format, xtype uint32, pixels unsafe.Pointer) {
+	m.call("TexSubImage2D")
+	tex := m.texture(target)
+	if x < 0 || y < 0 || x+width > tex.Width || y+height > tex.Height {
+		panic(fmt.Sprintf("mock: TexSubImage2D region %dx%d at (%d, %d) is outside %dx%d texture",
+			width, height, x, y, tex.Width, tex.Height))
+	}
+	tex.Uploads++
+}
+
+func (m *MockBackend) GetTexImage(target uint32, level int32, format, xtype uint32, pixels unsafe.Pointer) {
+	m.call("GetTexImage")
+	m.texture(target)
+}
+
+func (m *MockBackend) TexImage3D(target uint32, level, internalFormat, width, height, depth int32, format, xtype uint32, pixels unsafe.Pointer) {
+	m.call("TexImage3D")
+	tex := m.texture(target)
+	tex.Width, tex.Height, tex.Depth, tex.InternalFormat = width, height, depth, internalFormat
+	if pixels != nil {
+		tex.Uploads++
+	}
+}
+
+func (m *MockBackend) TexSubImage3D(target uint32, level, x, y, z, width, height, depth int32, format, xtype uint32, pixels unsafe.Pointer) {
+	m.call("TexSubImage3D")
+	tex := m.texture(target)
+	if x < 0 || y < 0 || z < 0 || x+width > tex.Width || y+height > tex.Height || z+depth > tex.Depth {
+		panic(fmt.Sprintf("mock: TexSubImage3D region %dx%dx%d at (%d, %d, %d) is outside %dx%dx%d texture",
+			width, height, depth, x, y, z, tex.Width, tex.Height, tex.Depth))
+	}
+	tex.Uploads++
+}
+
+func (m *MockBackend) TexBuffer(target, internalFormat, buffer uint32) {
+	m.call("TexBuffer")
+	tex := m.texture(target)
+	tex.InternalFormat, tex.Buffer = int32(internalFormat), buffer
+}
+
+func (m *MockBackend) CreateShader(shaderType uint32) uint32 { return m.gen("CreateShader") }
+func (m *MockBackend) DeleteShader(shader uint32)            { m.call("DeleteShader") }
+func (m *MockBackend) CreateProgram() uint32                 { return m.gen("CreateProgram") }
+func (m *MockBackend) AttachShader(program, shader uint32)   { m.call("AttachShader") }
+func (m *MockBackend) UseProgram(program uint32)             { m.call("UseProgram") }
+func (m *MockBackend) DeleteProgram(program uint32)          { m.call("DeleteProgram") }
+
+func (m *MockBackend) CompileShader(shader uint32, source string) (ok bool, log string) {
+	m.call("CompileShader")
+	return true, ""
+}
+
+func (m *MockBackend) LinkProgram(program uint32) (ok bool, log string) {
+	m.call("LinkProgram")
+	return true, ""
+}
+
+func (m *MockBackend) GetAttribLocation(program uint32, name string) int32 {
+	m.call("GetAttribLocation")
+	return m.location(name)
+}
+
+func (m *MockBackend) GetUniformLocation(program uint32, name string) int32 {
+	m.call("GetUniformLocation")
+	return m.location(name)
+}
+
+func (m *MockBackend) Uniform1iv(location, count int32, value *int32)   { m.call("Uniform1iv") }
+func (m *MockBackend) Uniform3iv(location, count int32, value *int32)   { m.call("Uniform3iv") }
+func (m *MockBackend) Uniform1fv(location, count int32, value *float32) { m.call("Uniform1fv") }
+func (m *MockBackend) Uniform2fv(location, count int32, value *float32) { m.call("Uniform2fv") }
+func (m *MockBackend) Uniform3fv(location, count int32, value *float32) { m.call("Uniform3fv") }
+func (m *MockBackend) Uniform4fv(location, count int32, value *float32) { m.call("Uniform4fv") }
+func (m *MockBackend) UniformMatrix4fv(location, count int32, transpose bool, value *float32) {
+	m.call("UniformMatrix4fv")
+}
+
+func (m *MockBackend) GenFramebuffer() uint32               { return m.gen("GenFramebuffer") }
+func (m *MockBackend) DeleteFramebuffer(framebuffer uint32) { m.call("DeleteFramebuffer") }
+func (m *MockBackend) BindFramebuffer(target, framebuffer uint32) {
+	m.bind("BindFramebuffer", target, framebuffer)
+}
+
+func (m *MockBackend) FramebufferTexture2D(target, attachment, textarget, texture uint32, level int32) {
+	m.call("FramebufferTexture2D")
+}
+
+func (m *MockBackend) CheckFramebufferStatus(target uint32) uint32 {
+	m.call("CheckFramebufferStatus")
+	return gl.FRAMEBUFFER_COMPLETE
+}
+
+func (m *MockBackend) DrawBuffers(buffers []uint32) {
+	m.call("DrawBuffers")
+	m.Draws = append(m.Draws[:0], buffers...)
+}
+
+func (m *MockBackend) ClearBufferfv(buffer uint32, drawBuffer int32, value *float32) {
+	m.call("ClearBufferfv")
+}
+
+func (m *MockBackend) GenRenderbuffer() uint32                { return m.gen("GenRenderbuffer") }
+func (m *MockBackend) DeleteRenderbuffer(renderbuffer uint32) { m.call("DeleteRenderbuffer") }
+func (m *MockBackend) BindRenderbuffer(target, renderbuffer uint32) {
+	m.bind("BindRenderbuffer", target, renderbuffer)
+}
+
+func (m *MockBackend) RenderbufferStorage(target, internalFormat uint32, width, height int32) {
+	m.call("RenderbufferStorage")
+}
+
+func (m *MockBackend) FramebufferRenderbuffer(target, attachment, renderbufferTarget, renderbuffer uint32) {
+	m.call("FramebufferRenderbuffer")
+}
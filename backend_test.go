@@ -0,0 +1,207 @@
+package sgl
+
+import (
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// useMock sets a new MockBackend for the rest of the test.
+func useMock(t *testing.T) *MockBackend {
+	mock := NewMockBackend()
+	previous := SetBackend(mock)
+	t.Cleanup(func() { SetBackend(previous) })
+	return mock
+}
+
+// count gets how many times the call named name was made.
+func count(mock *MockBackend, name string) int {
+	n := 0
+	for _, call := range mock.Calls {
+		if call == name {
+			n++
+		}
+	}
+	return n
+}
+
+func TestProgramBuild(t *testing.T) {
+	mock := useMock(t)
+
+	prog := NewProgram()
+	prog.AddShader(VertexShader, "vertex", []string{"projection", "view"},
+		Attribute{Name: "position", Size: 3, Type: gl.FLOAT})
+	prog.AddShader(FragmentShader, "fragment", []string{"color"})
+	if err := prog.Build(); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	defer prog.Delete()
+
+	if prog.ID == 0 {
+		t.Error("program has no id")
+	}
+	if n := count(mock, "CompileShader"); n != 2 {
+		t.Errorf("compiled %d shaders, want 2", n)
+	}
+	if n := count(mock, "AttachShader"); n != 2 {
+		t.Errorf("attached %d shaders, want 2", n)
+	}
+	if n := count(mock, "DeleteShader"); n != 2 {
+		t.Errorf("deleted %d shaders after linking, want 2", n)
+	}
+
+	vertex := prog.Vertex()
+	if vertex.Uniforms["projection"] == vertex.Uniforms["view"] {
+		t.Errorf("uniforms share location %d", vertex.Uniforms["view"])
+	}
+	if _, ok := prog.Fragment().Uniforms["color"]; !ok {
+		t.Error("fragment uniform color wasn't looked up")
+	}
+	if loc := vertex.Attribs["position"].ID; loc != uint32(mock.location("position")) {
+		t.Errorf("position attribute at %d, want %d", loc, mock.location("position"))
+	}
+}
+
+func TestVaoSetup(t *testing.T) {
+	mock := useMock(t)
+
+	const stride = 5 * SizeOfFloat
+	vbo := NewVbo("vertices",
+		Attribute{ID: 0, Name: "position", Size: 3, Type: gl.FLOAT, Stride: stride},
+		Attribute{ID: 1, Name: "uv", Size: 2, Type: gl.FLOAT, Stride: stride, Offset: 3 * SizeOfFloat})
+	vao := NewVao(Triangles, vbo)
+
+	for index, want := range map[uint32]MockAttrib{
+		0: {Buffer: vbo.ID, Size: 3, Type: gl.FLOAT, Stride: stride, Enabled: true},
+		1: {Buffer: vbo.ID, Size: 2, Type: gl.FLOAT, Stride: stride, Offset: 3 * SizeOfFloat, Enabled: true},
+	} {
+		if got := mock.Attribs[index]; got != want {
+			t.Errorf("attribute %d = %+v, want %+v", index, got, want)
+		}
+	}
+	if mock.Bound(0) != 0 || mock.Bound(gl.ARRAY_BUFFER) != 0 {
+		t.Error("vao or vbo left bound")
+	}
+
+	vertices := make([]float32, 4*5)
+	vbo.Initalize(vertices)
+	if got := len(mock.Buffers[vbo.ID]); got != len(vertices)*SizeOfFloat {
+		t.Errorf("vbo has %d bytes, want %d", got, len(vertices)*SizeOfFloat)
+	}
+	if vbo.Count() != 4 {
+		t.Errorf("vbo has %d vertices, want 4", vbo.Count())
+	}
+
+	vao.Ebo.Initalize([]uint32{0, 1, 2, 2, 3, 0})
+	if vao.count() != 6 {
+		t.Errorf("vao draws %d vertices, want the 6 indices", vao.count())
+	}
+
+	vao.Delete()
+	if len(mock.Buffers) != 0 {
+		t.Errorf("%d buffers not deleted", len(mock.Buffers))
+	}
+}
+
+func TestFboSetup(t *testing.T) {
+	mock := useMock(t)
+	before := EstimateMemory()
+
+	fbo, err := NewFbo(64, 32)
+	if err != nil {
+		t.Fatalf("NewFbo() = %v", err)
+	}
+	tex := mock.Textures[fbo.ColorBuffer.ID]
+	if tex == nil || tex.Width != 64 || tex.Height != 32 {
+		t.Fatalf("color buffer = %+v, want 64x32", tex)
+	}
+	if tex.Params[gl.TEXTURE_MIN_FILTER] != gl.LINEAR {
+		t.Error("color buffer isn't filtered linearly")
+	}
+	if mock.Bound(gl.FRAMEBUFFER) != 0 {
+		t.Error("fbo left bound")
+	}
+	if used := EstimateMemory().Total() - before.Total(); used != 2*64*32*4 {
+		t.Errorf("fbo uses %d bytes, want %d", used, 2*64*32*4)
+	}
+
+	fbo.Use()
+	if mock.Bound(gl.FRAMEBUFFER) != fbo.ID {
+		t.Error("Use() didn't bind the fbo")
+	}
+
+	fbo.Delete()
+	if len(mock.Textures) != 0 {
+		t.Error("color buffer not deleted")
+	}
+	if EstimateMemory() != before {
+		t.Errorf("memory after Delete() = %+v, want %+v", EstimateMemory(), before)
+	}
+}
+
+func TestTargetStateRestore(t *testing.T) {
+	mock := useMock(t)
+
+	screen, err := NewFbo(64, 32)
+	if err != nil {
+		t.Fatalf("NewFbo() = %v", err)
+	}
+	defer screen.Delete()
+	screen.Use()
+	backend.Viewport(0, 0, 64, 32)
+	backend.ClearColor(0.1, 0.2, 0.3, 1)
+	backend.BlendFuncSeparate(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA, gl.ONE, gl.ZERO)
+	backend.Enable(gl.BLEND)
+	backend.Disable(gl.DEPTH_TEST)
+
+	saved := saveTargetState()
+	if saved.fbo != int32(screen.ID) || saved.viewport != [4]int32{0, 0, 64, 32} {
+		t.Fatalf("saved fbo %d and viewport %v, want %d and [0 0 64 32]", saved.fbo, saved.viewport, screen.ID)
+	}
+
+	target, err := NewFbo(16, 16)
+	if err != nil {
+		t.Fatalf("NewFbo() = %v", err)
+	}
+	defer target.Delete()
+	target.Use()
+	backend.Viewport(0, 0, 16, 16)
+	backend.ClearColor(0, 0, 0, 0)
+	backend.BlendFuncSeparate(gl.ONE, gl.ONE, gl.ONE, gl.ONE)
+	setEnabled(gl.BLEND, false)
+	setEnabled(gl.DEPTH_TEST, true)
+
+	saved.restore()
+	if mock.Bound(gl.FRAMEBUFFER) != screen.ID {
+		t.Errorf("fbo %d bound after restore, want %d", mock.Bound(gl.FRAMEBUFFER), screen.ID)
+	}
+	if !mock.Enabled[gl.BLEND] || mock.Enabled[gl.DEPTH_TEST] {
+		t.Error("blending and depth testing weren't restored")
+	}
+	if got := saveTargetState(); got != saved {
+		t.Errorf("state after restore = %+v, want %+v", got, saved)
+	}
+}
+
+func TestTexture3DSlices(t *testing.T) {
+	mock := useMock(t)
+
+	tex, err := NewTexture3D(4, 4, 3, TexR8, make([]byte, 4*4*3))
+	if err != nil {
+		t.Fatalf("NewTexture3D() = %v", err)
+	}
+	defer tex.Delete()
+	if got := mock.Textures[tex.ID]; got.Width != 4 || got.Height != 4 || got.Depth != 3 {
+		t.Fatalf("texture is %dx%dx%d, want 4x4x3", got.Width, got.Height, got.Depth)
+	}
+
+	if err := tex.SetSlices(1, 2, make([]byte, 4*4*2)); err != nil {
+		t.Errorf("SetSlices(1, 2) = %v", err)
+	}
+	if err := tex.SetSlices(2, 2, make([]byte, 4*4*2)); err == nil {
+		t.Error("SetSlices(2, 2) of a 3 slice texture didn't fail")
+	}
+	if err := tex.SetSlices(0, 1, make([]byte, 3)); err == nil {
+		t.Error("SetSlices() with too little data didn't fail")
+	}
+}
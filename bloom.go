@@ -0,0 +1,299 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/inkyblackness/imgui-go/v4"
+)
+
+// Emissive is the light emitted by a surface, such as a neon sign or a
+// screen, set in shaders using EmissiveShaderSource. Emitted light isn't
+// affected by lighting, and glows with Bloom.
+type Emissive struct {
+	Color    Color
+	Strength float32    // multiplies Color; above 1 for hdr glow
+	Texture  *Texture2D // multiplies Color if not nil
+}
+
+// EmissiveUniforms are the uniforms declared by EmissiveShaderSource. Add
+// them to the fragment shader's uniforms in Program.AddShader().
+var EmissiveUniforms = []string{"emissiveColor", "emissiveMap", "useEmissiveMap"}
+
+// EmissiveShaderSource declares the emissive uniforms and a function to get
+// the light emitted. Put it directly after the #version line of a fragment
+// shader, then use
+//
+//	vec3 emitted = emission(texCoords);
+//	FragColor = vec4(lit + emitted, 1.0);
+//	Emitted = vec4(emitted, 1.0); // location 3 of a GBuffer
+//
+// and call Shader.SetEmissive() after Program.Use().
+const EmissiveShaderSource = `
+uniform vec3 emissiveColor;
+uniform sampler2D emissiveMap;
+uniform bool useEmissiveMap;
+
+// emission gets the light emitted at texture coords uv.
+vec3 emission(vec2 uv)
+{
+    vec3 e = emissiveColor;
+    if (useEmissiveMap) {
+        e *= texture(emissiveMap, uv).rgb;
+    }
+    return e;
+}
+`
+
+// SetEmissive sets the uniforms declared by EmissiveShaderSource for e,
+// binding e.Texture, if any, to texture unit. The shader's program must be
+// in use.
+func (s *Shader) SetEmissive(e Emissive, unit uint32) {
+	color := e.Color.Vec4().Vec3().Mul(e.Strength)
+	if loc, ok := s.Uniforms["emissiveColor"]; ok {
+		gl.Uniform3f(loc, color[0], color[1], color[2])
+	}
+	if loc, ok := s.Uniforms["useEmissiveMap"]; ok {
+		gl.Uniform1i(loc, boolToInt32(e.Texture != nil))
+	}
+	if loc, ok := s.Uniforms["emissiveMap"]; ok {
+		gl.Uniform1i(loc, int32(unit))
+	}
+	if e.Texture != nil {
+		e.Texture.Bind(unit)
+		gl.ActiveTexture(gl.TEXTURE0)
+	}
+}
+
+// only need this once in the package
+var bloomProgram *Program
+
+// called to create and build the bloom program.
+func initBloomProgram() error {
+	bloomProgram = NewProgram()
+	bloomProgram.AddShader(VertexShader, quadVertexShader,
+		[]string{"projection", "model"},
+		Attribute{Name: "vertex", Type: gl.FLOAT, Size: 4, Stride: 4 * SizeOfFloat, Offset: 0})
+	bloomProgram.AddShader(FragmentShader, bloomFragmentShader,
+		[]string{"source", "emissive", "bloom", "mode", "useEmissive", "threshold", "knee",
+			"intensity", "texel"})
+	if err := bloomProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build bloom program: %w", err)
+	}
+	var source, emissive, bloom int32 = 0, 1, 2
+	bloomProgram.Fragment().SetInt("source", 1, &source)
+	bloomProgram.Fragment().SetInt("emissive", 1, &emissive)
+	bloomProgram.Fragment().SetInt("bloom", 1, &bloom)
+	return nil
+}
+
+// passes of the bloom shader
+const (
+	bloomPrefilter int32 = iota
+	bloomDownsample
+	bloomUpsample
+	bloomComposite
+)
+
+// Bloom is a post-processing pass making bright and emissive things glow,
+// by blurring them over a chain of smaller and smaller copies and adding
+// the blur back to the frame.
+type Bloom struct {
+	Threshold float32 // brightness above which things glow
+	Knee      float32 // softens the threshold, from 0 (hard) to 1
+	Intensity float32 // of the glow added
+	Levels    int     // of the chain; more spreads the glow further
+	Output    *Texture2D
+
+	chain  []bloomLevel // half size, quarter size, ...
+	fbo    uint32       // for Output
+	width  int
+	height int
+}
+
+// bloomLevel is a target in the chain.
+type bloomLevel struct {
+	tex *Texture2D
+	fbo uint32
+}
+
+// NewBloom creates a bloom pass.
+func NewBloom() (*Bloom, error) {
+	if bloomProgram == nil {
+		if err := initBloomProgram(); err != nil {
+			return nil, err
+		}
+	}
+	return &Bloom{Threshold: 1, Knee: 0.5, Intensity: 0.5, Levels: 6}, nil
+}
+
+// resize (re)creates the targets for a width x height source.
+func (b *Bloom) resize(width, height int) error {
+	if err := resizeColorTarget(&b.Output, &b.fbo, width, height); err != nil {
+		return err
+	}
+	if b.width == width && b.height == height && len(b.chain) == b.Levels {
+		return nil
+	}
+	b.deleteChain()
+	b.width, b.height = width, height
+	for i := 0; i < b.Levels; i++ {
+		width, height = maxInt(width/2, 1), maxInt(height/2, 1)
+		tex, fbo, err := newColorTarget(width, height)
+		if err != nil {
+			b.deleteChain()
+			return err
+		}
+		b.chain = append(b.chain, bloomLevel{tex, fbo})
+	}
+	return nil
+}
+
+// maxInt gets the larger of a and b.
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Apply adds glow to source, into Output. Things brighter than Threshold
+// glow, and so does all of emissive, such as a GBuffer's Emissive, if it
+// isn't nil.
+func (b *Bloom) Apply(source, emissive *Texture2D) error {
+	if b.Levels < 1 {
+		b.Levels = 1
+	}
+	if err := b.resize(int(source.Width), int(source.Height)); err != nil {
+		return fmt.Errorf("couldn't create bloom targets: %w", err)
+	}
+
+	saved := saveTargetState()
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.BLEND)
+	bloomProgram.Use()
+	useEmissive := boolToInt32(emissive != nil)
+	bloomProgram.Fragment().SetInt("useEmissive", 1, &useEmissive)
+	bloomProgram.Fragment().SetFloat("threshold", 1, &b.Threshold)
+	bloomProgram.Fragment().SetFloat("knee", 1, &b.Knee)
+	bloomProgram.Fragment().SetFloat("intensity", 1, &b.Intensity)
+	pass := func(mode int32, fbo uint32, target, input *Texture2D) {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, fbo)
+		gl.Viewport(0, 0, target.Width, target.Height)
+		w, h := float32(target.Width), float32(target.Height)
+		projection, model := quadTransform(0, 0, w, h, w, h)
+		texel := mgl32.Vec2{1 / float32(input.Width), 1 / float32(input.Height)}
+		bloomProgram.Vertex().SetMat4("projection", 1, &projection)
+		bloomProgram.Vertex().SetMat4("model", 1, &model)
+		bloomProgram.Fragment().SetInt("mode", 1, &mode)
+		bloomProgram.Fragment().SetVec2("texel", 1, &texel)
+		input.Bind(2)
+		gl.ActiveTexture(gl.TEXTURE0)
+		drawQuad()
+	}
+
+	source.Bind(0)
+	if emissive != nil {
+		emissive.Bind(1)
+	}
+	// bright parts into the first level, then down the chain, then back up
+	// adding each level to the one above
+	pass(bloomPrefilter, b.chain[0].fbo, b.chain[0].tex, source)
+	for i := 1; i < len(b.chain); i++ {
+		pass(bloomDownsample, b.chain[i].fbo, b.chain[i].tex, b.chain[i-1].tex)
+	}
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.ONE, gl.ONE)
+	for i := len(b.chain) - 1; i > 0; i-- {
+		pass(bloomUpsample, b.chain[i-1].fbo, b.chain[i-1].tex, b.chain[i].tex)
+	}
+	gl.Disable(gl.BLEND)
+	source.Bind(0)
+	pass(bloomComposite, b.fbo, b.Output, b.chain[0].tex)
+	saved.restore()
+	return nil
+}
+
+// Gui shows controls for the glow in a window. Call it inside the func of
+// Window.RenderImgui().
+func (b *Bloom) Gui(title string) {
+	if imgui.Begin(title + "##bloom") {
+		levels := int32(b.Levels)
+		imgui.SliderFloat("threshold", &b.Threshold, 0, 10)
+		imgui.SliderFloat("knee", &b.Knee, 0, 1)
+		imgui.SliderFloat("intensity", &b.Intensity, 0, 2)
+		if imgui.SliderInt("levels", &levels, 1, 10) {
+			b.Levels = int(levels)
+		}
+	}
+	imgui.End()
+}
+
+// deleteChain deletes the chain's targets.
+func (b *Bloom) deleteChain() {
+	for _, level := range b.chain {
+		level.tex.Delete()
+		gl.DeleteFramebuffers(1, &level.fbo)
+	}
+	b.chain = nil
+}
+
+// Delete resources.
+func (b *Bloom) Delete() {
+	b.deleteChain()
+	if b.Output != nil {
+		b.Output.Delete()
+		gl.DeleteFramebuffers(1, &b.fbo)
+	}
+}
+
+const bloomFragmentShader = `#version 330 core
+in vec2 TexCoords;
+
+uniform sampler2D source;   // the frame
+uniform sampler2D emissive;
+uniform sampler2D bloom;    // input to this pass
+uniform int mode;           // prefilter, downsample, upsample, composite
+uniform bool useEmissive;
+uniform float threshold;
+uniform float knee;
+uniform float intensity;
+uniform vec2 texel;         // of bloom
+
+out vec4 FragColor;
+
+// box4 averages 4 bilinear samples around uv, so 16 texels.
+vec3 box4(vec2 uv, float spread)
+{
+    vec4 o = texel.xyxy * vec4(-1.0, -1.0, 1.0, 1.0) * spread;
+    return (texture(bloom, uv + o.xy).rgb + texture(bloom, uv + o.zy).rgb +
+            texture(bloom, uv + o.xw).rgb + texture(bloom, uv + o.zw).rgb) * 0.25;
+}
+
+void main()
+{
+    // TexCoords are y down, so flip to sample the same row
+    vec2 uv = vec2(TexCoords.x, 1.0 - TexCoords.y);
+
+    if (mode == 0) {
+        // soft threshold on brightness, plus everything emissive
+        vec3 c = box4(uv, 0.5);
+        float brightness = max(c.r, max(c.g, c.b));
+        float soft = clamp(brightness - threshold + knee, 0.0, 2.0 * knee);
+        soft = soft * soft / (4.0 * knee + 0.0001);
+        float contribution = max(soft, brightness - threshold) / max(brightness, 0.0001);
+        vec3 glow = c * contribution;
+        if (useEmissive) {
+            glow += texture(emissive, uv).rgb;
+        }
+        FragColor = vec4(glow, 1.0);
+    } else if (mode == 1) {
+        FragColor = vec4(box4(uv, 1.0), 1.0);
+    } else if (mode == 2) {
+        FragColor = vec4(box4(uv, 0.5), 1.0);
+    } else {
+        vec4 c = texture(source, uv);
+        FragColor = vec4(c.rgb + texture(bloom, uv).rgb * intensity, c.a);
+    }
+}`
@@ -0,0 +1,188 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// frameCapture saves frames to png files on worker goroutines.
+type frameCapture struct {
+	every   int
+	dir     string
+	frame   int // frames since capture started
+	saved   int // index of the next file
+	dropped int // frames skipped because the workers were busy
+
+	jobs chan captureJob
+	wg   sync.WaitGroup
+
+	// frames are read into pixel buffers and collected a few frames later,
+	// when the GPU is done, so reading doesn't stall rendering.
+	ring    *FenceRing
+	pbos    []uint32
+	pending []captureRead // by slot of ring
+}
+
+// captureRead is a frame being read into a pixel buffer.
+type captureRead struct {
+	width, height int
+	ok            bool
+}
+
+type captureJob struct {
+	img  *image.RGBA
+	path string
+}
+
+// CaptureEveryNthFrame starts saving every nth frame into dir as numbered png
+// files, for assembling into a video with an external tool. Frames are read
+// asynchronously in EndFrame() and encoded on worker goroutines, so
+// rendering isn't stalled.
+// If the workers fall behind, frames are dropped rather than waited for.
+// Any capture already in progress is stopped first.
+func (platform *Window) CaptureEveryNthFrame(n int, dir string) error {
+	platform.StopCapture()
+	if n <= 0 {
+		return fmt.Errorf("invalid capture interval %d", n)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create capture dir: %w", err)
+	}
+
+	workers := runtime.NumCPU()
+	c := &frameCapture{
+		every: n,
+		dir:   dir,
+		jobs:  make(chan captureJob, workers*2),
+		ring:  NewFenceRing(3),
+	}
+	c.pbos = make([]uint32, c.ring.Size())
+	c.pending = make([]captureRead, c.ring.Size())
+	gl.GenBuffers(int32(len(c.pbos)), &c.pbos[0])
+	for i := 0; i < workers; i++ {
+		c.wg.Add(1)
+		go c.work()
+	}
+	platform.capture = c
+	return nil
+}
+
+// StopCapture stops a capture started by CaptureEveryNthFrame(), waiting
+// for frames already captured to be saved.
+func (platform *Window) StopCapture() {
+	c := platform.capture
+	if c == nil {
+		return
+	}
+	platform.capture = nil
+	// collect frames still being read
+	for range c.pbos {
+		c.collect(c.ring.Begin())
+		c.ring.End()
+	}
+	c.ring.Delete()
+	gl.DeleteBuffers(int32(len(c.pbos)), &c.pbos[0])
+	close(c.jobs)
+	c.wg.Wait()
+	if c.dropped > 0 {
+		log.Printf("sgl: capture dropped %d of %d frames", c.dropped, c.saved+c.dropped)
+	}
+}
+
+// Capturing returns true if frames are being captured.
+func (platform *Window) Capturing() bool { return platform.capture != nil }
+
+// CaptureChord makes a chord that starts and stops capturing every nth frame
+// into dir when keys are pressed.
+func (platform *Window) CaptureChord(n int, dir string, keys ...glfw.Key) Chord {
+	return Chord{
+		Name: "capture",
+		Keys: keys,
+		Wait: 0.5,
+		Execute: func() {
+			if platform.Capturing() {
+				platform.StopCapture()
+				return
+			}
+			if err := platform.CaptureEveryNthFrame(n, dir); err != nil {
+				log.Printf("sgl: %v", err)
+			}
+		},
+	}
+}
+
+// captureFrame starts reading the back buffer if this frame is to be
+// captured, and collects the frame read a few frames ago. Called by
+// EndFrame().
+func (platform *Window) captureFrame() {
+	c := platform.capture
+	if c == nil {
+		return
+	}
+	slot := c.ring.Begin()
+	c.collect(slot)
+
+	c.frame++
+	if (c.frame-1)%c.every == 0 {
+		w, h := platform.GlfwWindow.GetFramebufferSize()
+		gl.BindBuffer(gl.PIXEL_PACK_BUFFER, c.pbos[slot])
+		gl.BufferData(gl.PIXEL_PACK_BUFFER, w*h*4, nil, gl.STREAM_READ)
+		gl.ReadBuffer(gl.BACK)
+		gl.ReadPixels(0, 0, int32(w), int32(h), gl.RGBA, gl.UNSIGNED_BYTE, gl.PtrOffset(0))
+		gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+		c.pending[slot] = captureRead{width: w, height: h, ok: true}
+	}
+	c.ring.End()
+}
+
+// collect copies the frame read into slot's pixel buffer, if any, and hands
+// it to the workers. The GPU must be finished with the slot.
+func (c *frameCapture) collect(slot int) {
+	read := c.pending[slot]
+	if !read.ok {
+		return
+	}
+	c.pending[slot] = captureRead{}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, read.width, read.height))
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, c.pbos[slot])
+	ptr := gl.MapBufferRange(gl.PIXEL_PACK_BUFFER, 0, len(rgba.Pix), gl.MAP_READ_BIT)
+	if ptr != nil {
+		copy(rgba.Pix, unsafe.Slice((*byte)(ptr), len(rgba.Pix)))
+		gl.UnmapBuffer(gl.PIXEL_PACK_BUFFER)
+	}
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+	if ptr == nil {
+		c.dropped++
+		return
+	}
+
+	job := captureJob{img: rgba, path: filepath.Join(c.dir, fmt.Sprintf("frame-%06d.png", c.saved))}
+	select {
+	case c.jobs <- job:
+		c.saved++
+	default:
+		c.dropped++
+	}
+}
+
+func (c *frameCapture) work() {
+	defer c.wg.Done()
+	for job := range c.jobs {
+		FlipVertical(job.img)
+		if err := savePNG(job.path, job.img); err != nil {
+			log.Printf("sgl: capture: %v", err)
+		}
+	}
+}
@@ -1,6 +1,11 @@
+//go:build !egl
+
 package sgl
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"sort"
 	"time"
 
@@ -9,25 +14,72 @@ import (
 
 // Chord is an input "gesture", which may be one or more keys (eg CTRL+ALT+T)
 // or mouse buttons (A + left-click).
+//
+// By default a chord executes every frame its input is down (limited by Wait).
+// Setting Hold, Repeat, or DoubleTap instead makes it execute once per press,
+// after the input has been held for Hold seconds, then again every Repeat
+// seconds while still held. If DoubleTap is set, the press must follow the
+// previous press within DoubleTap seconds.
 type Chord struct {
 	lastPressed time.Time
+	Name        string             // Optional name, used to identify the chord when saving and loading bindings
 	Keys        []glfw.Key         // List of keys to be down to execute this chord
 	Mouse       []glfw.MouseButton // List of mouse buttons to be down to execute this chord
 	Execute     func()             // The function to execute
 	Wait        float64            // Wait time (seconds) between sucessive allowable executions
 	Stop        bool               // When set, no further chords will be executed after this one has been
+	Context     string             // Context in which the chord is active. Empty means all contexts.
+	Hold        float64            // Time (seconds) the input must be held before executing
+	Repeat      float64            // Interval (seconds) between repeated executions while held. 0 for no repeat.
+	DoubleTap   float64            // Max time (seconds) between the two presses of a double-tap. 0 to disable.
+
+	enabled func() bool // optional predicate set by When()
+
+	// gesture state
+	wasDown   bool      // input was down during the previous Track()
+	downSince time.Time // when the input was last pressed
+	lastTap   time.Time // previous press, for double-tap detection
+	tapped    bool      // current press completes a double-tap
+	fired     bool      // executed at least once during the current press
 
 	// TODO: consider using time.Duration for "Wait".
 }
 
 // Match determines whether or not the keys for this chord are pressed and if
-// the chord's Wait time has elapsed.
+// the chord's Wait time has elapsed. For chords using Hold, Repeat, or
+// DoubleTap, it also determines if the gesture is complete, from the state
+// kept by Track().
 func (c *Chord) Match(win *glfw.Window, now time.Time) bool {
+	if c.enabled != nil && !c.enabled() {
+		return false
+	}
+
 	// check wait time
 	if now.Sub(c.lastPressed).Seconds() < c.Wait {
 		return false
 	}
 
+	if !c.down(win) || (c.isGesture() && !c.gestureReady(now)) {
+		return false
+	}
+
+	c.lastPressed = now // reset
+	return true
+}
+
+// Track follows presses and releases of the input of a chord using Hold,
+// Repeat, or DoubleTap. It must be called once per frame, whether or not
+// the chord is matched, so none are missed. ChordSet.Match(),
+// ChordSet.Execute(), and Window.ExecuteChords() call it for every chord in
+// the set.
+func (c *Chord) Track(win *glfw.Window, now time.Time) {
+	if c.isGesture() {
+		c.updateGesture(c.down(win), now)
+	}
+}
+
+// down tests if all keys and mouse buttons of the chord are pressed.
+func (c *Chord) down(win *glfw.Window) bool {
 	for i := range c.Keys {
 		if win.GetKey(c.Keys[i]) != glfw.Press {
 			return false
@@ -38,11 +90,46 @@ func (c *Chord) Match(win *glfw.Window, now time.Time) bool {
 			return false
 		}
 	}
-
-	c.lastPressed = now // reset
 	return true
 }
 
+func (c *Chord) isGesture() bool {
+	return c.Hold > 0 || c.Repeat > 0 || c.DoubleTap > 0
+}
+
+// updateGesture tracks presses and releases of the chord's input.
+func (c *Chord) updateGesture(down bool, now time.Time) {
+	if down && !c.wasDown {
+		c.downSince = now
+		c.fired = false
+		if c.DoubleTap > 0 {
+			c.tapped = now.Sub(c.lastTap).Seconds() <= c.DoubleTap
+			if c.tapped {
+				c.lastTap = time.Time{} // a third tap starts a new double-tap
+			} else {
+				c.lastTap = now
+			}
+		}
+	}
+	c.wasDown = down
+}
+
+// gestureReady determines if a gesture should execute while the input is down.
+func (c *Chord) gestureReady(now time.Time) bool {
+	if c.DoubleTap > 0 && !c.tapped {
+		return false
+	}
+	if now.Sub(c.downSince).Seconds() < c.Hold {
+		return false
+	}
+	if !c.fired {
+		c.fired = true
+		return true
+	}
+	// lastPressed is the time of the previous execution
+	return c.Repeat > 0 && now.Sub(c.lastPressed).Seconds() >= c.Repeat
+}
+
 // ChordSet is a logic grouping of (related) Chords.
 type ChordSet []Chord
 
@@ -51,6 +138,7 @@ type ChordSet []Chord
 func (cs ChordSet) Match(win *glfw.Window) *Chord {
 	var i int
 	now := time.Now()
+	cs.track(win, now)
 	for i = 0; i < len(cs); i++ {
 		if cs[i].Match(win, now) {
 			return &cs[i]
@@ -63,16 +151,36 @@ func (cs ChordSet) Match(win *glfw.Window) *Chord {
 // the current key state. Execution of chords will stop when
 // the first chord is encountered with its "Stop" member set to true.
 func (cs ChordSet) Execute(win *glfw.Window) {
+	cs.execute(win, nil)
+}
+
+// execute runs matching chords for which active returns true, or all
+// matching chords if active is nil.
+func (cs ChordSet) execute(win *glfw.Window, active func(*Chord) bool) {
 	var done bool
 	now := time.Now()
+	cs.track(win, now)
 	for i := 0; i < len(cs) && !done; i++ {
+		if active != nil && !active(&cs[i]) {
+			continue
+		}
 		if cs[i].Match(win, now) {
-			cs[i].Execute()
+			if cs[i].Execute != nil {
+				cs[i].Execute()
+			}
 			done = cs[i].Stop
 		}
 	}
 }
 
+// track calls Track() for every chord in the set, including those which
+// won't be matched this frame.
+func (cs ChordSet) track(win *glfw.Window, now time.Time) {
+	for i := range cs {
+		cs[i].Track(win, now)
+	}
+}
+
 // Sort called sort.Sort() on the ChordSet, returning the same
 // ChordSet for convenience.
 func (cs ChordSet) Sort() ChordSet {
@@ -120,3 +228,302 @@ func ExecuteSets(sets []ChordSet, win *glfw.Window) {
 		sets[i].Execute(win)
 	}
 }
+
+// When sets a predicate that must return true for the chord to match,
+// returning the chord for convenience in ChordSet literals. Example:
+//
+//	Chord{Keys: []glfw.Key{glfw.KeyDelete}, Execute: deleteSelection}.When(hasSelection)
+func (c Chord) When(enabled func() bool) Chord {
+	c.enabled = enabled
+	return c
+}
+
+// InContext sets the Context of every chord in the set, returning the
+// set for convenience.
+func (cs ChordSet) InContext(name string) ChordSet {
+	for i := range cs {
+		cs[i].Context = name
+	}
+	return cs
+}
+
+// ContextStack tracks the current input context, such as "gameplay" or
+// "editing". The context on top of the stack is the current one.
+type ContextStack []string
+
+// Push makes name the current context.
+func (s *ContextStack) Push(name string) {
+	*s = append(*s, name)
+}
+
+// Pop removes the current context and returns it, restoring the previous
+// context. Returns "" if the stack is empty.
+func (s *ContextStack) Pop() string {
+	if len(*s) == 0 {
+		return ""
+	}
+	top := (*s)[len(*s)-1]
+	*s = (*s)[:len(*s)-1]
+	return top
+}
+
+// Current gets the current context, or "" if the stack is empty.
+func (s ContextStack) Current() string {
+	if len(s) == 0 {
+		return ""
+	}
+	return s[len(s)-1]
+}
+
+// Active returns true if a chord with the given context should be evaluated.
+// The empty context is always active.
+func (s ContextStack) Active(context string) bool {
+	return context == "" || context == s.Current()
+}
+
+// ExecuteChords runs the chords in each set like ExecuteSets(), except that
+// only chords active in the Window's current Contexts are evaluated, and
+// chords are skipped while imgui is capturing the keyboard (for chords with
+// keys) or mouse (for chords with mouse buttons).
+func (platform *Window) ExecuteChords(sets []ChordSet) {
+	keyboard, mouse := platform.CapturesKeyboard(), platform.CapturesMouse()
+	active := func(c *Chord) bool {
+		if (keyboard && len(c.Keys) > 0) || (mouse && len(c.Mouse) > 0) {
+			return false
+		}
+		return platform.Contexts.Active(c.Context)
+	}
+	for i := range sets {
+		sets[i].execute(platform.GlfwWindow, active)
+	}
+}
+
+// chordJSON is the serialized form of a Chord. The Execute function can't be
+// serialized, so chords are matched to their functions by Name.
+type chordJSON struct {
+	Name      string  `json:"name"`
+	Input     string  `json:"input"`
+	Wait      float64 `json:"wait,omitempty"`
+	Stop      bool    `json:"stop,omitempty"`
+	Hold      float64 `json:"hold,omitempty"`
+	Repeat    float64 `json:"repeat,omitempty"`
+	DoubleTap float64 `json:"doubleTap,omitempty"`
+	Context   string  `json:"context,omitempty"`
+}
+
+// MarshalJSON saves the bindings of the ChordSet as a list of objects
+// with the chord's Name, input (as from Chord.String()), timing options, and
+// Context.
+func (cs ChordSet) MarshalJSON() ([]byte, error) {
+	list := make([]chordJSON, len(cs))
+	for i := range cs {
+		list[i] = chordJSON{
+			Name:      cs[i].Name,
+			Input:     cs[i].String(),
+			Wait:      cs[i].Wait,
+			Stop:      cs[i].Stop,
+			Hold:      cs[i].Hold,
+			Repeat:    cs[i].Repeat,
+			DoubleTap: cs[i].DoubleTap,
+			Context:   cs[i].Context,
+		}
+	}
+	return json.Marshal(list)
+}
+
+// UnmarshalJSON loads bindings into the ChordSet. Chords already in the set
+// with a matching Name keep their Execute function, When() predicate, and
+// Context (unless the entry has one) but have their input and timing options
+// replaced. Entries with names not in the set are appended with a nil
+// Execute, which can be set later with Bind().
+func (cs *ChordSet) UnmarshalJSON(data []byte) error {
+	var list []chordJSON
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	for _, item := range list {
+		parsed, err := ParseChord(item.Input)
+		if err != nil {
+			return fmt.Errorf("chord %q: %w", item.Name, err)
+		}
+		parsed.Name = item.Name
+		parsed.Wait = item.Wait
+		parsed.Stop = item.Stop
+		parsed.Hold = item.Hold
+		parsed.Repeat = item.Repeat
+		parsed.DoubleTap = item.DoubleTap
+		parsed.Context = item.Context
+
+		if c := cs.Find(item.Name); c != nil && item.Name != "" {
+			parsed.Execute = c.Execute
+			parsed.enabled = c.enabled
+			if parsed.Context == "" {
+				parsed.Context = c.Context
+			}
+			*c = parsed
+		} else {
+			*cs = append(*cs, parsed)
+		}
+	}
+	return nil
+}
+
+// Find gets the first Chord in the set with the given name, or nil if
+// there is none.
+func (cs ChordSet) Find(name string) *Chord {
+	for i := range cs {
+		if cs[i].Name == name {
+			return &cs[i]
+		}
+	}
+	return nil
+}
+
+// Bind sets the Execute function of the chord with the given name. It
+// returns false if no such chord is in the set.
+func (cs ChordSet) Bind(name string, execute func()) bool {
+	c := cs.Find(name)
+	if c == nil {
+		return false
+	}
+	c.Execute = execute
+	return true
+}
+
+// ConflictKind describes how two chords conflict.
+type ConflictKind int
+
+// Kinds of chord conflicts.
+const (
+	// Both chords use exactly the same input.
+	Duplicate ConflictKind = iota
+	// The chord with fewer inputs is checked first and has Stop set, so the
+	// other chord can never execute.
+	Shadowed
+	// Pressing the chord with more inputs also executes the chord with
+	// fewer inputs (eg CTRL+SHIFT+S also executes CTRL+S).
+	Overlapped
+)
+
+func (k ConflictKind) String() string {
+	switch k {
+	case Duplicate:
+		return "duplicates"
+	case Shadowed:
+		return "shadows"
+	case Overlapped:
+		return "overlaps"
+	default:
+		return "conflicts with"
+	}
+}
+
+// ChordRef locates a Chord by the index of its set and its index in that set.
+type ChordRef struct {
+	Set, Index int
+	Chord      *Chord
+}
+
+func (r ChordRef) String() string {
+	name := r.Chord.Name
+	if name == "" {
+		name = fmt.Sprintf("set %d, chord %d", r.Set, r.Index)
+	}
+	return fmt.Sprintf("%s (%s)", r.Chord, name)
+}
+
+// ChordConflict is a pair of chords whose inputs conflict. A has the same or
+// fewer inputs than B.
+type ChordConflict struct {
+	Kind ConflictKind
+	A, B ChordRef
+}
+
+func (c ChordConflict) String() string {
+	return fmt.Sprintf("%s %s %s", c.A, c.Kind, c.B)
+}
+
+// FindConflicts checks all pairs of chords in the sets for chords that are
+// duplicated or that will also execute (or block) another chord. Sets are
+// assumed to be executed in order, as with ExecuteSets().
+func FindConflicts(sets []ChordSet) []ChordConflict {
+	var refs []ChordRef
+	for s := range sets {
+		for i := range sets[s] {
+			refs = append(refs, ChordRef{Set: s, Index: i, Chord: &sets[s][i]})
+		}
+	}
+
+	var conflicts []ChordConflict
+	for i := 0; i < len(refs); i++ {
+		for j := i + 1; j < len(refs); j++ {
+			first, second := refs[i], refs[j] // first is always checked before second
+			if kind, ok := chordConflict(first, second); ok {
+				conflicts = append(conflicts, ChordConflict{Kind: kind, A: first, B: second})
+			} else if kind, ok := chordConflict(second, first); ok {
+				conflicts = append(conflicts, ChordConflict{Kind: kind, A: second, B: first})
+			}
+		}
+	}
+	return conflicts
+}
+
+// chordConflict tests if the input of a is a subset of that of b, and if so
+// what kind of conflict it is.
+func chordConflict(a, b ChordRef) (ConflictKind, bool) {
+	if !a.Chord.subsetOf(b.Chord) {
+		return 0, false
+	}
+	if b.Chord.subsetOf(a.Chord) {
+		return Duplicate, true
+	}
+
+	aFirst := a.Set < b.Set || (a.Set == b.Set && a.Index < b.Index)
+	switch {
+	case a.Set != b.Set:
+		// Stop has no effect across sets
+		return Overlapped, true
+	case aFirst && a.Chord.Stop:
+		return Shadowed, true
+	case !aFirst && b.Chord.Stop:
+		// the larger chord goes first and stops the smaller one. this is
+		// the correct way to set up such chords.
+		return 0, false
+	default:
+		return Overlapped, true
+	}
+}
+
+// subsetOf tests if all the input of c is also part of other.
+func (c *Chord) subsetOf(other *Chord) bool {
+	for _, k := range c.Keys {
+		found := false
+		for _, o := range other.Keys {
+			found = found || k == o
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, m := range c.Mouse {
+		found := false
+		for _, o := range other.Mouse {
+			found = found || m == o
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ReportConflicts writes each conflict found by FindConflicts() to w, and
+// returns the number of conflicts. Useful to call once at startup.
+func ReportConflicts(w io.Writer, sets []ChordSet) int {
+	conflicts := FindConflicts(sets)
+	for _, c := range conflicts {
+		fmt.Fprintln(w, "chord conflict:", c)
+	}
+	return len(conflicts)
+}
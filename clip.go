@@ -0,0 +1,82 @@
+package sgl
+
+import "github.com/go-gl/gl/v3.3-core/gl"
+
+// ClipRect is a rectangle in pixels with (0, 0) at the top left of the
+// viewport, like CharacterDict.DrawString().
+type ClipRect struct {
+	X, Y, W, H int32
+}
+
+// intersect gets the overlap of two rects, which may be empty.
+func (r ClipRect) intersect(o ClipRect) ClipRect {
+	x0, y0 := maxInt32(r.X, o.X), maxInt32(r.Y, o.Y)
+	x1, y1 := minInt32(r.X+r.W, o.X+o.W), minInt32(r.Y+r.H, o.Y+o.H)
+	if x1 < x0 {
+		x1 = x0
+	}
+	if y1 < y0 {
+		y1 = y0
+	}
+	return ClipRect{X: x0, Y: y0, W: x1 - x0, H: y1 - y0}
+}
+
+// clip rects pushed by PushClipRect(). opengl state is global, so this is too.
+var clipStack []ClipRect
+
+// PushClipRect restricts drawing to the rectangle, using the scissor test.
+// The rectangle is intersected with any rectangle already pushed, so nested
+// regions (eg a scrolling list inside a panel) clip correctly. Every push must
+// be matched by PopClipRect().
+func PushClipRect(x, y, w, h int32) {
+	r := ClipRect{X: x, Y: y, W: w, H: h}
+	if len(clipStack) > 0 {
+		r = r.intersect(clipStack[len(clipStack)-1])
+	}
+	clipStack = append(clipStack, r)
+	applyClipRect(r)
+}
+
+// PopClipRect restores the clipping in effect before the matching
+// PushClipRect(), disabling the scissor test if none remains.
+func PopClipRect() {
+	if len(clipStack) == 0 {
+		return
+	}
+	clipStack = clipStack[:len(clipStack)-1]
+	if len(clipStack) == 0 {
+		gl.Disable(gl.SCISSOR_TEST)
+		return
+	}
+	applyClipRect(clipStack[len(clipStack)-1])
+}
+
+// CurrentClipRect gets the clip rect in effect, and false if there is none.
+func CurrentClipRect() (ClipRect, bool) {
+	if len(clipStack) == 0 {
+		return ClipRect{}, false
+	}
+	return clipStack[len(clipStack)-1], true
+}
+
+func applyClipRect(r ClipRect) {
+	// scissor uses opengl's bottom left origin
+	var viewport [4]int32
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	gl.Enable(gl.SCISSOR_TEST)
+	gl.Scissor(viewport[0]+r.X, viewport[1]+viewport[3]-(r.Y+r.H), r.W, r.H)
+}
+
+func minInt32(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
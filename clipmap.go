@@ -0,0 +1,359 @@
+package sgl
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// only need this once in the package
+var clipmapProgram *Program
+
+// called to create and build the clipmap program.
+func initClipmapProgram() error {
+	clipmapProgram = NewProgram()
+	clipmapProgram.AddShader(VertexShader, clipmapVertexShader,
+		append([]string{"projection", "view", "cameraPos", "levelCenter", "spacing", "halfGrid",
+			"heightmap", "texelSize", "heightmapTexels", "heightScale"}, ClipPlaneUniforms...),
+		Attribute{Name: "aGrid", Type: gl.FLOAT, Size: 2, Stride: 2 * SizeOfFloat, Offset: 0})
+	clipmapProgram.AddShader(FragmentShader, clipmapFragmentShader,
+		[]string{"heightmap", "texelSize", "heightmapTexels", "heightScale", "hole",
+			"color", "lightDirection", "lightColor", "ambient", "cameraPos"})
+
+	if err := clipmapProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build clipmap program: %w", err)
+	}
+	BindFog(clipmapProgram)
+	var heightmap int32 = 0
+	clipmapProgram.Vertex().SetInt("heightmap", 1, &heightmap)
+	clipmapProgram.Fragment().SetInt("heightmap", 1, &heightmap)
+	return nil
+}
+
+// HeightFunc gets the heights of the w x h texels starting at texel (x, y),
+// row by row, for a ClipmapTerrain. Texel (x, y) is at world position
+// (x*TexelSize, y*TexelSize) on the xz plane, and may be negative.
+type HeightFunc func(x, y, w, h int) []float32
+
+// ClipmapTerrain is terrain drawn as a geometry clipmap: square grids of the
+// same number of vertices, nested around the camera, each twice the size
+// and spacing of the one inside it. Detail falls off with distance while the
+// vertex count stays fixed, so the terrain can be as large as its height
+// data. Grid vertices near the edge of each level morph to match the next
+// level, so there are no cracks between them.
+//
+// Heights come from Heightmap, a single-channel float texture which is
+// streamed from Heights as the camera moves. It wraps around (toroidally),
+// so only the rows and columns newly in range are loaded. Each frame, call
+// Update() before Draw().
+//
+//	terrain, err := sgl.NewClipmapTerrain(64, 6, 1, 2560, 1, noiseHeights)
+//	...
+//	if err := terrain.Update(cameraPos); err != nil {
+//		log.Print(err)
+//	}
+//	terrain.Draw(view, projection, cameraPos, sun)
+type ClipmapTerrain struct {
+	GridSize    int     // quads along each side of a level; a multiple of 4
+	Levels      int     // nested levels
+	Spacing     float32 // distance between vertices of the finest level
+	TexelSize   float32 // distance between heightmap texels
+	HeightScale float32 // multiplies the heights
+	Color       Color
+	Heights     HeightFunc
+	Heightmap   *Texture2D // Heightmap.Width texels square
+
+	origin [2]int // world texel at the low corner of the loaded heightmap
+	loaded bool
+	grid   *Vao // full grid for the finest level
+	ring   *Vao // grid with a hole for the inner level, for the others
+}
+
+// NewClipmapTerrain creates terrain with levels nested grids, each with
+// gridSize quads per side, and a heightmapSize texels square heightmap which
+// must cover the outermost level. The finest vertices are spacing apart.
+func NewClipmapTerrain(gridSize, levels int, spacing float32, heightmapSize int, texelSize float32, heights HeightFunc) (*ClipmapTerrain, error) {
+	if gridSize < 16 || gridSize%4 != 0 || levels < 1 {
+		return nil, fmt.Errorf("invalid clipmap grid size %d or levels %d", gridSize, levels)
+	}
+	// the outer level moves in steps of twice its spacing, plus a texel of
+	// margin for filtering
+	outer := float32(gridSize+4) * spacing * float32(int(1)<<(levels-1))
+	if need := int(math.Ceil(float64(outer/texelSize))) + 2; heightmapSize < need {
+		return nil, fmt.Errorf("clipmap heightmap of %d texels is too small for the terrain (need %d)", heightmapSize, need)
+	}
+	if clipmapProgram == nil {
+		if err := initClipmapProgram(); err != nil {
+			return nil, err
+		}
+	}
+
+	heightmap, err := NewTextureData(heightmapSize, heightmapSize, TexR32F, nil)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create clipmap heightmap: %w", err)
+	}
+	backend.BindTexture(gl.TEXTURE_2D, heightmap.ID)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+	backend.BindTexture(gl.TEXTURE_2D, 0)
+
+	return &ClipmapTerrain{
+		GridSize:    gridSize,
+		Levels:      levels,
+		Spacing:     spacing,
+		TexelSize:   texelSize,
+		HeightScale: 1,
+		Color:       Color{0.45, 0.5, 0.35, 1},
+		Heights:     heights,
+		Heightmap:   heightmap,
+		grid:        clipmapGrid(gridSize, 0),
+		ring:        clipmapGrid(gridSize, gridSize/4-1),
+	}, nil
+}
+
+// clipmapGrid makes a grid of n x n quads centered on the origin, with
+// vertices at integer positions, leaving out the quads within hole of the
+// center.
+func clipmapGrid(n, hole int) *Vao {
+	half := n / 2
+	vertices := make([]float32, 0, (n+1)*(n+1)*2)
+	for z := -half; z <= half; z++ {
+		for x := -half; x <= half; x++ {
+			vertices = append(vertices, float32(x), float32(z))
+		}
+	}
+	var indices []uint32
+	for z := 0; z < n; z++ {
+		for x := 0; x < n; x++ {
+			qx, qz := x-half, z-half
+			if qx >= -hole && qx < hole && qz >= -hole && qz < hole {
+				continue
+			}
+			i := uint32(z*(n+1) + x)
+			row := uint32(n + 1)
+			indices = append(indices, i, i+row, i+1, i+1, i+row, i+row+1)
+		}
+	}
+
+	vao := NewVao(Triangles, NewVbo("vbo", clipmapProgram.Vertex().Attributes()...))
+	vao.Vbo["vbo"].Initalize(vertices)
+	vao.Ebo.Initalize(indices)
+	return vao
+}
+
+// Delete resources.
+func (t *ClipmapTerrain) Delete() {
+	t.Heightmap.Delete()
+	t.grid.Delete()
+	t.ring.Delete()
+}
+
+// Update streams the heights around cameraPos into Heightmap, loading only
+// those which weren't already loaded.
+func (t *ClipmapTerrain) Update(cameraPos mgl32.Vec3) error {
+	size := int(t.Heightmap.Width)
+	origin := [2]int{
+		int(math.Floor(float64(cameraPos.X()/t.TexelSize))) - size/2,
+		int(math.Floor(float64(cameraPos.Z()/t.TexelSize))) - size/2,
+	}
+	window := image.Rect(origin[0], origin[1], origin[0]+size, origin[1]+size)
+	old := image.Rect(t.origin[0], t.origin[1], t.origin[0]+size, t.origin[1]+size)
+	t.origin = origin
+	if !t.loaded || !window.Overlaps(old) {
+		t.loaded = false
+		if err := t.load(window); err != nil {
+			return err
+		}
+		t.loaded = true
+		return nil
+	}
+
+	// the columns, then the rows, which came into range
+	columns, rows := window, window
+	switch {
+	case window.Min.X > old.Min.X:
+		columns.Min.X = old.Max.X
+	case window.Min.X < old.Min.X:
+		columns.Max.X = old.Min.X
+	default:
+		columns = image.Rectangle{}
+	}
+	switch {
+	case window.Min.Y > old.Min.Y:
+		rows.Min.Y = old.Max.Y
+	case window.Min.Y < old.Min.Y:
+		rows.Max.Y = old.Min.Y
+	default:
+		rows = image.Rectangle{}
+	}
+	for _, rect := range []image.Rectangle{columns, rows} {
+		if err := t.load(rect); err != nil {
+			t.loaded = false // reload everything next time
+			return err
+		}
+	}
+	return nil
+}
+
+// load gets the heights of rect, in world texels, from Heights and copies
+// them to where they wrap to in Heightmap, splitting rect where it wraps.
+func (t *ClipmapTerrain) load(rect image.Rectangle) error {
+	size := int(t.Heightmap.Width)
+	for y := rect.Min.Y; y < rect.Max.Y; {
+		wy := wrap(y, size)
+		h := rect.Max.Y - y
+		if h > size-wy {
+			h = size - wy
+		}
+		for x := rect.Min.X; x < rect.Max.X; {
+			wx := wrap(x, size)
+			w := rect.Max.X - x
+			if w > size-wx {
+				w = size - wx
+			}
+			at := image.Rect(wx, wy, wx+w, wy+h)
+			if err := t.Heightmap.SetPixels(at, t.Heights(x, y, w, h)); err != nil {
+				return fmt.Errorf("couldn't load clipmap heights: %w", err)
+			}
+			x += w
+		}
+		y += h
+	}
+	return nil
+}
+
+// Draw the terrain around cameraPos, lit by sun.
+func (t *ClipmapTerrain) Draw(view, projection mgl32.Mat4, cameraPos mgl32.Vec3, sun DirectionalLight) {
+	color := t.Color.Vec4()
+	lightDir := sun.Direction.Normalize()
+	lightColor := mgl32.Vec3{sun.Color.R, sun.Color.G, sun.Color.B}
+	ambient := mgl32.Vec3{sun.Ambient.R, sun.Ambient.G, sun.Ambient.B}
+	halfGrid := float32(t.GridSize / 2)
+	texels := float32(t.Heightmap.Width)
+
+	clipmapProgram.Use()
+	vs, fs := clipmapProgram.Vertex(), clipmapProgram.Fragment()
+	vs.SetMat4("projection", 1, &projection)
+	vs.SetMat4("view", 1, &view)
+	vs.SetVec3("cameraPos", 1, &cameraPos)
+	vs.SetFloat("halfGrid", 1, &halfGrid)
+	vs.SetClipPlanes()
+	for _, s := range []*Shader{vs, fs} {
+		s.SetFloat("texelSize", 1, &t.TexelSize)
+		s.SetFloat("heightmapTexels", 1, &texels)
+		s.SetFloat("heightScale", 1, &t.HeightScale)
+	}
+	fs.SetVec4("color", 1, &color)
+	fs.SetVec3("lightDirection", 1, &lightDir)
+	fs.SetVec3("lightColor", 1, &lightColor)
+	fs.SetVec3("ambient", 1, &ambient)
+	fs.SetVec3("cameraPos", 1, &cameraPos)
+	t.Heightmap.Bind(0)
+
+	hole := mgl32.Vec4{1, 1, -1, -1} // none
+	for level := 0; level < t.Levels; level++ {
+		spacing := t.Spacing * float32(int(1)<<level)
+		// snapped to twice the spacing, so the vertices of each level line
+		// up with those of the next
+		center := mgl32.Vec2{
+			snapDown(cameraPos.X(), 2*spacing),
+			snapDown(cameraPos.Z(), 2*spacing),
+		}
+		vs.SetVec2("levelCenter", 1, &center)
+		vs.SetFloat("spacing", 1, &spacing)
+		fs.SetVec4("hole", 1, &hole)
+		if level == 0 {
+			t.grid.Draw()
+		} else {
+			t.ring.Draw()
+		}
+		// the next level leaves out this one
+		extent := halfGrid * spacing
+		hole = mgl32.Vec4{center.X() - extent, center.Y() - extent, center.X() + extent, center.Y() + extent}
+	}
+}
+
+// snapDown rounds x down to a multiple of step.
+func snapDown(x, step float32) float32 {
+	return float32(math.Floor(float64(x/step))) * step
+}
+
+// wrap gets x modulo m, which is never negative.
+func wrap(x, m int) int {
+	return (x%m + m) % m
+}
+
+const clipmapHeightSource = `
+uniform sampler2D heightmap;
+uniform float texelSize;
+uniform float heightmapTexels;
+uniform float heightScale;
+
+// height gets the terrain height at a world xz position. The heightmap wraps,
+// with texel i at i * texelSize.
+float height(vec2 world)
+{
+    vec2 uv = (world / texelSize + 0.5) / heightmapTexels;
+    return textureLod(heightmap, uv, 0.0).r * heightScale;
+}
+`
+
+const clipmapVertexShader = `#version 330 core
+` + ClipPlaneShaderSource + clipmapHeightSource + `
+in vec2 aGrid;
+
+uniform mat4 projection;
+uniform mat4 view;
+uniform vec3 cameraPos;
+uniform vec2 levelCenter;
+uniform float spacing;
+uniform float halfGrid;
+
+out vec3 WorldPos;
+
+void main()
+{
+    // morph odd vertices onto their even neighbors near the edge, where
+    // they meet the next level's grid, which has half as many
+    vec2 world = levelCenter + aGrid * spacing;
+    vec2 fromCamera = abs(world - cameraPos.xz) / spacing;
+    float morphWidth = halfGrid / 4.0;
+    float alpha = clamp((max(fromCamera.x, fromCamera.y) - (halfGrid - 2.0 - morphWidth)) / morphWidth, 0.0, 1.0);
+    vec2 grid = aGrid - mod(aGrid, 2.0) * alpha;
+    world = levelCenter + grid * spacing;
+
+    WorldPos = vec3(world.x, height(world), world.y);
+    applyClipPlanes(WorldPos);
+    gl_Position = projection * view * vec4(WorldPos, 1.0);
+}`
+
+const clipmapFragmentShader = `#version 330 core
+` + FogShaderSource + clipmapHeightSource + `
+uniform vec4 hole; // xz min and max covered by the finer level
+uniform vec4 color;
+uniform vec3 lightDirection;
+uniform vec3 lightColor;
+uniform vec3 ambient;
+uniform vec3 cameraPos;
+
+in vec3 WorldPos;
+
+out vec4 FragColor;
+
+void main()
+{
+    if (all(greaterThan(WorldPos.xz, hole.xy)) && all(lessThan(WorldPos.xz, hole.zw))) {
+        discard;
+    }
+
+    float dx = height(WorldPos.xz + vec2(texelSize, 0.0)) - height(WorldPos.xz - vec2(texelSize, 0.0));
+    float dz = height(WorldPos.xz + vec2(0.0, texelSize)) - height(WorldPos.xz - vec2(0.0, texelSize));
+    vec3 normal = normalize(vec3(-dx, 2.0 * texelSize, -dz));
+
+    float diffuse = max(dot(normal, -lightDirection), 0.0);
+    vec3 lit = color.rgb * (ambient + lightColor * diffuse);
+    FragColor = vec4(applyFog(lit, WorldPos, cameraPos), color.a);
+}`
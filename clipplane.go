@@ -0,0 +1,81 @@
+package sgl
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// MaxClipPlanes is the number of clip planes supported by SetClipPlanes().
+// Opengl guarantees at least 8.
+const MaxClipPlanes = 8
+
+// ClipPlaneUniforms are the uniforms declared by ClipPlaneShaderSource. Add
+// them to the vertex shader's uniforms in Program.AddShader().
+var ClipPlaneUniforms = []string{"clipPlanes", "clipPlaneCount"}
+
+// ClipPlaneShaderSource declares the clip plane uniforms and a function to
+// apply them. Put it directly after the #version line of a vertex shader,
+// then call
+//
+//	applyClipPlanes(worldPos);
+//
+// in main(), and Shader.SetClipPlanes() after Program.Use().
+const ClipPlaneShaderSource = `
+uniform vec4 clipPlanes[8];
+uniform int clipPlaneCount;
+
+out float gl_ClipDistance[8];
+
+// applyClipPlanes clips away everything on the negative side of each plane.
+void applyClipPlanes(vec3 worldPos)
+{
+    for (int i = 0; i < 8; i++) {
+        gl_ClipDistance[i] = i < clipPlaneCount ? dot(vec4(worldPos, 1.0), clipPlanes[i]) : 1.0;
+    }
+}
+`
+
+// clip planes set by SetClipPlanes(). opengl state is global, so this is too.
+var clipPlanes []mgl32.Vec4
+
+// SetClipPlanes enables clipping by each plane, given as (normal, distance)
+// in world space, and disables any other planes. Everything on the negative
+// side of a plane is clipped, in shaders using ClipPlaneShaderSource. Call
+// with no planes to disable clipping.
+func SetClipPlanes(planes ...mgl32.Vec4) {
+	if len(planes) > MaxClipPlanes {
+		planes = planes[:MaxClipPlanes]
+	}
+	for i := 0; i < MaxClipPlanes; i++ {
+		if i < len(planes) {
+			gl.Enable(uint32(gl.CLIP_DISTANCE0 + i))
+		} else if i < len(clipPlanes) {
+			gl.Disable(uint32(gl.CLIP_DISTANCE0 + i))
+		}
+	}
+	clipPlanes = append(clipPlanes[:0], planes...)
+}
+
+// ClipPlanes gets a copy of the clip planes set by SetClipPlanes().
+func ClipPlanes() []mgl32.Vec4 {
+	return append([]mgl32.Vec4(nil), clipPlanes...)
+}
+
+// ClipPlaneFromPoint makes a clip plane which keeps the side of point that
+// normal points towards.
+func ClipPlaneFromPoint(normal, point mgl32.Vec3) mgl32.Vec4 {
+	normal = normal.Normalize()
+	return normal.Vec4(-normal.Dot(point))
+}
+
+// SetClipPlanes sets the uniforms declared by ClipPlaneShaderSource to the
+// planes set by SetClipPlanes(). The shader's program must be in use.
+func (s *Shader) SetClipPlanes() {
+	count := int32(len(clipPlanes))
+	if loc, ok := s.Uniforms["clipPlaneCount"]; ok {
+		gl.Uniform1i(loc, count)
+	}
+	if loc, ok := s.Uniforms["clipPlanes"]; ok && count > 0 {
+		gl.Uniform4fv(loc, count, &clipPlanes[0][0])
+	}
+}
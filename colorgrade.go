@@ -0,0 +1,366 @@
+package sgl
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// only need this once in the package
+var colorGradeProgram *Program
+
+// called to create and build the color grade program.
+func initColorGradeProgram() error {
+	colorGradeProgram = NewProgram()
+	colorGradeProgram.AddShader(VertexShader, quadVertexShader,
+		[]string{"projection", "model"},
+		Attribute{Name: "vertex", Type: gl.FLOAT, Size: 4, Stride: 4 * SizeOfFloat, Offset: 0})
+	colorGradeProgram.AddShader(FragmentShader, colorGradeFragmentShader,
+		[]string{"source", "lut", "cdf", "useLUT", "lutSize", "strength", "domainMin", "domainMax",
+			"lift", "gamma", "gain", "equalize", "minLog", "maxLog"})
+	if err := colorGradeProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build color grade program: %w", err)
+	}
+	var source, lut, cdf int32 = 0, 1, 2
+	colorGradeProgram.Fragment().SetInt("source", 1, &source)
+	colorGradeProgram.Fragment().SetInt("lut", 1, &lut)
+	colorGradeProgram.Fragment().SetInt("cdf", 1, &cdf)
+	return nil
+}
+
+// CubeLUT is a 3D color lookup table, as stored in .cube files.
+type CubeLUT struct {
+	Title                string
+	Size                 int        // entries along each axis
+	DomainMin, DomainMax mgl32.Vec3 // input colors mapped to the first and last entries
+	Data                 []float32  // rgb for each entry, with red changing fastest
+}
+
+// IdentityCubeLUT creates a LUT of size entries per axis that doesn't change
+// colors, to start a look from.
+func IdentityCubeLUT(size int) *CubeLUT {
+	lut := &CubeLUT{
+		Size:      size,
+		DomainMax: mgl32.Vec3{1, 1, 1},
+		Data:      make([]float32, 0, size*size*size*3),
+	}
+	for b := 0; b < size; b++ {
+		for g := 0; g < size; g++ {
+			for r := 0; r < size; r++ {
+				n := float32(size - 1)
+				lut.Data = append(lut.Data, float32(r)/n, float32(g)/n, float32(b)/n)
+			}
+		}
+	}
+	return lut
+}
+
+// OpenCubeLUT reads a 3D LUT from a .cube file.
+func OpenCubeLUT(filename string) (*CubeLUT, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s: %w", filename, err)
+	}
+	defer file.Close()
+	lut, err := ReadCubeLUT(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", filename, err)
+	}
+	return lut, nil
+}
+
+// ReadCubeLUT reads a 3D LUT in the .cube format. 1D LUTs aren't supported.
+func ReadCubeLUT(r io.Reader) (*CubeLUT, error) {
+	lut := &CubeLUT{DomainMax: mgl32.Vec3{1, 1, 1}}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		switch fields[0] {
+		case "TITLE":
+			lut.Title = strings.Trim(strings.TrimSpace(strings.TrimPrefix(text, "TITLE")), `"`)
+		case "LUT_3D_SIZE":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: bad LUT_3D_SIZE", line)
+			}
+			size, err := strconv.Atoi(fields[1])
+			if err != nil || size < 2 {
+				return nil, fmt.Errorf("line %d: bad LUT_3D_SIZE %q", line, fields[1])
+			}
+			lut.Size = size
+			lut.Data = make([]float32, 0, size*size*size*3)
+		case "LUT_1D_SIZE":
+			return nil, fmt.Errorf("line %d: 1D LUTs aren't supported", line)
+		case "DOMAIN_MIN", "DOMAIN_MAX":
+			v, err := parseFloats(fields[1:], 3)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if fields[0] == "DOMAIN_MIN" {
+				lut.DomainMin = mgl32.Vec3{v[0], v[1], v[2]}
+			} else {
+				lut.DomainMax = mgl32.Vec3{v[0], v[1], v[2]}
+			}
+		case "LUT_3D_INPUT_RANGE":
+			v, err := parseFloats(fields[1:], 2)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			lut.DomainMin = mgl32.Vec3{v[0], v[0], v[0]}
+			lut.DomainMax = mgl32.Vec3{v[1], v[1], v[1]}
+		default:
+			if lut.Size == 0 {
+				return nil, fmt.Errorf("line %d: data before LUT_3D_SIZE", line)
+			}
+			v, err := parseFloats(fields, 3)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			lut.Data = append(lut.Data, v...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if lut.Size == 0 {
+		return nil, fmt.Errorf("no LUT_3D_SIZE")
+	}
+	if want := lut.Size * lut.Size * lut.Size * 3; len(lut.Data) != want {
+		return nil, fmt.Errorf("got %d entries, want %d", len(lut.Data)/3, want/3)
+	}
+	return lut, nil
+}
+
+// parseFloats parses exactly n floats.
+func parseFloats(fields []string, n int) ([]float32, error) {
+	if len(fields) != n {
+		return nil, fmt.Errorf("want %d numbers, got %d", n, len(fields))
+	}
+	values := make([]float32, n)
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 32)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q", f)
+		}
+		values[i] = float32(v)
+	}
+	return values, nil
+}
+
+// Texture creates an RGBA32F 3D texture of the LUT.
+func (lut *CubeLUT) Texture() (*Texture3D, error) {
+	n := lut.Size * lut.Size * lut.Size
+	if len(lut.Data) != n*3 {
+		return nil, fmt.Errorf("LUT has %d entries, want %d", len(lut.Data)/3, n)
+	}
+	data := make([]float32, 0, n*4)
+	for i := 0; i < n; i++ {
+		data = append(data, lut.Data[i*3], lut.Data[i*3+1], lut.Data[i*3+2], 1)
+	}
+	return NewTexture3D(lut.Size, lut.Size, lut.Size, TexRGBA32F, data)
+}
+
+// ColorGrade is a post-processing pass giving a rendered frame a look:
+// histogram equalization of its brightness, lift/gamma/gain adjustment of
+// shadows, midtones, and highlights, then a 3D LUT, such as one exported
+// from grading software as a .cube file. It's meant for display colors, so
+// apply it after tone mapping.
+type ColorGrade struct {
+	Lift     mgl32.Vec3 // added to shadows; 0 for none
+	Gamma    mgl32.Vec3 // midtones are raised to 1/Gamma; 1 for none
+	Gain     mgl32.Vec3 // multiplies highlights; 1 for none
+	Strength float32    // of the LUT, from 0 to 1
+	Equalize float32    // of histogram equalization, from 0 to 1
+	Output   *Fbo
+
+	lut                  *Texture3D
+	lutSize              float32
+	domainMin, domainMax mgl32.Vec3
+
+	cdf            *Texture2D // cumulative histogram for equalization
+	minLog, maxLog float32
+}
+
+// NewColorGrade creates a pass that changes nothing until it's given a LUT,
+// lift, gamma, gain, or histogram.
+func NewColorGrade() (*ColorGrade, error) {
+	if colorGradeProgram == nil {
+		if err := initColorGradeProgram(); err != nil {
+			return nil, err
+		}
+	}
+	cdf, err := NewTextureData(2, 1, TexR32F, []float32{0, 1})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create histogram texture: %w", err)
+	}
+	return &ColorGrade{
+		Gamma:    mgl32.Vec3{1, 1, 1},
+		Gain:     mgl32.Vec3{1, 1, 1},
+		Strength: 1,
+		cdf:      cdf,
+		maxLog:   1,
+	}, nil
+}
+
+// SetLUT sets the LUT applied, or none if lut is nil.
+func (cg *ColorGrade) SetLUT(lut *CubeLUT) error {
+	if cg.lut != nil {
+		cg.lut.Delete()
+		cg.lut = nil
+	}
+	if lut == nil {
+		return nil
+	}
+	tex, err := lut.Texture()
+	if err != nil {
+		return fmt.Errorf("couldn't create LUT texture: %w", err)
+	}
+	cg.lut = tex
+	cg.lutSize = float32(lut.Size)
+	cg.domainMin, cg.domainMax = lut.DomainMin, lut.DomainMax
+	return nil
+}
+
+// SetHistogram sets the histogram of log2 luminance equalized by Equalize,
+// such as LuminanceAnalyzer.Histogram with its MinLog and MaxLog. The
+// brightness of each pixel is remapped so it's evenly spread from 0 to 1.
+func (cg *ColorGrade) SetHistogram(histogram []float32, minLog, maxLog float32) error {
+	if len(histogram) == 0 || maxLog <= minLog {
+		return fmt.Errorf("invalid histogram of %d bins from %g to %g", len(histogram), minLog, maxLog)
+	}
+	// cdf at the edges of the bins, so there's one more entry than bins
+	cdf := make([]float32, len(histogram)+1)
+	for i, fraction := range histogram {
+		cdf[i+1] = cdf[i] + fraction
+	}
+	if total := cdf[len(cdf)-1]; total > 0 {
+		for i := range cdf {
+			cdf[i] /= total
+		}
+	}
+	if int(cg.cdf.Width) != len(cdf) {
+		tex, err := NewTextureData(len(cdf), 1, TexR32F, cdf)
+		if err != nil {
+			return fmt.Errorf("couldn't create histogram texture: %w", err)
+		}
+		cg.cdf.Delete()
+		cg.cdf = tex
+	} else if err := cg.cdf.SetPixels(image.Rect(0, 0, len(cdf), 1), cdf); err != nil {
+		return err
+	}
+	cg.minLog, cg.maxLog = minLog, maxLog
+	return nil
+}
+
+// Apply grades source into Output, which is resized to match it.
+func (cg *ColorGrade) Apply(source *Texture2D) error {
+	width, height := int(source.Width), int(source.Height)
+	if cg.Output == nil || int(cg.Output.Width) != width || int(cg.Output.Height) != height {
+		if cg.Output != nil {
+			cg.Output.Delete()
+		}
+		var err error
+		if cg.Output, err = NewFbo(width, height); err != nil {
+			cg.Output = nil
+			return fmt.Errorf("couldn't create color grade target: %w", err)
+		}
+	}
+
+	saved := saveTargetState()
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.BLEND)
+	cg.Output.Use()
+	gl.Viewport(0, 0, cg.Output.Width, cg.Output.Height)
+
+	w, h := float32(width), float32(height)
+	useLUT := boolToInt32(cg.lut != nil)
+	projection, model := quadTransform(0, 0, w, h, w, h)
+	colorGradeProgram.Use()
+	colorGradeProgram.Vertex().SetMat4("projection", 1, &projection)
+	colorGradeProgram.Vertex().SetMat4("model", 1, &model)
+	colorGradeProgram.Fragment().SetInt("useLUT", 1, &useLUT)
+	colorGradeProgram.Fragment().SetFloat("lutSize", 1, &cg.lutSize)
+	colorGradeProgram.Fragment().SetFloat("strength", 1, &cg.Strength)
+	colorGradeProgram.Fragment().SetVec3("domainMin", 1, &cg.domainMin)
+	colorGradeProgram.Fragment().SetVec3("domainMax", 1, &cg.domainMax)
+	colorGradeProgram.Fragment().SetVec3("lift", 1, &cg.Lift)
+	colorGradeProgram.Fragment().SetVec3("gamma", 1, &cg.Gamma)
+	colorGradeProgram.Fragment().SetVec3("gain", 1, &cg.Gain)
+	colorGradeProgram.Fragment().SetFloat("equalize", 1, &cg.Equalize)
+	colorGradeProgram.Fragment().SetFloat("minLog", 1, &cg.minLog)
+	colorGradeProgram.Fragment().SetFloat("maxLog", 1, &cg.maxLog)
+	source.Bind(0)
+	if cg.lut != nil {
+		cg.lut.Bind(1)
+	}
+	cg.cdf.Bind(2)
+	gl.ActiveTexture(gl.TEXTURE0)
+	drawQuad()
+	saved.restore()
+	return nil
+}
+
+// Delete resources.
+func (cg *ColorGrade) Delete() {
+	cg.SetLUT(nil)
+	cg.cdf.Delete()
+	if cg.Output != nil {
+		cg.Output.Delete()
+	}
+}
+
+const colorGradeFragmentShader = `#version 330 core
+in vec2 TexCoords;
+
+uniform sampler2D source;
+uniform sampler3D lut;
+uniform sampler2D cdf;
+uniform bool useLUT;
+uniform float lutSize;
+uniform float strength;
+uniform vec3 domainMin;
+uniform vec3 domainMax;
+uniform vec3 lift;
+uniform vec3 gamma;
+uniform vec3 gain;
+uniform float equalize;
+uniform float minLog;
+uniform float maxLog;
+
+out vec4 FragColor;
+
+void main()
+{
+    // TexCoords are y down, so flip to sample the same row of source
+    vec4 color = texture(source, vec2(TexCoords.x, 1.0 - TexCoords.y));
+    vec3 rgb = color.rgb;
+
+    if (equalize > 0.0) {
+        float lum = max(dot(rgb, vec3(0.2126, 0.7152, 0.0722)), 1e-5);
+        float x = clamp((log2(lum) - minLog) / (maxLog - minLog), 0.0, 1.0);
+        float n = float(textureSize(cdf, 0).x);
+        float target = texture(cdf, vec2(x * (n - 1.0) / n + 0.5 / n, 0.5)).r;
+        rgb *= mix(1.0, target / lum, equalize);
+    }
+
+    rgb = gain * (rgb + lift * (1.0 - rgb));
+    rgb = pow(max(rgb, 0.0), 1.0 / gamma);
+
+    if (useLUT) {
+        // sample at texel centers, so the domain maps to the first and last entries
+        vec3 p = clamp((rgb - domainMin) / (domainMax - domainMin), 0.0, 1.0);
+        vec3 graded = texture(lut, p * (lutSize - 1.0) / lutSize + 0.5 / lutSize).rgb;
+        rgb = mix(rgb, graded, strength);
+    }
+    FragColor = vec4(rgb, color.a);
+}`
@@ -0,0 +1,117 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// InstallCrashHandler recovers a panic in the render loop, writes a crash
+// report and screenshot of the last frame into dir, restores the window so
+// the desktop is usable (leaves fullscreen, releases the cursor), and then
+// panics again with the original value. It must be deferred directly on the
+// goroutine running the render loop:
+//
+//	defer win.InstallCrashHandler("crash")
+//
+// The report has the panic value, stack trace, and a summary of the OpenGL
+// state at the time of the panic.
+func (platform *Window) InstallCrashHandler(dir string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+
+	// the gl context may be in a bad state, so don't let writing the report
+	// or restoring the window hide the original panic.
+	func() {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Printf("sgl: crash handler failed: %v", err)
+			}
+		}()
+		defer platform.restoreDesktop() // after the screenshot, even if the report fails
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			log.Printf("sgl: crash handler: %v", err)
+			return
+		}
+		base := filepath.Join(dir, "crash-"+time.Now().Format("20060102-150405"))
+
+		report := fmt.Sprintf("panic: %v\n\n%s\n%s", r, platform.glStateSummary(), stack)
+		if err := os.WriteFile(base+".txt", []byte(report), 0644); err != nil {
+			log.Printf("sgl: crash handler: %v", err)
+		} else {
+			log.Printf("sgl: crash report written to %s.txt", base)
+		}
+
+		UseDefaultFramebuffer()
+		if err := writePng(base+".png", platform.ScreenCapture()); err != nil {
+			log.Printf("sgl: crash handler: %v", err)
+		}
+	}()
+
+	panic(r)
+}
+
+// glStateSummary describes some opengl state useful when debugging a crash.
+func (platform *Window) glStateSummary() string {
+	var b strings.Builder
+	var program, fbo, vao, texture, activeTexture int32
+	var viewport [4]int32
+	gl.GetIntegerv(gl.CURRENT_PROGRAM, &program)
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &fbo)
+	gl.GetIntegerv(gl.VERTEX_ARRAY_BINDING, &vao)
+	gl.GetIntegerv(gl.ACTIVE_TEXTURE, &activeTexture)
+	gl.GetIntegerv(gl.TEXTURE_BINDING_2D, &texture)
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+
+	fmt.Fprintf(&b, "opengl: %s\n", platform.GlVersion)
+	fmt.Fprintf(&b, "bound program: %d\n", program)
+	fmt.Fprintf(&b, "bound framebuffer: %d\n", fbo)
+	fmt.Fprintf(&b, "bound vao: %d\n", vao)
+	fmt.Fprintf(&b, "bound texture: %d (unit %d)\n", texture, activeTexture-gl.TEXTURE0)
+	fmt.Fprintf(&b, "viewport: %v\n", viewport)
+	if err := CheckError(); err != nil {
+		fmt.Fprintf(&b, "gl error: %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "gl error: none\n")
+	}
+	return b.String()
+}
+
+// restoreDesktop leaves fullscreen and releases the cursor so a crash doesn't
+// leave the user stuck.
+func (platform *Window) restoreDesktop() {
+	if platform.Dimensions.Fullscreen {
+		platform.Fullscreen(false, 0, 0)
+	}
+	platform.GlfwWindow.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+	platform.GlfwWindow.Hide()
+	glfw.PollEvents()
+}
+
+// writePng saves img as a png file.
+func writePng(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	return file.Close()
+}
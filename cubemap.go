@@ -0,0 +1,95 @@
+package sgl
+
+import (
+	"fmt"
+	"image"
+)
+
+// CubemapLayout is the arrangement of the 6 faces of a cubemap in a single
+// image.
+type CubemapLayout int
+
+// Cubemap layouts. The crosses are laid out as
+//
+//	horizontal (4x3)     vertical (3x4)
+//	   +Y                   +Y
+//	-X +Z +X -Z          -X +Z +X
+//	   -Y                   -Y
+//	                        -Z (upside down)
+//
+// and the strips have the faces in the order expected by NewSkybox().
+const (
+	LayoutAuto CubemapLayout = iota // detect from the aspect ratio
+	LayoutHorizontalCross
+	LayoutVerticalCross
+	LayoutHorizontalStrip
+	LayoutVerticalStrip
+)
+
+// cell in the layout grid of each face, in NewSkybox() order.
+var cubemapLayoutCells = map[CubemapLayout][6]image.Point{
+	LayoutHorizontalCross: {{2, 1}, {0, 1}, {1, 0}, {1, 2}, {1, 1}, {3, 1}},
+	LayoutVerticalCross:   {{2, 1}, {0, 1}, {1, 0}, {1, 2}, {1, 1}, {1, 3}},
+	LayoutHorizontalStrip: {{0, 0}, {1, 0}, {2, 0}, {3, 0}, {4, 0}, {5, 0}},
+	LayoutVerticalStrip:   {{0, 0}, {0, 1}, {0, 2}, {0, 3}, {0, 4}, {0, 5}},
+}
+
+// grid size (columns, rows) of each layout.
+var cubemapLayoutGrid = map[CubemapLayout]image.Point{
+	LayoutHorizontalCross: {4, 3},
+	LayoutVerticalCross:   {3, 4},
+	LayoutHorizontalStrip: {6, 1},
+	LayoutVerticalStrip:   {1, 6},
+}
+
+// DetectCubemapLayout guesses the layout of a single image cubemap from its
+// size.
+func DetectCubemapLayout(width, height int) (CubemapLayout, error) {
+	for layout, grid := range cubemapLayoutGrid {
+		if width*grid.Y == height*grid.X {
+			return layout, nil
+		}
+	}
+	return LayoutAuto, fmt.Errorf("can't detect cubemap layout of %dx%d image", width, height)
+}
+
+// SplitCubemap cuts a single image into the 6 faces of a cubemap, in the
+// order expected by NewSkybox(). The faces share img's pixels, except for
+// the -Z face of a vertical cross, which is rotated upright.
+func SplitCubemap(img *image.RGBA, layout CubemapLayout) ([]*image.RGBA, error) {
+	b := img.Bounds()
+	if layout == LayoutAuto {
+		var err error
+		if layout, err = DetectCubemapLayout(b.Dx(), b.Dy()); err != nil {
+			return nil, err
+		}
+	}
+	grid, ok := cubemapLayoutGrid[layout]
+	if !ok {
+		return nil, fmt.Errorf("unknown cubemap layout %d", layout)
+	}
+	size := b.Dx() / grid.X
+	if size == 0 || b.Dx() != size*grid.X || b.Dy() != size*grid.Y {
+		return nil, fmt.Errorf("%dx%d image doesn't fit cubemap layout %d", b.Dx(), b.Dy(), layout)
+	}
+
+	faces := make([]*image.RGBA, 6)
+	for i, cell := range cubemapLayoutCells[layout] {
+		min := b.Min.Add(cell.Mul(size))
+		faces[i] = img.SubImage(image.Rectangle{min, min.Add(image.Pt(size, size))}).(*image.RGBA)
+	}
+	if layout == LayoutVerticalCross {
+		faces[5] = Rotate90(Rotate90(faces[5], true), true)
+	}
+	return faces, nil
+}
+
+// NewSkyboxImage creates a skybox from a single image containing all 6 faces
+// in a cross or strip layout, which is detected automatically.
+func NewSkyboxImage(img *image.RGBA) (*Skybox, error) {
+	faces, err := SplitCubemap(img, LayoutAuto)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create skybox: %w", err)
+	}
+	return NewSkybox(faces)
+}
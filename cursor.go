@@ -0,0 +1,131 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"image"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// CursorMode is how the cursor behaves over the window.
+type CursorMode int
+
+// Cursor modes for Window.SetCursorMode().
+const (
+	CursorNormal   CursorMode = iota // visible and free to move
+	CursorHidden                     // invisible over the window, but free to leave it
+	CursorDisabled                   // invisible and locked to the window, for mouse look
+)
+
+// glfwCursorModes maps CursorMode to the glfw input mode value.
+var glfwCursorModes = map[CursorMode]int{
+	CursorNormal:   glfw.CursorNormal,
+	CursorHidden:   glfw.CursorHidden,
+	CursorDisabled: glfw.CursorDisabled,
+}
+
+// SetCursorMode changes how the cursor behaves. While CursorDisabled, the
+// cursor position is virtual and unbounded, so use InputState.MouseDX and
+// MouseDY (or MouseDelta()) to turn a camera. Raw, unaccelerated motion is
+// used while disabled if the system supports it.
+//
+// The cursor jumps when the mode changes, so the mouse delta is 0 for the
+// next frame rather than that jump.
+func (platform *Window) SetCursorMode(mode CursorMode) {
+	glfwMode, ok := glfwCursorModes[mode]
+	if !ok || mode == platform.cursorMode {
+		return
+	}
+	platform.GlfwWindow.SetInputMode(glfw.CursorMode, glfwMode)
+	if glfw.RawMouseMotionSupported() {
+		raw := glfw.False
+		if mode == CursorDisabled {
+			raw = glfw.True
+		}
+		platform.GlfwWindow.SetInputMode(glfw.RawMouseMotion, raw)
+	}
+	platform.cursorMode = mode
+	platform.input.started = false // start the delta over from the new position
+}
+
+// CursorMode gets the cursor mode set by SetCursorMode().
+func (platform *Window) CursorMode() CursorMode { return platform.cursorMode }
+
+// RawMouseMotion returns true if the cursor is disabled and raw,
+// unaccelerated motion is being used.
+func (platform *Window) RawMouseMotion() bool {
+	return platform.cursorMode == CursorDisabled && glfw.RawMouseMotionSupported()
+}
+
+// MouseDelta gets the cursor movement since the previous frame, in screen
+// coordinates. It's the same as Input().MouseDX and MouseDY.
+func (platform *Window) MouseDelta() (dx, dy float64) {
+	return platform.input.MouseDX, platform.input.MouseDY
+}
+
+// CursorShape is one of the system's standard cursors.
+type CursorShape int
+
+// Standard cursor shapes for Window.SetCursorShape().
+const (
+	CursorArrow     CursorShape = iota // the default
+	CursorIBeam                        // for text
+	CursorCrosshair                    // for picking
+	CursorHand                         // for links and dragging
+	CursorResizeH                      // horizontal resize arrows
+	CursorResizeV                      // vertical resize arrows
+)
+
+// glfwCursorShapes maps CursorShape to the glfw standard cursor.
+var glfwCursorShapes = map[CursorShape]glfw.StandardCursor{
+	CursorArrow:     glfw.ArrowCursor,
+	CursorIBeam:     glfw.IBeamCursor,
+	CursorCrosshair: glfw.CrosshairCursor,
+	CursorHand:      glfw.HandCursor,
+	CursorResizeH:   glfw.HResizeCursor,
+	CursorResizeV:   glfw.VResizeCursor,
+}
+
+// SetCursorShape shows a standard cursor shape over the window. Cursors are
+// created on first use and kept until Dispose().
+func (platform *Window) SetCursorShape(shape CursorShape) {
+	standard, ok := glfwCursorShapes[shape]
+	if !ok {
+		return
+	}
+	if platform.cursors == nil {
+		platform.cursors = make(map[CursorShape]*glfw.Cursor)
+	}
+	cursor := platform.cursors[shape]
+	if cursor == nil {
+		cursor = glfw.CreateStandardCursor(standard)
+		platform.cursors[shape] = cursor
+	}
+	platform.GlfwWindow.SetCursor(cursor)
+}
+
+// SetCursorImage shows img as the cursor over the window, with the point
+// (hotspotX, hotspotY) pixels from its top left as the point that clicks.
+// The previous image cursor, if any, is destroyed.
+func (platform *Window) SetCursorImage(img image.Image, hotspotX, hotspotY int) {
+	cursor := glfw.CreateCursor(img, hotspotX, hotspotY)
+	platform.GlfwWindow.SetCursor(cursor)
+	if platform.imageCursor != nil {
+		platform.imageCursor.Destroy()
+	}
+	platform.imageCursor = cursor
+}
+
+// destroyCursors destroys the cursors created by SetCursorShape() and
+// SetCursorImage().
+func (platform *Window) destroyCursors() {
+	for shape, cursor := range platform.cursors {
+		cursor.Destroy()
+		delete(platform.cursors, shape)
+	}
+	if platform.imageCursor != nil {
+		platform.imageCursor.Destroy()
+		platform.imageCursor = nil
+	}
+}
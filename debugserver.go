@@ -0,0 +1,325 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// DebugServer is an HTTP server for driving a running app from scripts and
+// automated tests. Requests are handled on the main thread at the end of
+// each frame, so they can make opengl calls and safely change app state.
+// Commands:
+//
+//	GET  /screenshot              png of the frame just drawn
+//	GET  /stats                   json render stats, memory, and frame rate
+//	GET  /tweaks                  json of all tweak values
+//	POST /tweaks?name=N&value=V   set the tweak named N to V
+//	POST /reload                  call all the reload funcs (eg for shaders)
+//
+// Without a token, it only listens on loopback addresses or a unix socket,
+// and only answers requests for localhost, 127.0.0.1, or ::1, so web pages
+// can't reach it by DNS rebinding or cross-site requests. With a token, it
+// may listen on any address, and requests must send the token, either as
+// "Authorization: Bearer <token>" or a "token" query parameter. For example:
+//
+//	curl -o frame.png localhost:6060/screenshot
+//	curl -X POST 'localhost:6060/tweaks?name=exposure&value=1.5'
+type DebugServer struct {
+	listener net.Listener
+	server   *http.Server
+	requests chan func(*Window)
+	token    string
+	unix     bool
+
+	closed    chan struct{} // closed by Close()
+	closeOnce sync.Once
+
+	mu      sync.Mutex
+	tweaks  map[string]interface{} // pointers to values
+	reloads map[string]func() error
+}
+
+// UseDebugServer is an option to start a DebugServer, available as
+// Window.Debug. network is "tcp" or "unix".
+func UseDebugServer(network, address string) WindowOption {
+	return UseDebugServerToken(network, address, "")
+}
+
+// UseDebugServerToken is an option to start a DebugServer which requires
+// token, available as Window.Debug. See NewDebugServerToken().
+func UseDebugServerToken(network, address, token string) WindowOption {
+	return func(win *Window) error {
+		srv, err := NewDebugServerToken(network, address, token)
+		if err != nil {
+			return err
+		}
+		win.Debug = srv
+		return nil
+	}
+}
+
+// NewDebugServer starts a server listening on address, which is eg
+// "localhost:6060" for network "tcp", or a path for "unix". It's an error
+// for a tcp address not to be a loopback address. Requests aren't handled
+// until Serve() is called, which Window does in EndFrame() for the server
+// in Window.Debug.
+func NewDebugServer(network, address string) (*DebugServer, error) {
+	return NewDebugServerToken(network, address, "")
+}
+
+// NewDebugServerToken starts a server like NewDebugServer(), except that
+// if token isn't empty, the server may listen on any address, and requests
+// must send the token.
+func NewDebugServerToken(network, address, token string) (*DebugServer, error) {
+	unix := network == "unix"
+	if !unix && token == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't start debug server: %w", err)
+		}
+		if !loopbackHost(host) {
+			return nil, fmt.Errorf("couldn't start debug server: %q isn't a loopback address; use a token", address)
+		}
+	}
+	if unix {
+		os.Remove(address) // left by a previous run
+	}
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't start debug server: %w", err)
+	}
+	srv := &DebugServer{
+		listener: listener,
+		requests: make(chan func(*Window), 16),
+		token:    token,
+		unix:     unix,
+		closed:   make(chan struct{}),
+		tweaks:   make(map[string]interface{}),
+		reloads:  make(map[string]func() error),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/screenshot", srv.handleScreenshot)
+	mux.HandleFunc("/stats", srv.handleStats)
+	mux.HandleFunc("/tweaks", srv.handleTweaks)
+	mux.HandleFunc("/reload", srv.handleReload)
+	srv.server = &http.Server{Handler: guardRequests(srv.token, !srv.unix, mux)}
+	go srv.server.Serve(listener)
+	return srv, nil
+}
+
+// Addr gets the address the server is listening on.
+func (srv *DebugServer) Addr() net.Addr { return srv.listener.Addr() }
+
+// Tweak makes the value pointed to by ptr settable by name. ptr is a *bool,
+// *int, *float32, *float64, or *string.
+func (srv *DebugServer) Tweak(name string, ptr interface{}) {
+	switch ptr.(type) {
+	case *bool, *int, *float32, *float64, *string:
+	default:
+		panic(fmt.Sprintf("can't tweak %s of type %T", name, ptr))
+	}
+	srv.mu.Lock()
+	srv.tweaks[name] = ptr
+	srv.mu.Unlock()
+}
+
+// OnReload adds a func called by the /reload command, such as one that
+// rebuilds shader programs from their files.
+func (srv *DebugServer) OnReload(name string, reload func() error) {
+	srv.mu.Lock()
+	srv.reloads[name] = reload
+	srv.mu.Unlock()
+}
+
+// Serve handles all waiting requests. It must be called on the main thread.
+func (srv *DebugServer) Serve(win *Window) {
+	for {
+		select {
+		case request := <-srv.requests:
+			request(win)
+		default:
+			return
+		}
+	}
+}
+
+// Close stops the server. Requests waiting for Serve() are abandoned.
+func (srv *DebugServer) Close() error {
+	srv.closeOnce.Do(func() { close(srv.closed) })
+	err := srv.server.Close()
+	for {
+		select {
+		case <-srv.requests:
+		default:
+			return err
+		}
+	}
+}
+
+// states of a request queued by onMainThread().
+const (
+	requestQueued int32 = iota
+	requestRunning
+	requestAbandoned
+)
+
+// onMainThread runs fn in Serve() and waits for it to finish. It returns
+// false, with an error response written, if the request is cancelled or the
+// server closed before fn runs, such as while the window is minimized and
+// Serve() isn't called.
+func (srv *DebugServer) onMainThread(w http.ResponseWriter, r *http.Request, fn func(*Window)) bool {
+	var state int32
+	done := make(chan struct{})
+	request := func(win *Window) {
+		if !atomic.CompareAndSwapInt32(&state, requestQueued, requestRunning) {
+			return // the handler has returned
+		}
+		fn(win)
+		close(done)
+	}
+	select {
+	case srv.requests <- request:
+	case <-r.Context().Done():
+		return false
+	case <-srv.closed:
+		http.Error(w, "server closed", http.StatusServiceUnavailable)
+		return false
+	}
+	glfw.PostEmptyEvent() // in case the loop is waiting for events
+
+	select {
+	case <-done:
+		return true
+	case <-r.Context().Done():
+	case <-srv.closed:
+	}
+	if !atomic.CompareAndSwapInt32(&state, requestQueued, requestAbandoned) {
+		<-done // already running, so it must finish before w is released
+		return true
+	}
+	http.Error(w, "not served", http.StatusServiceUnavailable)
+	return false
+}
+
+func (srv *DebugServer) handleScreenshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "use GET", http.StatusMethodNotAllowed)
+		return
+	}
+	srv.onMainThread(w, r, func(win *Window) {
+		w.Header().Set("Content-Type", "image/png")
+		png.Encode(w, win.BackBufferCapture())
+	})
+}
+
+func (srv *DebugServer) handleStats(w http.ResponseWriter, r *http.Request) {
+	srv.onMainThread(w, r, func(win *Window) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Frame     uint64
+			FrameTime float64
+			AvgFps    float64
+			Render    RenderStats
+			Memory    GPUMemory
+		}{win.Clock.TotalFrames, win.Clock.UnscaledDeltaT, win.Clock.AvgFps(), Stats(), EstimateMemory()})
+	})
+}
+
+func (srv *DebugServer) handleTweaks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		srv.onMainThread(w, r, func(*Window) {
+			srv.mu.Lock()
+			defer srv.mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(srv.tweaks)
+		})
+
+	case http.MethodPost:
+		name, value := r.FormValue("name"), r.FormValue("value")
+		srv.mu.Lock()
+		ptr, ok := srv.tweaks[name]
+		srv.mu.Unlock()
+		if !ok {
+			http.Error(w, fmt.Sprintf("no tweak named %q", name), http.StatusNotFound)
+			return
+		}
+		var err error
+		if !srv.onMainThread(w, r, func(*Window) { err = setTweak(ptr, value) }) {
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+
+	default:
+		http.Error(w, "use GET or POST", http.StatusMethodNotAllowed)
+	}
+}
+
+// setTweak parses value into the value pointed to by ptr.
+func setTweak(ptr interface{}, value string) error {
+	switch p := ptr.(type) {
+	case *bool:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*p = v
+	case *int:
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*p = v
+	case *float32:
+		v, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return err
+		}
+		*p = float32(v)
+	case *float64:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		*p = v
+	case *string:
+		*p = value
+	}
+	return nil
+}
+
+func (srv *DebugServer) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	var failed []string
+	ok := srv.onMainThread(w, r, func(*Window) {
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		for name, reload := range srv.reloads {
+			if err := reload(); err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+	})
+	if ok && len(failed) > 0 {
+		sort.Strings(failed)
+		http.Error(w, fmt.Sprint(failed), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,271 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// UseDeepColor is an option to ask for 10 bits per color channel in the
+// window's framebuffer, where the display and driver support it, so smooth
+// gradients band less. Use Window.ColorBits() to see what was given.
+func UseDeepColor() WindowHint {
+	return func(h *windowHints) error {
+		h.set(glfw.RedBits, 10, 8)
+		h.set(glfw.GreenBits, 10, 8)
+		h.set(glfw.BlueBits, 10, 8)
+		h.set(glfw.AlphaBits, 2, 8)
+		return nil
+	}
+}
+
+// ColorBits gets the bits per color channel of the window's framebuffer.
+func (platform *Window) ColorBits() int {
+	var fbo, bits int32
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &fbo)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.GetFramebufferAttachmentParameteriv(gl.FRAMEBUFFER, gl.BACK_LEFT, gl.FRAMEBUFFER_ATTACHMENT_GREEN_SIZE, &bits)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(fbo))
+	return int(bits)
+}
+
+// only need these once in the package
+var (
+	ditherProgram *Program
+	blueNoise     *Texture2D
+)
+
+// blueNoiseSize is the size of the tiled blue noise texture.
+const blueNoiseSize = 64
+
+// called to create and build the dither program and blue noise texture.
+func initDitherProgram() error {
+	ditherProgram = NewProgram()
+	ditherProgram.AddShader(VertexShader, quadVertexShader,
+		[]string{"projection", "model"},
+		Attribute{Name: "vertex", Type: gl.FLOAT, Size: 4, Stride: 4 * SizeOfFloat, Offset: 0})
+	ditherProgram.AddShader(FragmentShader, ditherFragmentShader,
+		[]string{"source", "noise", "mode", "levels", "offset", "shift"})
+	if err := ditherProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build dither program: %w", err)
+	}
+	var source, noise int32 = 0, 1
+	ditherProgram.Fragment().SetInt("source", 1, &source)
+	ditherProgram.Fragment().SetInt("noise", 1, &noise)
+
+	var err error
+	blueNoise, err = NewTextureData(blueNoiseSize, blueNoiseSize, TexR8, BlueNoise(blueNoiseSize))
+	if err != nil {
+		ditherProgram.Delete()
+		ditherProgram = nil
+		return fmt.Errorf("couldn't create blue noise texture: %w", err)
+	}
+	blueNoise.Bind(0)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return nil
+}
+
+// BlueNoise makes a size x size tile of blue noise, made with the
+// void-and-cluster method: thresholds 0 to 255 spread so that every
+// threshold level is evenly spaced, with no low frequencies. It tiles
+// seamlessly and is the same every time.
+func BlueNoise(size int) []byte {
+	n := size * size
+	const sigma = 1.5
+
+	// gaussian falloff for every toroidal offset
+	falloff := make([]float64, n)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := float64(minInt(x, size-x)), float64(minInt(y, size-y))
+			falloff[y*size+x] = math.Exp(-(dx*dx + dy*dy) / (2 * sigma * sigma))
+		}
+	}
+	on := make([]bool, n)
+	energy := make([]float64, n)
+	toggle := func(i int) {
+		on[i] = !on[i]
+		sign := 1.0
+		if !on[i] {
+			sign = -1
+		}
+		px, py := i%size, i/size
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				off := ((y-py+size)%size)*size + (x-px+size)%size
+				energy[y*size+x] += sign * falloff[off]
+			}
+		}
+	}
+	// tightest cluster is the set pixel with the most energy; largest void
+	// is the unset pixel with the least.
+	find := func(set bool) int {
+		best := -1
+		for i := range on {
+			if on[i] != set {
+				continue
+			}
+			if best < 0 || (set && energy[i] > energy[best]) || (!set && energy[i] < energy[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+
+	// random initial points, relaxed until evenly spread
+	rng := NewRNG(RandomSeed)
+	ones := n / 10
+	for _, i := range rng.Perm(n)[:ones] {
+		toggle(i)
+	}
+	for {
+		cluster := find(true)
+		toggle(cluster)
+		void := find(false)
+		toggle(void)
+		if void == cluster {
+			break
+		}
+	}
+	initial := append([]bool(nil), on...)
+	initialEnergy := append([]float64(nil), energy...)
+
+	// rank the initial points by removing the most clustered first, then
+	// rank the rest by filling the largest voids
+	rank := make([]int, n)
+	for r := ones - 1; r >= 0; r-- {
+		i := find(true)
+		toggle(i)
+		rank[i] = r
+	}
+	copy(on, initial)
+	copy(energy, initialEnergy)
+	for r := ones; r < n; r++ {
+		i := find(false)
+		toggle(i)
+		rank[i] = r
+	}
+
+	noise := make([]byte, n)
+	for i, r := range rank {
+		noise[i] = byte(r * 256 / n)
+	}
+	return noise
+}
+
+// DitherMode is the pattern used by Dither.
+type DitherMode int32
+
+// Dither patterns.
+const (
+	DitherOrdered   DitherMode = iota // 8x8 Bayer matrix; cheap but a visible grid
+	DitherBlueNoise                   // blue noise; looks like fine film grain
+)
+
+// Dither is the last post-processing pass, drawing a frame to the screen
+// with a tiny amount of structured noise, so smooth gradients, especially in
+// dark scenes, don't show bands where the color steps from one level to the
+// next. It's only useful if the source has more precision than the screen,
+// such as a float Fbo.
+type Dither struct {
+	Mode    DitherMode
+	Bits    int  // per channel of the target; 8, or 10 with UseDeepColor()
+	Animate bool // change the noise every frame, so it averages out over time
+
+	frame int
+}
+
+// NewDither creates a blue noise dither for a target with bits per channel,
+// such as from Window.ColorBits().
+func NewDither(bits int) (*Dither, error) {
+	if ditherProgram == nil {
+		if err := initDitherProgram(); err != nil {
+			return nil, err
+		}
+	}
+	return &Dither{Mode: DitherBlueNoise, Bits: bits}, nil
+}
+
+// Draw source, dithered, to fill the bound framebuffer, which is width x
+// height pixels.
+func (d *Dither) Draw(source *Texture2D, width, height int) {
+	saved := saveTargetState()
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.BLEND)
+	gl.Viewport(0, 0, int32(width), int32(height))
+
+	w, h := float32(width), float32(height)
+	mode := int32(d.Mode)
+	levels := float32(int(1)<<uint(d.Bits) - 1)
+	var offset mgl32.Vec2
+	var shift float32
+	if d.Animate {
+		// a different tile offset and threshold shift each frame
+		d.frame++
+		offset = mgl32.Vec2{float32((d.frame * 29) % blueNoiseSize), float32((d.frame * 47) % blueNoiseSize)}
+		shift = float32(math.Mod(float64(d.frame)*0.618034, 1))
+	}
+	projection, model := quadTransform(0, 0, w, h, w, h)
+	ditherProgram.Use()
+	ditherProgram.Vertex().SetMat4("projection", 1, &projection)
+	ditherProgram.Vertex().SetMat4("model", 1, &model)
+	ditherProgram.Fragment().SetInt("mode", 1, &mode)
+	ditherProgram.Fragment().SetFloat("levels", 1, &levels)
+	ditherProgram.Fragment().SetVec2("offset", 1, &offset)
+	ditherProgram.Fragment().SetFloat("shift", 1, &shift)
+	source.Bind(0)
+	blueNoise.Bind(1)
+	gl.ActiveTexture(gl.TEXTURE0)
+	drawQuad()
+	saved.restore()
+}
+
+const ditherFragmentShader = `#version 330 core
+in vec2 TexCoords;
+
+uniform sampler2D source;
+uniform sampler2D noise;
+uniform int mode;      // 0 ordered, 1 blue noise
+uniform float levels;  // of each channel of the target, less 1
+uniform vec2 offset;   // of the noise tile
+uniform float shift;   // of the thresholds
+
+out vec4 FragColor;
+
+// bayer8 gets the 8x8 ordered dither threshold, by interleaving bits.
+float bayer8(ivec2 p)
+{
+    int x = p.x & 7;
+    int xy = x ^ (p.y & 7);
+    int v = ((xy & 1) << 5) | ((x & 1) << 4) | ((xy & 2) << 2) | ((x & 2) << 1) | ((xy & 4) >> 1) | ((x & 4) >> 2);
+    return (float(v) + 0.5) / 64.0;
+}
+
+void main()
+{
+    // TexCoords are y down, so flip to sample the same row of source
+    vec4 color = texture(source, vec2(TexCoords.x, 1.0 - TexCoords.y));
+    ivec2 p = ivec2(gl_FragCoord.xy + offset);
+
+    float threshold;
+    if (mode == 0) {
+        threshold = bayer8(p);
+    } else {
+        threshold = texelFetch(noise, p % textureSize(noise, 0), 0).r;
+    }
+    threshold = fract(threshold + shift);
+
+    // move by up to half a level either way, so rounding to the target's
+    // levels picks the nearer level in proportion
+    color.rgb += (threshold - 0.5) / levels;
+    FragColor = vec4(clamp(color.rgb, 0.0, 1.0), color.a);
+}`
@@ -0,0 +1,174 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/inkyblackness/imgui-go/v4"
+)
+
+// only need this once in the package
+var depthOfFieldProgram *Program
+
+// called to create and build the depth of field program.
+func initDepthOfFieldProgram() error {
+	depthOfFieldProgram = NewProgram()
+	depthOfFieldProgram.AddShader(VertexShader, quadVertexShader,
+		[]string{"projection", "model"},
+		Attribute{Name: "vertex", Type: gl.FLOAT, Size: 4, Stride: 4 * SizeOfFloat, Offset: 0})
+	depthOfFieldProgram.AddShader(FragmentShader, depthOfFieldFragmentShader,
+		[]string{"source", "depth", "depthParams", "focusDistance", "aperture", "maxRadius",
+			"radiusStep"})
+	if err := depthOfFieldProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build depth of field program: %w", err)
+	}
+	var source, depth int32 = 0, 1
+	depthOfFieldProgram.Fragment().SetInt("source", 1, &source)
+	depthOfFieldProgram.Fragment().SetInt("depth", 1, &depth)
+	return nil
+}
+
+// DepthOfField is a post-processing pass blurring things nearer or farther
+// than FocusDistance, like a camera lens, with round bokeh where bright
+// spots are out of focus. Blur is gathered from a disc around each pixel,
+// with far samples limited so the background doesn't bleed over things in
+// focus. It needs a perspective projection.
+type DepthOfField struct {
+	FocusDistance float32 // from the camera to the sharpest point
+	Aperture      float32 // larger blurs more away from FocusDistance
+	MaxRadius     float32 // largest blur, in pixels
+	Quality       float32 // smaller is more samples; 0.5 is good
+	Output        *Texture2D
+
+	fbo uint32
+}
+
+// NewDepthOfField creates a depth of field pass focused at focusDistance.
+func NewDepthOfField(focusDistance float32) (*DepthOfField, error) {
+	if depthOfFieldProgram == nil {
+		if err := initDepthOfFieldProgram(); err != nil {
+			return nil, err
+		}
+	}
+	return &DepthOfField{
+		FocusDistance: focusDistance,
+		Aperture:      2,
+		MaxRadius:     16,
+		Quality:       0.5,
+	}, nil
+}
+
+// Apply blurs source, with depth, such as a GBuffer's Color and Depth, into
+// Output. projection is the one used to draw the scene.
+func (dof *DepthOfField) Apply(source, depth *Texture2D, projection mgl32.Mat4) error {
+	if err := resizeColorTarget(&dof.Output, &dof.fbo, int(source.Width), int(source.Height)); err != nil {
+		return fmt.Errorf("couldn't create depth of field target: %w", err)
+	}
+
+	saved := saveTargetState()
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.BLEND)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, dof.fbo)
+	gl.Viewport(0, 0, dof.Output.Width, dof.Output.Height)
+
+	w, h := float32(dof.Output.Width), float32(dof.Output.Height)
+	// the terms of the projection needed to get distance from depth
+	depthParams := mgl32.Vec2{projection[10], projection[14]}
+	step := mgl32.Clamp(dof.Quality, 0.05, 4)
+	quadProjection, model := quadTransform(0, 0, w, h, w, h)
+	depthOfFieldProgram.Use()
+	depthOfFieldProgram.Vertex().SetMat4("projection", 1, &quadProjection)
+	depthOfFieldProgram.Vertex().SetMat4("model", 1, &model)
+	depthOfFieldProgram.Fragment().SetVec2("depthParams", 1, &depthParams)
+	depthOfFieldProgram.Fragment().SetFloat("focusDistance", 1, &dof.FocusDistance)
+	depthOfFieldProgram.Fragment().SetFloat("aperture", 1, &dof.Aperture)
+	depthOfFieldProgram.Fragment().SetFloat("maxRadius", 1, &dof.MaxRadius)
+	depthOfFieldProgram.Fragment().SetFloat("radiusStep", 1, &step)
+	source.Bind(0)
+	depth.Bind(1)
+	gl.ActiveTexture(gl.TEXTURE0)
+	drawQuad()
+	saved.restore()
+	return nil
+}
+
+// Gui shows controls for the blur in a window. Call it inside the func of
+// Window.RenderImgui().
+func (dof *DepthOfField) Gui(title string) {
+	if imgui.Begin(title + "##dof") {
+		imgui.DragFloatV("focus distance", &dof.FocusDistance, 0.1, 0, 10000, "%.2f", imgui.SliderFlagsNone)
+		imgui.SliderFloat("aperture", &dof.Aperture, 0, 20)
+		imgui.SliderFloat("max radius (px)", &dof.MaxRadius, 1, 64)
+		imgui.SliderFloat("quality", &dof.Quality, 0.1, 2)
+	}
+	imgui.End()
+}
+
+// Delete resources.
+func (dof *DepthOfField) Delete() {
+	if dof.Output != nil {
+		dof.Output.Delete()
+		gl.DeleteFramebuffers(1, &dof.fbo)
+	}
+}
+
+const depthOfFieldFragmentShader = `#version 330 core
+in vec2 TexCoords;
+
+uniform sampler2D source;
+uniform sampler2D depth;
+uniform vec2 depthParams; // projection[2][2] and projection[3][2]
+uniform float focusDistance;
+uniform float aperture;
+uniform float maxRadius;  // pixels
+uniform float radiusStep;
+
+out vec4 FragColor;
+
+const float goldenAngle = 2.39996323;
+
+// distance gets the distance from the camera at uv.
+float distanceAt(vec2 uv)
+{
+    float ndc = texture(depth, uv).r * 2.0 - 1.0;
+    return depthParams.y / (ndc + depthParams.x);
+}
+
+// blurSize gets the radius of the circle of confusion, in pixels.
+float blurSize(float dist)
+{
+    float coc = clamp((1.0 / focusDistance - 1.0 / dist) * aperture * focusDistance, -1.0, 1.0);
+    return abs(coc) * maxRadius;
+}
+
+void main()
+{
+    // TexCoords are y down, so flip to sample the same row
+    vec2 uv = vec2(TexCoords.x, 1.0 - TexCoords.y);
+    vec2 texel = 1.0 / vec2(textureSize(source, 0));
+
+    float centerDist = distanceAt(uv);
+    float centerSize = blurSize(centerDist);
+    vec3 color = texture(source, uv).rgb;
+    float total = 1.0;
+
+    // a spiral of samples filling the largest possible circle
+    float radius = radiusStep;
+    for (float angle = 0.0; radius < maxRadius; angle += goldenAngle) {
+        vec2 p = uv + vec2(cos(angle), sin(angle)) * texel * radius;
+        vec3 sampleColor = texture(source, p).rgb;
+        float sampleDist = distanceAt(p);
+        float sampleSize = blurSize(sampleDist);
+        if (sampleDist > centerDist) {
+            // the background can't blur over something nearer
+            sampleSize = clamp(sampleSize, 0.0, centerSize * 2.0);
+        }
+        // samples only count if their blur reaches this pixel
+        float m = smoothstep(radius - 0.5, radius + 0.5, sampleSize);
+        color += mix(color / total, sampleColor, m);
+        total += 1.0;
+        radius += radiusStep / radius;
+    }
+    FragColor = vec4(color / total, 1.0);
+}`
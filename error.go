@@ -17,13 +17,13 @@ func (e GlError) Error() string {
 }
 
 func CheckError() error {
-	errorCode := gl.GetError()
+	errorCode := backend.GetError()
 	if errorCode == gl.NO_ERROR {
 		return nil
 	}
 
 	err := make(GlError)
-	for ; errorCode != gl.NO_ERROR; errorCode = gl.GetError() {
+	for ; errorCode != gl.NO_ERROR; errorCode = backend.GetError() {
 		var errorMsg string
 		switch errorCode {
 		case gl.INVALID_ENUM:
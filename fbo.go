@@ -18,7 +18,7 @@ shadow volumes to cubemap (pointlights)
 
 // UseDefaultFramebuffer unbinds other FBOs and binds the default framebuffer.
 func UseDefaultFramebuffer() {
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	backend.BindFramebuffer(gl.FRAMEBUFFER, 0)
 }
 
 // Fbo is a very simple Frame Buffer Object with a texture
@@ -35,45 +35,93 @@ func NewFbo(width, height int) (*Fbo, error) {
 
 	var fbo Fbo
 	fbo.Width, fbo.Height = int32(width), int32(height)
-	gl.GenFramebuffers(1, &fbo.ID)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, fbo.ID)
+	fbo.ID = backend.GenFramebuffer()
+	backend.BindFramebuffer(gl.FRAMEBUFFER, fbo.ID)
 
 	// generate texture and attach it to as a color buffer for this fbo
 	fbo.ColorBuffer = new(Texture2D)
 	fbo.ColorBuffer.Width, fbo.ColorBuffer.Height = fbo.Width, fbo.Height
-	gl.GenTextures(1, &fbo.ColorBuffer.ID)
-	gl.BindTexture(gl.TEXTURE_2D, fbo.ColorBuffer.ID)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB, fbo.Width, fbo.Height, 0, gl.RGB, gl.UNSIGNED_BYTE, gl.Ptr(nil))
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.BindTexture(gl.TEXTURE_2D, 0)                                                                    // unbind texture
-	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, fbo.ColorBuffer.ID, 0) // attach
+	fbo.ColorBuffer.ID = backend.GenTexture()
+	backend.BindTexture(gl.TEXTURE_2D, fbo.ColorBuffer.ID)
+	backend.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB, fbo.Width, fbo.Height, gl.RGB, gl.UNSIGNED_BYTE, nil)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	backend.BindTexture(gl.TEXTURE_2D, 0)                                                                    // unbind texture
+	backend.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, fbo.ColorBuffer.ID, 0) // attach
 
 	// generate and attach render buffer object as depth and stencil buffers for this fbo.
-	gl.GenRenderbuffers(1, &fbo.depthStencilRbo)
-	gl.BindRenderbuffer(gl.RENDERBUFFER, fbo.depthStencilRbo)
-	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH24_STENCIL8, fbo.Width, fbo.Height)
-	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)                                                                       // unbind rbo
-	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, fbo.depthStencilRbo) // attach
+	fbo.depthStencilRbo = backend.GenRenderbuffer()
+	backend.BindRenderbuffer(gl.RENDERBUFFER, fbo.depthStencilRbo)
+	backend.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH24_STENCIL8, fbo.Width, fbo.Height)
+	backend.BindRenderbuffer(gl.RENDERBUFFER, 0)                                                                       // unbind rbo
+	backend.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, fbo.depthStencilRbo) // attach
 
 	// check that fbo is complete
-	if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
+	if backend.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
 		fbo.Delete()
 		return nil, fmt.Errorf("framebuffer is not complete")
 	}
 
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	backend.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	trackTexture(fbo.ColorBuffer.ID, width*height*4) // RGB is usually padded
+	trackRenderbuffer(fbo.depthStencilRbo, width*height*4)
+	watchLeak(&fbo, "Fbo", fbo.ID)
 	return &fbo, nil
 }
 
 // Delete resources associated with the FBO.
 func (fbo *Fbo) Delete() {
+	unwatchLeak(fbo, "Fbo", fbo.ID)
 	fbo.ColorBuffer.Delete()
-	gl.DeleteRenderbuffers(1, &fbo.depthStencilRbo)
-	gl.DeleteFramebuffers(1, &fbo.ID)
+	trackRenderbuffer(fbo.depthStencilRbo, 0)
+	backend.DeleteRenderbuffer(fbo.depthStencilRbo)
+	backend.DeleteFramebuffer(fbo.ID)
 }
 
 // Use binds the FBO for use.
 func (fbo *Fbo) Use() {
-	gl.BindFramebuffer(gl.FRAMEBUFFER, fbo.ID)
+	backend.BindFramebuffer(gl.FRAMEBUFFER, fbo.ID)
+}
+
+// targetState is the opengl state changed by helpers which draw offscreen,
+// saved so it can be restored afterwards.
+type targetState struct {
+	fbo                                int32
+	viewport                           [4]int32
+	clearColor                         [4]float32
+	srcRGB, dstRGB, srcAlpha, dstAlpha int32
+	blend, depth                       bool
+}
+
+// saveTargetState gets the bound framebuffer, viewport, clear color, blend
+// function, and whether blending and depth testing are enabled.
+func saveTargetState() (s targetState) {
+	backend.GetIntegerv(gl.FRAMEBUFFER_BINDING, &s.fbo)
+	backend.GetIntegerv(gl.VIEWPORT, &s.viewport[0])
+	backend.GetFloatv(gl.COLOR_CLEAR_VALUE, &s.clearColor[0])
+	backend.GetIntegerv(gl.BLEND_SRC_RGB, &s.srcRGB)
+	backend.GetIntegerv(gl.BLEND_DST_RGB, &s.dstRGB)
+	backend.GetIntegerv(gl.BLEND_SRC_ALPHA, &s.srcAlpha)
+	backend.GetIntegerv(gl.BLEND_DST_ALPHA, &s.dstAlpha)
+	s.blend, s.depth = backend.IsEnabled(gl.BLEND), backend.IsEnabled(gl.DEPTH_TEST)
+	return
+}
+
+// restore the saved state.
+func (s targetState) restore() {
+	backend.BindFramebuffer(gl.FRAMEBUFFER, uint32(s.fbo))
+	backend.Viewport(s.viewport[0], s.viewport[1], s.viewport[2], s.viewport[3])
+	backend.ClearColor(s.clearColor[0], s.clearColor[1], s.clearColor[2], s.clearColor[3])
+	backend.BlendFuncSeparate(uint32(s.srcRGB), uint32(s.dstRGB), uint32(s.srcAlpha), uint32(s.dstAlpha))
+	setEnabled(gl.BLEND, s.blend)
+	setEnabled(gl.DEPTH_TEST, s.depth)
+}
+
+// setEnabled enables or disables an opengl capability.
+func setEnabled(capability uint32, enabled bool) {
+	if enabled {
+		backend.Enable(capability)
+	} else {
+		backend.Disable(capability)
+	}
 }
@@ -0,0 +1,126 @@
+package sgl
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Fence marks a point in the opengl command stream, so the CPU can find out
+// when the GPU has finished every command before it without calling
+// gl.Finish(). A nil or deleted Fence is always done.
+type Fence struct {
+	sync uintptr
+}
+
+// NewFence places a fence after the commands issued so far.
+func NewFence() *Fence {
+	return &Fence{sync: gl.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)}
+}
+
+// Done returns true if the GPU has finished the fenced commands. It doesn't
+// block.
+func (f *Fence) Done() bool {
+	if f == nil || f.sync == 0 {
+		return true
+	}
+	var status int32
+	gl.GetSynciv(f.sync, gl.SYNC_STATUS, 1, nil, &status)
+	return status == gl.SIGNALED
+}
+
+// ErrFenceFailed is returned by Fence.Wait() if opengl couldn't wait, such
+// as when the context was lost. Waiting again won't succeed.
+var ErrFenceFailed = errors.New("fence wait failed")
+
+// Wait blocks until the GPU has finished the fenced commands, or timeout
+// passes, and returns true if they were finished, or false if timeout
+// passed. Pending commands are flushed so the fence is sure to be reached.
+// If the wait failed, it returns ErrFenceFailed.
+func (f *Fence) Wait(timeout time.Duration) (bool, error) {
+	if f == nil || f.sync == 0 {
+		return true, nil
+	}
+	switch gl.ClientWaitSync(f.sync, gl.SYNC_FLUSH_COMMANDS_BIT, uint64(timeout.Nanoseconds())) {
+	case gl.ALREADY_SIGNALED, gl.CONDITION_SATISFIED:
+		return true, nil
+	case gl.TIMEOUT_EXPIRED:
+		return false, nil
+	default: // WAIT_FAILED
+		return false, ErrFenceFailed
+	}
+}
+
+// Delete the fence. It is then always done.
+func (f *Fence) Delete() {
+	if f != nil && f.sync != 0 {
+		gl.DeleteSync(f.sync)
+		f.sync = 0
+	}
+}
+
+// FenceRing cycles through a few slots of per-frame resources, such as
+// buffers being written or read by the GPU, and fences each slot's use so
+// it isn't reused until the GPU is finished with it. Each frame:
+//
+//	slot := ring.Begin() // waits for the GPU to finish with slot
+//	// use the slot's resources
+//	ring.End()
+//
+// With 3 slots, the CPU can work up to 2 frames ahead of the GPU.
+type FenceRing struct {
+	fences  []*Fence
+	current int
+}
+
+// NewFenceRing creates a ring of size slots.
+func NewFenceRing(size int) *FenceRing {
+	if size < 1 {
+		size = 1
+	}
+	return &FenceRing{fences: make([]*Fence, size)}
+}
+
+// Size gets the number of slots.
+func (r *FenceRing) Size() int { return len(r.fences) }
+
+// Current gets the slot in use, or about to be used.
+func (r *FenceRing) Current() int { return r.current }
+
+// Begin waits for the GPU to finish with the current slot, then returns it.
+// If the fence can't be waited on, it falls back to gl.Finish().
+func (r *FenceRing) Begin() int {
+	if f := r.fences[r.current]; f != nil {
+		for {
+			done, err := f.Wait(time.Second)
+			if err != nil {
+				log.Printf("sgl: %v; finishing instead", err)
+				gl.Finish()
+				break
+			}
+			if done {
+				break
+			}
+		}
+		f.Delete()
+		r.fences[r.current] = nil
+	}
+	return r.current
+}
+
+// End fences the commands using the current slot and moves to the next.
+func (r *FenceRing) End() {
+	r.fences[r.current].Delete()
+	r.fences[r.current] = NewFence()
+	r.current = (r.current + 1) % len(r.fences)
+}
+
+// Delete the fences.
+func (r *FenceRing) Delete() {
+	for i, f := range r.fences {
+		f.Delete()
+		r.fences[i] = nil
+	}
+}
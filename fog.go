@@ -0,0 +1,150 @@
+package sgl
+
+import (
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// FogMode is the way fog thickens with distance.
+type FogMode int32
+
+// Fog modes.
+const (
+	FogNone   FogMode = iota
+	FogLinear         // from none at Start to full at End
+	FogExp            // 1 - e^(-density * distance)
+	FogExp2           // 1 - e^(-(density * distance)^2)
+)
+
+// FogBinding is the uniform buffer binding point used for the Fog block.
+const FogBinding = 1
+
+// Fog is distance fog shared by all shaders using FogShaderSource, including
+// the skyboxes and skies, which blend into the fog color at the horizon.
+type Fog struct {
+	Mode          FogMode
+	Color         Color
+	Start, End    float32 // distances for FogLinear
+	Density       float32 // for FogExp and FogExp2
+	HeightFalloff float32 // if > 0, fog thins exponentially above BaseHeight
+	BaseHeight    float32
+	SkyBlend      float32 // height above the horizon (0 to 1, sine of elevation) where the sky is clear of fog
+}
+
+// fogBlock matches the std140 layout of the Fog uniform block.
+type fogBlock struct {
+	Color         [4]float32
+	Start, End    float32
+	Density       float32
+	HeightFalloff float32
+	BaseHeight    float32
+	SkyBlend      float32
+	Mode          int32
+	_             int32
+}
+
+// the shared uniform buffer, created on first use
+var fogUbo uint32
+
+func initFogUbo() {
+	gl.GenBuffers(1, &fogUbo)
+	gl.BindBuffer(gl.UNIFORM_BUFFER, fogUbo)
+	block := fogBlock{}
+	gl.BufferData(gl.UNIFORM_BUFFER, int(unsafe.Sizeof(block)), gl.Ptr(&block), gl.DYNAMIC_DRAW)
+	gl.BindBuffer(gl.UNIFORM_BUFFER, 0)
+	gl.BindBufferBase(gl.UNIFORM_BUFFER, FogBinding, fogUbo)
+}
+
+// SetFog uploads fog to the shared uniform buffer, changing the fog for all
+// shaders using it. Use Fog{} to disable fog.
+func SetFog(fog Fog) {
+	if fogUbo == 0 {
+		initFogUbo()
+	}
+	block := fogBlock{
+		Color:         [4]float32{fog.Color.R, fog.Color.G, fog.Color.B, fog.Color.A},
+		Start:         fog.Start,
+		End:           fog.End,
+		Density:       fog.Density,
+		HeightFalloff: fog.HeightFalloff,
+		BaseHeight:    fog.BaseHeight,
+		SkyBlend:      fog.SkyBlend,
+		Mode:          int32(fog.Mode),
+	}
+	gl.BindBuffer(gl.UNIFORM_BUFFER, fogUbo)
+	gl.BufferSubData(gl.UNIFORM_BUFFER, 0, int(unsafe.Sizeof(block)), gl.Ptr(&block))
+	gl.BindBuffer(gl.UNIFORM_BUFFER, 0)
+}
+
+// BindFog connects the Fog uniform block of prog, which must be built, to
+// the shared uniform buffer. Call it once for each program whose shaders use
+// FogShaderSource.
+func BindFog(prog *Program) {
+	if fogUbo == 0 {
+		initFogUbo()
+	}
+	index := gl.GetUniformBlockIndex(prog.ID, gl.Str("Fog\x00"))
+	if index != gl.INVALID_INDEX {
+		gl.UniformBlockBinding(prog.ID, index, FogBinding)
+	}
+}
+
+// FogShaderSource declares the Fog uniform block and functions to apply it.
+// Put it directly after the #version line of a fragment shader, then use
+//
+//	color.rgb = applyFog(color.rgb, worldPos, cameraPos);
+//
+// on lit surfaces, and
+//
+//	color.rgb = applySkyFog(color.rgb, direction);
+//
+// on backgrounds, and call BindFog() on the program after building it.
+const FogShaderSource = `
+layout (std140) uniform Fog {
+    vec4 color;
+    float start;
+    float end;
+    float density;
+    float heightFalloff;
+    float baseHeight;
+    float skyBlend;
+    int mode;
+} fog;
+
+// fogAmount gets the fog from 0 (none) to 1 (full) between points.
+float fogAmount(vec3 worldPos, vec3 cameraPos)
+{
+    float dist = distance(worldPos, cameraPos);
+    float amount = 0.0;
+    if (fog.mode == 1) {
+        amount = clamp((dist - fog.start) / (fog.end - fog.start), 0.0, 1.0);
+    } else if (fog.mode == 2) {
+        amount = 1.0 - exp(-fog.density * dist);
+    } else if (fog.mode == 3) {
+        float d = fog.density * dist;
+        amount = 1.0 - exp(-d * d);
+    }
+    if (fog.heightFalloff > 0.0) {
+        // average density along the ray, thinning above baseHeight
+        float h = min(worldPos.y, cameraPos.y) - fog.baseHeight;
+        amount *= exp(-fog.heightFalloff * max(h, 0.0));
+    }
+    return amount * fog.color.a;
+}
+
+vec3 applyFog(vec3 color, vec3 worldPos, vec3 cameraPos)
+{
+    return mix(color, fog.color.rgb, fogAmount(worldPos, cameraPos));
+}
+
+// applySkyFog blends the sky in direction dir into the fog at the horizon.
+vec3 applySkyFog(vec3 color, vec3 dir)
+{
+    if (fog.mode == 0) {
+        return color;
+    }
+    float amount = 1.0 - smoothstep(0.0, max(fog.skyBlend, 0.001), normalize(dir).y);
+    return mix(color, fog.color.rgb, amount * fog.color.a);
+}
+`
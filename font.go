@@ -3,6 +3,7 @@ package sgl
 import (
 	"image"
 	"image/draw"
+	"unicode"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
@@ -35,6 +36,7 @@ func newFontTexture(face *basicfont.Face) (uint32, error) {
 		gl.Ptr(rgba.Pix))
 
 	gl.BindTexture(gl.TEXTURE_2D, 0) // unbind texture
+	trackTexture(texture, len(rgba.Pix))
 	return texture, nil
 }
 
@@ -44,6 +46,7 @@ type CharacterDict struct {
 	shader        uint32
 	shaderProgram *Program
 	fw, fh        float32
+	fallbacks     []*CharacterDict
 }
 
 func NewCharacterDict(font *basicfont.Face) *CharacterDict {
@@ -80,7 +83,55 @@ func NewCharacterDict(font *basicfont.Face) *CharacterDict {
 	return cd
 }
 
+// AddFallback adds dicts to search, in order, for characters missing from
+// this one, such as symbols, emoji, or other scripts. Characters found in
+// none of them are drawn as U+FFFD (or nothing, if it too is missing).
+// Fallbacks are not deleted with this dict.
+func (cd *CharacterDict) AddFallback(fallbacks ...*CharacterDict) {
+	cd.fallbacks = append(cd.fallbacks, fallbacks...)
+}
+
+// glyph finds the character for r in cd or its fallbacks, along with its
+// font texture and advance width.
+func (cd CharacterDict) glyph(r rune) (c Character, font uint32, advance float32, ok bool) {
+	if c, ok := cd.dict[r]; ok {
+		return c, cd.font, cd.fw, true
+	}
+	for _, fb := range cd.fallbacks {
+		if c, ok := fb.dict[r]; ok {
+			return c, fb.font, fb.fw, true
+		}
+	}
+	return Character{}, 0, cd.fw, false
+}
+
+// zeroWidth is true for runes which take no space, such as emoji variation
+// selectors, zero width joiners, skin tone modifiers, and combining marks,
+// which the fonts can't draw.
+func zeroWidth(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) ||
+		(r >= 0x1f3fb && r <= 0x1f3ff) // skin tone modifiers
+}
+
+// Width gets the width of text in pixels, when drawn at scale.
+func (cd CharacterDict) Width(text string, scale float32) float32 {
+	text = ShapeText(text)
+	var width float32
+	for _, r := range text {
+		if zeroWidth(r) {
+			continue
+		}
+		_, _, advance, ok := cd.glyph(r)
+		if !ok {
+			_, _, advance, _ = cd.glyph(unicode.ReplacementChar)
+		}
+		width += advance * scale
+	}
+	return width
+}
+
 func (cd CharacterDict) Delete() {
+	trackTexture(cd.font, 0)
 	gl.DeleteTextures(1, &cd.font)
 	for k := range cd.dict {
 		cd.dict[k].delete()
@@ -89,7 +140,9 @@ func (cd CharacterDict) Delete() {
 }
 
 // (0, 0) are in the top left of the screen (inverted Y compared to standard opengl)
+// The text is shaped by ShapeText(), which doesn't handle Indic scripts.
 func (cd CharacterDict) DrawString(text string, x, y, scale float32, color mgl32.Vec3, width, height float32) {
+	assertGLThread()
 	gl.UseProgram(cd.shader)
 
 	// gl.ActiveTexture(gl.TEXTURE0) // this is implicit here.
@@ -113,15 +166,25 @@ func (cd CharacterDict) DrawString(text string, x, y, scale float32, color mgl32
 	gl.Uniform3fv(textColorUniform, 1, &color[0])
 
 	var model mgl32.Mat4
-	for i, r := range text {
-		model = mgl32.Translate3D(x+scale*(float32(i)*cd.fw), y*scale, 0).Mul4(mgl32.Scale3D(scale, scale, scale))
-		c, ok := cd.dict[r]
-		if !ok {
+	bound := cd.font
+	for _, r := range ShapeText(text) {
+		if zeroWidth(r) {
 			continue
 		}
-
-		gl.UniformMatrix4fv(modelUniform, 1, false, &model[0])
-		c.draw()
+		c, font, advance, ok := cd.glyph(r)
+		if !ok {
+			c, font, advance, ok = cd.glyph(unicode.ReplacementChar)
+		}
+		if ok {
+			if font != bound { // character from a fallback
+				gl.BindTexture(gl.TEXTURE_2D, font)
+				bound = font
+			}
+			model = mgl32.Translate3D(x, y*scale, 0).Mul4(mgl32.Scale3D(scale, scale, scale))
+			gl.UniformMatrix4fv(modelUniform, 1, false, &model[0])
+			c.draw()
+		}
+		x += advance * scale
 	}
 
 	gl.BindTexture(gl.TEXTURE_2D, 0)
@@ -140,6 +203,7 @@ func (c Character) delete() {
 func (c Character) draw() {
 	gl.BindVertexArray(c.vao)              // bind vao once
 	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4) // draw 4 verticies from VAO
+	countDraw(gl.TRIANGLE_STRIP, 4)
 	gl.BindVertexArray(0)
 }
 
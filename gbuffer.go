@@ -0,0 +1,153 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// GBuffer is a framebuffer for the scene with the extra per-pixel data used
+// by screen space passes such as TAA, SSR, and Bloom: hdr color, screen
+// motion (velocity), surface normal and reflectivity, emitted light, and
+// depth, each a texture.
+//
+// Shaders drawing into it write color to location 0, velocity to location
+// 1, with VelocityShaderSource, normal and reflectivity to location 2, and
+// emitted light to location 3. Emitted light should also be included in the
+// color; it's kept separately so it glows whatever its brightness.
+// Pixels with zero velocity are reprojected with the camera's motion alone,
+// which is right for static objects.
+type GBuffer struct {
+	ID            uint32
+	Width, Height int32
+	Color         *Texture2D // RGBA16F
+	Velocity      *Texture2D // RG16F, change in texture coords since the last frame
+	Normal        *Texture2D // RGBA16F, world space normal in rgb, reflectivity (0 to 1) in a
+	Emissive      *Texture2D // RGBA16F, light emitted by the surface, for glow
+	Depth         *Texture2D // 24 bit depth
+}
+
+// texRG16F is the format of the GBuffer's velocity.
+var texRG16F = TextureFormat{gl.RG16F, gl.RG, gl.FLOAT, 8}
+
+// NewGBuffer creates a GBuffer of the given size.
+func NewGBuffer(width, height int) (*GBuffer, error) {
+	g := &GBuffer{Width: int32(width), Height: int32(height)}
+	g.Color = newAttachment(width, height, TexRGBA16F, gl.LINEAR)
+	g.Velocity = newAttachment(width, height, texRG16F, gl.NEAREST)
+	g.Normal = newAttachment(width, height, TexRGBA16F, gl.NEAREST)
+	g.Emissive = newAttachment(width, height, TexRGBA16F, gl.LINEAR)
+	g.Depth = newAttachment(width, height, TexDepth24, gl.NEAREST)
+
+	g.ID = backend.GenFramebuffer()
+	backend.BindFramebuffer(gl.FRAMEBUFFER, g.ID)
+	backend.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, g.Color.ID, 0)
+	backend.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT1, gl.TEXTURE_2D, g.Velocity.ID, 0)
+	backend.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT2, gl.TEXTURE_2D, g.Normal.ID, 0)
+	backend.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT3, gl.TEXTURE_2D, g.Emissive.ID, 0)
+	backend.FramebufferTexture2D(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.TEXTURE_2D, g.Depth.ID, 0)
+	buffers := []uint32{gl.COLOR_ATTACHMENT0, gl.COLOR_ATTACHMENT1, gl.COLOR_ATTACHMENT2, gl.COLOR_ATTACHMENT3}
+	backend.DrawBuffers(buffers)
+	status := backend.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	backend.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		g.Delete()
+		return nil, fmt.Errorf("gbuffer is not complete")
+	}
+	return g, nil
+}
+
+// newAttachment creates a texture with no data for use as a framebuffer
+// attachment.
+func newAttachment(width, height int, format TextureFormat, filter int32) *Texture2D {
+	tex := &Texture2D{Width: int32(width), Height: int32(height), Format: format}
+	tex.ID = backend.GenTexture()
+	backend.BindTexture(gl.TEXTURE_2D, tex.ID)
+	backend.TexImage2D(gl.TEXTURE_2D, 0, format.Internal, tex.Width, tex.Height, format.Format, format.Type, nil)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, filter)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, filter)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	backend.BindTexture(gl.TEXTURE_2D, 0)
+	trackTexture(tex.ID, width*height*format.Size)
+	return tex
+}
+
+// newColorTarget creates an RGBA16F texture attached to a new fbo, for the
+// output of a post-processing pass.
+func newColorTarget(width, height int) (*Texture2D, uint32, error) {
+	tex := newAttachment(width, height, TexRGBA16F, gl.LINEAR)
+	var fbo uint32
+	fbo = backend.GenFramebuffer()
+	backend.BindFramebuffer(gl.FRAMEBUFFER, fbo)
+	backend.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, tex.ID, 0)
+	status := backend.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	backend.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		tex.Delete()
+		backend.DeleteFramebuffer(fbo)
+		return nil, 0, fmt.Errorf("framebuffer is not complete")
+	}
+	return tex, fbo, nil
+}
+
+// Use binds the GBuffer and sets the viewport to cover it.
+func (g *GBuffer) Use() {
+	backend.BindFramebuffer(gl.FRAMEBUFFER, g.ID)
+	backend.Viewport(0, 0, g.Width, g.Height)
+}
+
+// Clear clears color to c, velocity to none, normals to unreflective,
+// emissive to none, and depth to far.
+func (g *GBuffer) Clear(c Color) {
+	color := c.Vec4()
+	var zero [4]float32
+	var depth float32 = 1
+	backend.ClearBufferfv(gl.COLOR, 0, &color[0])
+	backend.ClearBufferfv(gl.COLOR, 1, &zero[0])
+	backend.ClearBufferfv(gl.COLOR, 2, &zero[0])
+	backend.ClearBufferfv(gl.COLOR, 3, &zero[0])
+	backend.ClearBufferfv(gl.DEPTH, 0, &depth)
+}
+
+// Delete resources.
+func (g *GBuffer) Delete() {
+	for _, tex := range []*Texture2D{g.Color, g.Velocity, g.Normal, g.Emissive, g.Depth} {
+		if tex != nil {
+			tex.Delete()
+		}
+	}
+	backend.DeleteFramebuffer(g.ID)
+}
+
+// VelocityShaderSource has a function for writing a GBuffer's velocity. Put
+// it directly after the #version line of a fragment shader, pass the
+// position in clip space this frame and last frame, both without jitter,
+// from the vertex shader, and use
+//
+//	layout (location = 1) out vec2 Velocity;
+//	...
+//	Velocity = velocity(ClipPos, PrevClipPos);
+//
+// Last frame's clip position uses last frame's model and view-projection
+// matrices, so moving objects blur and reproject correctly.
+const VelocityShaderSource = `
+// velocity gets the change in texture coords of a point since last frame.
+vec2 velocity(vec4 clipPos, vec4 prevClipPos)
+{
+    return (clipPos.xy / clipPos.w - prevClipPos.xy / prevClipPos.w) * 0.5;
+}
+`
+
+// cameraMotionShaderSource has a function for the velocity of a static
+// point, from its depth and the camera's movement.
+const cameraMotionShaderSource = `
+// cameraMotion gets the change in texture coords since last frame of the
+// static point at uv with depth.
+vec2 cameraMotion(vec2 uv, float depth, mat4 invViewProj, mat4 prevViewProj)
+{
+    vec4 world = invViewProj * vec4(uv * 2.0 - 1.0, depth * 2.0 - 1.0, 1.0);
+    vec4 prev = prevViewProj * (world / world.w);
+    return uv - (prev.xy / prev.w * 0.5 + 0.5);
+}
+`
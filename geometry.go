@@ -0,0 +1,118 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// Save writes the metric to a JSON file.
+func (m WindowMetric) Save(filename string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode window geometry: %w", err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("could not save window geometry: %w", err)
+	}
+	return nil
+}
+
+// Load replaces the metric with one from a JSON file written by Save().
+func (m *WindowMetric) Load(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("could not load window geometry: %w", err)
+	}
+	var loaded WindowMetric
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("could not decode window geometry %s: %w", filename, err)
+	}
+	*m = loaded
+	return nil
+}
+
+// minVisible is how many screen coordinates of a window, from its top left,
+// must be on a monitor for ClampToMonitors() to leave it be. It's enough of
+// the title bar to grab.
+const minVisible = 64
+
+// ClampToMonitors moves and shrinks the window rect, if needed, so it's on
+// the work area of a connected monitor, such as when restoring a position
+// saved while another monitor was connected. A rect whose top left corner
+// isn't on a monitor is moved to the primary monitor.
+func (m *WindowMetric) ClampToMonitors() {
+	type area struct{ x, y, w, h int }
+	var areas []area
+	for _, monitor := range glfw.GetMonitors() {
+		x, y, w, h := monitor.GetWorkarea()
+		areas = append(areas, area{x, y, w, h})
+	}
+	if len(areas) == 0 {
+		return
+	}
+
+	target := areas[0]
+	if primary := glfw.GetPrimaryMonitor(); primary != nil {
+		x, y, w, h := primary.GetWorkarea()
+		target = area{x, y, w, h}
+	}
+	for _, a := range areas {
+		if m.X+minVisible > a.x && m.X < a.x+a.w-minVisible &&
+			m.Y+minVisible > a.y && m.Y < a.y+a.h-minVisible {
+			target = a
+			break
+		}
+	}
+
+	m.W, m.H = minInt(m.W, target.w), minInt(m.H, target.h)
+	m.X = maxInt(target.x, minInt(m.X, target.x+target.w-m.W))
+	m.Y = maxInt(target.y, minInt(m.Y, target.y+target.h-m.H))
+}
+
+// UseRestoreGeometry is an option to restore the window's position, size,
+// and fullscreen state saved in filename by the previous run, and save them
+// there again when the window is disposed. The size passed to NewWindow()
+// is used the first time, when there's no file, and if the file can't be
+// read, which is logged. Restored positions are kept on the connected
+// monitors with WindowMetric.ClampToMonitors().
+func UseRestoreGeometry(filename string) WindowOption {
+	return func(win *Window) error {
+		win.geometryFile = filename
+		var m WindowMetric
+		if err := m.Load(filename); err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				log.Printf("sgl: %v", err)
+			}
+			return nil
+		}
+		if m.W <= 0 || m.H <= 0 {
+			return nil
+		}
+		m.ClampToMonitors()
+		win.GlfwWindow.SetPos(m.X, m.Y)
+		win.GlfwWindow.SetSize(m.W, m.H)
+		win.Dimensions.X, win.Dimensions.Y, win.Dimensions.W, win.Dimensions.H = m.X, m.Y, m.W, m.H
+		if m.Fullscreen && !win.Dimensions.Fullscreen {
+			win.FullscreenOn(win.CurrentMonitor(), 0, 0, 0)
+		}
+		return nil
+	}
+}
+
+// saveGeometry saves the window's dimensions for UseRestoreGeometry().
+func (platform *Window) saveGeometry() {
+	if platform.geometryFile == "" {
+		return
+	}
+	if err := platform.Dimensions.Save(platform.geometryFile); err != nil {
+		log.Printf("sgl: %v", err)
+	}
+}
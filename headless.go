@@ -0,0 +1,167 @@
+//go:build egl
+
+package sgl
+
+/*
+#cgo linux freebsd netbsd openbsd pkg-config: egl
+#include <stdlib.h>
+#include <string.h>
+#include <EGL/egl.h>
+#include <EGL/eglext.h>
+
+// headlessDisplay gets a display that needs no display server, preferring
+// mesa's surfaceless platform, then a gpu device, then the default display.
+static EGLDisplay headlessDisplay() {
+	const char *exts = eglQueryString(EGL_NO_DISPLAY, EGL_EXTENSIONS);
+	PFNEGLGETPLATFORMDISPLAYEXTPROC getPlatformDisplay =
+		(PFNEGLGETPLATFORMDISPLAYEXTPROC)eglGetProcAddress("eglGetPlatformDisplayEXT");
+	if (exts && getPlatformDisplay) {
+		if (strstr(exts, "EGL_MESA_platform_surfaceless")) {
+			EGLDisplay d = getPlatformDisplay(EGL_PLATFORM_SURFACELESS_MESA, EGL_DEFAULT_DISPLAY, NULL);
+			if (d != EGL_NO_DISPLAY) return d;
+		}
+		PFNEGLQUERYDEVICESEXTPROC queryDevices =
+			(PFNEGLQUERYDEVICESEXTPROC)eglGetProcAddress("eglQueryDevicesEXT");
+		if (strstr(exts, "EGL_EXT_platform_device") && queryDevices) {
+			EGLDeviceEXT device;
+			EGLint count = 0;
+			if (queryDevices(1, &device, &count) && count > 0) {
+				EGLDisplay d = getPlatformDisplay(EGL_PLATFORM_DEVICE_EXT, device, NULL);
+				if (d != EGL_NO_DISPLAY) return d;
+			}
+		}
+	}
+	return eglGetDisplay(EGL_DEFAULT_DISPLAY);
+}
+
+// headlessContext creates an opengl 3.3 core context on display and makes it
+// current, with a 1x1 pbuffer surface if surfaceless contexts aren't
+// supported. It returns an error message, or NULL.
+static const char *headlessContext(EGLDisplay display, EGLContext *context, EGLSurface *surface) {
+	EGLint major, minor;
+	if (!eglInitialize(display, &major, &minor)) return "couldn't initialize display";
+	if (!eglBindAPI(EGL_OPENGL_API)) return "opengl isn't supported";
+
+	EGLint configAttribs[] = {
+		EGL_SURFACE_TYPE, EGL_PBUFFER_BIT,
+		EGL_RENDERABLE_TYPE, EGL_OPENGL_BIT,
+		EGL_RED_SIZE, 8, EGL_GREEN_SIZE, 8, EGL_BLUE_SIZE, 8, EGL_ALPHA_SIZE, 8,
+		EGL_DEPTH_SIZE, 24, EGL_STENCIL_SIZE, 8,
+		EGL_NONE,
+	};
+	EGLConfig config;
+	EGLint count = 0;
+	if (!eglChooseConfig(display, configAttribs, &config, 1, &count) || count == 0) {
+		// surfaceless platforms may have no pbuffer configs
+		configAttribs[1] = 0;
+		if (!eglChooseConfig(display, configAttribs, &config, 1, &count) || count == 0)
+			return "no suitable config";
+	}
+
+	EGLint contextAttribs[] = {
+		EGL_CONTEXT_MAJOR_VERSION, 3,
+		EGL_CONTEXT_MINOR_VERSION, 3,
+		EGL_CONTEXT_OPENGL_PROFILE_MASK, EGL_CONTEXT_OPENGL_CORE_PROFILE_BIT,
+		EGL_NONE,
+	};
+	*context = eglCreateContext(display, config, EGL_NO_CONTEXT, contextAttribs);
+	if (*context == EGL_NO_CONTEXT) return "couldn't create opengl 3.3 core context";
+
+	*surface = EGL_NO_SURFACE;
+	const char *exts = eglQueryString(display, EGL_EXTENSIONS);
+	if (!exts || !strstr(exts, "EGL_KHR_surfaceless_context")) {
+		EGLint pbufferAttribs[] = {EGL_WIDTH, 1, EGL_HEIGHT, 1, EGL_NONE};
+		*surface = eglCreatePbufferSurface(display, config, pbufferAttribs);
+		if (*surface == EGL_NO_SURFACE) return "couldn't create pbuffer surface";
+	}
+	if (!eglMakeCurrent(display, *surface, *surface, *context)) return "couldn't make context current";
+	return NULL;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"image"
+	"runtime"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// HeadlessContext is an opengl context with no window, created with EGL so
+// it needs no display server. It's for rendering on servers and in
+// containers, such as for thumbnails or golden image tests. Drawing goes to
+// Target, since there is no default framebuffer.
+//
+// It's only built with the "egl" build tag, which also makes go-gl load
+// opengl through EGL, and leaves out Window and everything else using glfw,
+// so X11 isn't needed to build:
+//
+//	go test -tags egl ./...
+//
+// With mesa, LIBGL_ALWAYS_SOFTWARE=1 renders on the cpu where there's no gpu.
+type HeadlessContext struct {
+	GlVersion string
+	Target    *Fbo
+
+	display C.EGLDisplay
+	context C.EGLContext
+	surface C.EGLSurface
+}
+
+// NewHeadlessContext creates a context and makes it current on this thread,
+// which it locks until Delete() or an error, with a width x height Target bound for drawing.
+func NewHeadlessContext(width, height int) (*HeadlessContext, error) {
+	runtime.LockOSThread()
+	recordGLThread()
+
+	hc := &HeadlessContext{display: C.headlessDisplay()}
+	if hc.display == 0 {
+		runtime.UnlockOSThread()
+		return nil, fmt.Errorf("couldn't get an EGL display")
+	}
+	if msg := C.headlessContext(hc.display, &hc.context, &hc.surface); msg != nil {
+		C.eglTerminate(hc.display)
+		runtime.UnlockOSThread()
+		return nil, fmt.Errorf("failed to create headless context: %s", C.GoString(msg))
+	}
+	if err := gl.Init(); err != nil {
+		hc.Delete()
+		return nil, fmt.Errorf("failed to initialize opengl: %w", err)
+	}
+	hc.GlVersion = gl.GoStr(gl.GetString(gl.VERSION))
+
+	var err error
+	if hc.Target, err = NewFbo(width, height); err != nil {
+		hc.Delete()
+		return nil, err
+	}
+	hc.Use()
+	return hc, nil
+}
+
+// Use binds Target and sets the viewport to its size.
+func (hc *HeadlessContext) Use() {
+	hc.Target.Use()
+	gl.Viewport(0, 0, hc.Target.Width, hc.Target.Height)
+}
+
+// ReadImage gets what has been drawn to Target.
+func (hc *HeadlessContext) ReadImage() *image.RGBA {
+	gl.Finish()
+	return hc.Target.ColorBuffer.ReadImage()
+}
+
+// Delete the Target and context.
+func (hc *HeadlessContext) Delete() {
+	if hc.Target != nil {
+		hc.Target.Delete()
+	}
+	C.eglMakeCurrent(hc.display, nil, nil, nil)
+	if hc.surface != nil {
+		C.eglDestroySurface(hc.display, hc.surface)
+	}
+	C.eglDestroyContext(hc.display, hc.context)
+	C.eglTerminate(hc.display)
+	runtime.UnlockOSThread()
+}
@@ -0,0 +1,64 @@
+package sgl
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// loopbackHost returns true if host is localhost or a loopback ip. An empty
+// host, which listens on every address, isn't.
+func loopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// guardRequests rejects requests without token or, with no token, whose
+// Host (if checkHost) or Origin isn't localhost, before they reach next.
+// Servers without a token should also only listen on loopback addresses
+// (see loopbackHost()), so web pages can't reach them by DNS rebinding or
+// cross-site requests.
+func guardRequests(token string, checkHost bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			sent := r.URL.Query().Get("token")
+			if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+				sent = strings.TrimPrefix(auth, "Bearer ")
+			}
+			if subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+				http.Error(w, "bad token", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if checkHost && !localHost(r.Host) {
+			http.Error(w, "bad host", http.StatusForbidden)
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || !localHost(u.Host) {
+				http.Error(w, "bad origin", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// localHost returns true if hostport, such as a request's Host, is
+// localhost, 127.0.0.1, or ::1, with or without a port.
+func localHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	return strings.EqualFold(host, "localhost") || host == "127.0.0.1" || host == "::1"
+}
@@ -0,0 +1,203 @@
+package sgl
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// ResizeNearest makes a copy of img scaled to width x height using nearest
+// neighbor sampling, which keeps pixel art sharp.
+func ResizeNearest(img *image.RGBA, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	src := img.Bounds()
+	if src.Empty() {
+		return dst
+	}
+	for y := 0; y < height; y++ {
+		sy := src.Min.Y + y*src.Dy()/height
+		for x := 0; x < width; x++ {
+			sx := src.Min.X + x*src.Dx()/width
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], img.Pix[img.PixOffset(sx, sy):])
+		}
+	}
+	return dst
+}
+
+// ResizeBilinear makes a copy of img scaled to width x height using bilinear
+// filtering. It's best for modest changes in size; shrinking by more than half
+// skips pixels and may alias.
+func ResizeBilinear(img *image.RGBA, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	src := img.Bounds()
+	if src.Empty() {
+		return dst
+	}
+	scaleX := float64(src.Dx()) / float64(width)
+	scaleY := float64(src.Dy()) / float64(height)
+
+	for y := 0; y < height; y++ {
+		// sample at pixel centers
+		fy := math.Max((float64(y)+0.5)*scaleY-0.5, 0)
+		y0 := int(fy)
+		y1 := minInt(y0+1, src.Dy()-1)
+		ty := fy - float64(y0)
+		for x := 0; x < width; x++ {
+			fx := math.Max((float64(x)+0.5)*scaleX-0.5, 0)
+			x0 := int(fx)
+			x1 := minInt(x0+1, src.Dx()-1)
+			tx := fx - float64(x0)
+
+			p00 := img.PixOffset(src.Min.X+x0, src.Min.Y+y0)
+			p10 := img.PixOffset(src.Min.X+x1, src.Min.Y+y0)
+			p01 := img.PixOffset(src.Min.X+x0, src.Min.Y+y1)
+			p11 := img.PixOffset(src.Min.X+x1, src.Min.Y+y1)
+			d := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				top := float64(img.Pix[p00+c])*(1-tx) + float64(img.Pix[p10+c])*tx
+				bottom := float64(img.Pix[p01+c])*(1-tx) + float64(img.Pix[p11+c])*tx
+				dst.Pix[d+c] = uint8(top*(1-ty) + bottom*ty + 0.5)
+			}
+		}
+	}
+	return dst
+}
+
+// FlipVertical flips img upside down, in place.
+func FlipVertical(img *image.RGBA) {
+	b := img.Bounds()
+	rowBytes := 4 * b.Dx()
+	temp := make([]byte, rowBytes)
+	for y := 0; y < b.Dy()/2; y++ {
+		top := img.Pix[img.PixOffset(b.Min.X, b.Min.Y+y):][:rowBytes]
+		bottom := img.Pix[img.PixOffset(b.Min.X, b.Max.Y-1-y):][:rowBytes]
+		copy(temp, top)
+		copy(top, bottom)
+		copy(bottom, temp)
+	}
+}
+
+// FlipHorizontal mirrors img left to right, in place.
+func FlipHorizontal(img *image.RGBA) {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for l, r := b.Min.X, b.Max.X-1; l < r; l, r = l+1, r-1 {
+			pl, pr := img.PixOffset(l, y), img.PixOffset(r, y)
+			for c := 0; c < 4; c++ {
+				img.Pix[pl+c], img.Pix[pr+c] = img.Pix[pr+c], img.Pix[pl+c]
+			}
+		}
+	}
+}
+
+// Rotate90 makes a copy of img rotated by 90 degrees, clockwise or
+// counter-clockwise.
+func Rotate90(img *image.RGBA, clockwise bool) *image.RGBA {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, h, w))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := h-1-y, x // clockwise
+			if !clockwise {
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):][:4], img.Pix[img.PixOffset(b.Min.X+x, b.Min.Y+y):])
+		}
+	}
+	return dst
+}
+
+// Premultiply converts an image with straight (non-premultiplied) alpha to
+// one with premultiplied alpha, as is best for blending with
+// gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA) and for filtering.
+func Premultiply(img *image.NRGBA) *image.RGBA {
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Src)
+	return dst
+}
+
+// Swizzle rearranges the channels of each pixel of img in place. Each of
+// r, g, b, and a is the index (0 to 3, for R, G, B, A) of the source channel
+// to use for that channel. For example, Swizzle(img, 2, 1, 0, 3) swaps red
+// and blue, converting BGRA data to RGBA.
+func Swizzle(img *image.RGBA, r, g, b, a int) {
+	bounds := img.Bounds()
+	var px [4]uint8
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			p := img.Pix[img.PixOffset(x, y):][:4]
+			copy(px[:], p)
+			p[0], p[1], p[2], p[3] = px[r], px[g], px[b], px[a]
+		}
+	}
+}
+
+// SolidImage makes an image filled with a single color.
+func SolidImage(width, height int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+// CheckerImage makes a checkerboard of squares size pixels wide, alternating
+// between colors a and b. It's useful as a placeholder or "missing" texture.
+func CheckerImage(width, height, size int, a, b color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	ca := color.RGBAModel.Convert(a).(color.RGBA)
+	cb := color.RGBAModel.Convert(b).(color.RGBA)
+	if size <= 0 {
+		size = 1
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (x/size+y/size)%2 == 0 {
+				img.SetRGBA(x, y, ca)
+			} else {
+				img.SetRGBA(x, y, cb)
+			}
+		}
+	}
+	return img
+}
+
+// GradientImage makes an image with a linear gradient from color "from" to
+// "to", left to right, or top to bottom if vertical is true.
+func GradientImage(width, height int, from, to color.Color, vertical bool) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	r0, g0, b0, a0 := from.RGBA()
+	r1, g1, b1, a1 := to.RGBA()
+	mix := func(c0, c1 uint32, t float64) uint8 {
+		return uint8((float64(c0)*(1-t)+float64(c1)*t)/257 + 0.5)
+	}
+
+	steps := width
+	if vertical {
+		steps = height
+	}
+	for i := 0; i < steps; i++ {
+		var t float64
+		if steps > 1 {
+			t = float64(i) / float64(steps-1)
+		}
+		c := color.RGBA{mix(r0, r1, t), mix(g0, g1, t), mix(b0, b1, t), mix(a0, a1, t)}
+		if vertical {
+			for x := 0; x < width; x++ {
+				img.SetRGBA(x, i, c)
+			}
+		} else {
+			for y := 0; y < height; y++ {
+				img.SetRGBA(i, y, c)
+			}
+		}
+	}
+	return img
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
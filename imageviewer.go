@@ -0,0 +1,346 @@
+package sgl
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/inkyblackness/imgui-go/v4"
+)
+
+// only need this once in the package
+var imageViewerProgram *Program
+
+// called to create and build the image viewer program.
+func initImageViewerProgram() error {
+	imageViewerProgram = NewProgram()
+	imageViewerProgram.AddShader(VertexShader, quadVertexShader,
+		[]string{"projection", "model"},
+		Attribute{Name: "vertex", Type: gl.FLOAT, Size: 4, Stride: 4 * SizeOfFloat, Offset: 0})
+	imageViewerProgram.AddShader(FragmentShader, imageViewerFragmentShader,
+		[]string{"image", "size", "imageSize", "center", "zoom", "channels", "exposure",
+			"nearest", "flipY"})
+	if err := imageViewerProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build image viewer program: %w", err)
+	}
+	var image int32 = 0
+	imageViewerProgram.Fragment().SetInt("image", 1, &image)
+	return nil
+}
+
+// ImageViewer shows a texture for debugging, with panning, zooming down to
+// single texels, isolating channels, exposure for hdr textures, and the
+// value of the texel under the mouse. Gui() shows it in an imgui window
+// with controls; the view can also be rendered to Output with Render().
+type ImageViewer struct {
+	Texture  *Texture2D
+	Center   mgl32.Vec2 // texel coords at the center of the view
+	Zoom     float32    // screen pixels per texel
+	Channels [4]bool    // r, g, b, a shown; a single channel is shown in gray
+	Exposure float32    // in stops; color is scaled by 2^Exposure
+	Nearest  bool       // show texels as squares rather than filtered
+	FlipY    bool       // for render targets, whose row 0 is the bottom
+	Output   *Fbo
+
+	fitted  bool
+	readFbo uint32
+
+	// texel under the mouse in the last Gui()
+	hovered    bool
+	hoverTexel [2]int
+	hoverValue [4]float32
+}
+
+// NewImageViewer creates a viewer of tex, which may be nil and set later.
+func NewImageViewer(tex *Texture2D) (*ImageViewer, error) {
+	if imageViewerProgram == nil {
+		if err := initImageViewerProgram(); err != nil {
+			return nil, err
+		}
+	}
+	return &ImageViewer{
+		Texture:  tex,
+		Zoom:     1,
+		Channels: [4]bool{true, true, true, true},
+		Nearest:  true,
+		readFbo:  backend.GenFramebuffer(),
+	}, nil
+}
+
+// Fit zooms to show the whole texture in a view of width x height pixels.
+func (v *ImageViewer) Fit(width, height float32) {
+	if v.Texture == nil {
+		return
+	}
+	w, h := float32(v.Texture.Width), float32(v.Texture.Height)
+	v.Zoom = float32(math.Min(float64(width/w), float64(height/h)))
+	v.Center = mgl32.Vec2{w / 2, h / 2}
+}
+
+// ZoomAt multiplies the zoom by factor, keeping the texel at view position
+// (x, y), in pixels from the top left of a width x height view, in place.
+func (v *ImageViewer) ZoomAt(factor, x, y, width, height float32) {
+	before := v.ToTexel(x, y, width, height)
+	v.Zoom = mgl32.Clamp(v.Zoom*factor, 1.0/64, 256)
+	after := v.ToTexel(x, y, width, height)
+	v.Center = v.Center.Add(before.Sub(after))
+}
+
+// ToTexel gets the texel coords at view position (x, y), in pixels from the
+// top left of a width x height view.
+func (v *ImageViewer) ToTexel(x, y, width, height float32) mgl32.Vec2 {
+	return mgl32.Vec2{x - width/2, y - height/2}.Mul(1 / v.Zoom).Add(v.Center)
+}
+
+// Render the view into Output, resizing it to width x height.
+func (v *ImageViewer) Render(width, height int) error {
+	if width < 1 || height < 1 {
+		return nil
+	}
+	if v.Output == nil || int(v.Output.Width) != width || int(v.Output.Height) != height {
+		if v.Output != nil {
+			v.Output.Delete()
+		}
+		var err error
+		if v.Output, err = NewFbo(width, height); err != nil {
+			v.Output = nil
+			return fmt.Errorf("couldn't create image viewer target: %w", err)
+		}
+	}
+
+	saved := saveTargetState()
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.BLEND)
+	v.Output.Use()
+	gl.Viewport(0, 0, v.Output.Width, v.Output.Height)
+	if v.Texture == nil {
+		gl.ClearColor(0, 0, 0, 1)
+		gl.Clear(gl.COLOR_BUFFER_BIT)
+		saved.restore()
+		return nil
+	}
+
+	w, h := float32(width), float32(height)
+	size := mgl32.Vec2{w, h}
+	imageSize := mgl32.Vec2{float32(v.Texture.Width), float32(v.Texture.Height)}
+	var channels mgl32.Vec4
+	for i, on := range v.Channels {
+		if on {
+			channels[i] = 1
+		}
+	}
+	nearest, flipY := boolToInt32(v.Nearest), boolToInt32(v.FlipY)
+	projection, model := quadTransform(0, 0, w, h, w, h)
+	imageViewerProgram.Use()
+	imageViewerProgram.Vertex().SetMat4("projection", 1, &projection)
+	imageViewerProgram.Vertex().SetMat4("model", 1, &model)
+	imageViewerProgram.Fragment().SetVec2("size", 1, &size)
+	imageViewerProgram.Fragment().SetVec2("imageSize", 1, &imageSize)
+	imageViewerProgram.Fragment().SetVec2("center", 1, &v.Center)
+	imageViewerProgram.Fragment().SetFloat("zoom", 1, &v.Zoom)
+	imageViewerProgram.Fragment().SetVec4("channels", 1, &channels)
+	imageViewerProgram.Fragment().SetFloat("exposure", 1, &v.Exposure)
+	imageViewerProgram.Fragment().SetInt("nearest", 1, &nearest)
+	imageViewerProgram.Fragment().SetInt("flipY", 1, &flipY)
+	v.Texture.Bind(0)
+	drawQuad()
+	saved.restore()
+	return nil
+}
+
+// boolToInt32 gets 1 for true, for bool uniforms.
+func boolToInt32(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ReadTexel gets the value of the texel at (x, y) of the texture, where
+// row 0 is the first row of the texture's data. Channels the texture
+// doesn't have are 0, except alpha, which is 1. Depth textures give their
+// depth in r.
+func (v *ImageViewer) ReadTexel(x, y int) (value [4]float32, err error) {
+	if v.Texture == nil || x < 0 || y < 0 || x >= int(v.Texture.Width) || y >= int(v.Texture.Height) {
+		return value, fmt.Errorf("texel (%d, %d) is outside the texture", x, y)
+	}
+	var readFbo int32
+	gl.GetIntegerv(gl.READ_FRAMEBUFFER_BINDING, &readFbo)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, v.readFbo)
+	defer gl.BindFramebuffer(gl.READ_FRAMEBUFFER, uint32(readFbo))
+
+	if v.Texture.format().Format == gl.DEPTH_COMPONENT {
+		gl.FramebufferTexture2D(gl.READ_FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, 0, 0)
+		gl.FramebufferTexture2D(gl.READ_FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.TEXTURE_2D, v.Texture.ID, 0)
+		gl.ReadBuffer(gl.NONE)
+	} else {
+		gl.FramebufferTexture2D(gl.READ_FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.TEXTURE_2D, 0, 0)
+		gl.FramebufferTexture2D(gl.READ_FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, v.Texture.ID, 0)
+		gl.ReadBuffer(gl.COLOR_ATTACHMENT0)
+	}
+	if status := gl.CheckFramebufferStatus(gl.READ_FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		return value, fmt.Errorf("can't read texels of this texture (status 0x%x)", status)
+	}
+	if v.Texture.format().Format == gl.DEPTH_COMPONENT {
+		gl.ReadPixels(int32(x), int32(y), 1, 1, gl.DEPTH_COMPONENT, gl.FLOAT, gl.Ptr(&value[0]))
+		value[3] = 1
+	} else {
+		gl.ReadPixels(int32(x), int32(y), 1, 1, gl.RGBA, gl.FLOAT, gl.Ptr(&value[0]))
+	}
+	return value, CheckError()
+}
+
+// Gui shows the viewer in a window, with toolbar controls. Drag to pan and
+// use the mouse wheel to zoom. Call it inside the func of
+// Window.RenderImgui().
+func (v *ImageViewer) Gui(title string) {
+	imgui.SetNextWindowSizeV(imgui.Vec2{X: 512, Y: 512}, imgui.ConditionFirstUseEver)
+	if imgui.Begin(title + "##imageviewer") {
+		v.gui()
+	}
+	imgui.End()
+}
+
+func (v *ImageViewer) gui() {
+	for i, name := range []string{"R", "G", "B", "A"} {
+		imgui.Checkbox(name, &v.Channels[i])
+		imgui.SameLine()
+	}
+	imgui.Checkbox("nearest", &v.Nearest)
+	imgui.SameLine()
+	imgui.Checkbox("flip", &v.FlipY)
+	imgui.SameLine()
+	fit := imgui.Button("fit")
+	imgui.SameLine()
+	if imgui.Button("1:1") {
+		v.Zoom = 1
+	}
+	imgui.SliderFloatV("exposure", &v.Exposure, -10, 10, "%.1f stops", imgui.SliderFlagsNone)
+
+	if v.Texture == nil {
+		imgui.Text("no texture")
+		return
+	}
+	imgui.Text(fmt.Sprintf("%d x %d  zoom %.3gx", v.Texture.Width, v.Texture.Height, v.Zoom))
+	if v.hovered {
+		imgui.SameLine()
+		imgui.Text(fmt.Sprintf("  (%d, %d) = %.4g %.4g %.4g %.4g", v.hoverTexel[0], v.hoverTexel[1],
+			v.hoverValue[0], v.hoverValue[1], v.hoverValue[2], v.hoverValue[3]))
+	}
+
+	avail := imgui.ContentRegionAvail()
+	width, height := avail.X, avail.Y
+	if width < 1 || height < 1 {
+		return
+	}
+	if fit || !v.fitted {
+		v.Fit(width, height)
+		v.fitted = true
+	}
+	if err := v.Render(int(width), int(height)); err != nil {
+		imgui.Text(err.Error())
+		return
+	}
+
+	origin := imgui.CursorScreenPos()
+	imgui.ImageV(imgui.TextureID(v.Output.ColorBuffer.ID), imgui.Vec2{X: width, Y: height},
+		imgui.Vec2{X: 0, Y: 1}, imgui.Vec2{X: 1, Y: 0}, // render targets are upside down
+		imgui.Vec4{X: 1, Y: 1, Z: 1, W: 1}, imgui.Vec4{})
+	// over the image, so dragging pans rather than moving the window
+	imgui.SetCursorScreenPos(origin)
+	imgui.InvisibleButton("##view", imgui.Vec2{X: width, Y: height})
+
+	io := imgui.CurrentIO()
+	if imgui.IsItemActive() {
+		delta := io.MouseDelta()
+		v.Center = v.Center.Sub(mgl32.Vec2{delta.X, delta.Y}.Mul(1 / v.Zoom))
+	}
+	v.hovered = imgui.IsItemHovered()
+	if !v.hovered {
+		return
+	}
+	mouse := imgui.MousePos()
+	x, y := mouse.X-origin.X, mouse.Y-origin.Y
+	if _, wheel := io.MouseWheel(); wheel != 0 {
+		v.ZoomAt(float32(math.Pow(1.25, float64(wheel))), x, y, width, height)
+	}
+
+	texel := v.ToTexel(x, y, width, height)
+	tx, ty := int(math.Floor(float64(texel.X()))), int(math.Floor(float64(texel.Y())))
+	if v.FlipY {
+		ty = int(v.Texture.Height) - 1 - ty
+	}
+	value, err := v.ReadTexel(tx, ty)
+	v.hovered = err == nil
+	v.hoverTexel, v.hoverValue = [2]int{tx, ty}, value
+}
+
+// Delete the viewer's resources. The Texture isn't deleted.
+func (v *ImageViewer) Delete() {
+	if v.Output != nil {
+		v.Output.Delete()
+	}
+	backend.DeleteFramebuffer(v.readFbo)
+}
+
+const imageViewerFragmentShader = `#version 330 core
+in vec2 TexCoords;
+
+uniform sampler2D image;
+uniform vec2 size;      // of the view
+uniform vec2 imageSize;
+uniform vec2 center;    // texel coords at the center of the view
+uniform float zoom;     // pixels per texel
+uniform vec4 channels;  // 1 for each channel shown
+uniform float exposure;
+uniform bool nearest;
+uniform bool flipY;
+
+out vec4 FragColor;
+
+vec4 fetch(ivec2 texel)
+{
+    return texelFetch(image, clamp(texel, ivec2(0), ivec2(imageSize) - 1), 0);
+}
+
+void main()
+{
+    // TexCoords are y down, like texel rows
+    vec2 texel = (TexCoords * size - size * 0.5) / zoom + center;
+    float checker = mod(floor(TexCoords.x * size.x / 8.0) + floor(TexCoords.y * size.y / 8.0), 2.0);
+    vec3 background = vec3(mix(0.4, 0.6, checker));
+    if (any(lessThan(texel, vec2(0.0))) || any(greaterThanEqual(texel, imageSize))) {
+        FragColor = vec4(background * 0.5, 1.0);
+        return;
+    }
+    if (flipY) {
+        texel.y = imageSize.y - texel.y;
+    }
+
+    vec4 color;
+    if (nearest) {
+        color = fetch(ivec2(floor(texel)));
+    } else {
+        // bilinear by hand, so the texture's own filter doesn't matter
+        vec2 p = texel - 0.5;
+        ivec2 i = ivec2(floor(p));
+        vec2 f = fract(p);
+        color = mix(mix(fetch(i), fetch(i + ivec2(1, 0)), f.x),
+                    mix(fetch(i + ivec2(0, 1)), fetch(i + ivec2(1, 1)), f.x), f.y);
+    }
+
+    float shown = dot(channels, vec4(1.0));
+    if (shown == 1.0) {
+        // a single channel in gray
+        float value = dot(color, channels);
+        if (channels.a == 0.0) {
+            value *= exp2(exposure);
+        }
+        FragColor = vec4(vec3(value), 1.0);
+        return;
+    }
+    vec3 rgb = color.rgb * channels.rgb * exp2(exposure);
+    float alpha = channels.a == 1.0 ? clamp(color.a, 0.0, 1.0) : 1.0;
+    FragColor = vec4(mix(background, rgb, alpha), 1.0);
+}`
@@ -0,0 +1,210 @@
+package sgl
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/inkyblackness/imgui-go/v4"
+)
+
+// FileDialogMode is the kind of path chosen with a FileBrowser.
+type FileDialogMode int
+
+const (
+	FileOpen   FileDialogMode = iota // choose an existing file
+	FileSave                         // choose an existing or new file
+	FolderPick                       // choose a directory
+)
+
+// FileBrowser is an imgui file dialog. It doesn't block the render loop:
+// call Draw() each frame (inside the gui func of RenderImgui), and OnSelect
+// is called with the chosen path once the user confirms. Example:
+//
+//	browser := sgl.OpenFileDialog(".", []string{".png", ".jpg"}, func(path string) {
+//		texture = loadTexture(path)
+//	})
+//	...
+//	platform.RenderImgui(func() {
+//		browser.Draw()
+//	})
+type FileBrowser struct {
+	Title      string
+	Mode       FileDialogMode
+	Dir        string   // directory being shown
+	Extensions []string // files listed, eg ".png". Empty lists all files.
+	ShowHidden bool     // list files beginning with "."
+
+	OnSelect func(path string)
+	OnCancel func() // may be nil
+
+	open    bool
+	name    string // file name being chosen
+	dirs    []string
+	files   []string
+	readErr error
+	loaded  string // dir that dirs/files were read from
+}
+
+// OpenFileDialog creates an open FileBrowser for choosing an existing file
+// in dir with one of the extensions (or any file if none are given).
+func OpenFileDialog(dir string, extensions []string, onSelect func(path string)) *FileBrowser {
+	b := &FileBrowser{Title: "Open File", Mode: FileOpen, Dir: dir, Extensions: extensions, OnSelect: onSelect}
+	b.Open()
+	return b
+}
+
+// SaveFileDialog creates an open FileBrowser for choosing a file name to
+// save to, starting in dir with the given name.
+func SaveFileDialog(dir, name string, onSelect func(path string)) *FileBrowser {
+	b := &FileBrowser{Title: "Save File", Mode: FileSave, Dir: dir, OnSelect: onSelect}
+	b.Open()
+	b.name = name
+	return b
+}
+
+// PickFolder creates an open FileBrowser for choosing a directory.
+func PickFolder(dir string, onSelect func(path string)) *FileBrowser {
+	b := &FileBrowser{Title: "Choose Folder", Mode: FolderPick, Dir: dir, OnSelect: onSelect}
+	b.Open()
+	return b
+}
+
+// Open shows the browser.
+func (b *FileBrowser) Open() {
+	if abs, err := filepath.Abs(b.Dir); err == nil {
+		b.Dir = abs
+	}
+	b.open = true
+	b.name = ""
+	b.loaded = ""
+}
+
+// IsOpen returns true if the browser is showing.
+func (b *FileBrowser) IsOpen() bool { return b.open }
+
+// Close hides the browser without choosing anything.
+func (b *FileBrowser) Close() {
+	if b.open && b.OnCancel != nil {
+		b.OnCancel()
+	}
+	b.open = false
+}
+
+// Draw draws the browser if it is open.
+func (b *FileBrowser) Draw() {
+	if !b.open {
+		return
+	}
+	if b.loaded != b.Dir {
+		b.readDir()
+	}
+
+	imgui.SetNextWindowSizeV(imgui.Vec2{X: 500, Y: 400}, imgui.ConditionFirstUseEver)
+	open := true
+	if !imgui.BeginV(b.Title+"##FileBrowser", &open, 0) {
+		imgui.End()
+		return
+	}
+	if !open {
+		imgui.End()
+		b.Close()
+		return
+	}
+
+	if imgui.Button("Up") {
+		b.Dir = filepath.Dir(b.Dir)
+	}
+	imgui.SameLine()
+	imgui.Text(b.Dir)
+
+	footer := imgui.FrameHeightWithSpacing() * 2
+	imgui.BeginChildV("entries", imgui.Vec2{X: 0, Y: -footer}, true, 0)
+	if b.readErr != nil {
+		imgui.Text(b.readErr.Error())
+	}
+	for _, d := range b.dirs {
+		if imgui.Selectable(d + string(filepath.Separator)) {
+			b.Dir = filepath.Join(b.Dir, d)
+		}
+	}
+	if b.Mode != FolderPick {
+		for _, f := range b.files {
+			if imgui.SelectableV(f, f == b.name, imgui.SelectableFlagsAllowDoubleClick, imgui.Vec2{}) {
+				b.name = f
+				if imgui.IsMouseDoubleClicked(0) {
+					b.choose(filepath.Join(b.Dir, f))
+				}
+			}
+		}
+	}
+	imgui.EndChild()
+
+	if b.Mode == FolderPick {
+		if imgui.Button("Choose") {
+			b.choose(b.Dir)
+		}
+	} else {
+		imgui.InputText("Name", &b.name)
+		label := "Open"
+		if b.Mode == FileSave {
+			label = "Save"
+		}
+		if imgui.Button(label) && b.name != "" {
+			b.choose(filepath.Join(b.Dir, b.name))
+		}
+	}
+	imgui.SameLine()
+	if imgui.Button("Cancel") {
+		b.Close()
+	}
+	imgui.End()
+}
+
+func (b *FileBrowser) choose(path string) {
+	if b.Mode == FileOpen {
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			return
+		}
+	}
+	b.open = false
+	if b.OnSelect != nil {
+		b.OnSelect(path)
+	}
+}
+
+// readDir lists the directories and matching files in Dir.
+func (b *FileBrowser) readDir() {
+	b.loaded = b.Dir
+	b.dirs, b.files = b.dirs[:0], b.files[:0]
+
+	entries, err := os.ReadDir(b.Dir)
+	b.readErr = err
+	for _, e := range entries {
+		name := e.Name()
+		if !b.ShowHidden && strings.HasPrefix(name, ".") {
+			continue
+		}
+		if e.IsDir() {
+			b.dirs = append(b.dirs, name)
+		} else if b.matches(name) {
+			b.files = append(b.files, name)
+		}
+	}
+	sort.Strings(b.dirs)
+	sort.Strings(b.files)
+}
+
+func (b *FileBrowser) matches(name string) bool {
+	if len(b.Extensions) == 0 {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range b.Extensions {
+		if strings.ToLower(e) == ext {
+			return true
+		}
+	}
+	return false
+}
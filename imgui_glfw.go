@@ -1,3 +1,5 @@
+//go:build !egl
+
 package sgl
 
 import (
@@ -6,6 +8,8 @@ import (
 	"math"
 	"os"
 	"runtime"
+	"sort"
+	"unicode/utf8"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
@@ -16,6 +20,7 @@ import (
 // deferred call to Destroy.
 func Init() error {
 	runtime.LockOSThread()
+	recordGLThread()
 	err := glfw.Init()
 	if err != nil {
 		return fmt.Errorf("failed to initialize glfw: %w", err)
@@ -78,12 +83,66 @@ type Window struct {
 	// Updated each frame.
 	Clock Timer
 
-	mouseJustPressed [3]bool // for imgui
+	// Amount of smoothing applied to InputState.SmoothX/SmoothY, from
+	// 0 (none) to nearly 1 (heavy).
+	CursorSmoothing float64
+
+	// Input context used by ExecuteChords().
+	Contexts ContextStack
+
+	// Optional frame profiler and watchdog. See UseProfiler().
+	Profiler *Profiler
+
+	// Optional server for scripts and tests. See UseDebugServer().
+	Debug *DebugServer
+
+	// Optional MJPEG stream of the frames drawn. See UseFrameStream().
+	Stream *FrameStream
+
+	// Optional history of the last few frames drawn. See UseFrameHistory().
+	History *FrameHistory
+
+	// Frame rate limit while minimized (and unfocused, if ThrottleUnfocused
+	// is true). 0 disables the limit. See UseIdleThrottle().
+	IdleFPS           float64
+	ThrottleUnfocused bool
+
+	input       InputState  // snapshot for the current frame
+	inputEvents inputEvents // accumulated since the last snapshot
+
+	mouseJustPressed [5]bool // for imgui: left, right, middle, and 2 extra buttons
+	typedChars       []rune  // for imgui: characters input since the last frame
+
+	inFrame   bool             // BeginFrame() called without a matching EndFrame()
+	frameFunc func(dt float64) // see SetFrameFunc()
+
+	eventDriven  bool    // see SetEventDriven()
+	eventTimeout float64 // seconds
+	redrawFrames int32   // frames to draw before waiting again. accessed atomically.
+
+	swapInterval int     // see SetVSync()
+	targetFPS    float64 // see SetTargetFPS()
+	geometryFile string  // see UseRestoreGeometry()
+	hidden       bool    // see UseHidden()
+
+	cursorMode  CursorMode                   // see SetCursorMode()
+	cursors     map[CursorShape]*glfw.Cursor // see SetCursorShape()
+	imageCursor *glfw.Cursor                 // see SetCursorImage()
+
+	capture *frameCapture // see CaptureEveryNthFrame()
+	replay  *inputReplay  // see RecordInput() and ReplayInput()
 
 	keyCallbacks    []glfw.KeyCallback
 	mouseCallbacks  []glfw.MouseButtonCallback
 	scrollCallbacks []glfw.ScrollCallback
 	charCallbacks   []glfw.CharCallback
+	dropCallbacks   []glfw.DropCallback
+
+	focusCallbacks        []glfw.FocusCallback
+	iconifyCallbacks      []glfw.IconifyCallback
+	maximizeCallbacks     []glfw.MaximizeCallback
+	refreshCallbacks      []glfw.RefreshCallback
+	contentScaleCallbacks []glfw.ContentScaleCallback
 }
 
 // FontMap associates a friendly name (key) with info about a font loaded
@@ -111,6 +170,22 @@ type imguiData struct {
 	imguiCtx *imgui.Context
 	renderer *openGL3
 	Fonts    FontMap
+	Themes   map[string]GuiStyle // custom themes usable with ApplyTheme()
+
+	// EncodeSRGB leaves the framebuffer's sRGB encoding (see UseSRGB()) on
+	// while imgui is drawn. imgui's colors are already sRGB, so by default
+	// encoding is turned off for imgui, which would otherwise look washed
+	// out. Set it for styles whose colors have been made linear.
+	EncodeSRGB bool
+
+	theme           string               // name of current theme
+	styleVars       map[string][]float32 // pushed each frame
+	styleVarsPushed int                  // by pushStyleVars(), for popStyleVars()
+	styleFile       string               // for StyleEditor()
+	styleError      error                // for StyleEditor()
+
+	configFlags imgui.ConfigFlags
+	gamepadKeys map[glfw.Key]bool // keys held down by gamepad navigation
 }
 
 // Font returns a font from the FontMap with the given name key.
@@ -142,9 +217,19 @@ type WindowMetric struct {
 // WindowOption sets a option during window creation.
 type WindowOption func(*Window) error
 
-// NewWindow attempts to initialize a GLFW context/window/imgui etc.
-func NewWindow(title string, size WindowMetric, options ...WindowOption) (*Window, error) {
+// NewWindow attempts to initialize a GLFW context/window/imgui etc. Settings
+// are WindowHints, used to create the window, and WindowOptions, called once
+// it's created.
+func NewWindow(title string, size WindowMetric, settings ...WindowSetting) (*Window, error) {
 	var win *Window
+	var hints windowHints
+	for i, setting := range settings {
+		if hint, ok := setting.(WindowHint); ok {
+			if hintErr := hint(&hints); hintErr != nil {
+				return nil, fmt.Errorf("option %d had an error: %w", i, hintErr)
+			}
+		}
+	}
 
 	// i always just use these, so just set them here to simplify window creation
 	if !size.Resizable {
@@ -156,7 +241,9 @@ func NewWindow(title string, size WindowMetric, options ...WindowOption) (*Windo
 	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
 	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
 	// glfw.WindowHint(glfw.Samples, 4)
+	hints.apply()
 	window, err := glfw.CreateWindow(size.W, size.H, title, nil, nil)
+	hints.reset()
 	if err != nil {
 		glfw.Terminate()
 		return nil, fmt.Errorf("failed to create window: %w", err)
@@ -171,7 +258,7 @@ func NewWindow(title string, size WindowMetric, options ...WindowOption) (*Windo
 
 	window.SetPos(size.X, size.Y)
 	defer func() {
-		if window != nil {
+		if window != nil && !win.hidden {
 			if size.Fullscreen {
 				win.Fullscreen(true, 0, 0)
 			}
@@ -180,8 +267,10 @@ func NewWindow(title string, size WindowMetric, options ...WindowOption) (*Windo
 	}()
 
 	win = &Window{
-		GlfwWindow: window,
-		GlVersion:  gl.GoStr(gl.GetString(gl.VERSION)),
+		GlfwWindow:   window,
+		GlVersion:    gl.GoStr(gl.GetString(gl.VERSION)),
+		Clock:        Timer{Scale: 1},
+		swapInterval: 1,
 	}
 
 	// save initial window position and size
@@ -191,8 +280,19 @@ func NewWindow(title string, size WindowMetric, options ...WindowOption) (*Windo
 
 	win.installWindowDimensionsCallbacks()
 	win.installControlCallbacks()
+	win.installLifecycleCallbacks()
+	win.installInputCallbacks()
 
-	for i, option := range options {
+	for _, setup := range hints.setup {
+		if setupErr := setup(win); setupErr != nil {
+			return nil, fmt.Errorf("hint setup had an error: %w", setupErr)
+		}
+	}
+	for i, setting := range settings {
+		option, ok := setting.(WindowOption)
+		if !ok {
+			continue
+		}
 		optErr := option(win)
 		if optErr != nil {
 			return nil, fmt.Errorf("option %d had an error: %w", i, optErr)
@@ -218,11 +318,19 @@ func UseImgui(fonts FontMap) WindowOption {
 		// default font would be added if the fontmap was empty, but this lets
 		// imgui.DefaultFont reference the built-in font rather than the "first"
 		// font added via the fontmap.
-		io.Fonts().AddFontDefault()
+		// on hidpi displays (framebuffer larger than the window) fonts are
+		// rasterized at the framebuffer resolution and scaled back down, so
+		// they're the same apparent size but not blurry.
+		fontScale := win.FramebufferScale()[0]
+		defaultFont := imgui.NewFontConfig()
+		defaultFont.SetSize(13 * fontScale) // imgui's default size
+		io.Fonts().AddFontDefaultV(defaultFont)
+		defaultFont.Delete()
 		for name, font := range fonts {
-			font.Font = io.Fonts().AddFontFromFileTTF(font.Filename, font.Size)
+			font.Font = io.Fonts().AddFontFromFileTTF(font.Filename, font.Size*fontScale)
 			fonts[name] = font
 		}
+		io.SetFontGlobalScale(1 / fontScale)
 
 		// the renderer creates a texture font atlas so fonts have
 		// to be added to the "io" before this call.
@@ -232,15 +340,19 @@ func UseImgui(fonts FontMap) WindowOption {
 		}
 
 		gui := imguiData{
-			IO:       io,
-			imguiCtx: imgctx,
-			renderer: glrenderer,
-			Fonts:    fonts,
+			IO:        io,
+			imguiCtx:  imgctx,
+			renderer:  glrenderer,
+			Fonts:     fonts,
+			Themes:    make(map[string]GuiStyle),
+			theme:     ThemeDark, // imgui's default
+			styleFile: "style.json",
 		}
 
 		win.Gui = &gui
 		win.setImguiKeyMapping()
 		win.installImguiCallbacks()
+		gui.IO.SetClipboard(windowClipboard{win})
 
 		return nil
 	}
@@ -283,19 +395,19 @@ func (platform *Window) MakeContextCurrent() {
 
 // Fullscreen toggles windowed and fullscreen modes. Parameters width and height
 // will set screen resolution only for fullscreen mode, and values of 0 will
-// use the current resolution.
+// use the current resolution. Fullscreen uses the primary monitor; see
+// FullscreenOn() to choose another, and FullscreenRate() to choose a
+// refresh rate.
 func (platform *Window) Fullscreen(full bool, width, height int) (setWidth, setHeight int) {
+	return platform.FullscreenRate(full, width, height, 0)
+}
+
+// FullscreenRate is like Fullscreen(), but also sets the refresh rate (in Hz)
+// for fullscreen mode. 0 will use the highest rate available for the
+// resolution. See VideoModes() for the supported combinations.
+func (platform *Window) FullscreenRate(full bool, width, height, refreshRate int) (setWidth, setHeight int) {
 	if full {
-		m := glfw.GetPrimaryMonitor()
-		if width <= 0 {
-			width = m.GetVideoMode().Width
-		}
-		if height <= 0 {
-			height = m.GetVideoMode().Height
-		}
-		platform.GlfwWindow.SetMonitor(m, 0, 0, width, height, glfw.DontCare)
-		platform.Dimensions.Fullscreen = true
-		return width, height
+		return platform.FullscreenOn(nil, width, height, refreshRate)
 	}
 
 	d := platform.Dimensions
@@ -304,9 +416,136 @@ func (platform *Window) Fullscreen(full bool, width, height int) (setWidth, setH
 	return d.W, d.H
 }
 
+// FullscreenOn makes the window fullscreen on monitor, such as one from
+// Monitors(), or the primary monitor if nil. width, height, and refreshRate
+// are like those of FullscreenRate(). The windowed position and size are kept,
+// so Fullscreen(false, 0, 0) restores the window where it was, even if that's
+// on another monitor.
+func (platform *Window) FullscreenOn(monitor *glfw.Monitor, width, height, refreshRate int) (setWidth, setHeight int) {
+	if monitor == nil {
+		monitor = glfw.GetPrimaryMonitor()
+	}
+	if width <= 0 {
+		width = monitor.GetVideoMode().Width
+	}
+	if height <= 0 {
+		height = monitor.GetVideoMode().Height
+	}
+	if refreshRate <= 0 {
+		refreshRate = glfw.DontCare
+	}
+	// set first, so the position and size callbacks during the switch
+	// don't replace the windowed position and size
+	platform.Dimensions.Fullscreen = true
+	platform.GlfwWindow.SetMonitor(monitor, 0, 0, width, height, refreshRate)
+	return width, height
+}
+
+// MonitorInfo describes a connected monitor.
+type MonitorInfo struct {
+	Monitor *glfw.Monitor
+	Name    string
+	X, Y    int // of the top left, in the virtual desktop, in screen coordinates
+	Primary bool
+	Current *glfw.VidMode   // the video mode in use
+	Modes   []*glfw.VidMode // supported, as from VideoModes()
+}
+
+// Monitors gets the connected monitors, with the primary monitor first.
+func Monitors() []MonitorInfo {
+	primary := glfw.GetPrimaryMonitor()
+	var infos []MonitorInfo
+	for _, m := range glfw.GetMonitors() {
+		x, y := m.GetPos()
+		info := MonitorInfo{
+			Monitor: m,
+			Name:    m.GetName(),
+			X:       x,
+			Y:       y,
+			Primary: m == primary,
+			Current: m.GetVideoMode(),
+			Modes:   m.GetVideoModes(),
+		}
+		if info.Primary {
+			infos = append([]MonitorInfo{info}, infos...)
+		} else {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// CurrentMonitor gets the monitor the window is fullscreen on, or else the
+// one containing the center of the window, or the primary monitor if none
+// do.
+func (platform *Window) CurrentMonitor() *glfw.Monitor {
+	if m := platform.GlfwWindow.GetMonitor(); m != nil {
+		return m
+	}
+	x, y := platform.GlfwWindow.GetPos()
+	w, h := platform.GlfwWindow.GetSize()
+	cx, cy := x+w/2, y+h/2
+	for _, m := range glfw.GetMonitors() {
+		mx, my := m.GetPos()
+		mode := m.GetVideoMode()
+		if cx >= mx && cy >= my && cx < mx+mode.Width && cy < my+mode.Height {
+			return m
+		}
+	}
+	return glfw.GetPrimaryMonitor()
+}
+
+// VideoModes gets the video modes (resolution, color depth, and refresh rate)
+// supported by the monitor, sorted by increasing color depth, resolution, and
+// then refresh rate. A nil monitor means the primary monitor.
+func VideoModes(monitor *glfw.Monitor) []*glfw.VidMode {
+	if monitor == nil {
+		monitor = glfw.GetPrimaryMonitor()
+	}
+	return monitor.GetVideoModes()
+}
+
+// RefreshRates gets the refresh rates (Hz) the monitor supports at the given
+// resolution, in increasing order. A nil monitor means the primary monitor.
+func RefreshRates(monitor *glfw.Monitor, width, height int) []int {
+	var rates []int
+	for _, mode := range VideoModes(monitor) {
+		if mode.Width == width && mode.Height == height {
+			rates = append(rates, mode.RefreshRate)
+		}
+	}
+	// modes with different color depths may share a rate
+	sort.Ints(rates)
+	unique := rates[:0]
+	for i, r := range rates {
+		if i == 0 || r != rates[i-1] {
+			unique = append(unique, r)
+		}
+	}
+	return unique
+}
+
 // Dispose cleans up the resources.
 func (platform *Window) Dispose() {
+	platform.saveGeometry()
+	platform.StopCapture()
+	if platform.Debug != nil {
+		platform.Debug.Close()
+	}
+	if platform.Stream != nil {
+		platform.Stream.Close()
+	}
+	if platform.History != nil {
+		platform.History.Delete()
+	}
+	if platform.Profiler != nil {
+		platform.Profiler.Delete()
+	}
+	if platform.Gui != nil {
+		platform.Gui.IO.SetClipboard(nil)
+	}
 	platform.GlfwWindow.Destroy()
+	platform.destroyCursors()
 	if platform.Gui != nil {
 		platform.Gui.Destroy()
 	}
@@ -317,15 +556,61 @@ func (platform *Window) InitLoop() {
 	platform.Clock.Reset()
 }
 
-// BeginFrame updates certain state for the new frame, and returns true
-// if the render loop should continue running.
+// BeginFrame updates the clock, polls events, and captures input for the
+// new frame, and returns true if the render loop should continue running.
+// Each frame should then be drawn and finished with EndFrame():
+//
+//	platform.InitLoop()
+//	for platform.BeginFrame() {
+//		platform.ClearBuffers()
+//		draw()
+//		platform.RenderImgui(gui)
+//		platform.EndFrame()
+//	}
+//
+// For compatibility with older loops that don't call EndFrame(), BeginFrame
+// swaps the buffers itself if the previous frame was not ended.
 func (platform *Window) BeginFrame() (continueRendering bool) {
+	assertGLThread()
+	if platform.inFrame {
+		platform.EndFrame() // legacy loop without EndFrame()
+	}
+	platform.inFrame = true
+
+	platform.throttle()
+	platform.limitFrameRate()
 	platform.Clock.Update()
-	platform.PollEvents()
-	platform.SwapBuffers()
+	if platform.Profiler != nil {
+		platform.Profiler.EndFrame(platform.Clock.UnscaledDeltaT)
+	}
+	platform.pollEvents()
+	platform.replayInput()
+	platform.captureInput()
 	return !platform.ShouldClose()
 }
 
+// EndFrame finishes the frame begun by BeginFrame() by swapping buffers.
+// The frame drawn can be captured with BackBufferCapture() before calling
+// EndFrame(), or with ScreenCapture() after.
+func (platform *Window) EndFrame() {
+	assertGLThread()
+	platform.keepHistory()
+	platform.captureFrame()
+	if platform.Debug != nil {
+		platform.Debug.Serve(platform)
+	}
+	if platform.Stream != nil && platform.Stream.Wants() {
+		platform.Stream.Publish(platform.BackBufferCapture())
+	}
+	PollAsyncReads()
+	endStatsFrame()
+	if platform.History != nil {
+		platform.History.setStats(platform.Clock.TotalFrames, Stats())
+	}
+	platform.SwapBuffers()
+	platform.inFrame = false
+}
+
 // ShouldClose returns true if the window is to be closed.
 func (platform *Window) ShouldClose() bool {
 	return platform.GlfwWindow.ShouldClose()
@@ -352,20 +637,43 @@ func (platform *Window) CanUseGui() bool { return platform.Gui != nil }
 // RenderImgui will perform the beginning and ending steps of rendering
 // the imgui constructed by calls to the imgui pkg in the 'gui' function.
 func (platform *Window) RenderImgui(gui func()) {
+	assertGLThread()
+	platform.keepHistory() // without the gui
+
 	// start 'frame'
 	platform.forwardStateToImgui()
 	imgui.NewFrame()
+	// GuiViewport() callbacks of the last frame, which Render() doesn't clear
+	// if it returns early, such as while minimized
+	platform.Gui.renderer.callbacks = platform.Gui.renderer.callbacks[:0]
+	platform.Gui.pushStyleVars()
 
 	gui()
 
 	// end 'frame'
+	platform.Gui.popStyleVars()
 	imgui.Render()
 
 	// render gui
 	drawdata := imgui.RenderedDrawData()
+	platform.Gui.renderer.encodeSRGB = platform.Gui.EncodeSRGB
 	platform.Gui.renderer.Render(platform.DisplaySize(), platform.FramebufferSize(), drawdata)
 }
 
+// keepHistory keeps the back buffer in History, if there is one.
+func (platform *Window) keepHistory() {
+	if platform.History == nil {
+		return
+	}
+	var readFbo int32
+	gl.GetIntegerv(gl.READ_FRAMEBUFFER_BINDING, &readFbo)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	gl.ReadBuffer(gl.BACK)
+	w, h := platform.GlfwWindow.GetFramebufferSize()
+	platform.History.Keep(&platform.Clock, w, h)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, uint32(readFbo))
+}
+
 // Aspect returns aspect ratio.
 func (platform *Window) Aspect() float32 {
 	size := platform.DisplaySize()
@@ -384,30 +692,38 @@ func (platform *Window) FramebufferSize() [2]float32 {
 	return [2]float32{float32(w), float32(h)}
 }
 
+// FramebufferScale returns the ratio of framebuffer size to window size,
+// which is greater than 1 on hidpi ("retina") displays.
+func (platform *Window) FramebufferScale() [2]float32 {
+	display, fb := platform.DisplaySize(), platform.FramebufferSize()
+	if display[0] <= 0 || display[1] <= 0 {
+		return [2]float32{1, 1} // minimized
+	}
+	return [2]float32{fb[0] / display[0], fb[1] / display[1]}
+}
+
 // ScreenCapture saves a copy of the opengl front buffer and saves it into
-// an image.Image.
+// an image.Image. This is the most recently finished frame.
 func (platform *Window) ScreenCapture() image.Image {
-	w, h := platform.GlfwWindow.GetFramebufferSize()
+	return captureBuffer(gl.FRONT, platform.GlfwWindow)
+}
+
+// BackBufferCapture saves a copy of the opengl back buffer into an
+// image.Image. Call it before EndFrame() to capture the frame being drawn.
+func (platform *Window) BackBufferCapture() image.Image {
+	return captureBuffer(gl.BACK, platform.GlfwWindow)
+}
+
+func captureBuffer(buffer uint32, win *glfw.Window) *image.RGBA {
+	w, h := win.GetFramebufferSize()
 	rgba := image.NewRGBA(image.Rect(0, 0, w, h))
-	gl.ReadBuffer(gl.FRONT)
+	gl.ReadBuffer(buffer)
 	gl.ReadPixels(0, 0, int32(w), int32(h), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
 
-	flipVertically(rgba)
+	FlipVertical(rgba)
 	return rgba
 }
 
-// flip image vertically
-func flipVertically(img *image.RGBA) {
-	temp := make([]byte, img.Stride)
-	for y := 0; y < img.Bounds().Dy()/2; y++ {
-		top := img.Pix[y*img.Stride : (y+1)*img.Stride]
-		bottom := img.Pix[(img.Bounds().Dy()-1-y)*img.Stride : (img.Bounds().Dy()-y)*img.Stride]
-		copy(temp, top)
-		copy(top, bottom)
-		copy(bottom, temp)
-	}
-}
-
 // ClipboardText returns the current clipboard text, if available.
 func (platform *Window) ClipboardText() string {
 	return platform.GlfwWindow.GetClipboardString()
@@ -418,6 +734,17 @@ func (platform *Window) SetClipboardText(text string) {
 	platform.GlfwWindow.SetClipboardString(text)
 }
 
+// windowClipboard lets imgui copy and paste with the system clipboard.
+type windowClipboard struct {
+	platform *Window
+}
+
+func (c windowClipboard) Text() (string, error) { return c.platform.ClipboardText(), nil }
+
+func (c windowClipboard) SetText(text string) { c.platform.SetClipboardText(text) }
+
+// AddKeyCallback adds a function to be called when a key is pressed,
+// repeated, or released.
 func (platform *Window) AddKeyCallback(callback glfw.KeyCallback) {
 	platform.keyCallbacks = append(platform.keyCallbacks, callback)
 }
@@ -426,6 +753,8 @@ func (platform *Window) AddKeyCallback(callback glfw.KeyCallback) {
 // 	delete(platform.keyCallbacks, callback)
 // }
 
+// AddMouseButtonCallback adds a function to be called when any mouse
+// button (including extra buttons 4 through 8) is pressed or released.
 func (platform *Window) AddMouseButtonCallback(callback glfw.MouseButtonCallback) {
 	platform.mouseCallbacks = append(platform.mouseCallbacks, callback)
 }
@@ -434,6 +763,8 @@ func (platform *Window) AddMouseButtonCallback(callback glfw.MouseButtonCallback
 // 	delete(platform.mouseCallbacks, callback)
 // }
 
+// AddScrollCallback adds a function to be called when the mouse wheel or
+// touchpad is scrolled. The x offset is horizontal scrolling.
 func (platform *Window) AddScrollCallback(callback glfw.ScrollCallback) {
 	platform.scrollCallbacks = append(platform.scrollCallbacks, callback)
 }
@@ -442,6 +773,7 @@ func (platform *Window) AddScrollCallback(callback glfw.ScrollCallback) {
 // 	delete(platform.scrollCallbacks, callback)
 // }
 
+// AddCharCallback adds a function to be called when a unicode character is input.
 func (platform *Window) AddCharCallback(callback glfw.CharCallback) {
 	platform.charCallbacks = append(platform.charCallbacks, callback)
 }
@@ -450,6 +782,64 @@ func (platform *Window) AddCharCallback(callback glfw.CharCallback) {
 // 	delete(platform.charCallbacks, callback)
 // }
 
+// AddDropCallback adds a function to be called with the paths of files
+// dropped onto the window.
+func (platform *Window) AddDropCallback(callback glfw.DropCallback) {
+	platform.dropCallbacks = append(platform.dropCallbacks, callback)
+}
+
+// AddImageDropCallback adds a function to be called with the files dropped
+// onto the window, decoded with OpenImages(). For each name, either the
+// image or the error is not nil, so files which aren't images can be
+// ignored or reported. Files are decoded before the callback, while events
+// are processed, so dropping large images pauses the window briefly.
+func (platform *Window) AddImageDropCallback(callback func(names []string, images []*image.RGBA, errs []error)) {
+	platform.AddDropCallback(func(w *glfw.Window, names []string) {
+		images := make([]*image.RGBA, len(names))
+		errs := make([]error, len(names))
+		for i, name := range names {
+			decoded, err := OpenImages(name)
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			images[i] = decoded[0]
+		}
+		callback(names, images, errs)
+	})
+}
+
+// AddFocusCallback adds a function to be called when the window gains or
+// loses input focus.
+func (platform *Window) AddFocusCallback(callback glfw.FocusCallback) {
+	platform.focusCallbacks = append(platform.focusCallbacks, callback)
+}
+
+// AddIconifyCallback adds a function to be called when the window is
+// minimized (iconified) or restored.
+func (platform *Window) AddIconifyCallback(callback glfw.IconifyCallback) {
+	platform.iconifyCallbacks = append(platform.iconifyCallbacks, callback)
+}
+
+// AddMaximizeCallback adds a function to be called when the window is
+// maximized or restored.
+func (platform *Window) AddMaximizeCallback(callback glfw.MaximizeCallback) {
+	platform.maximizeCallbacks = append(platform.maximizeCallbacks, callback)
+}
+
+// AddRefreshCallback adds a function to be called when the window's
+// contents need to be drawn again, such as after being uncovered.
+func (platform *Window) AddRefreshCallback(callback glfw.RefreshCallback) {
+	platform.refreshCallbacks = append(platform.refreshCallbacks, callback)
+}
+
+// AddContentScaleCallback adds a function to be called when the window's
+// content scale changes, such as when moved to a monitor with a different
+// DPI.
+func (platform *Window) AddContentScaleCallback(callback glfw.ContentScaleCallback) {
+	platform.contentScaleCallbacks = append(platform.contentScaleCallbacks, callback)
+}
+
 // installWindowDimensionsCallbacks set various window/frame size callbacks
 func (platform *Window) installWindowDimensionsCallbacks() {
 	platform.GlfwWindow.SetPosCallback(func(w *glfw.Window, xpos, ypos int) {
@@ -471,28 +861,85 @@ func (platform *Window) installWindowDimensionsCallbacks() {
 
 func (platform *Window) installControlCallbacks() {
 	platform.GlfwWindow.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		if platform.ignoreInput() {
+			return
+		}
+		platform.record(InputEvent{Kind: MouseButtonEvent, Button: button, Action: action, Mods: mods})
 		for _, cb := range platform.mouseCallbacks {
 			cb(w, button, action, mods)
 		}
 	})
 
 	platform.GlfwWindow.SetScrollCallback(func(w *glfw.Window, xoff, yoff float64) {
+		if platform.ignoreInput() {
+			return
+		}
+		platform.record(InputEvent{Kind: ScrollEvent, X: xoff, Y: yoff})
 		for _, cb := range platform.scrollCallbacks {
 			cb(w, xoff, yoff)
 		}
 	})
 
 	platform.GlfwWindow.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if platform.ignoreInput() {
+			return
+		}
+		platform.record(InputEvent{Kind: KeyEvent, Key: key, Scancode: scancode, Action: action, Mods: mods})
 		for _, cb := range platform.keyCallbacks {
 			cb(w, key, scancode, action, mods)
 		}
 	})
 
 	platform.GlfwWindow.SetCharCallback(func(w *glfw.Window, char rune) {
+		if platform.ignoreInput() {
+			return
+		}
+		platform.record(InputEvent{Kind: CharEvent, Char: char})
 		for _, cb := range platform.charCallbacks {
 			cb(w, char)
 		}
 	})
+
+	platform.GlfwWindow.SetDropCallback(func(w *glfw.Window, names []string) {
+		for _, cb := range platform.dropCallbacks {
+			cb(w, names)
+		}
+	})
+}
+
+// installLifecycleCallbacks sets the glfw callbacks that fan out to the
+// focus, iconify, maximize, refresh, and content scale callbacks.
+func (platform *Window) installLifecycleCallbacks() {
+	platform.GlfwWindow.SetFocusCallback(func(w *glfw.Window, focused bool) {
+		for _, cb := range platform.focusCallbacks {
+			cb(w, focused)
+		}
+	})
+
+	platform.GlfwWindow.SetIconifyCallback(func(w *glfw.Window, iconified bool) {
+		for _, cb := range platform.iconifyCallbacks {
+			cb(w, iconified)
+		}
+	})
+
+	platform.GlfwWindow.SetMaximizeCallback(func(w *glfw.Window, maximized bool) {
+		for _, cb := range platform.maximizeCallbacks {
+			cb(w, maximized)
+		}
+	})
+
+	platform.GlfwWindow.SetRefreshCallback(func(w *glfw.Window) {
+		platform.Invalidate() // so event driven windows draw again
+		for _, cb := range platform.refreshCallbacks {
+			cb(w)
+		}
+	})
+
+	platform.GlfwWindow.SetContentScaleCallback(func(w *glfw.Window, x, y float32) {
+		for _, cb := range platform.contentScaleCallbacks {
+			cb(w, x, y)
+		}
+	})
 }
 
 ///////////////////////////////
@@ -514,23 +961,35 @@ func (platform *Window) forwardStateToImgui() {
 	// Setup display size (every frame to accommodate for window resizing)
 	displaySize := platform.DisplaySize()
 	platform.Gui.IO.SetDisplaySize(imgui.Vec2{X: displaySize[0], Y: displaySize[1]})
+	fbScale := platform.FramebufferScale()
+	platform.Gui.IO.SetDisplayFrameBufferScale(imgui.Vec2{X: fbScale[0], Y: fbScale[1]})
 
 	// Setup time step
-	platform.Gui.IO.SetDeltaTime(float32(platform.Clock.DeltaT))
+	platform.Gui.IO.SetDeltaTime(float32(platform.Clock.UnscaledDeltaT))
 
 	// Setup inputs
-	if platform.GlfwWindow.GetAttrib(glfw.Focused) != 0 {
-		x, y := platform.GlfwWindow.GetCursorPos()
+	if platform.GlfwWindow.GetAttrib(glfw.Focused) != 0 && platform.cursorMode != CursorDisabled {
+		x, y := platform.cursorPos()
 		platform.Gui.IO.SetMousePosition(imgui.Vec2{X: float32(x), Y: float32(y)})
 	} else {
 		platform.Gui.IO.SetMousePosition(imgui.Vec2{X: -math.MaxFloat32, Y: -math.MaxFloat32})
 	}
 
 	for i := 0; i < len(platform.mouseJustPressed); i++ {
-		down := platform.mouseJustPressed[i] || (platform.GlfwWindow.GetMouseButton(glfwButtonIDByIndex[i]) == glfw.Press)
+		down := platform.mouseJustPressed[i] || platform.mouseButtonDown(glfwButtonIDByIndex[i])
 		platform.Gui.IO.SetMouseButtonDown(i, down)
 		platform.mouseJustPressed[i] = false
 	}
+
+	// all characters typed since the last frame at once. Pasted text isn't
+	// typed; imgui reads it from the clipboard (see windowClipboard) when
+	// the paste shortcut is pressed.
+	if len(platform.typedChars) > 0 {
+		platform.Gui.IO.AddInputCharacters(string(platform.typedChars))
+		platform.typedChars = platform.typedChars[:0]
+	}
+
+	platform.Gui.forwardGamepadToImgui()
 }
 
 func (platform *Window) setImguiKeyMapping() {
@@ -563,18 +1022,23 @@ func (platform *Window) installImguiCallbacks() {
 	platform.AddScrollCallback(platform.guiMouseScrollChange)
 	platform.AddKeyCallback(platform.guiKeyChange)
 	platform.AddCharCallback(platform.guiCharChange)
+	platform.AddFocusCallback(platform.guiFocusChange)
 }
 
 var glfwButtonIndexByID = map[glfw.MouseButton]int{
 	glfw.MouseButton1: 0,
 	glfw.MouseButton2: 1,
 	glfw.MouseButton3: 2,
+	glfw.MouseButton4: 3,
+	glfw.MouseButton5: 4,
 }
 
 var glfwButtonIDByIndex = map[int]glfw.MouseButton{
 	0: glfw.MouseButton1,
 	1: glfw.MouseButton2,
 	2: glfw.MouseButton3,
+	3: glfw.MouseButton4,
+	4: glfw.MouseButton5,
 }
 
 func (platform *Window) guiMouseButtonChange(window *glfw.Window, rawButton glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
@@ -604,6 +1068,22 @@ func (platform *Window) guiKeyChange(window *glfw.Window, key glfw.Key, scancode
 	platform.Gui.IO.KeySuper(int(glfw.KeyLeftSuper), int(glfw.KeyRightSuper))
 }
 
+func (platform *Window) guiFocusChange(window *glfw.Window, focused bool) {
+	if focused {
+		return
+	}
+	// keys released while unfocused aren't reported, so release them now
+	ev := &platform.inputEvents
+	for key := range ev.keysDown {
+		platform.Gui.IO.KeyRelease(int(key))
+		delete(ev.keysDown, key)
+		ev.keysReleased[key] = true
+	}
+}
+
 func (platform *Window) guiCharChange(window *glfw.Window, char rune) {
-	platform.Gui.IO.AddInputCharacters(string(char))
+	// forwarded in forwardStateToImgui()
+	if utf8.ValidRune(char) {
+		platform.typedChars = append(platform.typedChars, char)
+	}
 }
@@ -0,0 +1,107 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/inkyblackness/imgui-go/v4"
+)
+
+// SetConfigFlags sets imgui's config flags, such as
+// imgui.ConfigFlagsNavEnableKeyboard.
+func (gui *imguiData) SetConfigFlags(flags imgui.ConfigFlags) {
+	gui.configFlags = flags
+	gui.IO.SetConfigFlags(flags)
+}
+
+// ConfigFlags gets the flags last set with SetConfigFlags() or the Enable*
+// methods.
+func (gui *imguiData) ConfigFlags() imgui.ConfigFlags { return gui.configFlags }
+
+// EnableKeyboardNav lets the gui be navigated with the keyboard: arrow keys
+// to move, space to activate, escape to cancel, and ctrl+tab to switch windows.
+func (gui *imguiData) EnableKeyboardNav() {
+	gui.SetConfigFlags(gui.configFlags | imgui.ConfigFlagsNavEnableKeyboard)
+}
+
+// DisableKeyboardNav undoes EnableKeyboardNav().
+func (gui *imguiData) DisableKeyboardNav() {
+	gui.SetConfigFlags(gui.configFlags &^ imgui.ConfigFlagsNavEnableKeyboard)
+}
+
+// EnableGamepadNav lets the gui be navigated with the first connected gamepad:
+// d-pad or left stick to move, A to activate, and B to cancel.
+// Gamepad input is translated to the keys used by keyboard navigation, so
+// keyboard navigation is enabled as well.
+func (gui *imguiData) EnableGamepadNav() {
+	gui.SetConfigFlags(gui.configFlags | imgui.ConfigFlagsNavEnableGamepad | imgui.ConfigFlagsNavEnableKeyboard)
+}
+
+// DisableGamepadNav undoes EnableGamepadNav(), but leaves keyboard navigation enabled.
+func (gui *imguiData) DisableGamepadNav() {
+	gui.SetConfigFlags(gui.configFlags &^ imgui.ConfigFlagsNavEnableGamepad)
+	gui.releaseGamepadKeys()
+}
+
+// dead zone of gamepad sticks for navigation.
+const gamepadNavDeadZone = 0.5
+
+// forwardGamepadToImgui feeds the state of the first gamepad to imgui as the
+// keys used for keyboard navigation. (imgui-go doesn't expose io.NavInputs.)
+func (gui *imguiData) forwardGamepadToImgui() {
+	if gui.configFlags&imgui.ConfigFlagsNavEnableGamepad == 0 {
+		return
+	}
+
+	var state *glfw.GamepadState
+	for joy := glfw.Joystick1; joy <= glfw.JoystickLast; joy++ {
+		if joy.IsGamepad() {
+			state = joy.GetGamepadState()
+			break
+		}
+	}
+	flags := gui.IO.GetBackendFlags()
+	if state == nil {
+		gui.IO.SetBackendFlags(flags &^ imgui.BackendFlagsHasGamepad)
+		gui.releaseGamepadKeys()
+		return
+	}
+	gui.IO.SetBackendFlags(flags | imgui.BackendFlagsHasGamepad)
+
+	button := func(b glfw.GamepadButton) bool { return state.Buttons[b] == glfw.Press }
+	x, y := state.Axes[glfw.AxisLeftX], state.Axes[glfw.AxisLeftY]
+	keys := []struct {
+		key  glfw.Key
+		down bool
+	}{
+		{glfw.KeyUp, button(glfw.ButtonDpadUp) || y < -gamepadNavDeadZone},
+		{glfw.KeyDown, button(glfw.ButtonDpadDown) || y > gamepadNavDeadZone},
+		{glfw.KeyLeft, button(glfw.ButtonDpadLeft) || x < -gamepadNavDeadZone},
+		{glfw.KeyRight, button(glfw.ButtonDpadRight) || x > gamepadNavDeadZone},
+		{glfw.KeySpace, button(glfw.ButtonA)},
+		{glfw.KeyEscape, button(glfw.ButtonB)},
+	}
+
+	if gui.gamepadKeys == nil {
+		gui.gamepadKeys = make(map[glfw.Key]bool)
+	}
+	for _, k := range keys {
+		key, isDown := k.key, k.down
+		if isDown && !gui.gamepadKeys[key] {
+			gui.IO.KeyPress(int(key))
+		} else if !isDown && gui.gamepadKeys[key] {
+			gui.IO.KeyRelease(int(key))
+		}
+		gui.gamepadKeys[key] = isDown
+	}
+}
+
+// releaseGamepadKeys releases any keys being held down by the gamepad.
+func (gui *imguiData) releaseGamepadKeys() {
+	for key, isDown := range gui.gamepadKeys {
+		if isDown {
+			gui.IO.KeyRelease(int(key))
+		}
+		delete(gui.gamepadKeys, key)
+	}
+}
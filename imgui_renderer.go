@@ -21,6 +21,31 @@ type openGL3 struct {
 	attribLocationColor    int32
 	vboHandle              uint32
 	elementsHandle         uint32
+
+	encodeSRGB bool // see imguiData.EncodeSRGB
+
+	callbacks []guiRenderCallback // added by Window.GuiViewport() this frame
+}
+
+// guiRenderCallback draws into a rectangle of an imgui window.
+type guiRenderCallback struct {
+	draw     func(width, height int32)
+	min, max imgui.Vec2 // display coordinates
+}
+
+// callbackTextureID gets the texture id marking callback i in a draw list.
+// They count down from the largest id, so never clash with opengl textures.
+func callbackTextureID(i int) imgui.TextureID {
+	return imgui.TextureID(^uintptr(0) - uintptr(i))
+}
+
+// callback gets the callback marked by id, if it is one.
+func (renderer *openGL3) callback(id imgui.TextureID) (guiRenderCallback, bool) {
+	i := int(^uintptr(0) - uintptr(id))
+	if i < 0 || i >= len(renderer.callbacks) {
+		return guiRenderCallback{}, false
+	}
+	return renderer.callbacks[i], true
 }
 
 // newOpenGL3 attempts to initialize a renderer.
@@ -41,6 +66,23 @@ func (renderer *openGL3) Dispose() {
 	renderer.invalidateDeviceObjects()
 }
 
+// callCallback calls cb with the viewport set to its rectangle and the
+// scissor set to clip, which is in framebuffer pixels. scaleX and scaleY
+// convert display coordinates to framebuffer pixels.
+func (renderer *openGL3) callCallback(cb guiRenderCallback, clip imgui.Vec4, scaleX, scaleY, fbHeight float32) {
+	x, y := int32(cb.min.X*scaleX), int32(fbHeight-cb.max.Y*scaleY)
+	w, h := int32((cb.max.X-cb.min.X)*scaleX), int32((cb.max.Y-cb.min.Y)*scaleY)
+	if w <= 0 || h <= 0 {
+		return
+	}
+	gl.Viewport(x, y, w, h)
+	gl.Enable(gl.SCISSOR_TEST)
+	gl.Scissor(int32(clip.X), int32(fbHeight)-int32(clip.W), int32(clip.Z-clip.X), int32(clip.W-clip.Y))
+	gl.Disable(gl.BLEND)
+	gl.BindVertexArray(0)
+	cb.draw(w, h)
+}
+
 // PreRender clears the framebuffer.
 func (renderer *openGL3) PreRender(clearColor [3]float32) {
 	gl.ClearColor(clearColor[0], clearColor[1], clearColor[2], 1.0)
@@ -49,6 +91,7 @@ func (renderer *openGL3) PreRender(clearColor [3]float32) {
 
 // Render translates the ImGui draw data to OpenGL3 commands.
 func (renderer *openGL3) Render(displaySize [2]float32, framebufferSize [2]float32, drawData imgui.DrawData) {
+	assertGLThread()
 	// Avoid rendering when minimized, scale coordinates for retina displays (screen coordinates != framebuffer coordinates)
 	displayWidth, displayHeight := displaySize[0], displaySize[1]
 	fbWidth, fbHeight := framebufferSize[0], framebufferSize[1]
@@ -98,37 +141,44 @@ func (renderer *openGL3) Render(displaySize [2]float32, framebufferSize [2]float
 	lastEnableCullFace := gl.IsEnabled(gl.CULL_FACE)
 	lastEnableDepthTest := gl.IsEnabled(gl.DEPTH_TEST)
 	lastEnableScissorTest := gl.IsEnabled(gl.SCISSOR_TEST)
-
-	// Setup render state: alpha-blending enabled, no face culling, no depth testing, scissor enabled, polygon fill
-	gl.Enable(gl.BLEND)
-	gl.BlendEquation(gl.FUNC_ADD)
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
-	gl.Disable(gl.CULL_FACE)
-	gl.Disable(gl.DEPTH_TEST)
-	gl.Enable(gl.SCISSOR_TEST)
-	gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
-
-	// Setup viewport, orthographic projection matrix
-	// Our visible imgui space lies from draw_data->DisplayPos (top left) to draw_data->DisplayPos+data_data->DisplaySize (bottom right).
-	// DisplayMin is typically (0,0) for single viewport apps.
-	gl.Viewport(0, 0, int32(fbWidth), int32(fbHeight))
-	orthoProjection := [4][4]float32{
-		{2.0 / displayWidth, 0.0, 0.0, 0.0},
-		{0.0, 2.0 / -displayHeight, 0.0, 0.0},
-		{0.0, 0.0, -1.0, 0.0},
-		{-1.0, 1.0, 0.0, 1.0},
-	}
-	gl.UseProgram(renderer.shaderHandle)
-	gl.Uniform1i(renderer.attribLocationTex, 0)
-	gl.UniformMatrix4fv(renderer.attribLocationProjMtx, 1, false, &orthoProjection[0][0])
-	gl.BindSampler(0, 0) // Rely on combined texture/sampler state.
+	lastEnableFramebufferSRGB := gl.IsEnabled(gl.FRAMEBUFFER_SRGB)
 
 	// Recreate the VAO every time
 	// (This is to easily allow multiple GL contexts. VAO are not shared among GL contexts, and
 	// we don't track creation/deletion of windows so we don't have an obvious key to use to cache them.)
 	var vaoHandle uint32
 	gl.GenVertexArrays(1, &vaoHandle)
-	gl.BindVertexArray(vaoHandle)
+	setupRenderState := func() {
+		// Setup render state: alpha-blending enabled, no face culling, no depth testing, scissor enabled, polygon fill
+		gl.Enable(gl.BLEND)
+		gl.BlendEquation(gl.FUNC_ADD)
+		gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+		gl.Disable(gl.CULL_FACE)
+		gl.Disable(gl.DEPTH_TEST)
+		gl.Enable(gl.SCISSOR_TEST)
+		gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
+		if !renderer.encodeSRGB {
+			// imgui's colors are already sRGB, so don't encode them again
+			gl.Disable(gl.FRAMEBUFFER_SRGB)
+		}
+
+		// Setup viewport, orthographic projection matrix
+		// Our visible imgui space lies from draw_data->DisplayPos (top left) to draw_data->DisplayPos+data_data->DisplaySize (bottom right).
+		// DisplayMin is typically (0,0) for single viewport apps.
+		gl.Viewport(0, 0, int32(fbWidth), int32(fbHeight))
+		orthoProjection := [4][4]float32{
+			{2.0 / displayWidth, 0.0, 0.0, 0.0},
+			{0.0, 2.0 / -displayHeight, 0.0, 0.0},
+			{0.0, 0.0, -1.0, 0.0},
+			{-1.0, 1.0, 0.0, 1.0},
+		}
+		gl.UseProgram(renderer.shaderHandle)
+		gl.Uniform1i(renderer.attribLocationTex, 0)
+		gl.UniformMatrix4fv(renderer.attribLocationProjMtx, 1, false, &orthoProjection[0][0])
+		gl.BindSampler(0, 0) // Rely on combined texture/sampler state.
+		gl.BindVertexArray(vaoHandle)
+	}
+	setupRenderState()
 	gl.BindBuffer(gl.ARRAY_BUFFER, renderer.vboHandle)
 	gl.EnableVertexAttribArray(uint32(renderer.attribLocationPosition))
 	gl.EnableVertexAttribArray(uint32(renderer.attribLocationUV))
@@ -156,7 +206,12 @@ func (renderer *openGL3) Render(displaySize [2]float32, framebufferSize [2]float
 		gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, indexBufferSize, indexBuffer, gl.STREAM_DRAW)
 
 		for _, cmd := range list.Commands() {
-			if cmd.HasUserCallback() {
+			if cb, ok := renderer.callback(cmd.TextureID()); ok {
+				renderer.callCallback(cb, cmd.ClipRect(), fbWidth/displayWidth, fbHeight/displayHeight, fbHeight)
+				setupRenderState()
+				gl.BindBuffer(gl.ARRAY_BUFFER, renderer.vboHandle)
+				gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, renderer.elementsHandle)
+			} else if cmd.HasUserCallback() {
 				cmd.CallUserCallback(list)
 			} else {
 				gl.BindTexture(gl.TEXTURE_2D, uint32(cmd.TextureID()))
@@ -168,6 +223,7 @@ func (renderer *openGL3) Render(displaySize [2]float32, framebufferSize [2]float
 		}
 	}
 	gl.DeleteVertexArrays(1, &vaoHandle)
+	renderer.callbacks = renderer.callbacks[:0]
 
 	// Restore modified GL state
 	gl.UseProgram(uint32(lastProgram))
@@ -199,6 +255,11 @@ func (renderer *openGL3) Render(displaySize [2]float32, framebufferSize [2]float
 	} else {
 		gl.Disable(gl.SCISSOR_TEST)
 	}
+	if lastEnableFramebufferSRGB {
+		gl.Enable(gl.FRAMEBUFFER_SRGB)
+	} else {
+		gl.Disable(gl.FRAMEBUFFER_SRGB)
+	}
 	gl.PolygonMode(gl.FRONT_AND_BACK, uint32(lastPolygonMode[0]))
 	gl.Viewport(lastViewport[0], lastViewport[1], lastViewport[2], lastViewport[3])
 	gl.Scissor(lastScissorBox[0], lastScissorBox[1], lastScissorBox[2], lastScissorBox[3])
@@ -299,6 +360,7 @@ func (renderer *openGL3) createFontsTexture() {
 	gl.PixelStorei(gl.UNPACK_ROW_LENGTH, 0)
 	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RED, int32(image.Width), int32(image.Height),
 		0, gl.RED, gl.UNSIGNED_BYTE, image.Pixels)
+	trackTexture(renderer.fontTexture, image.Width*image.Height)
 
 	// Store our identifier
 	io.Fonts().SetTextureID(imgui.TextureID(renderer.fontTexture))
@@ -339,6 +401,7 @@ func (renderer *openGL3) invalidateDeviceObjects() {
 	renderer.shaderHandle = 0
 
 	if renderer.fontTexture != 0 {
+		trackTexture(renderer.fontTexture, 0)
 		gl.DeleteTextures(1, &renderer.fontTexture)
 		imgui.CurrentIO().Fonts().SetTextureID(0)
 		renderer.fontTexture = 0
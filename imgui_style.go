@@ -0,0 +1,328 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/inkyblackness/imgui-go/v4"
+)
+
+// GuiStyle is a serializable imgui style. Colors are keyed by the names in
+// styleColorNames (eg "WindowBg"), and Vars by the names in styleVarNames
+// (eg "FrameRounding") with either 1 or 2 values.
+type GuiStyle struct {
+	Colors map[string][4]float32 `json:"colors"`
+	Vars   map[string][]float32  `json:"vars,omitempty"`
+}
+
+// Names of the builtin themes usable with ApplyTheme().
+const (
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeClassic      = "classic"
+	ThemeHighContrast = "high-contrast"
+)
+
+// ThemeNames gets the builtin theme names followed by the names of any
+// custom themes, sorted. interface{} is used for easy use in a Selecter.
+func (gui *imguiData) ThemeNames() []interface{} {
+	names := []interface{}{ThemeDark, ThemeLight, ThemeClassic, ThemeHighContrast}
+	custom := make([]string, 0, len(gui.Themes))
+	for name := range gui.Themes {
+		custom = append(custom, name)
+	}
+	sort.Strings(custom)
+	for _, name := range custom {
+		names = append(names, name)
+	}
+	return names
+}
+
+// ApplyTheme sets the imgui style to one of the builtin themes or a theme
+// from gui.Themes. Style vars from a previous theme are cleared.
+func (gui *imguiData) ApplyTheme(name string) error {
+	gui.styleVars = nil
+	switch name {
+	case ThemeDark:
+		imgui.StyleColorsDark()
+	case ThemeLight:
+		imgui.StyleColorsLight()
+	case ThemeClassic:
+		imgui.StyleColorsClassic()
+	case ThemeHighContrast:
+		imgui.StyleColorsDark()
+		gui.ApplyStyle(highContrastStyle)
+	default:
+		style, ok := gui.Themes[name]
+		if !ok {
+			return fmt.Errorf("no theme named %q", name)
+		}
+		gui.ApplyStyle(style)
+	}
+	gui.theme = name
+	return nil
+}
+
+// Theme gets the name of the theme last set with ApplyTheme().
+func (gui *imguiData) Theme() string { return gui.theme }
+
+// ApplyStyle sets the colors in style, and replaces the current style vars
+// with those in style. Unknown names are ignored.
+func (gui *imguiData) ApplyStyle(style GuiStyle) {
+	current := imgui.CurrentStyle()
+	for name, c := range style.Colors {
+		if id, ok := styleColorIDs[name]; ok {
+			current.SetColor(id, imgui.Vec4{X: c[0], Y: c[1], Z: c[2], W: c[3]})
+		}
+	}
+	gui.styleVars = make(map[string][]float32, len(style.Vars))
+	for name, v := range style.Vars {
+		if _, ok := styleVarIDs[name]; ok {
+			gui.styleVars[name] = append([]float32(nil), v...)
+		}
+	}
+}
+
+// Style gets the current colors and style vars.
+func (gui *imguiData) Style() GuiStyle {
+	current := imgui.CurrentStyle()
+	style := GuiStyle{
+		Colors: make(map[string][4]float32, len(styleColorNames)),
+		Vars:   make(map[string][]float32, len(gui.styleVars)),
+	}
+	for id, name := range styleColorNames {
+		c := current.Color(imgui.StyleColorID(id))
+		style.Colors[name] = [4]float32{c.X, c.Y, c.Z, c.W}
+	}
+	for name, v := range gui.styleVars {
+		style.Vars[name] = append([]float32(nil), v...)
+	}
+	return style
+}
+
+// SetStyleVar sets a style var by name (eg "FrameRounding") which is applied
+// each frame. Vars that are a Vec2 take 2 values, others 1.
+func (gui *imguiData) SetStyleVar(name string, values ...float32) error {
+	v, ok := styleVarIDs[name]
+	if !ok {
+		return fmt.Errorf("no style var named %q", name)
+	}
+	if len(values) != v.size {
+		return fmt.Errorf("style var %q needs %d values, got %d", name, v.size, len(values))
+	}
+	if gui.styleVars == nil {
+		gui.styleVars = make(map[string][]float32)
+	}
+	gui.styleVars[name] = values
+	return nil
+}
+
+// SaveStyle writes the current style to a JSON file.
+func (gui *imguiData) SaveStyle(filename string) error {
+	data, err := json.MarshalIndent(gui.Style(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode style: %w", err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("could not save style: %w", err)
+	}
+	return nil
+}
+
+// LoadStyle reads a style from a JSON file and applies it.
+func (gui *imguiData) LoadStyle(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("could not load style: %w", err)
+	}
+	var style GuiStyle
+	if err := json.Unmarshal(data, &style); err != nil {
+		return fmt.Errorf("could not decode style %s: %w", filename, err)
+	}
+	gui.ApplyStyle(style)
+	return nil
+}
+
+// pushStyleVars applies the style vars for a frame. Must be matched by popStyleVars().
+func (gui *imguiData) pushStyleVars() {
+	gui.styleVarsPushed = len(gui.styleVars)
+	for name, v := range gui.styleVars {
+		id := styleVarIDs[name].id
+		if len(v) == 2 {
+			imgui.PushStyleVarVec2(id, imgui.Vec2{X: v[0], Y: v[1]})
+		} else {
+			imgui.PushStyleVarFloat(id, v[0])
+		}
+	}
+}
+
+// popStyleVars pops the style vars pushed by pushStyleVars(), which may
+// differ from those set now if they were changed during the frame (eg by
+// StyleEditor()).
+func (gui *imguiData) popStyleVars() {
+	if gui.styleVarsPushed > 0 {
+		imgui.PopStyleVarV(gui.styleVarsPushed)
+	}
+	gui.styleVarsPushed = 0
+}
+
+// StyleEditor shows a window for choosing a theme, editing colors and style
+// vars, and saving or loading the style. Call it within the 'gui' func
+// passed to Window.RenderImgui(). The window has a close button if open is
+// non-nil.
+func (gui *imguiData) StyleEditor(open *bool) {
+	if !imgui.BeginV("Style Editor", open, 0) {
+		imgui.End()
+		return
+	}
+
+	if imgui.BeginCombo("Theme", gui.theme) {
+		for _, name := range gui.ThemeNames() {
+			if imgui.SelectableV(name.(string), name == gui.theme, 0, imgui.Vec2{}) {
+				gui.ApplyTheme(name.(string))
+			}
+		}
+		imgui.EndCombo()
+	}
+
+	imgui.InputText("File", &gui.styleFile)
+	if imgui.Button("Save") {
+		gui.styleError = gui.SaveStyle(gui.styleFile)
+	}
+	imgui.SameLine()
+	if imgui.Button("Load") {
+		gui.styleError = gui.LoadStyle(gui.styleFile)
+	}
+	if gui.styleError != nil {
+		imgui.Text(gui.styleError.Error())
+	}
+	imgui.Separator()
+
+	if imgui.TreeNode("Vars") {
+		names := make([]string, 0, len(gui.styleVars))
+		for name := range gui.styleVars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			v := gui.styleVars[name]
+			for i := range v {
+				imgui.DragFloat(fmt.Sprintf("%s[%d]", name, i), &v[i])
+			}
+		}
+		imgui.TreePop()
+	}
+
+	if imgui.TreeNode("Colors") {
+		current := imgui.CurrentStyle()
+		for id, name := range styleColorNames {
+			c := current.Color(imgui.StyleColorID(id))
+			col := [4]float32{c.X, c.Y, c.Z, c.W}
+			if imgui.ColorEdit4(name, &col) {
+				current.SetColor(imgui.StyleColorID(id), imgui.Vec4{X: col[0], Y: col[1], Z: col[2], W: col[3]})
+			}
+		}
+		imgui.TreePop()
+	}
+
+	imgui.End()
+}
+
+// names of style colors, indexed by imgui.StyleColorID.
+var styleColorNames = []string{
+	"Text", "TextDisabled", "WindowBg", "ChildBg", "PopupBg", "Border",
+	"BorderShadow", "FrameBg", "FrameBgHovered", "FrameBgActive", "TitleBg",
+	"TitleBgActive", "TitleBgCollapsed", "MenuBarBg", "ScrollbarBg",
+	"ScrollbarGrab", "ScrollbarGrabHovered", "ScrollbarGrabActive", "CheckMark",
+	"SliderGrab", "SliderGrabActive", "Button", "ButtonHovered", "ButtonActive",
+	"Header", "HeaderHovered", "HeaderActive", "Separator", "SeparatorHovered",
+	"SeparatorActive", "ResizeGrip", "ResizeGripHovered", "ResizeGripActive",
+	"Tab", "TabHovered", "TabActive", "TabUnfocused", "TabUnfocusedActive",
+	"PlotLines", "PlotLinesHovered", "PlotHistogram", "PlotHistogramHovered",
+	"TableHeaderBg", "TableBorderStrong", "TableBorderLight", "TableRowBg",
+	"TableRowBgAlt", "TextSelectedBg", "DragDropTarget", "NavHighlight",
+	"NavWindowingHighlight", "NavWindowingDarkening", "ModalWindowDarkening",
+}
+
+type styleVar struct {
+	id   imgui.StyleVarID
+	size int // 1 for float, 2 for Vec2
+}
+
+// style vars by name.
+var styleVarIDs = map[string]styleVar{
+	"Alpha":               {imgui.StyleVarAlpha, 1},
+	"DisabledAlpha":       {imgui.StyleVarDisabledAlpha, 1},
+	"WindowPadding":       {imgui.StyleVarWindowPadding, 2},
+	"WindowRounding":      {imgui.StyleVarWindowRounding, 1},
+	"WindowBorderSize":    {imgui.StyleVarWindowBorderSize, 1},
+	"WindowMinSize":       {imgui.StyleVarWindowMinSize, 2},
+	"WindowTitleAlign":    {imgui.StyleVarWindowTitleAlign, 2},
+	"ChildRounding":       {imgui.StyleVarChildRounding, 1},
+	"ChildBorderSize":     {imgui.StyleVarChildBorderSize, 1},
+	"PopupRounding":       {imgui.StyleVarPopupRounding, 1},
+	"PopupBorderSize":     {imgui.StyleVarPopupBorderSize, 1},
+	"FramePadding":        {imgui.StyleVarFramePadding, 2},
+	"FrameRounding":       {imgui.StyleVarFrameRounding, 1},
+	"FrameBorderSize":     {imgui.StyleVarFrameBorderSize, 1},
+	"ItemSpacing":         {imgui.StyleVarItemSpacing, 2},
+	"ItemInnerSpacing":    {imgui.StyleVarItemInnerSpacing, 2},
+	"IndentSpacing":       {imgui.StyleVarIndentSpacing, 1},
+	"CellPadding":         {imgui.StyleVarCellPadding, 2},
+	"ScrollbarSize":       {imgui.StyleVarScrollbarSize, 1},
+	"ScrollbarRounding":   {imgui.StyleVarScrollbarRounding, 1},
+	"GrabMinSize":         {imgui.StyleVarGrabMinSize, 1},
+	"GrabRounding":        {imgui.StyleVarGrabRounding, 1},
+	"TabRounding":         {imgui.StyleVarTabRounding, 1},
+	"ButtonTextAlign":     {imgui.StyleVarButtonTextAlign, 2},
+	"SelectableTextAlign": {imgui.StyleVarSelectableTextAlign, 2},
+}
+
+// style colors by name, built from styleColorNames.
+var styleColorIDs = map[string]imgui.StyleColorID{}
+
+func init() {
+	for id, name := range styleColorNames {
+		styleColorIDs[name] = imgui.StyleColorID(id)
+	}
+}
+
+// changes to the dark theme for the high contrast theme.
+var highContrastStyle = GuiStyle{
+	Colors: map[string][4]float32{
+		"Text":                 {1, 1, 1, 1},
+		"TextDisabled":         {0.75, 0.75, 0.75, 1},
+		"WindowBg":             {0, 0, 0, 1},
+		"ChildBg":              {0, 0, 0, 1},
+		"PopupBg":              {0, 0, 0, 1},
+		"Border":               {1, 1, 1, 1},
+		"FrameBg":              {0, 0, 0, 1},
+		"FrameBgHovered":       {0.2, 0.2, 0.6, 1},
+		"FrameBgActive":        {0.3, 0.3, 0.9, 1},
+		"TitleBg":              {0, 0, 0, 1},
+		"TitleBgActive":        {0.1, 0.1, 0.5, 1},
+		"Button":               {0, 0, 0, 1},
+		"ButtonHovered":        {0.2, 0.2, 0.6, 1},
+		"ButtonActive":         {0.3, 0.3, 0.9, 1},
+		"Header":               {0.1, 0.1, 0.5, 1},
+		"HeaderHovered":        {0.2, 0.2, 0.6, 1},
+		"HeaderActive":         {0.3, 0.3, 0.9, 1},
+		"CheckMark":            {1, 1, 0, 1},
+		"SliderGrab":           {1, 1, 0, 1},
+		"SliderGrabActive":     {1, 1, 1, 1},
+		"TextSelectedBg":       {1, 1, 0, 0.5},
+		"NavHighlight":         {1, 1, 0, 1},
+		"PlotLines":            {1, 1, 0, 1},
+		"PlotHistogram":        {1, 1, 0, 1},
+		"ScrollbarGrab":        {0.75, 0.75, 0.75, 1},
+		"ScrollbarGrabHovered": {1, 1, 1, 1},
+	},
+	Vars: map[string][]float32{
+		"WindowBorderSize": {1},
+		"FrameBorderSize":  {1},
+	},
+}
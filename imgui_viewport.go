@@ -0,0 +1,43 @@
+//go:build !egl
+
+package sgl
+
+import "github.com/inkyblackness/imgui-go/v4"
+
+// GuiViewport reserves a region of the current imgui window, size in display
+// coordinates or the rest of the window if size is zero, and has draw()
+// called while imgui is rendered to draw a scene directly into it, such as
+// for an editor's 3D view, without rendering to an Fbo first.
+//
+// draw() is called with the viewport set to the region and the scissor set
+// to the part of it visible in the window, so clearing, such as with
+// Window.ClearBuffers(), only clears the region. width and height are the
+// region's size in framebuffer pixels, for the projection's aspect ratio.
+// Blending and depth testing are off and no vao is bound when draw() is
+// called, and imgui's opengl state is set again afterwards.
+//
+// It returns true if the mouse is over the region.
+func (platform *Window) GuiViewport(id string, size imgui.Vec2, draw func(width, height int32)) (hovered bool) {
+	if size.X <= 0 || size.Y <= 0 {
+		avail := imgui.ContentRegionAvail()
+		if size.X <= 0 {
+			size.X = avail.X
+		}
+		if size.Y <= 0 {
+			size.Y = avail.Y
+		}
+	}
+	if size.X <= 0 || size.Y <= 0 {
+		return false
+	}
+
+	renderer := platform.Gui.renderer
+	min := imgui.CursorScreenPos()
+	max := min.Plus(size)
+	textureID := callbackTextureID(len(renderer.callbacks))
+	renderer.callbacks = append(renderer.callbacks, guiRenderCallback{draw: draw, min: min, max: max})
+	imgui.WindowDrawList().AddImage(textureID, min, max)
+
+	imgui.InvisibleButton(id, size)
+	return imgui.IsItemHovered()
+}
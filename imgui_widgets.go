@@ -0,0 +1,131 @@
+package sgl
+
+import (
+	"strings"
+
+	"github.com/inkyblackness/imgui-go/v4"
+)
+
+// HistoryInput is a single line imgui text input that remembers entered lines,
+// which can be recalled with the up and down arrow keys, like a console.
+type HistoryInput struct {
+	Label      string
+	Hint       string   // shown when the input is empty
+	Text       string   // current contents
+	History    []string // oldest first
+	MaxHistory int      // 0 for no limit
+
+	back      int  // while browsing, how far back in History. 0 when not browsing.
+	keepFocus bool // give focus back after Enter
+}
+
+// Draw draws the input box. When Enter is pressed, the line is added to the
+// history, the input is cleared, and the line is returned with entered true.
+func (h *HistoryInput) Draw() (line string, entered bool) {
+	if h.keepFocus {
+		imgui.SetKeyboardFocusHere()
+		h.keepFocus = false
+	}
+
+	flags := imgui.InputTextFlagsEnterReturnsTrue | imgui.InputTextFlagsCallbackHistory
+	if !imgui.InputTextWithHintV(h.Label, h.Hint, &h.Text, flags, h.browse) {
+		return "", false
+	}
+
+	line = h.Text
+	h.Text = ""
+	h.back = 0
+	h.keepFocus = true
+	if strings.TrimSpace(line) != "" && (len(h.History) == 0 || h.History[len(h.History)-1] != line) {
+		h.History = append(h.History, line)
+		if h.MaxHistory > 0 && len(h.History) > h.MaxHistory {
+			h.History = h.History[len(h.History)-h.MaxHistory:]
+		}
+	}
+	return line, true
+}
+
+// browse is the input callback handling up/down arrows.
+func (h *HistoryInput) browse(data imgui.InputTextCallbackData) int32 {
+	prev := h.back
+	switch data.EventKey() {
+	case imgui.KeyUpArrow:
+		if h.back < len(h.History) {
+			h.back++
+		}
+	case imgui.KeyDownArrow:
+		if h.back > 0 {
+			h.back--
+		}
+	}
+	if h.back == prev {
+		return 0
+	}
+
+	var text string // empty line after the newest entry
+	if h.back > 0 {
+		text = h.History[len(h.History)-h.back]
+	}
+	data.DeleteBytes(0, len(data.Buffer()))
+	data.InsertBytes(0, []byte(text))
+	return 0
+}
+
+// SearchList draws a list box of the Selecter's names with a search box above
+// it. Only names containing the search text (case insensitive) are listed.
+// The search text is kept in filter. Returns true if the selection changed.
+func SearchList(label string, filter *string, s *Selecter, height float32) (changed bool) {
+	imgui.PushID(label)
+	defer imgui.PopID()
+
+	imgui.InputTextWithHint("##filter", "search", filter)
+	search := strings.ToLower(*filter)
+
+	if imgui.BeginListBoxV(label, imgui.Vec2{X: 0, Y: height}) {
+		for i, name := range s.Names {
+			if search != "" && !strings.Contains(strings.ToLower(name), search) {
+				continue
+			}
+			imgui.PushIDInt(i) // names aren't necessarily unique
+			if imgui.SelectableV(name, s.Selected(i), 0, imgui.Vec2{}) && !s.Selected(i) {
+				s.Set(i)
+				changed = true
+			}
+			imgui.PopID()
+		}
+		imgui.EndListBox()
+	}
+	return changed
+}
+
+// ToggleGroup draws the Selecter's names as a row of buttons, with the
+// selected one highlighted. Returns true if the selection changed.
+func ToggleGroup(label string, s *Selecter) (changed bool) {
+	imgui.PushID(label)
+	defer imgui.PopID()
+
+	active := imgui.CurrentStyle().Color(imgui.StyleColorButtonActive)
+	for i, name := range s.Names {
+		if i > 0 {
+			imgui.SameLine()
+		}
+		selected := s.Selected(i)
+		if selected {
+			imgui.PushStyleColor(imgui.StyleColorButton, active)
+		}
+		imgui.PushIDInt(i)
+		if imgui.Button(name) && !selected {
+			s.Set(i)
+			changed = true
+		}
+		imgui.PopID()
+		if selected {
+			imgui.PopStyleColor()
+		}
+	}
+	if label != "" && !strings.HasPrefix(label, "##") {
+		imgui.SameLine()
+		imgui.Text(label)
+	}
+	return changed
+}
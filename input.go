@@ -0,0 +1,180 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"math"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl64"
+)
+
+// InputState is a snapshot of the keyboard and mouse taken once per frame
+// in Window.BeginFrame(). Polling it gives consistent state for the whole
+// frame, unlike mixing callbacks and calls to GetKey().
+type InputState struct {
+	MouseX, MouseY   float64 // cursor position in screen coordinates
+	MouseDX, MouseDY float64 // cursor movement since the previous frame
+	SmoothX, SmoothY float64 // cursor position smoothed by Window.CursorSmoothing
+	ScrollX, ScrollY float64 // total scroll offset received during the previous frame
+
+	keysDown        map[glfw.Key]bool
+	keysPressed     map[glfw.Key]bool
+	keysReleased    map[glfw.Key]bool
+	buttonsDown     map[glfw.MouseButton]bool
+	buttonsPressed  map[glfw.MouseButton]bool
+	buttonsReleased map[glfw.MouseButton]bool
+
+	started bool // false until the first snapshot is taken
+}
+
+// KeyDown returns true if key is being held down.
+func (in *InputState) KeyDown(key glfw.Key) bool { return in.keysDown[key] }
+
+// KeyPressed returns true if key was pressed since the previous frame.
+func (in *InputState) KeyPressed(key glfw.Key) bool { return in.keysPressed[key] }
+
+// KeyReleased returns true if key was released since the previous frame.
+func (in *InputState) KeyReleased(key glfw.Key) bool { return in.keysReleased[key] }
+
+// ButtonDown returns true if the mouse button is being held down.
+func (in *InputState) ButtonDown(button glfw.MouseButton) bool { return in.buttonsDown[button] }
+
+// ButtonPressed returns true if the mouse button was pressed since the previous frame.
+func (in *InputState) ButtonPressed(button glfw.MouseButton) bool { return in.buttonsPressed[button] }
+
+// ButtonReleased returns true if the mouse button was released since the previous frame.
+func (in *InputState) ButtonReleased(button glfw.MouseButton) bool { return in.buttonsReleased[button] }
+
+// KeysDown gets all keys currently held down, in no particular order.
+func (in *InputState) KeysDown() []glfw.Key {
+	keys := make([]glfw.Key, 0, len(in.keysDown))
+	for k := range in.keysDown {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// inputEvents accumulates input from the window callbacks between snapshots.
+type inputEvents struct {
+	keysDown        map[glfw.Key]bool
+	keysPressed     map[glfw.Key]bool
+	keysReleased    map[glfw.Key]bool
+	buttonsDown     map[glfw.MouseButton]bool
+	buttonsPressed  map[glfw.MouseButton]bool
+	buttonsReleased map[glfw.MouseButton]bool
+	scrollX         float64
+	scrollY         float64
+}
+
+func newInputEvents() inputEvents {
+	return inputEvents{
+		keysDown:        make(map[glfw.Key]bool),
+		keysPressed:     make(map[glfw.Key]bool),
+		keysReleased:    make(map[glfw.Key]bool),
+		buttonsDown:     make(map[glfw.MouseButton]bool),
+		buttonsPressed:  make(map[glfw.MouseButton]bool),
+		buttonsReleased: make(map[glfw.MouseButton]bool),
+	}
+}
+
+// Input gets the input snapshot for the current frame. The returned pointer
+// remains valid, but its contents change during each BeginFrame().
+func (platform *Window) Input() *InputState {
+	return &platform.input
+}
+
+// installInputCallbacks sets the callbacks that feed the per-frame InputState.
+func (platform *Window) installInputCallbacks() {
+	platform.inputEvents = newInputEvents()
+	platform.input = InputState{
+		keysDown:        make(map[glfw.Key]bool),
+		keysPressed:     make(map[glfw.Key]bool),
+		keysReleased:    make(map[glfw.Key]bool),
+		buttonsDown:     make(map[glfw.MouseButton]bool),
+		buttonsPressed:  make(map[glfw.MouseButton]bool),
+		buttonsReleased: make(map[glfw.MouseButton]bool),
+	}
+
+	ev := &platform.inputEvents
+	platform.AddKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		switch action {
+		case glfw.Press:
+			ev.keysDown[key] = true
+			ev.keysPressed[key] = true
+		case glfw.Release:
+			delete(ev.keysDown, key)
+			ev.keysReleased[key] = true
+		}
+	})
+	platform.AddMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		switch action {
+		case glfw.Press:
+			ev.buttonsDown[button] = true
+			ev.buttonsPressed[button] = true
+		case glfw.Release:
+			delete(ev.buttonsDown, button)
+			ev.buttonsReleased[button] = true
+		}
+	})
+	platform.AddScrollCallback(func(w *glfw.Window, xoff, yoff float64) {
+		ev.scrollX += xoff
+		ev.scrollY += yoff
+	})
+}
+
+// captureInput takes the snapshot of input for the current frame. It must be
+// called after events are polled.
+func (platform *Window) captureInput() {
+	in, ev := &platform.input, &platform.inputEvents
+
+	// pressed/released sets are swapped so neither map is reallocated each frame
+	in.keysPressed, ev.keysPressed = ev.keysPressed, in.keysPressed
+	in.keysReleased, ev.keysReleased = ev.keysReleased, in.keysReleased
+	in.buttonsPressed, ev.buttonsPressed = ev.buttonsPressed, in.buttonsPressed
+	in.buttonsReleased, ev.buttonsReleased = ev.buttonsReleased, in.buttonsReleased
+	clearKeys(ev.keysPressed)
+	clearKeys(ev.keysReleased)
+	clearButtons(ev.buttonsPressed)
+	clearButtons(ev.buttonsReleased)
+
+	clearKeys(in.keysDown)
+	for k := range ev.keysDown {
+		in.keysDown[k] = true
+	}
+	clearButtons(in.buttonsDown)
+	for b := range ev.buttonsDown {
+		in.buttonsDown[b] = true
+	}
+
+	in.ScrollX, in.ScrollY = ev.scrollX, ev.scrollY
+	ev.scrollX, ev.scrollY = 0, 0
+
+	x, y := platform.cursorPos()
+	if !in.started {
+		in.MouseX, in.MouseY = x, y
+		in.SmoothX, in.SmoothY = x, y
+		in.started = true
+	}
+	in.MouseDX, in.MouseDY = x-in.MouseX, y-in.MouseY
+	in.MouseX, in.MouseY = x, y
+
+	// exponential smoothing, scaled so the result doesn't depend on framerate.
+	// CursorSmoothing is the fraction of the distance that remains after 1/60th second.
+	s := mgl64.Clamp(platform.CursorSmoothing, 0, 0.999)
+	k := 1 - math.Pow(s, platform.Clock.UnscaledDeltaT*60)
+	in.SmoothX += (x - in.SmoothX) * k
+	in.SmoothY += (y - in.SmoothY) * k
+}
+
+func clearKeys(m map[glfw.Key]bool) {
+	for k := range m {
+		delete(m, k)
+	}
+}
+
+func clearButtons(m map[glfw.MouseButton]bool) {
+	for b := range m {
+		delete(m, b)
+	}
+}
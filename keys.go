@@ -0,0 +1,267 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// KeyName gets a human-readable name for the key. Printable keys are named
+// according to the current keyboard layout (via glfw.GetKeyName), so the result
+// is suitable for display but not for saving. If the key is glfw.KeyUnknown,
+// the scancode is used instead. Other keys use the same names as Chord.String().
+func KeyName(key glfw.Key, scancode int) string {
+	if name := glfw.GetKeyName(key, scancode); name != "" {
+		return strings.ToUpper(name)
+	}
+	if name, ok := keyNames[key]; ok {
+		return name
+	}
+	if key == glfw.KeyUnknown {
+		return fmt.Sprintf("Scancode%d", scancode)
+	}
+	return fmt.Sprintf("Key%d", key)
+}
+
+// MouseButtonName gets a human-readable name for the mouse button.
+func MouseButtonName(button glfw.MouseButton) string {
+	if name, ok := mouseButtonNames[button]; ok {
+		return name
+	}
+	return fmt.Sprintf("Mouse%d", button+1)
+}
+
+// String gets the chord in the form "Ctrl+Shift+S". Modifier keys are listed
+// first, followed by other keys and then mouse buttons. Names are independent
+// of keyboard layout so the result can be read back with ParseChord().
+func (c Chord) String() string {
+	parts := make([]string, 0, len(c.Keys)+len(c.Mouse))
+	for _, mod := range modifierOrder {
+		for _, k := range c.Keys {
+			if k == mod {
+				parts = append(parts, keyNames[k])
+			}
+		}
+	}
+	for _, k := range c.Keys {
+		if isModifier(k) {
+			continue
+		}
+		if name, ok := keyNames[k]; ok {
+			parts = append(parts, name)
+		} else {
+			parts = append(parts, fmt.Sprintf("Key%d", k))
+		}
+	}
+	for _, b := range c.Mouse {
+		parts = append(parts, MouseButtonName(b))
+	}
+	return strings.Join(parts, "+")
+}
+
+// ParseChord makes a Chord from a string such as "Ctrl+Shift+S" or
+// "Alt+MouseLeft". Names are case insensitive. Only the Keys and Mouse
+// fields of the returned Chord are set.
+func ParseChord(s string) (Chord, error) {
+	var c Chord
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return c, fmt.Errorf("empty chord")
+	}
+
+	for _, part := range strings.Split(s, "+") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if key, ok := keysByName[name]; ok {
+			c.Keys = append(c.Keys, key)
+			continue
+		}
+		if button, ok := mouseButtonsByName[name]; ok {
+			c.Mouse = append(c.Mouse, button)
+			continue
+		}
+		var n int
+		if _, err := fmt.Sscanf(name, "key%d", &n); err == nil {
+			c.Keys = append(c.Keys, glfw.Key(n))
+			continue
+		}
+		return Chord{}, fmt.Errorf("unknown key or button %q in chord %q", part, s)
+	}
+	return c, nil
+}
+
+// modifier keys in the order they are listed by Chord.String().
+var modifierOrder = []glfw.Key{
+	glfw.KeyLeftControl, glfw.KeyRightControl,
+	glfw.KeyLeftShift, glfw.KeyRightShift,
+	glfw.KeyLeftAlt, glfw.KeyRightAlt,
+	glfw.KeyLeftSuper, glfw.KeyRightSuper,
+}
+
+func isModifier(key glfw.Key) bool {
+	for _, mod := range modifierOrder {
+		if key == mod {
+			return true
+		}
+	}
+	return false
+}
+
+// layout independent names of keys, as used in Chord.String().
+var keyNames = map[glfw.Key]string{
+	glfw.KeySpace:        "Space",
+	glfw.KeyApostrophe:   "'",
+	glfw.KeyComma:        ",",
+	glfw.KeyMinus:        "-",
+	glfw.KeyPeriod:       ".",
+	glfw.KeySlash:        "/",
+	glfw.Key0:            "0",
+	glfw.Key1:            "1",
+	glfw.Key2:            "2",
+	glfw.Key3:            "3",
+	glfw.Key4:            "4",
+	glfw.Key5:            "5",
+	glfw.Key6:            "6",
+	glfw.Key7:            "7",
+	glfw.Key8:            "8",
+	glfw.Key9:            "9",
+	glfw.KeySemicolon:    ";",
+	glfw.KeyEqual:        "=",
+	glfw.KeyA:            "A",
+	glfw.KeyB:            "B",
+	glfw.KeyC:            "C",
+	glfw.KeyD:            "D",
+	glfw.KeyE:            "E",
+	glfw.KeyF:            "F",
+	glfw.KeyG:            "G",
+	glfw.KeyH:            "H",
+	glfw.KeyI:            "I",
+	glfw.KeyJ:            "J",
+	glfw.KeyK:            "K",
+	glfw.KeyL:            "L",
+	glfw.KeyM:            "M",
+	glfw.KeyN:            "N",
+	glfw.KeyO:            "O",
+	glfw.KeyP:            "P",
+	glfw.KeyQ:            "Q",
+	glfw.KeyR:            "R",
+	glfw.KeyS:            "S",
+	glfw.KeyT:            "T",
+	glfw.KeyU:            "U",
+	glfw.KeyV:            "V",
+	glfw.KeyW:            "W",
+	glfw.KeyX:            "X",
+	glfw.KeyY:            "Y",
+	glfw.KeyZ:            "Z",
+	glfw.KeyLeftBracket:  "[",
+	glfw.KeyBackslash:    "\\",
+	glfw.KeyRightBracket: "]",
+	glfw.KeyGraveAccent:  "`",
+	glfw.KeyWorld1:       "World1",
+	glfw.KeyWorld2:       "World2",
+	glfw.KeyEscape:       "Escape",
+	glfw.KeyEnter:        "Enter",
+	glfw.KeyTab:          "Tab",
+	glfw.KeyBackspace:    "Backspace",
+	glfw.KeyInsert:       "Insert",
+	glfw.KeyDelete:       "Delete",
+	glfw.KeyRight:        "Right",
+	glfw.KeyLeft:         "Left",
+	glfw.KeyDown:         "Down",
+	glfw.KeyUp:           "Up",
+	glfw.KeyPageUp:       "PageUp",
+	glfw.KeyPageDown:     "PageDown",
+	glfw.KeyHome:         "Home",
+	glfw.KeyEnd:          "End",
+	glfw.KeyCapsLock:     "CapsLock",
+	glfw.KeyScrollLock:   "ScrollLock",
+	glfw.KeyNumLock:      "NumLock",
+	glfw.KeyPrintScreen:  "PrintScreen",
+	glfw.KeyPause:        "Pause",
+	glfw.KeyF1:           "F1",
+	glfw.KeyF2:           "F2",
+	glfw.KeyF3:           "F3",
+	glfw.KeyF4:           "F4",
+	glfw.KeyF5:           "F5",
+	glfw.KeyF6:           "F6",
+	glfw.KeyF7:           "F7",
+	glfw.KeyF8:           "F8",
+	glfw.KeyF9:           "F9",
+	glfw.KeyF10:          "F10",
+	glfw.KeyF11:          "F11",
+	glfw.KeyF12:          "F12",
+	glfw.KeyF13:          "F13",
+	glfw.KeyF14:          "F14",
+	glfw.KeyF15:          "F15",
+	glfw.KeyF16:          "F16",
+	glfw.KeyF17:          "F17",
+	glfw.KeyF18:          "F18",
+	glfw.KeyF19:          "F19",
+	glfw.KeyF20:          "F20",
+	glfw.KeyF21:          "F21",
+	glfw.KeyF22:          "F22",
+	glfw.KeyF23:          "F23",
+	glfw.KeyF24:          "F24",
+	glfw.KeyF25:          "F25",
+	glfw.KeyKP0:          "KP0",
+	glfw.KeyKP1:          "KP1",
+	glfw.KeyKP2:          "KP2",
+	glfw.KeyKP3:          "KP3",
+	glfw.KeyKP4:          "KP4",
+	glfw.KeyKP5:          "KP5",
+	glfw.KeyKP6:          "KP6",
+	glfw.KeyKP7:          "KP7",
+	glfw.KeyKP8:          "KP8",
+	glfw.KeyKP9:          "KP9",
+	glfw.KeyKPDecimal:    "KPDecimal",
+	glfw.KeyKPDivide:     "KPDivide",
+	glfw.KeyKPMultiply:   "KPMultiply",
+	glfw.KeyKPSubtract:   "KPSubtract",
+	glfw.KeyKPAdd:        "KPAdd",
+	glfw.KeyKPEnter:      "KPEnter",
+	glfw.KeyKPEqual:      "KPEqual",
+	glfw.KeyLeftShift:    "Shift",
+	glfw.KeyLeftControl:  "Ctrl",
+	glfw.KeyLeftAlt:      "Alt",
+	glfw.KeyLeftSuper:    "Super",
+	glfw.KeyRightShift:   "RightShift",
+	glfw.KeyRightControl: "RightCtrl",
+	glfw.KeyRightAlt:     "RightAlt",
+	glfw.KeyRightSuper:   "RightSuper",
+	glfw.KeyMenu:         "Menu",
+}
+
+var mouseButtonNames = map[glfw.MouseButton]string{
+	glfw.MouseButtonLeft:   "MouseLeft",
+	glfw.MouseButtonRight:  "MouseRight",
+	glfw.MouseButtonMiddle: "MouseMiddle",
+}
+
+// lookup tables for ParseChord(), keyed by lowercase name.
+var keysByName = map[string]glfw.Key{}
+var mouseButtonsByName = map[string]glfw.MouseButton{}
+
+func init() {
+	for k, name := range keyNames {
+		keysByName[strings.ToLower(name)] = k
+	}
+	// a few common aliases
+	keysByName["control"] = glfw.KeyLeftControl
+	keysByName["leftctrl"] = glfw.KeyLeftControl
+	keysByName["leftshift"] = glfw.KeyLeftShift
+	keysByName["leftalt"] = glfw.KeyLeftAlt
+	keysByName["leftsuper"] = glfw.KeyLeftSuper
+	keysByName["esc"] = glfw.KeyEscape
+	keysByName["return"] = glfw.KeyEnter
+	keysByName["del"] = glfw.KeyDelete
+	keysByName["ins"] = glfw.KeyInsert
+
+	for b, name := range mouseButtonNames {
+		mouseButtonsByName[strings.ToLower(name)] = b
+	}
+	for b := glfw.MouseButton1; b <= glfw.MouseButtonLast; b++ {
+		mouseButtonsByName[fmt.Sprintf("mouse%d", b+1)] = b
+	}
+}
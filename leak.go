@@ -0,0 +1,109 @@
+package sgl
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// leak checking state. finalizers run on their own goroutine, so it's
+// guarded by leakMu.
+var (
+	leakMu       sync.Mutex
+	leakChecking bool
+	liveObjects  = map[leakKey]string{} // creation site, by object
+	leakReport   func(kind string, id uint32, site string)
+)
+
+// leakKey identifies a gl object. ids are unique per kind of object.
+type leakKey struct {
+	kind string
+	id   uint32
+}
+
+// SetLeakCheck turns leak checking on or off for Buffers, Texture2Ds,
+// Programs, and Fbos created afterwards. While on, objects garbage collected
+// without Delete() being called are reported to report (or logged if report
+// is nil) with where they were created. The gl object itself is leaked, not
+// deleted, since finalizers don't run on the gl thread. CheckLeaks() lists
+// the objects not yet deleted. It's meant for debugging, as it costs a
+// stack lookup for each object created.
+func SetLeakCheck(enabled bool, report func(kind string, id uint32, site string)) {
+	leakMu.Lock()
+	defer leakMu.Unlock()
+	leakChecking, leakReport = enabled, report
+	if !enabled {
+		liveObjects = map[leakKey]string{}
+	}
+}
+
+// CheckLeaks returns an error listing the objects created while leak checking
+// was on which haven't been deleted, or nil if there are none. It's useful at
+// the end of a program or test, after everything should have been deleted.
+func CheckLeaks() error {
+	leakMu.Lock()
+	defer leakMu.Unlock()
+	if len(liveObjects) == 0 {
+		return nil
+	}
+	leaks := make([]string, 0, len(liveObjects))
+	for key, site := range liveObjects {
+		leaks = append(leaks, fmt.Sprintf("%s %d created at %s", key.kind, key.id, site))
+	}
+	sort.Strings(leaks)
+	return fmt.Errorf("%d gl objects not deleted:\n  %s", len(leaks), strings.Join(leaks, "\n  "))
+}
+
+// watchLeak records obj (a pointer to the Buffer, etc with id) as live, and
+// sets a finalizer to report it if it's collected before unwatchLeak() is
+// called.
+func watchLeak(obj interface{}, kind string, id uint32) {
+	leakMu.Lock()
+	defer leakMu.Unlock()
+	if !leakChecking {
+		return
+	}
+	key := leakKey{kind, id}
+	site := callerSite()
+	liveObjects[key] = site
+	runtime.SetFinalizer(obj, func(interface{}) {
+		leakMu.Lock()
+		defer leakMu.Unlock()
+		if liveObjects[key] != site {
+			return // deleted, or its id reused after leak checking was reset
+		}
+		delete(liveObjects, key)
+		if leakReport != nil {
+			leakReport(kind, id, site)
+			return
+		}
+		log.Printf("sgl: %s %d was garbage collected without Delete(); created at %s", kind, id, site)
+	})
+}
+
+// unwatchLeak marks obj as deleted.
+func unwatchLeak(obj interface{}, kind string, id uint32) {
+	leakMu.Lock()
+	defer leakMu.Unlock()
+	key := leakKey{kind, id}
+	if _, live := liveObjects[key]; !live {
+		return
+	}
+	delete(liveObjects, key)
+	runtime.SetFinalizer(obj, nil)
+}
+
+// callerSite gets the file and line of the first caller outside of sgl.
+func callerSite() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/quillaja/sgl.") || !more {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package sgl
+
+import "github.com/go-gl/mathgl/mgl32"
+
+// DirectionalLight is a light infinitely far away, such as the sun, so its
+// rays are parallel.
+type DirectionalLight struct {
+	Direction mgl32.Vec3 // direction the light travels, from the light towards the scene
+	Color     Color      // diffuse and specular color, with intensity included
+	Ambient   Color      // ambient color, such as light scattered by the sky
+}
+
+// PointLight is a light shining in all directions from a point, such as a
+// lamp, fading to nothing at Radius.
+type PointLight struct {
+	Position mgl32.Vec3
+	Color    Color   // diffuse and specular color, with intensity included
+	Radius   float32 // distance beyond which the light has no effect
+}
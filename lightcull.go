@@ -0,0 +1,262 @@
+package sgl
+
+import (
+	"math"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// LightClusters culls point lights into clusters, a grid of tiles across
+// the screen, each sliced by depth, so that shaders using
+// ClusteredLightShaderSource (forward+ shading) only light each pixel with
+// the lights which can reach it. Scenes with hundreds of lights stay fast,
+// since each pixel is usually reached by only a few.
+//
+// Culling is done on the cpu each frame by Update(), and the results are
+// read by shaders from buffer textures.
+type LightClusters struct {
+	TilesX, TilesY, Slices int
+
+	width, height int
+	near, far     float32
+
+	// buffers and their buffer textures
+	lightBuffer, indexBuffer, gridBuffer uint32
+	lightTex, indexTex, gridTex          uint32
+
+	// reused between updates
+	lightData []float32
+	indices   []uint32
+	grid      []uint32
+	lists     [][]uint32
+}
+
+// NewLightClusters creates clusters with the screen split into tilesX x
+// tilesY tiles and depth into slices. 16 x 9 x 24 suits most scenes.
+func NewLightClusters(tilesX, tilesY, slices int) *LightClusters {
+	c := &LightClusters{TilesX: tilesX, TilesY: tilesY, Slices: slices}
+	c.lightBuffer = backend.GenBuffer()
+	c.indexBuffer = backend.GenBuffer()
+	c.gridBuffer = backend.GenBuffer()
+	c.lightTex = backend.GenTexture()
+	c.indexTex = backend.GenTexture()
+	c.gridTex = backend.GenTexture()
+	c.lists = make([][]uint32, tilesX*tilesY*slices)
+	return c
+}
+
+// Update culls lights for a width x height screen drawn with view and
+// projection, which must be a perspective projection.
+func (c *LightClusters) Update(lights []PointLight, view, projection mgl32.Mat4, width, height int) {
+	c.width, c.height = width, height
+	c.near = projection[14] / (projection[10] - 1)
+	c.far = projection[14] / (projection[10] + 1)
+	if clusters := c.TilesX * c.TilesY * c.Slices; len(c.lists) != clusters {
+		c.lists = make([][]uint32, clusters)
+	}
+	for i := range c.lists {
+		c.lists[i] = c.lists[i][:0]
+	}
+
+	logDepth := float32(math.Log(float64(c.far / c.near)))
+	slice := func(dist float32) int {
+		s := int(float32(math.Log(float64(dist/c.near))) / logDepth * float32(c.Slices))
+		if s < 0 {
+			return 0
+		}
+		if s >= c.Slices {
+			return c.Slices - 1
+		}
+		return s
+	}
+	tile := func(ndc float32, tiles int) int {
+		t := int((ndc*0.5 + 0.5) * float32(tiles))
+		if t < 0 {
+			return 0
+		}
+		if t >= tiles {
+			return tiles - 1
+		}
+		return t
+	}
+
+	c.lightData = c.lightData[:0]
+	for i, light := range lights {
+		c.lightData = append(c.lightData,
+			light.Position[0], light.Position[1], light.Position[2], light.Radius,
+			light.Color.R, light.Color.G, light.Color.B, 0)
+
+		center := view.Mul4x1(light.Position.Vec4(1)).Vec3()
+		r := light.Radius
+		nearDist, farDist := -center.Z()-r, -center.Z()+r
+		if farDist < c.near || nearDist > c.far {
+			continue
+		}
+		firstSlice, lastSlice := slice(mgl32.Clamp(nearDist, c.near, c.far)), slice(mgl32.Clamp(farDist, c.near, c.far))
+
+		// screen bounds of the sphere's bounding box, or the whole screen
+		// if it reaches the camera
+		minX, minY, maxX, maxY := float32(-1), float32(-1), float32(1), float32(1)
+		if nearDist > c.near {
+			minX, minY, maxX, maxY = 1, 1, -1, -1
+			for corner := 0; corner < 8; corner++ {
+				p := center.Add(mgl32.Vec3{
+					r * float32(corner&1*2-1),
+					r * float32(corner>>1&1*2-1),
+					r * float32(corner>>2&1*2-1)})
+				clip := projection.Mul4x1(p.Vec4(1))
+				x, y := clip.X()/clip.W(), clip.Y()/clip.W()
+				minX, maxX = float32(math.Min(float64(minX), float64(x))), float32(math.Max(float64(maxX), float64(x)))
+				minY, maxY = float32(math.Min(float64(minY), float64(y))), float32(math.Max(float64(maxY), float64(y)))
+			}
+			if maxX < -1 || minX > 1 || maxY < -1 || minY > 1 {
+				continue
+			}
+		}
+
+		x0, x1 := tile(minX, c.TilesX), tile(maxX, c.TilesX)
+		y0, y1 := tile(minY, c.TilesY), tile(maxY, c.TilesY)
+		for z := firstSlice; z <= lastSlice; z++ {
+			for y := y0; y <= y1; y++ {
+				for x := x0; x <= x1; x++ {
+					cluster := (z*c.TilesY+y)*c.TilesX + x
+					c.lists[cluster] = append(c.lists[cluster], uint32(i))
+				}
+			}
+		}
+	}
+
+	// flatten the lists into an offset and count per cluster
+	c.indices, c.grid = c.indices[:0], c.grid[:0]
+	for _, list := range c.lists {
+		c.grid = append(c.grid, uint32(len(c.indices)), uint32(len(list)))
+		c.indices = append(c.indices, list...)
+	}
+	if len(c.lightData) == 0 {
+		c.lightData = append(c.lightData, make([]float32, 8)...) // buffers can't be empty
+	}
+	if len(c.indices) == 0 {
+		c.indices = append(c.indices, 0)
+	}
+	c.upload(c.lightBuffer, c.lightTex, gl.RGBA32F, len(c.lightData)*SizeOfFloat, gl.Ptr(c.lightData))
+	c.upload(c.indexBuffer, c.indexTex, gl.R32UI, len(c.indices)*4, gl.Ptr(c.indices))
+	c.upload(c.gridBuffer, c.gridTex, gl.RG32UI, len(c.grid)*4, gl.Ptr(c.grid))
+}
+
+// upload replaces the contents of buffer, and attaches it to its buffer
+// texture.
+func (c *LightClusters) upload(buffer, tex uint32, format uint32, size int, data unsafe.Pointer) {
+	backend.BindBuffer(gl.TEXTURE_BUFFER, buffer)
+	backend.BufferData(gl.TEXTURE_BUFFER, size, data, gl.STREAM_DRAW)
+	backend.BindBuffer(gl.TEXTURE_BUFFER, 0)
+	backend.BindTexture(gl.TEXTURE_BUFFER, tex)
+	backend.TexBuffer(gl.TEXTURE_BUFFER, format, buffer)
+	backend.BindTexture(gl.TEXTURE_BUFFER, 0)
+	trackBuffer(buffer, size)
+	countUpload(size)
+}
+
+// Delete resources.
+func (c *LightClusters) Delete() {
+	for _, buffer := range []uint32{c.lightBuffer, c.indexBuffer, c.gridBuffer} {
+		trackBuffer(buffer, 0)
+	}
+	backend.DeleteTexture(c.lightTex)
+	backend.DeleteTexture(c.indexTex)
+	backend.DeleteTexture(c.gridTex)
+	backend.DeleteBuffer(c.lightBuffer)
+	backend.DeleteBuffer(c.indexBuffer)
+	backend.DeleteBuffer(c.gridBuffer)
+}
+
+// ClusteredLightUniforms are the uniforms declared by
+// ClusteredLightShaderSource. Add them to the fragment shader's uniforms in
+// Program.AddShader().
+var ClusteredLightUniforms = []string{"lightData", "lightIndices", "lightGrid", "clusterDims",
+	"clusterDepth", "clusterScreen"}
+
+// ClusteredLightShaderSource declares the uniforms for LightClusters and a
+// function to light a surface with the point lights in its cluster. Put it
+// directly after the #version line of a fragment shader, then use
+//
+//	color.rgb += applyPointLights(worldPos, normal, cameraPos, albedo, shininess, viewDepth);
+//
+// where viewDepth is the distance from the camera along its view direction
+// (-z in view space), and call Shader.SetLightClusters() after
+// Program.Use().
+const ClusteredLightShaderSource = `
+uniform samplerBuffer lightData;     // position, radius, color, 0 per light
+uniform usamplerBuffer lightIndices;
+uniform usamplerBuffer lightGrid;    // offset and count per cluster
+uniform ivec3 clusterDims;
+uniform vec2 clusterDepth;           // near, far
+uniform vec2 clusterScreen;          // width, height
+
+// applyPointLights gets the diffuse and blinn-phong specular light reflected
+// to the camera by the point lights in the fragment's cluster.
+vec3 applyPointLights(vec3 worldPos, vec3 normal, vec3 cameraPos, vec3 albedo, float shininess, float viewDepth)
+{
+    ivec2 tile = ivec2(gl_FragCoord.xy / clusterScreen * vec2(clusterDims.xy));
+    int slice = int(log(viewDepth / clusterDepth.x) / log(clusterDepth.y / clusterDepth.x) * float(clusterDims.z));
+    ivec3 cluster = clamp(ivec3(tile, slice), ivec3(0), clusterDims - 1);
+    uvec2 range = texelFetch(lightGrid, (cluster.z * clusterDims.y + cluster.y) * clusterDims.x + cluster.x).xy;
+
+    vec3 n = normalize(normal);
+    vec3 toCamera = normalize(cameraPos - worldPos);
+    vec3 total = vec3(0.0);
+    for (uint i = 0u; i < range.y; i++) {
+        int light = int(texelFetch(lightIndices, int(range.x + i)).r);
+        vec4 posRadius = texelFetch(lightData, light * 2);
+        vec3 color = texelFetch(lightData, light * 2 + 1).rgb;
+
+        vec3 toLight = posRadius.xyz - worldPos;
+        float dist = length(toLight);
+        if (dist >= posRadius.w) {
+            continue;
+        }
+        toLight /= dist;
+        // inverse square, smoothly reaching 0 at the radius
+        float falloff = clamp(1.0 - pow(dist / posRadius.w, 4.0), 0.0, 1.0);
+        float attenuation = falloff * falloff / (dist * dist + 1.0);
+
+        float diffuse = max(dot(n, toLight), 0.0);
+        float specular = diffuse > 0.0 ? pow(max(dot(n, normalize(toLight + toCamera)), 0.0), shininess) : 0.0;
+        total += color * attenuation * (albedo * diffuse + specular);
+    }
+    return total;
+}
+`
+
+// SetLightClusters sets the uniforms declared by ClusteredLightShaderSource
+// for c, binding its buffer textures to texture units firstUnit to
+// firstUnit+2. The shader's program must be in use.
+func (s *Shader) SetLightClusters(c *LightClusters, firstUnit uint32) {
+	units := []struct {
+		name string
+		tex  uint32
+	}{{"lightData", c.lightTex}, {"lightIndices", c.indexTex}, {"lightGrid", c.gridTex}}
+	for i, u := range units {
+		unit := firstUnit + uint32(i)
+		backend.ActiveTexture(gl.TEXTURE0 + unit)
+		backend.BindTexture(gl.TEXTURE_BUFFER, u.tex)
+		if _, ok := s.Uniforms[u.name]; ok {
+			value := int32(unit)
+			s.SetInt(u.name, 1, &value)
+		}
+	}
+	backend.ActiveTexture(gl.TEXTURE0)
+	if loc, ok := s.Uniforms["clusterDims"]; ok {
+		dims := [3]int32{int32(c.TilesX), int32(c.TilesY), int32(c.Slices)}
+		backend.Uniform3iv(loc, 1, &dims[0])
+	}
+	if _, ok := s.Uniforms["clusterDepth"]; ok {
+		depth := mgl32.Vec2{c.near, c.far}
+		s.SetVec2("clusterDepth", 1, &depth)
+	}
+	if _, ok := s.Uniforms["clusterScreen"]; ok {
+		screen := mgl32.Vec2{float32(c.width), float32(c.height)}
+		s.SetVec2("clusterScreen", 1, &screen)
+	}
+}
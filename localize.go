@@ -0,0 +1,176 @@
+package sgl
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Localizer translates keys, such as "menu.quit", to strings in the current
+// language, from tables loaded per language. Keys missing from the current
+// language are looked up in Fallback, then used as is, so untranslated text
+// is obvious but still readable.
+//
+//	loc := NewLocalizer("en")
+//	if err := loc.LoadCSV("strings.csv"); err != nil {
+//		log.Fatal(err)
+//	}
+//	label.Localize(loc, "menu.quit")
+//	toasts.Localize(loc)
+//	...
+//	loc.SetLanguage("fr") // the label and toasts change too
+type Localizer struct {
+	Fallback string // language for keys missing from the current one
+
+	tables    map[string]map[string]string // by language, then key
+	language  string
+	listeners []func(language string)
+}
+
+// NewLocalizer creates a localizer with no tables, using language, which is
+// also the fallback.
+func NewLocalizer(language string) *Localizer {
+	return &Localizer{
+		Fallback: language,
+		tables:   make(map[string]map[string]string),
+		language: language,
+	}
+}
+
+// AddTable adds the strings in table to language, replacing those with the
+// same keys.
+func (l *Localizer) AddTable(language string, table map[string]string) {
+	t := l.tables[language]
+	if t == nil {
+		t = make(map[string]string, len(table))
+		l.tables[language] = t
+	}
+	for key, s := range table {
+		t[key] = s
+	}
+}
+
+// LoadJSON adds the strings of language from a JSON file of a single object
+// with keys as names and strings as values.
+func (l *Localizer) LoadJSON(language, filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("could not load strings: %w", err)
+	}
+	var table map[string]string
+	if err := json.Unmarshal(data, &table); err != nil {
+		return fmt.Errorf("could not decode strings %s: %w", filename, err)
+	}
+	l.AddTable(language, table)
+	return nil
+}
+
+// LoadCSV adds the strings of every language in a CSV file. The first row
+// is a header of "key" followed by the languages, eg "key,en,fr", then
+// each row is a key followed by its strings. Empty strings are skipped, so
+// those keys fall back.
+func (l *Localizer) LoadCSV(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("could not load strings: %w", err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return fmt.Errorf("could not decode strings %s: %w", filename, err)
+	}
+	if len(rows) == 0 || len(rows[0]) < 2 {
+		return fmt.Errorf("could not decode strings %s: no languages in header", filename)
+	}
+	languages := rows[0][1:]
+	tables := make([]map[string]string, len(languages))
+	for i := range tables {
+		tables[i] = make(map[string]string)
+	}
+	for _, row := range rows[1:] {
+		for i, s := range row[1:] {
+			if s != "" {
+				tables[i][row[0]] = s
+			}
+		}
+	}
+	for i, language := range languages {
+		l.AddTable(language, tables[i])
+	}
+	return nil
+}
+
+// Languages gets the languages with tables, sorted.
+func (l *Localizer) Languages() []string {
+	languages := make([]string, 0, len(l.tables))
+	for language := range l.tables {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Language gets the current language.
+func (l *Localizer) Language() string { return l.language }
+
+// SetLanguage changes the current language and calls the functions added
+// by OnLanguageChange(). It's an error if the language has no table.
+func (l *Localizer) SetLanguage(language string) error {
+	if _, ok := l.tables[language]; !ok {
+		return fmt.Errorf("no strings for language %q", language)
+	}
+	if language == l.language {
+		return nil
+	}
+	l.language = language
+	for _, fn := range l.listeners {
+		fn(language)
+	}
+	return nil
+}
+
+// OnLanguageChange adds a function to be called after the language changes,
+// such as to redraw cached text.
+func (l *Localizer) OnLanguageChange(fn func(language string)) {
+	l.listeners = append(l.listeners, fn)
+}
+
+// Lookup gets the string for key in the current language, or else the
+// fallback, and false if neither has it.
+func (l *Localizer) Lookup(key string) (string, bool) {
+	if s, ok := l.tables[l.language][key]; ok {
+		return s, true
+	}
+	s, ok := l.tables[l.Fallback][key]
+	return s, ok
+}
+
+// T gets the string for key, or key itself if there isn't one.
+func (l *Localizer) T(key string) string {
+	if s, ok := l.Lookup(key); ok {
+		return s
+	}
+	return key
+}
+
+// Tf gets the string for key, formatted with args as with fmt.Sprintf().
+func (l *Localizer) Tf(key string, args ...interface{}) string {
+	return fmt.Sprintf(l.T(key), args...)
+}
+
+// Plural gets the string for key+".one" if n is 1, or else key+".other",
+// formatted with n followed by args, so "%d files" suits the ".other"
+// string. If the plural key is missing, key itself is used.
+func (l *Localizer) Plural(key string, n int, args ...interface{}) string {
+	plural := key + ".other"
+	if n == 1 {
+		plural = key + ".one"
+	}
+	format, ok := l.Lookup(plural)
+	if !ok {
+		format = l.T(key)
+	}
+	return fmt.Sprintf(format, append([]interface{}{n}, args...)...)
+}
@@ -0,0 +1,309 @@
+package sgl
+
+import (
+	"fmt"
+	"math"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/inkyblackness/imgui-go/v4"
+)
+
+// only need these once in the package
+var logLuminanceProgram, histogramProgram *Program
+
+// called to create and build the luminance programs.
+func initLuminancePrograms() error {
+	logLuminanceProgram = NewProgram()
+	logLuminanceProgram.AddShader(VertexShader, quadVertexShader,
+		[]string{"projection", "model"},
+		Attribute{Name: "vertex", Type: gl.FLOAT, Size: 4, Stride: 4 * SizeOfFloat, Offset: 0})
+	logLuminanceProgram.AddShader(FragmentShader, logLuminanceFragmentShader, []string{"source"})
+	if err := logLuminanceProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build log luminance program: %w", err)
+	}
+
+	histogramProgram = NewProgram()
+	histogramProgram.AddShader(VertexShader, histogramVertexShader,
+		[]string{"logLuminance", "size", "bins", "minLog", "maxLog"})
+	histogramProgram.AddShader(FragmentShader, histogramFragmentShader, nil)
+	if err := histogramProgram.Build(); err != nil {
+		logLuminanceProgram.Delete()
+		logLuminanceProgram = nil
+		return fmt.Errorf("couldn't build histogram program: %w", err)
+	}
+	return nil
+}
+
+// LuminanceAnalyzer measures the brightness of rendered frames on the GPU:
+// the average luminance, for auto-exposure, and a histogram of log2
+// luminance. The source is downsampled to a small square first, so the cost
+// is low and independent of the source's size. Results are read back without
+// stalling, so they're from a frame or two ago.
+type LuminanceAnalyzer struct {
+	Histogram      []float32 // fraction of pixels in each bin
+	MinLog, MaxLog float32   // range of log2 luminance covered by the bins
+	Average        float32   // average (geometric mean) luminance
+
+	size       int32  // of the downsampled square
+	logTex     uint32 // log2 luminance, with mipmaps
+	logFbo     uint32
+	histTex    uint32 // bins x 1 counts
+	histFbo    uint32
+	copyTex    uint32 // back buffer, for AnalyzeBackBuffer()
+	emptyVao   uint32 // for drawing points without vertex data
+	pbo        uint32 // histogram, then average
+	fence      *Fence // read pending in pbo
+	sourceSize [2]int32
+}
+
+// NewLuminanceAnalyzer creates an analyzer with a histogram of bins from
+// log2 luminance -8 to 4. sampleSize is the size of the square the source is
+// downsampled to, and should be a power of 2; 64 is plenty.
+func NewLuminanceAnalyzer(bins, sampleSize int) (*LuminanceAnalyzer, error) {
+	if logLuminanceProgram == nil {
+		if err := initLuminancePrograms(); err != nil {
+			return nil, err
+		}
+	}
+
+	a := &LuminanceAnalyzer{
+		Histogram: make([]float32, bins),
+		MinLog:    -8,
+		MaxLog:    4,
+		size:      int32(sampleSize),
+	}
+
+	a.logTex, a.logFbo = newFloatTarget(a.size, a.size, true)
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	if status == gl.FRAMEBUFFER_COMPLETE {
+		a.histTex, a.histFbo = newFloatTarget(int32(bins), 1, false)
+		status = gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		a.Delete()
+		return nil, fmt.Errorf("luminance framebuffer is not complete")
+	}
+
+	gl.GenTextures(1, &a.copyTex)
+	gl.BindTexture(gl.TEXTURE_2D, a.copyTex)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	gl.GenVertexArrays(1, &a.emptyVao)
+	gl.GenBuffers(1, &a.pbo)
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, a.pbo)
+	gl.BufferData(gl.PIXEL_PACK_BUFFER, (bins+1)*SizeOfFloat, nil, gl.STREAM_READ)
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+	return a, nil
+}
+
+// newFloatTarget makes an R32F texture attached to a new fbo, which is left
+// bound.
+func newFloatTarget(width, height int32, mipmaps bool) (tex, fbo uint32) {
+	gl.GenTextures(1, &tex)
+	gl.BindTexture(gl.TEXTURE_2D, tex)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.R32F, width, height, 0, gl.RED, gl.FLOAT, nil)
+	bytes := int(width * height * 4)
+	minFilter := int32(gl.NEAREST)
+	if mipmaps {
+		minFilter = gl.LINEAR_MIPMAP_LINEAR
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+		bytes = bytes * 4 / 3
+	}
+	trackTexture(tex, bytes)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, minFilter)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	gl.GenFramebuffers(1, &fbo)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, fbo)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, tex, 0)
+	return
+}
+
+// Delete resources.
+func (a *LuminanceAnalyzer) Delete() {
+	a.fence.Delete()
+	gl.DeleteFramebuffers(1, &a.logFbo)
+	gl.DeleteFramebuffers(1, &a.histFbo)
+	for _, tex := range []uint32{a.logTex, a.histTex, a.copyTex} {
+		trackTexture(tex, 0)
+	}
+	gl.DeleteTextures(1, &a.logTex)
+	gl.DeleteTextures(1, &a.histTex)
+	gl.DeleteTextures(1, &a.copyTex)
+	gl.DeleteVertexArrays(1, &a.emptyVao)
+	gl.DeleteBuffers(1, &a.pbo)
+}
+
+// AnalyzeBackBuffer measures the back buffer, which is width x height
+// pixels, such as from Window.GlfwWindow.GetFramebufferSize(). Call it
+// after drawing the scene but before the gui.
+func (a *LuminanceAnalyzer) AnalyzeBackBuffer(width, height int) {
+	if !a.collect() {
+		return
+	}
+	var prevFbo int32
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &prevFbo)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.ReadBuffer(gl.BACK)
+	gl.BindTexture(gl.TEXTURE_2D, a.copyTex)
+	if a.sourceSize != [2]int32{int32(width), int32(height)} {
+		a.sourceSize = [2]int32{int32(width), int32(height)}
+		gl.CopyTexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, 0, 0, int32(width), int32(height), 0)
+		trackTexture(a.copyTex, width*height*4)
+	} else {
+		gl.CopyTexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, 0, 0, int32(width), int32(height))
+	}
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(prevFbo))
+	a.analyze(a.copyTex)
+}
+
+// Analyze measures a texture, such as an Fbo's ColorBuffer.
+func (a *LuminanceAnalyzer) Analyze(tex *Texture2D) {
+	if a.collect() {
+		a.analyze(tex.ID)
+	}
+}
+
+// analyze draws the passes and starts reading the results.
+func (a *LuminanceAnalyzer) analyze(source uint32) {
+	bins := int32(len(a.Histogram))
+
+	saved := saveTargetState()
+	gl.Disable(gl.DEPTH_TEST)
+
+	// downsample to log luminance, then average with mipmaps
+	gl.Disable(gl.BLEND)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, a.logFbo)
+	gl.Viewport(0, 0, a.size, a.size)
+	projection, model := quadTransform(0, 0, float32(a.size), float32(a.size), float32(a.size), float32(a.size))
+	logLuminanceProgram.Use()
+	logLuminanceProgram.Vertex().SetMat4("projection", 1, &projection)
+	logLuminanceProgram.Vertex().SetMat4("model", 1, &model)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, source)
+	drawQuad()
+	gl.BindTexture(gl.TEXTURE_2D, a.logTex)
+	gl.GenerateMipmap(gl.TEXTURE_2D)
+
+	// histogram: one point per pixel, added into its bin
+	gl.BindFramebuffer(gl.FRAMEBUFFER, a.histFbo)
+	gl.Viewport(0, 0, bins, 1)
+	gl.ClearColor(0, 0, 0, 0)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	gl.Enable(gl.BLEND)
+	gl.BlendEquation(gl.FUNC_ADD)
+	gl.BlendFunc(gl.ONE, gl.ONE)
+	histogramProgram.Use()
+	histogramProgram.Vertex().SetInt("size", 1, &a.size)
+	histogramProgram.Vertex().SetInt("bins", 1, &bins)
+	histogramProgram.Vertex().SetFloat("minLog", 1, &a.MinLog)
+	histogramProgram.Vertex().SetFloat("maxLog", 1, &a.MaxLog)
+	gl.BindVertexArray(a.emptyVao)
+	gl.DrawArrays(gl.POINTS, 0, a.size*a.size)
+	gl.BindVertexArray(0)
+
+	// read both into the pbo
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, a.pbo)
+	gl.ReadPixels(0, 0, bins, 1, gl.RED, gl.FLOAT, gl.PtrOffset(0))
+	top := int32(math.Log2(float64(a.size)))
+	gl.GetTexImage(gl.TEXTURE_2D, top, gl.RED, gl.FLOAT, gl.PtrOffset(int(bins)*SizeOfFloat))
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	a.fence = NewFence()
+
+	saved.restore()
+}
+
+// collect updates the results if a read has finished, and returns true if
+// a new analysis can start.
+func (a *LuminanceAnalyzer) collect() bool {
+	if a.fence == nil {
+		return true
+	}
+	if !a.fence.Done() {
+		return false
+	}
+	a.fence.Delete()
+	a.fence = nil
+
+	bins := len(a.Histogram)
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, a.pbo)
+	ptr := gl.MapBufferRange(gl.PIXEL_PACK_BUFFER, 0, (bins+1)*SizeOfFloat, gl.MAP_READ_BIT)
+	if ptr != nil {
+		results := unsafe.Slice((*float32)(ptr), bins+1)
+		total := float32(a.size * a.size)
+		for i := range a.Histogram {
+			a.Histogram[i] = results[i] / total
+		}
+		a.Average = float32(math.Exp2(float64(results[bins])))
+		gl.UnmapBuffer(gl.PIXEL_PACK_BUFFER)
+	}
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+	return true
+}
+
+// Exposure gets the exposure which brings the average luminance to key, the
+// desired middle gray (0.18 is typical).
+func (a *LuminanceAnalyzer) Exposure(key float32) float32 {
+	if a.Average <= 0 {
+		return 1
+	}
+	return key / a.Average
+}
+
+// AdaptExposure moves current towards Exposure(key) smoothly, as eyes
+// adjust, where speed is the rate per second and dt is the time since the
+// last frame.
+func (a *LuminanceAnalyzer) AdaptExposure(current, key, speed, dt float32) float32 {
+	target := a.Exposure(key)
+	return current + (target-current)*(1-float32(math.Exp(float64(-speed*dt))))
+}
+
+// DrawImgui shows the histogram and average. Call it inside an imgui window.
+func (a *LuminanceAnalyzer) DrawImgui() {
+	overlay := fmt.Sprintf("avg %.3f", a.Average)
+	imgui.PlotHistogramV("##luminance", a.Histogram, 0, overlay, 0, math.MaxFloat32, imgui.Vec2{X: 0, Y: 60})
+	imgui.Text(fmt.Sprintf("log2 luminance %.0f to %.0f", a.MinLog, a.MaxLog))
+}
+
+const logLuminanceFragmentShader = `#version 330 core
+uniform sampler2D source;
+
+in vec2 TexCoords;
+
+out float LogLuminance;
+
+void main()
+{
+    float l = dot(texture(source, TexCoords).rgb, vec3(0.2126, 0.7152, 0.0722));
+    LogLuminance = log2(max(l, 0.0001));
+}`
+
+const histogramVertexShader = `#version 330 core
+uniform sampler2D logLuminance;
+uniform int size;
+uniform int bins;
+uniform float minLog;
+uniform float maxLog;
+
+void main()
+{
+    ivec2 pixel = ivec2(gl_VertexID % size, gl_VertexID / size);
+    float l = texelFetch(logLuminance, pixel, 0).r;
+    float bin = floor(clamp((l - minLog) / (maxLog - minLog), 0.0, 0.9999) * float(bins));
+    gl_Position = vec4((bin + 0.5) / float(bins) * 2.0 - 1.0, 0.0, 0.0, 1.0);
+}`
+
+const histogramFragmentShader = `#version 330 core
+out float Count;
+
+void main()
+{
+    Count = 1.0;
+}`
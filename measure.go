@@ -0,0 +1,210 @@
+package sgl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// PickPoint gets the world position of what was drawn at (x, y), in pixels
+// with (0, 0) at the top left of the bound framebuffer of size (width,
+// height), by reading back its depth. view and projection are those used
+// to draw the scene. ok is false if nothing was drawn there.
+//
+// Depth can't be read from a multisampled framebuffer, such as the
+// window's with UseMSAA(). Blit its depth buffer into a same size Fbo from
+// NewFbo() first, with gl.BlitFramebuffer() and gl.DEPTH_BUFFER_BIT, and
+// bind that for reading.
+func PickPoint(x, y float32, view, projection mgl32.Mat4, width, height int) (p mgl32.Vec3, ok bool) {
+	assertGLThread()
+	px, py := int32(x), int32(height)-1-int32(y)
+	if px < 0 || py < 0 || px >= int32(width) || py >= int32(height) {
+		return p, false
+	}
+	var depth float32
+	gl.ReadPixels(px, py, 1, 1, gl.DEPTH_COMPONENT, gl.FLOAT, gl.Ptr(&depth))
+	if depth >= 1 {
+		return p, false
+	}
+	p, err := mgl32.UnProject(mgl32.Vec3{float32(px) + 0.5, float32(py) + 0.5, depth},
+		view, projection, 0, 0, width, height)
+	return p, err == nil
+}
+
+// worldToScreen gets the position of p in pixels with (0, 0) at the top
+// left of a screen of size (width, height), and false if it's behind the
+// camera.
+func worldToScreen(p mgl32.Vec3, viewProjection mgl32.Mat4, width, height float32) (mgl32.Vec2, bool) {
+	clip := viewProjection.Mul4x1(p.Vec4(1))
+	if clip.W() <= 0 {
+		return mgl32.Vec2{}, false
+	}
+	x, y := clip.X()/clip.W(), clip.Y()/clip.W()
+	return mgl32.Vec2{(x*0.5 + 0.5) * width, (0.5 - y*0.5) * height}, true
+}
+
+// Measurement is a ruler between two points in the world.
+type Measurement struct {
+	A mgl32.Vec3 `json:"a"`
+	B mgl32.Vec3 `json:"b"`
+}
+
+// Distance gets the length of the ruler.
+func (m Measurement) Distance() float32 { return m.B.Sub(m.A).Len() }
+
+// Annotation is a note pinned to a point in the world.
+type Annotation struct {
+	Position mgl32.Vec3 `json:"position"`
+	Text     string     `json:"text"`
+	Color    Color      `json:"color"`
+}
+
+// MeasureTool draws rulers between pairs of points clicked in the world,
+// labelled with their distance, and annotations. Measurements and
+// Annotations are tagged for JSON, so a MeasureTool can be saved as part
+// of an app's scene, or alone with Save() and Load().
+//
+// Each frame, something like
+//
+//	if in.ButtonPressed(glfw.MouseButtonLeft) {
+//		if p, ok := PickPoint(x, y, view, projection, w, h); ok {
+//			tool.Click(p)
+//		}
+//	}
+//	tool.Draw(dict, view, projection, w, h)
+type MeasureTool struct {
+	Measurements []Measurement `json:"measurements"`
+	Annotations  []Annotation  `json:"annotations"`
+
+	Color     Color   `json:"-"` // of rulers
+	Units     string  `json:"-"` // appended to distances, eg "m"
+	TextScale float32 `json:"-"`
+
+	first  *mgl32.Vec3 // the first point of a ruler, until the second click
+	shapes *ShapeBatch
+}
+
+// NewMeasureTool creates a tool with no measurements.
+func NewMeasureTool() (*MeasureTool, error) {
+	shapes, err := NewShapeBatch()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create measure tool: %w", err)
+	}
+	return &MeasureTool{
+		Color:     Color{1, 0.8, 0.1, 1},
+		TextScale: 1,
+		shapes:    shapes,
+	}, nil
+}
+
+// Click adds a point. Every second point completes a Measurement from the
+// point before it.
+func (t *MeasureTool) Click(p mgl32.Vec3) {
+	if t.first == nil {
+		t.first = &p
+		return
+	}
+	t.Measurements = append(t.Measurements, Measurement{A: *t.first, B: p})
+	t.first = nil
+}
+
+// Measuring returns true if a ruler has been started but not finished.
+func (t *MeasureTool) Measuring() bool { return t.first != nil }
+
+// Cancel forgets the first point of an unfinished ruler.
+func (t *MeasureTool) Cancel() { t.first = nil }
+
+// Annotate adds a note at p.
+func (t *MeasureTool) Annotate(p mgl32.Vec3, text string, color Color) {
+	t.Annotations = append(t.Annotations, Annotation{Position: p, Text: text, Color: color})
+}
+
+// Clear removes all measurements and annotations.
+func (t *MeasureTool) Clear() {
+	t.Measurements = t.Measurements[:0]
+	t.Annotations = t.Annotations[:0]
+	t.first = nil
+}
+
+// Draw the rulers, annotations, and the start of an unfinished ruler over
+// the scene drawn with view and projection, on a screen of size (width,
+// height). Labels are drawn with dict. Blending should be enabled.
+func (t *MeasureTool) Draw(dict *CharacterDict, view, projection mgl32.Mat4, width, height float32) {
+	viewProjection := projection.Mul4(view)
+	color := mgl32.Vec3{t.Color.R, t.Color.G, t.Color.B}
+	type label struct {
+		text  string
+		at    mgl32.Vec2
+		color mgl32.Vec3
+	}
+	var labels []label
+
+	t.shapes.Clear()
+	for _, m := range t.Measurements {
+		a, aOk := worldToScreen(m.A, viewProjection, width, height)
+		b, bOk := worldToScreen(m.B, viewProjection, width, height)
+		if !aOk || !bOk {
+			continue
+		}
+		t.shapes.Capsule(a, b, 1.5, t.Color)
+		t.shapes.Circle(a, 4, t.Color)
+		t.shapes.Circle(b, 4, t.Color)
+		text := fmt.Sprintf("%.3g%s", m.Distance(), t.Units)
+		labels = append(labels, label{text, a.Add(b).Mul(0.5), color})
+	}
+	if t.first != nil {
+		if p, ok := worldToScreen(*t.first, viewProjection, width, height); ok {
+			t.shapes.Ring(p, 5, 2, t.Color)
+		}
+	}
+	for _, note := range t.Annotations {
+		p, ok := worldToScreen(note.Position, viewProjection, width, height)
+		if !ok {
+			continue
+		}
+		t.shapes.Circle(p, 4, note.Color)
+		labels = append(labels, label{note.Text, p.Add(mgl32.Vec2{8, 0}), mgl32.Vec3{note.Color.R, note.Color.G, note.Color.B}})
+	}
+	t.shapes.Draw(width, height)
+
+	for _, l := range labels {
+		// DrawString() scales y along with the text
+		dict.DrawString(l.text, l.at[0], l.at[1]/t.TextScale, t.TextScale, l.color, width, height)
+	}
+}
+
+// Save writes the measurements and annotations to a JSON file.
+func (t *MeasureTool) Save(filename string) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode measurements: %w", err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("could not save measurements: %w", err)
+	}
+	return nil
+}
+
+// Load replaces the measurements and annotations with those in a JSON
+// file.
+func (t *MeasureTool) Load(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("could not load measurements: %w", err)
+	}
+	var loaded MeasureTool
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("could not decode measurements %s: %w", filename, err)
+	}
+	t.Measurements, t.Annotations = loaded.Measurements, loaded.Annotations
+	t.first = nil
+	return nil
+}
+
+// Delete resources.
+func (t *MeasureTool) Delete() {
+	t.shapes.Delete()
+}
@@ -0,0 +1,125 @@
+package sgl
+
+import (
+	"log"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// GPUMemory is an estimate of the GPU memory used by sgl-managed textures,
+// buffers, and renderbuffers (including those of FBOs), from their sizes and
+// formats. Drivers add padding, mipmaps, and their own overhead, so actual
+// use is somewhat higher.
+type GPUMemory struct {
+	Textures      int64 // bytes
+	Buffers       int64 // bytes
+	Renderbuffers int64 // bytes
+}
+
+// Total gets the total bytes.
+func (m GPUMemory) Total() int64 { return m.Textures + m.Buffers + m.Renderbuffers }
+
+// sizes of tracked objects, by id. ids are unique per kind of object.
+var (
+	textureBytes      = map[uint32]int64{}
+	bufferBytes       = map[uint32]int64{}
+	renderbufferBytes = map[uint32]int64{}
+	memoryUsed        GPUMemory
+)
+
+// budget set by SetMemoryBudget()
+var (
+	memoryBudget     int64
+	memoryWarn       func(GPUMemory)
+	memoryOverBudget bool
+)
+
+// EstimateMemory gets the estimated GPU memory in use.
+func EstimateMemory() GPUMemory { return memoryUsed }
+
+// SetMemoryBudget sets a limit in bytes on the estimated GPU memory. When
+// more than 90% of it is used, warn is called (or a message is logged if
+// warn is nil). It's called again only after use drops below 90%. A budget
+// of 0 disables warnings.
+func SetMemoryBudget(bytes int64, warn func(GPUMemory)) {
+	memoryBudget, memoryWarn = bytes, warn
+	memoryOverBudget = false
+	checkMemoryBudget()
+}
+
+// track records the size of object id in sizes, replacing any earlier size,
+// and updates the total.
+func track(sizes map[uint32]int64, total *int64, id uint32, bytes int64) {
+	*total += bytes - sizes[id]
+	if bytes == 0 {
+		delete(sizes, id)
+	} else {
+		sizes[id] = bytes
+	}
+	checkMemoryBudget()
+}
+
+func trackTexture(id uint32, bytes int) {
+	track(textureBytes, &memoryUsed.Textures, id, int64(bytes))
+}
+
+func trackBuffer(id uint32, bytes int) {
+	track(bufferBytes, &memoryUsed.Buffers, id, int64(bytes))
+}
+
+func trackRenderbuffer(id uint32, bytes int) {
+	track(renderbufferBytes, &memoryUsed.Renderbuffers, id, int64(bytes))
+}
+
+func checkMemoryBudget() {
+	if memoryBudget <= 0 {
+		return
+	}
+	over := memoryUsed.Total() > memoryBudget*9/10
+	if over && !memoryOverBudget {
+		if memoryWarn != nil {
+			memoryWarn(memoryUsed)
+		} else {
+			log.Printf("sgl: estimated GPU memory %d MiB is near the budget of %d MiB",
+				memoryUsed.Total()>>20, memoryBudget>>20)
+		}
+	}
+	memoryOverBudget = over
+}
+
+// memory info extension enums, which aren't in the core profile bindings.
+const (
+	gpuMemoryInfoTotalAvailableNVX   = 0x9048
+	gpuMemoryInfoCurrentAvailableNVX = 0x9049
+	textureFreeMemoryATI             = 0x87FC
+)
+
+// VideoMemory queries the driver for the free and total video memory in
+// bytes, if the GL_NVX_gpu_memory_info or GL_ATI_meminfo extension is
+// available. total is 0 if unknown, as it is with ATI_meminfo.
+func VideoMemory() (free, total int64, ok bool) {
+	switch {
+	case HasExtension("GL_NVX_gpu_memory_info"):
+		var freeKiB, totalKiB int32
+		gl.GetIntegerv(gpuMemoryInfoCurrentAvailableNVX, &freeKiB)
+		gl.GetIntegerv(gpuMemoryInfoTotalAvailableNVX, &totalKiB)
+		return int64(freeKiB) << 10, int64(totalKiB) << 10, true
+	case HasExtension("GL_ATI_meminfo"):
+		var info [4]int32 // first is total free KiB
+		gl.GetIntegerv(textureFreeMemoryATI, &info[0])
+		return int64(info[0]) << 10, 0, true
+	}
+	return 0, 0, false
+}
+
+// HasExtension returns true if the opengl extension is supported.
+func HasExtension(name string) bool {
+	var count int32
+	gl.GetIntegerv(gl.NUM_EXTENSIONS, &count)
+	for i := int32(0); i < count; i++ {
+		if gl.GoStr(gl.GetStringi(gl.EXTENSIONS, uint32(i))) == name {
+			return true
+		}
+	}
+	return false
+}
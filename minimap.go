@@ -0,0 +1,179 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// only need this once in the package
+var minimapProgram *Program
+
+// called to create and build the minimap program.
+func initMinimapProgram() error {
+	minimapProgram = NewProgram()
+	minimapProgram.AddShader(VertexShader, quadVertexShader,
+		[]string{"projection", "model"},
+		Attribute{Name: "vertex", Type: gl.FLOAT, Size: 4, Stride: 4 * SizeOfFloat, Offset: 0})
+	minimapProgram.AddShader(FragmentShader, minimapFragmentShader, []string{"scene", "opacity"})
+	if err := minimapProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build minimap program: %w", err)
+	}
+	return nil
+}
+
+// MinimapMarker is drawn over the minimap at a point in the world, such as
+// the player or an objective.
+type MinimapMarker struct {
+	Position mgl32.Vec3
+	Facing   mgl32.Vec3 // direction shown by a pointer; none if zero
+	Color    Color
+	Radius   float32 // pixels
+}
+
+// Minimap draws the scene from above into an Fbo every Interval frames,
+// then shows it as a HUD quad with markers drawn over it every frame. The
+// map is centered on Center with -z up, like north on a map.
+type Minimap struct {
+	Center   mgl32.Vec3 // the point in the middle of the map, usually the player
+	Extent   float32    // world units from the center to the map's edges
+	Height   float32    // of the camera above Center
+	Depth    float32    // below the camera that things are drawn
+	Interval uint64     // frames between renders; 1 renders every frame
+	Opacity  float32    // of the map, not the markers
+	Markers  []MinimapMarker
+	Fbo      *Fbo
+
+	view, projection mgl32.Mat4 // used by the last render
+	rendered         bool
+	shapes           *ShapeBatch
+}
+
+// NewMinimap creates a minimap with a size x size pixel Fbo.
+func NewMinimap(size int) (*Minimap, error) {
+	if minimapProgram == nil {
+		if err := initMinimapProgram(); err != nil {
+			return nil, err
+		}
+	}
+	fbo, err := NewFbo(size, size)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create minimap: %w", err)
+	}
+	shapes, err := NewShapeBatch()
+	if err != nil {
+		fbo.Delete()
+		return nil, fmt.Errorf("couldn't create minimap: %w", err)
+	}
+	return &Minimap{
+		Extent:   50,
+		Height:   100,
+		Depth:    200,
+		Interval: 4,
+		Opacity:  1,
+		Fbo:      fbo,
+		shapes:   shapes,
+	}, nil
+}
+
+// Camera gets the view and projection of the top down camera.
+func (m *Minimap) Camera() (view, projection mgl32.Mat4) {
+	eye := m.Center.Add(mgl32.Vec3{0, m.Height, 0})
+	view = mgl32.LookAtV(eye, m.Center, mgl32.Vec3{0, 0, -1})
+	projection = mgl32.Ortho(-m.Extent, m.Extent, -m.Extent, m.Extent, 0, m.Depth)
+	return
+}
+
+// Update draws the scene into the Fbo if it's the first update or clock is
+// on an Interval frame. drawScene is called with the top down camera. The
+// framebuffer and viewport in use are restored afterwards.
+func (m *Minimap) Update(clock *Timer, drawScene func(view, projection mgl32.Mat4)) {
+	interval := m.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	if m.rendered && !clock.IsNthFrame(interval) {
+		return
+	}
+	m.Render(drawScene)
+}
+
+// Render draws the scene into the Fbo now.
+func (m *Minimap) Render(drawScene func(view, projection mgl32.Mat4)) {
+	m.view, m.projection = m.Camera()
+	saved := saveTargetState()
+	m.Fbo.Use()
+	gl.Viewport(0, 0, m.Fbo.Width, m.Fbo.Height)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	drawScene(m.view, m.projection)
+	saved.restore()
+	m.rendered = true
+}
+
+// ToMap gets the position of world point p on a map drawn at (x, y) with
+// the given size, and whether it's on the map. Points are placed with the
+// camera of the last render, so markers stay put on the map between renders.
+func (m *Minimap) ToMap(p mgl32.Vec3, x, y, size float32) (mgl32.Vec2, bool) {
+	ndc := m.projection.Mul4(m.view).Mul4x1(p.Vec4(1))
+	mx := x + (ndc.X()*0.5+0.5)*size
+	my := y + (0.5-ndc.Y()*0.5)*size
+	inside := ndc.X() >= -1 && ndc.X() <= 1 && ndc.Y() >= -1 && ndc.Y() <= 1
+	return mgl32.Vec2{mx, my}, inside
+}
+
+// Draw the map with its top left at (x, y) and size pixels square, then
+// the Markers on it, in pixels with (0, 0) at the top left of a screen of
+// size (width, height). Markers off the map aren't drawn. Blending should
+// be enabled.
+func (m *Minimap) Draw(x, y, size, width, height float32) {
+	if !m.rendered {
+		return
+	}
+	projection, model := quadTransform(x, y, size, size, width, height)
+	minimapProgram.Use()
+	minimapProgram.Vertex().SetMat4("projection", 1, &projection)
+	minimapProgram.Vertex().SetMat4("model", 1, &model)
+	minimapProgram.Fragment().SetFloat("opacity", 1, &m.Opacity)
+	m.Fbo.ColorBuffer.Bind(0)
+	drawQuad()
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	m.shapes.Clear()
+	for _, marker := range m.Markers {
+		p, ok := m.ToMap(marker.Position, x, y, size)
+		if !ok {
+			continue
+		}
+		if marker.Facing.Len() > 0 {
+			ahead, _ := m.ToMap(marker.Position.Add(marker.Facing), x, y, size)
+			if dir := ahead.Sub(p); dir.Len() > 0 {
+				tip := p.Add(dir.Normalize().Mul(marker.Radius * 2))
+				m.shapes.Capsule(p, tip, marker.Radius*0.4, marker.Color)
+			}
+		}
+		m.shapes.Circle(p, marker.Radius, marker.Color)
+	}
+	m.shapes.Draw(width, height)
+}
+
+// Delete resources.
+func (m *Minimap) Delete() {
+	m.Fbo.Delete()
+	m.shapes.Delete()
+}
+
+const minimapFragmentShader = `#version 330 core
+uniform sampler2D scene;
+uniform float opacity;
+
+in vec2 TexCoords;
+
+out vec4 FragColor;
+
+void main()
+{
+    // the fbo's origin is the bottom left, but the quad's is the top left
+    vec3 color = texture(scene, vec2(TexCoords.x, 1.0 - TexCoords.y)).rgb;
+    FragColor = vec4(color, opacity);
+}`
@@ -0,0 +1,198 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// only need this once in the package
+var mirrorProgram *Program
+
+// called to create and build the mirror program.
+func initMirrorProgram() error {
+	mirrorProgram = NewProgram()
+	mirrorProgram.AddShader(VertexShader, mirrorVertexShader,
+		[]string{"projection", "view", "model"},
+		Attribute{Name: "aPos", Type: gl.FLOAT, Size: 3, Stride: 3 * SizeOfFloat, Offset: 0})
+	mirrorProgram.AddShader(FragmentShader, mirrorFragmentShader, []string{"reflection", "tint"})
+
+	if err := mirrorProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build mirror program: %w", err)
+	}
+	return nil
+}
+
+// unit square in the xy plane, centered on the origin, facing +z.
+var mirrorVertices = []float32{
+	-0.5, -0.5, 0,
+	0.5, -0.5, 0,
+	-0.5, 0.5, 0,
+	-0.5, 0.5, 0,
+	0.5, -0.5, 0,
+	0.5, 0.5, 0,
+}
+
+// Mirror is a flat reflective quad, such as a mirror or the surface of a
+// portal. Each frame, call Render() to draw the scene as seen in the mirror,
+// then draw the scene normally and Draw() the mirror.
+type Mirror struct {
+	Model mgl32.Mat4 // places a 1x1 quad in the xy plane, facing +z, in the world
+	Tint  Color      // alpha is the amount of tint
+	Fbo   *Fbo
+	vao   *Vao
+}
+
+// NewMirror creates a mirror with an FBO of the given size, which is
+// usually the size of the window.
+func NewMirror(fboWidth, fboHeight int) (*Mirror, error) {
+	if mirrorProgram == nil {
+		if err := initMirrorProgram(); err != nil {
+			return nil, err
+		}
+	}
+	fbo, err := NewFbo(fboWidth, fboHeight)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create mirror: %w", err)
+	}
+
+	vao := NewVao(Triangles, NewVbo("vbo", mirrorProgram.Vertex().Attributes()...))
+	vao.Vbo["vbo"].Initalize(mirrorVertices)
+
+	return &Mirror{
+		Model: mgl32.Ident4(),
+		Fbo:   fbo,
+		vao:   vao,
+	}, nil
+}
+
+// Delete resources.
+func (m *Mirror) Delete() {
+	m.Fbo.Delete()
+	m.vao.Delete()
+}
+
+// Plane gets the mirror's plane (normal, distance) in world space. The
+// normal points out of the mirror's front.
+func (m *Mirror) Plane() mgl32.Vec4 {
+	normal := m.Model.Mul4x1(mgl32.Vec4{0, 0, 1, 0}).Vec3()
+	return ClipPlaneFromPoint(normal, m.Model.Col(3).Vec3())
+}
+
+// Render draws the scene as seen in the mirror into its FBO. drawScene is
+// called with a reflected view and an oblique projection whose near plane
+// is the mirror, so nothing behind the mirror is drawn without the need for
+// clip planes. Nothing is drawn if the camera is behind the mirror. The
+// framebuffer and viewport in use are restored afterwards.
+func (m *Mirror) Render(view, projection mgl32.Mat4, drawScene func(view, projection mgl32.Mat4)) {
+	plane := m.Plane()
+	camera := view.Inv().Col(3)
+	if plane.Dot(camera) <= 0 {
+		return
+	}
+	reflectedView := view.Mul4(ReflectionMatrix(plane))
+	oblique := ObliqueProjection(projection, reflectedView, plane)
+
+	var prevFbo int32
+	var viewport [4]int32
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &prevFbo)
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+
+	m.Fbo.Use()
+	gl.Viewport(0, 0, m.Fbo.Width, m.Fbo.Height)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	gl.FrontFace(gl.CW) // mirroring reverses the winding of triangles
+	drawScene(reflectedView, oblique)
+	gl.FrontFace(gl.CCW)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(prevFbo))
+	gl.Viewport(viewport[0], viewport[1], viewport[2], viewport[3])
+}
+
+// Draw the mirror, showing what was drawn by Render().
+func (m *Mirror) Draw(view, projection mgl32.Mat4) {
+	tint := m.Tint.Vec4()
+	mirrorProgram.Use()
+	mirrorProgram.Vertex().SetMat4("projection", 1, &projection)
+	mirrorProgram.Vertex().SetMat4("view", 1, &view)
+	mirrorProgram.Vertex().SetMat4("model", 1, &m.Model)
+	mirrorProgram.Fragment().SetVec4("tint", 1, &tint)
+
+	m.Fbo.ColorBuffer.Bind(0)
+	gl.BindVertexArray(m.vao.ID)
+	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(mirrorVertices)/3))
+	countDraw(gl.TRIANGLES, int32(len(mirrorVertices)/3))
+	gl.BindVertexArray(0)
+}
+
+// ReflectionMatrix gets the matrix reflecting points across plane (normal,
+// distance), where the normal is normalized.
+func ReflectionMatrix(plane mgl32.Vec4) mgl32.Mat4 {
+	n, d := plane.Vec3(), plane.W()
+	var m mgl32.Mat4
+	for col := 0; col < 3; col++ {
+		for row := 0; row < 3; row++ {
+			m.Set(row, col, -2*n[row]*n[col])
+		}
+		m.Set(col, col, m.At(col, col)+1)
+		m.Set(col, 3, -2*d*n[col])
+	}
+	m.Set(3, 3, 1)
+	return m
+}
+
+// ObliqueProjection modifies a perspective projection so its near plane is
+// plane, in world space, which keeps only what is on the plane's positive
+// side. This is Lengyel's method, which clips exactly without clip planes
+// and costs little depth precision.
+func ObliqueProjection(projection, view mgl32.Mat4, plane mgl32.Vec4) mgl32.Mat4 {
+	// plane in camera space
+	c := view.Inv().Transpose().Mul4x1(plane)
+	// the corner of the view frustum opposite the plane
+	q := projection.Inv().Mul4x1(mgl32.Vec4{sign(c.X()), sign(c.Y()), 1, 1})
+	c = c.Mul(2 / c.Dot(q))
+	projection.SetRow(2, c.Sub(projection.Row(3)))
+	return projection
+}
+
+func sign(x float32) float32 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
+
+const mirrorVertexShader = `#version 330 core
+in vec3 aPos;
+
+uniform mat4 projection;
+uniform mat4 view;
+uniform mat4 model;
+
+out vec4 ClipSpace;
+
+void main()
+{
+    ClipSpace = projection * view * model * vec4(aPos, 1.0);
+    gl_Position = ClipSpace;
+}`
+
+const mirrorFragmentShader = `#version 330 core
+uniform sampler2D reflection;
+uniform vec4 tint;
+
+in vec4 ClipSpace;
+
+out vec4 FragColor;
+
+void main()
+{
+    // the reflection was drawn where it appears on screen
+    vec2 ndc = ClipSpace.xy / ClipSpace.w * 0.5 + 0.5;
+    vec3 color = texture(reflection, ndc).rgb;
+    FragColor = vec4(mix(color, tint.rgb, tint.a), 1.0);
+}`
@@ -0,0 +1,175 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/inkyblackness/imgui-go/v4"
+)
+
+// only need this once in the package
+var motionBlurProgram *Program
+
+// called to create and build the motion blur program.
+func initMotionBlurProgram() error {
+	motionBlurProgram = NewProgram()
+	motionBlurProgram.AddShader(VertexShader, quadVertexShader,
+		[]string{"projection", "model"},
+		Attribute{Name: "vertex", Type: gl.FLOAT, Size: 4, Stride: 4 * SizeOfFloat, Offset: 0})
+	motionBlurProgram.AddShader(FragmentShader, motionBlurFragmentShader,
+		[]string{"source", "velocity", "depth", "invViewProj", "prevViewProj", "shutter",
+			"maxBlur", "samples"})
+	if err := motionBlurProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build motion blur program: %w", err)
+	}
+	var source, velocity, depth int32 = 0, 1, 2
+	motionBlurProgram.Fragment().SetInt("source", 1, &source)
+	motionBlurProgram.Fragment().SetInt("velocity", 1, &velocity)
+	motionBlurProgram.Fragment().SetInt("depth", 1, &depth)
+	return nil
+}
+
+// MotionBlur is a post-processing pass blurring each pixel along its motion
+// since the last frame, from a GBuffer's velocity, or for static things,
+// from its depth and the camera's movement.
+type MotionBlur struct {
+	Shutter float32 // fraction of the frame time the shutter is open
+	MaxBlur float32 // longest blur, in pixels
+	Samples int     // along each blur
+	Output  *Texture2D
+
+	fbo          uint32
+	prevViewProj mgl32.Mat4
+	started      bool
+}
+
+// NewMotionBlur creates a motion blur pass.
+func NewMotionBlur() (*MotionBlur, error) {
+	if motionBlurProgram == nil {
+		if err := initMotionBlurProgram(); err != nil {
+			return nil, err
+		}
+	}
+	return &MotionBlur{Shutter: 0.5, MaxBlur: 32, Samples: 12}, nil
+}
+
+// Apply blurs source, which is the scene drawn in g, or eg TAA's Output of
+// it, into Output. view and projection are this frame's, without jitter.
+// There's no blur on the first frame.
+func (mb *MotionBlur) Apply(source *Texture2D, g *GBuffer, view, projection mgl32.Mat4) error {
+	viewProj := projection.Mul4(view)
+	if !mb.started {
+		mb.prevViewProj, mb.started = viewProj, true
+	}
+	if err := resizeColorTarget(&mb.Output, &mb.fbo, int(source.Width), int(source.Height)); err != nil {
+		return fmt.Errorf("couldn't create motion blur target: %w", err)
+	}
+
+	saved := saveTargetState()
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.BLEND)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, mb.fbo)
+	gl.Viewport(0, 0, mb.Output.Width, mb.Output.Height)
+
+	w, h := float32(mb.Output.Width), float32(mb.Output.Height)
+	invViewProj := viewProj.Inv()
+	samples := int32(mb.Samples)
+	quadProjection, model := quadTransform(0, 0, w, h, w, h)
+	motionBlurProgram.Use()
+	motionBlurProgram.Vertex().SetMat4("projection", 1, &quadProjection)
+	motionBlurProgram.Vertex().SetMat4("model", 1, &model)
+	motionBlurProgram.Fragment().SetMat4("invViewProj", 1, &invViewProj)
+	motionBlurProgram.Fragment().SetMat4("prevViewProj", 1, &mb.prevViewProj)
+	motionBlurProgram.Fragment().SetFloat("shutter", 1, &mb.Shutter)
+	motionBlurProgram.Fragment().SetFloat("maxBlur", 1, &mb.MaxBlur)
+	motionBlurProgram.Fragment().SetInt("samples", 1, &samples)
+	source.Bind(0)
+	g.Velocity.Bind(1)
+	g.Depth.Bind(2)
+	gl.ActiveTexture(gl.TEXTURE0)
+	drawQuad()
+	saved.restore()
+
+	mb.prevViewProj = viewProj
+	return nil
+}
+
+// resizeColorTarget (re)creates a color target if it's missing or isn't
+// width x height.
+func resizeColorTarget(tex **Texture2D, fbo *uint32, width, height int) error {
+	if *tex != nil && int((*tex).Width) == width && int((*tex).Height) == height {
+		return nil
+	}
+	if *tex != nil {
+		(*tex).Delete()
+		gl.DeleteFramebuffers(1, fbo)
+		*tex, *fbo = nil, 0
+	}
+	var err error
+	*tex, *fbo, err = newColorTarget(width, height)
+	return err
+}
+
+// Gui shows controls for the blur in a window. Call it inside the func of
+// Window.RenderImgui().
+func (mb *MotionBlur) Gui(title string) {
+	if imgui.Begin(title + "##motionblur") {
+		samples := int32(mb.Samples)
+		imgui.SliderFloat("shutter", &mb.Shutter, 0, 1)
+		imgui.SliderFloat("max blur (px)", &mb.MaxBlur, 0, 128)
+		if imgui.SliderInt("samples", &samples, 2, 64) {
+			mb.Samples = int(samples)
+		}
+	}
+	imgui.End()
+}
+
+// Delete resources.
+func (mb *MotionBlur) Delete() {
+	if mb.Output != nil {
+		mb.Output.Delete()
+		gl.DeleteFramebuffers(1, &mb.fbo)
+	}
+}
+
+const motionBlurFragmentShader = `#version 330 core
+` + cameraMotionShaderSource + `
+in vec2 TexCoords;
+
+uniform sampler2D source;
+uniform sampler2D velocity;
+uniform sampler2D depth;
+uniform mat4 invViewProj;  // this frame's
+uniform mat4 prevViewProj; // last frame's
+uniform float shutter;
+uniform float maxBlur;     // pixels
+uniform int samples;
+
+out vec4 FragColor;
+
+void main()
+{
+    // TexCoords are y down, so flip to sample the same row
+    vec2 uv = vec2(TexCoords.x, 1.0 - TexCoords.y);
+    vec2 size = vec2(textureSize(source, 0));
+
+    vec2 motion = texture(velocity, uv).xy;
+    if (motion == vec2(0.0)) {
+        motion = cameraMotion(uv, texture(depth, uv).r, invViewProj, prevViewProj);
+    }
+    motion *= shutter;
+    float pixels = length(motion * size);
+    if (pixels > maxBlur) {
+        motion *= maxBlur / pixels;
+    }
+
+    // centered on the pixel, offset by noise so few samples don't band
+    float noise = fract(sin(dot(gl_FragCoord.xy, vec2(12.9898, 78.233))) * 43758.5453);
+    vec4 color = vec4(0.0);
+    for (int i = 0; i < samples; i++) {
+        float t = (float(i) + noise) / float(samples) - 0.5;
+        color += texture(source, uv + motion * t);
+    }
+    FragColor = color / float(samples);
+}`
@@ -0,0 +1,73 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// UseHidden is an option to keep the window hidden after it's created, such
+// as for a window only hosting an opengl context. Fullscreen is ignored.
+// Show it later with GlfwWindow.Show().
+func UseHidden() WindowOption {
+	return func(win *Window) error {
+		win.hidden = true
+		return nil
+	}
+}
+
+// OffscreenWindow is a hidden window hosting an opengl context, for tests
+// and batch rendering which need a context but no visible window or user.
+// Drawing goes to Target, since a hidden window's default framebuffer may
+// not be drawn to. Unlike HeadlessContext, it needs a display server, but
+// no build tag.
+//
+//	sgl.Init()
+//	defer sgl.Destroy()
+//	off, err := sgl.NewOffscreenWindow(256, 256)
+//	...
+//	draw()
+//	img := off.ReadImage()
+type OffscreenWindow struct {
+	*Window
+	Target *Fbo
+}
+
+// NewOffscreenWindow creates a hidden window, with options, and a width x
+// height Target bound for drawing. Init() must be called first.
+func NewOffscreenWindow(width, height int, options ...WindowSetting) (*OffscreenWindow, error) {
+	options = append([]WindowSetting{UseHidden()}, options...)
+	win, err := NewWindow("offscreen", WindowMetric{W: width, H: height}, options...)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create offscreen window: %w", err)
+	}
+	target, err := NewFbo(width, height)
+	if err != nil {
+		win.Dispose()
+		return nil, fmt.Errorf("couldn't create offscreen window: %w", err)
+	}
+	off := &OffscreenWindow{Window: win, Target: target}
+	off.Use()
+	return off, nil
+}
+
+// Use binds Target and sets the viewport to its size.
+func (off *OffscreenWindow) Use() {
+	off.Target.Use()
+	gl.Viewport(0, 0, off.Target.Width, off.Target.Height)
+}
+
+// ReadImage gets what has been drawn to Target.
+func (off *OffscreenWindow) ReadImage() *image.RGBA {
+	gl.Finish()
+	return off.Target.ColorBuffer.ReadImage()
+}
+
+// Dispose deletes Target and disposes the window.
+func (off *OffscreenWindow) Dispose() {
+	off.Target.Delete()
+	off.Window.Dispose()
+}
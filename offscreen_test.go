@@ -0,0 +1,58 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"image/color"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+func TestOffscreenWindow(t *testing.T) {
+	if runtime.GOOS == "linux" && os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		t.Skip("no display")
+	}
+	if err := Init(); err != nil {
+		t.Skipf("no display: %v", err)
+	}
+	defer Destroy()
+
+	off, err := NewOffscreenWindow(32, 16)
+	if err != nil {
+		t.Skipf("no opengl 3.3 context: %v", err)
+	}
+	defer off.Dispose()
+	if off.GlfwWindow.GetAttrib(glfw.Visible) == glfw.True {
+		t.Error("offscreen window is visible")
+	}
+
+	// red, with the bottom left quarter blue
+	gl.ClearColor(1, 0, 0, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	gl.Enable(gl.SCISSOR_TEST)
+	gl.Scissor(0, 0, 16, 8)
+	gl.ClearColor(0, 0, 1, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+	gl.Disable(gl.SCISSOR_TEST)
+
+	img := off.ReadImage()
+	if w, h := img.Bounds().Dx(), img.Bounds().Dy(); w != 32 || h != 16 {
+		t.Fatalf("image is %dx%d, want 32x16", w, h)
+	}
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	for _, p := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{4, 4, red}, {20, 4, red}, {20, 12, red}, // image rows are top down
+		{4, 12, blue}, {15, 15, blue},
+	} {
+		if got := img.RGBAAt(p.x, p.y); got != p.want {
+			t.Errorf("pixel (%d, %d) = %v, want %v", p.x, p.y, got, p.want)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package sgl
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// only need this once in the package
+var paletteProgram *Program
+
+// called to create and build the indexed texture program.
+func initPaletteProgram() error {
+	paletteProgram = NewProgram()
+	paletteProgram.AddShader(VertexShader, quadVertexShader,
+		[]string{"projection", "model"},
+		Attribute{Name: "vertex", Type: gl.FLOAT, Size: 4, Stride: 4 * SizeOfFloat, Offset: 0})
+	paletteProgram.AddShader(FragmentShader, paletteFragmentShader,
+		[]string{"indices", "paletteFrom", "paletteTo", "mixAmount", "cycleStart", "cycleLength", "cycleOffset"})
+
+	if err := paletteProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build palette program: %w", err)
+	}
+	var from, to, indices int32 = 1, 2, 0
+	paletteProgram.Fragment().SetInt("indices", 1, &indices)
+	paletteProgram.Fragment().SetInt("paletteFrom", 1, &from)
+	paletteProgram.Fragment().SetInt("paletteTo", 1, &to)
+	return nil
+}
+
+// IndexedTexture is a texture of 8 bit palette indices (format R8), whose
+// colors are looked up in a Palette when drawn.
+type IndexedTexture struct {
+	ID            uint32
+	Width, Height int32
+}
+
+// NewIndexedTexture creates a texture from the indices of img. The image's
+// palette is not used; see NewPalette().
+func NewIndexedTexture(img *image.Paletted) (*IndexedTexture, error) {
+	tex := &IndexedTexture{
+		Width:  int32(img.Bounds().Dx()),
+		Height: int32(img.Bounds().Dy()),
+	}
+
+	gl.GenTextures(1, &tex.ID)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, tex.ID)
+	// indices can't be interpolated
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.R8, tex.Width, tex.Height, 0, gl.RED, gl.UNSIGNED_BYTE, nil)
+	trackTexture(tex.ID, int(tex.Width*tex.Height))
+	tex.Reload(img)
+
+	return tex, nil
+}
+
+// Reload replaces the indices with those of img, which must be the same size.
+func (tex *IndexedTexture) Reload(img *image.Paletted) {
+	restore := unpackRows(img.Stride, 1)
+	gl.BindTexture(gl.TEXTURE_2D, tex.ID)
+	gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, tex.Width, tex.Height, gl.RED, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	restore()
+}
+
+// Delete the texture.
+func (tex *IndexedTexture) Delete() {
+	trackTexture(tex.ID, 0)
+	gl.DeleteTextures(1, &tex.ID)
+}
+
+// Palette is a lookup table of up to 256 colors, stored as a 256x1 texture.
+type Palette struct {
+	ID     uint32
+	Colors [256]color.NRGBA
+}
+
+// NewPalette creates a palette from colors. Unused entries are transparent.
+func NewPalette(colors color.Palette) *Palette {
+	pal := &Palette{}
+	gl.GenTextures(1, &pal.ID)
+	gl.BindTexture(gl.TEXTURE_2D, pal.ID)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, 256, 1, 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	trackTexture(pal.ID, 256*4)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	pal.Set(colors)
+	return pal
+}
+
+// Set replaces the colors in the palette.
+func (pal *Palette) Set(colors color.Palette) {
+	for i := range pal.Colors {
+		pal.Colors[i] = color.NRGBA{}
+		if i < len(colors) {
+			pal.Colors[i] = color.NRGBAModel.Convert(colors[i]).(color.NRGBA)
+		}
+	}
+	pal.Upload()
+}
+
+// Upload sends Colors to the texture, after they've been changed directly.
+func (pal *Palette) Upload() {
+	gl.BindTexture(gl.TEXTURE_2D, pal.ID)
+	gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, 256, 1, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(&pal.Colors[0]))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+// Delete the texture.
+func (pal *Palette) Delete() {
+	trackTexture(pal.ID, 0)
+	gl.DeleteTextures(1, &pal.ID)
+}
+
+// PaletteState controls how an IndexedTexture's colors are resolved. Colors
+// are blended from From to To by Mix, so a palette swap can be animated (see
+// AnimatePaletteSwap()). The CycleLength entries starting at CycleStart are
+// rotated by CycleOffset, for classic color cycling effects.
+type PaletteState struct {
+	From, To    *Palette // To may be nil if Mix is 0
+	Mix         float32  // 0 is From, 1 is To
+	CycleStart  int32
+	CycleLength int32
+	CycleOffset int32
+}
+
+// AnimatePaletteSwap animates state from its current palette to the "to"
+// palette over durationSec seconds. When complete, state.From is "to" and
+// state.Mix is 0. onComplete is optional.
+func AnimatePaletteSwap(an *Animator, name string, state *PaletteState, to *Palette, durationSec float32, onComplete ...func()) {
+	state.To = to
+	finish := func() {
+		state.From, state.To, state.Mix = to, nil, 0
+	}
+	AnimateValue(an, name, &state.Mix, durationSec, 0, 1, append([]func(){finish}, onComplete...)...)
+}
+
+// DrawIndexed draws the texture at (x, y) with size (w, h), in pixels with
+// (0, 0) at the top left of a screen of size (screenW, screenH).
+func DrawIndexed(tex *IndexedTexture, state *PaletteState, x, y, w, h, screenW, screenH float32) error {
+	if paletteProgram == nil {
+		if err := initPaletteProgram(); err != nil {
+			return err
+		}
+	}
+
+	to := state.To
+	if to == nil {
+		to = state.From
+	}
+	projection, model := quadTransform(x, y, w, h, screenW, screenH)
+
+	paletteProgram.Use()
+	paletteProgram.Vertex().SetMat4("projection", 1, &projection)
+	paletteProgram.Vertex().SetMat4("model", 1, &model)
+	paletteProgram.Fragment().SetFloat("mixAmount", 1, &state.Mix)
+	paletteProgram.Fragment().SetInt("cycleStart", 1, &state.CycleStart)
+	paletteProgram.Fragment().SetInt("cycleLength", 1, &state.CycleLength)
+	paletteProgram.Fragment().SetInt("cycleOffset", 1, &state.CycleOffset)
+
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, tex.ID)
+	gl.ActiveTexture(gl.TEXTURE1)
+	gl.BindTexture(gl.TEXTURE_2D, state.From.ID)
+	gl.ActiveTexture(gl.TEXTURE2)
+	gl.BindTexture(gl.TEXTURE_2D, to.ID)
+	gl.ActiveTexture(gl.TEXTURE0)
+
+	drawQuad()
+	return nil
+}
+
+// PalettedImage converts a paletted image's colors to a Palette, for
+// convenience with images decoded from gif or 8 bit png files.
+func PalettedImage(img *image.Paletted) (*IndexedTexture, *Palette, error) {
+	tex, err := NewIndexedTexture(img)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tex, NewPalette(img.Palette), nil
+}
+
+const paletteFragmentShader = `#version 330 core
+uniform sampler2D indices;
+uniform sampler2D paletteFrom;
+uniform sampler2D paletteTo;
+uniform float mixAmount;
+uniform int cycleStart;
+uniform int cycleLength;
+uniform int cycleOffset;
+
+in vec2 TexCoords;
+
+out vec4 FragColor;
+
+void main()
+{
+    int index = int(texture(indices, TexCoords).r * 255.0 + 0.5);
+    if (cycleLength > 0 && index >= cycleStart && index < cycleStart + cycleLength) {
+        int shifted = (index - cycleStart + cycleOffset) % cycleLength;
+        index = cycleStart + (shifted < 0 ? shifted + cycleLength : shifted);
+    }
+    vec4 from = texelFetch(paletteFrom, ivec2(index, 0), 0);
+    vec4 to = texelFetch(paletteTo, ivec2(index, 0), 0);
+    FragColor = mix(from, to, mixAmount);
+}`
@@ -0,0 +1,198 @@
+package sgl
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// number of frames of gpu timer queries kept in flight per scope, so reading
+// results never stalls the pipeline.
+const gpuQueryFrames = 3
+
+// Profiler measures named CPU and GPU scopes each frame, and acts as a
+// watchdog that reports frames taking longer than Budget.
+type Profiler struct {
+	Budget float64           // Frame time (seconds) above which a frame is reported. 0 disables reports.
+	Hook   func(FrameReport) // Called for frames over budget. If nil, the report is logged.
+
+	frame  uint64
+	scopes map[string]*profileScope
+	order  []string // scope names in order of first use
+}
+
+// FrameReport has the timing of a single frame and its profiled scopes.
+type FrameReport struct {
+	Frame     uint64
+	FrameTime float64 // seconds
+	Budget    float64 // seconds
+	Scopes    []ScopeTime
+}
+
+// ScopeTime is the time spent in a profiled scope. GPU time lags a few
+// frames behind CPU time since gpu timer results aren't read until available.
+type ScopeTime struct {
+	Name string
+	CPU  float64 // seconds
+	GPU  float64 // seconds, 0 if the scope has no gpu measurements
+}
+
+func (r FrameReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "frame %d took %.2fms (budget %.2fms)", r.Frame, r.FrameTime*1000, r.Budget*1000)
+	for _, s := range r.Scopes {
+		fmt.Fprintf(&b, "\n  %-20s cpu %7.3fms", s.Name, s.CPU*1000)
+		if s.GPU > 0 {
+			fmt.Fprintf(&b, "  gpu %7.3fms", s.GPU*1000)
+		}
+	}
+	return b.String()
+}
+
+type profileScope struct {
+	name     string
+	cpuStart time.Time
+	cpu      float64 // accumulated during the current frame
+	lastCPU  float64 // total from the previous frame
+
+	queries   [gpuQueryFrames]uint32
+	issued    [gpuQueryFrames]bool
+	gpu       float64 // most recent result
+	gpuActive bool
+}
+
+// NewProfiler creates a profiler with the given frame budget (seconds).
+func NewProfiler(budget float64) *Profiler {
+	return &Profiler{
+		Budget: budget,
+		scopes: make(map[string]*profileScope),
+	}
+}
+
+func (p *Profiler) scope(name string) *profileScope {
+	s, ok := p.scopes[name]
+	if !ok {
+		s = &profileScope{name: name}
+		p.scopes[name] = s
+		p.order = append(p.order, name)
+	}
+	return s
+}
+
+// Begin starts timing the CPU scope. Scopes may be entered multiple times
+// per frame, and their times are summed.
+func (p *Profiler) Begin(name string) {
+	p.scope(name).cpuStart = time.Now()
+}
+
+// End stops timing the CPU scope.
+func (p *Profiler) End(name string) {
+	s := p.scope(name)
+	s.cpu += time.Since(s.cpuStart).Seconds()
+}
+
+// Scope begins a CPU scope and returns the func that ends it. Example:
+//
+//	defer profiler.Scope("physics")()
+func (p *Profiler) Scope(name string) func() {
+	p.Begin(name)
+	return func() { p.End(name) }
+}
+
+// BeginGPU starts timing the GPU scope with a timer query. GPU scopes
+// can't be nested or overlap, and should be used at most once per frame.
+func (p *Profiler) BeginGPU(name string) {
+	s := p.scope(name)
+	i := p.frame % gpuQueryFrames
+	if s.queries[i] == 0 {
+		gl.GenQueries(int32(len(s.queries)), &s.queries[0])
+	}
+	if s.issued[i] {
+		// query from gpuQueryFrames ago was never read; read it now
+		s.readQuery(int(i), true)
+	}
+	gl.BeginQuery(gl.TIME_ELAPSED, s.queries[i])
+	s.issued[i] = true
+	s.gpuActive = true
+}
+
+// EndGPU stops timing the GPU scope.
+func (p *Profiler) EndGPU(name string) {
+	if s := p.scope(name); s.gpuActive {
+		gl.EndQuery(gl.TIME_ELAPSED)
+		s.gpuActive = false
+	}
+}
+
+// readQuery reads the result of query i, if available or if wait is true.
+func (s *profileScope) readQuery(i int, wait bool) {
+	if !wait {
+		var available int32
+		gl.GetQueryObjectiv(s.queries[i], gl.QUERY_RESULT_AVAILABLE, &available)
+		if available == gl.FALSE {
+			return
+		}
+	}
+	var ns uint64
+	gl.GetQueryObjectui64v(s.queries[i], gl.QUERY_RESULT, &ns)
+	s.gpu = float64(ns) / 1e9
+	s.issued[i] = false
+}
+
+// Times gets the times of each scope during the previous frame, in the order
+// the scopes were first used.
+func (p *Profiler) Times() []ScopeTime {
+	times := make([]ScopeTime, 0, len(p.order))
+	for _, name := range p.order {
+		s := p.scopes[name]
+		times = append(times, ScopeTime{Name: name, CPU: s.lastCPU, GPU: s.gpu})
+	}
+	return times
+}
+
+// EndFrame finishes the frame, collecting gpu results and reporting if the
+// frame took longer than the budget. Window.BeginFrame() calls this when the
+// window has a Profiler.
+func (p *Profiler) EndFrame(frameTime float64) {
+	for _, s := range p.scopes {
+		// read oldest results first so the newest available one is kept
+		for k := 1; k <= gpuQueryFrames; k++ {
+			i := int((p.frame + uint64(k)) % gpuQueryFrames)
+			if s.issued[i] {
+				s.readQuery(i, false)
+			}
+		}
+		s.lastCPU, s.cpu = s.cpu, 0
+	}
+
+	if p.Budget > 0 && frameTime > p.Budget {
+		report := FrameReport{
+			Frame:     p.frame,
+			FrameTime: frameTime,
+			Budget:    p.Budget,
+			Scopes:    p.Times(),
+		}
+		sort.SliceStable(report.Scopes, func(i, j int) bool {
+			return report.Scopes[i].CPU > report.Scopes[j].CPU
+		})
+		if p.Hook != nil {
+			p.Hook(report)
+		} else {
+			log.Println(report)
+		}
+	}
+	p.frame++
+}
+
+// Delete releases the gpu timer queries.
+func (p *Profiler) Delete() {
+	for _, s := range p.scopes {
+		if s.queries[0] != 0 {
+			gl.DeleteQueries(int32(len(s.queries)), &s.queries[0])
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package sgl
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"sync"
+
+	"github.com/inkyblackness/imgui-go/v4"
+)
+
+// LoadProgress tracks the progress of loading assets in the background.
+// Loaders call Add() for work they expect and Finish() as each item
+// completes. It is safe to use from multiple goroutines.
+type LoadProgress struct {
+	mu      sync.Mutex
+	total   int
+	done    int
+	current string // name of the most recently finished item
+	errs    []error
+}
+
+// Add expects n more items to be loaded.
+func (p *LoadProgress) Add(n int) {
+	p.mu.Lock()
+	p.total += n
+	p.mu.Unlock()
+}
+
+// Finish records that the named item has finished loading, with an error
+// if it failed.
+func (p *LoadProgress) Finish(name string, err error) {
+	p.mu.Lock()
+	p.done++
+	p.current = name
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("%s: %w", name, err))
+	}
+	p.mu.Unlock()
+}
+
+// Go runs load in a new goroutine as a single item of work. load must not
+// make OpenGL calls.
+func (p *LoadProgress) Go(name string, load func() error) {
+	p.Add(1)
+	go func() {
+		p.Finish(name, load())
+	}()
+}
+
+// Status gets the number of items finished, the number expected, and the name
+// of the most recently finished item.
+func (p *LoadProgress) Status() (done, total int, current string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.done, p.total, p.current
+}
+
+// Fraction gets the fraction (0 to 1) of items finished.
+func (p *LoadProgress) Fraction() float32 {
+	done, total, _ := p.Status()
+	if total == 0 {
+		return 1
+	}
+	return float32(done) / float32(total)
+}
+
+// Complete returns true when every expected item has finished.
+func (p *LoadProgress) Complete() bool {
+	done, total, _ := p.Status()
+	return done >= total
+}
+
+// Err gets an error describing every item that failed, or nil.
+func (p *LoadProgress) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch len(p.errs) {
+	case 0:
+		return nil
+	case 1:
+		return p.errs[0]
+	}
+	msgs := make([]string, len(p.errs))
+	for i, err := range p.errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Errorf("%d items failed to load: %s", len(p.errs), strings.Join(msgs, "; "))
+}
+
+// OpenImagesAsync is like OpenImages(), but opens the images in the
+// background, reporting to p. The returned slice is filled in as images are
+// opened, and may be used once p is Complete(). Images that fail to open are
+// left nil.
+func OpenImagesAsync(p *LoadProgress, filenames ...string) []*image.RGBA {
+	images := make([]*image.RGBA, len(filenames))
+	for i, file := range filenames {
+		i, file := i, file
+		p.Go(file, func() error {
+			img, err := OpenImages(file)
+			if err != nil {
+				return err
+			}
+			images[i] = img[0]
+			return nil
+		})
+	}
+	return images
+}
+
+// ProgressGui draws a small imgui window in the center of the screen with a
+// spinner, progress bar, and the name of the last item loaded.
+func ProgressGui(title string, p *LoadProgress) {
+	done, total, current := p.Status()
+	spinner := `|/-\`[int(imgui.Time()*8)%4]
+
+	viewport := imgui.MainViewport()
+	imgui.SetNextWindowPosV(viewport.WorkCenter(), imgui.ConditionAlways, imgui.Vec2{X: 0.5, Y: 0.5})
+	imgui.SetNextWindowSizeV(imgui.Vec2{X: 300, Y: 0}, imgui.ConditionAlways)
+	flags := imgui.WindowFlagsNoDecoration | imgui.WindowFlagsNoSavedSettings | imgui.WindowFlagsNoMove
+	if imgui.BeginV(title+"##progress", nil, flags) {
+		imgui.Text(fmt.Sprintf("%s %c", title, spinner))
+		imgui.ProgressBarV(p.Fraction(), imgui.Vec2{X: -1, Y: 0}, fmt.Sprintf("%d / %d", done, total))
+		imgui.Text(current)
+	}
+	imgui.End()
+}
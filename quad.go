@@ -0,0 +1,64 @@
+package sgl
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// quadVertices is a unit square as a triangle strip, with texture coords.
+// (0, 0) is the top left, for use with a y-down orthographic projection.
+var quadVertices = []float32{
+	// pos(x,y), tex(u,v)
+	0, 0, 0, 0,
+	0, 1, 0, 1,
+	1, 0, 1, 0,
+	1, 1, 1, 1,
+}
+
+// quadVao is shared by the stock 2D shaders. Each shader must have the
+// "vertex" attribute at location 0.
+var quadVao uint32
+
+// drawQuad draws the unit quad with the current program.
+func drawQuad() {
+	if quadVao == 0 {
+		var vbo uint32
+		gl.GenVertexArrays(1, &quadVao)
+		gl.BindVertexArray(quadVao)
+		gl.GenBuffers(1, &vbo)
+		gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+		gl.BufferData(gl.ARRAY_BUFFER, len(quadVertices)*SizeOfFloat, gl.Ptr(quadVertices), gl.STATIC_DRAW)
+		gl.VertexAttribPointer(0, 4, gl.FLOAT, false, 4*SizeOfFloat, gl.PtrOffset(0))
+		gl.EnableVertexAttribArray(0)
+		gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	} else {
+		gl.BindVertexArray(quadVao)
+	}
+	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+	countDraw(gl.TRIANGLE_STRIP, 4)
+	gl.BindVertexArray(0)
+}
+
+// quadTransform gets the projection and model matrices placing the unit quad
+// at (x, y) with size (w, h), in pixels with (0, 0) at the top left of a
+// screen of the given size.
+func quadTransform(x, y, w, h, screenW, screenH float32) (projection, model mgl32.Mat4) {
+	projection = mgl32.Ortho2D(0, screenW, screenH, 0)
+	model = mgl32.Translate3D(x, y, 0).Mul4(mgl32.Scale3D(w, h, 1))
+	return
+}
+
+// quadVertexShader is shared by the stock 2D shaders.
+const quadVertexShader = `#version 330 core
+layout (location = 0) in vec4 vertex; // <vec2 pos, vec2 tex>
+
+uniform mat4 projection;
+uniform mat4 model;
+
+out vec2 TexCoords;
+
+void main()
+{
+    gl_Position = projection * model * vec4(vertex.xy, 0.0, 1.0);
+    TexCoords = vertex.zw;
+}`
@@ -0,0 +1,126 @@
+package sgl
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Halton gets the index-th number of the Halton sequence in base, from 0
+// to 1. With prime bases, consecutive numbers are spread evenly, so they
+// suit sample positions better than random numbers.
+func Halton(index, base int) float32 {
+	var result float32
+	f := float32(1)
+	for i := index; i > 0; i /= base {
+		f /= float32(base)
+		result += f * float32(i%base)
+	}
+	return result
+}
+
+// Halton2D gets the index-th point of the 2D Halton sequence, with bases 2
+// and 3, from 0 to 1.
+func Halton2D(index int) mgl32.Vec2 {
+	return mgl32.Vec2{Halton(index, 2), Halton(index, 3)}
+}
+
+// the plastic number, and the R2 sequence's steps from it.
+const (
+	plastic = 1.32471795724474602596
+	r2StepX = 1 / plastic
+	r2StepY = 1 / (plastic * plastic)
+)
+
+// R2 gets the index-th point of Roberts' R2 sequence, from 0 to 1. It's
+// spread more evenly than Halton2D() for any number of points, so it suits
+// sampling without a fixed sample count.
+func R2(index int) mgl32.Vec2 {
+	x := math.Mod(0.5+r2StepX*float64(index), 1)
+	y := math.Mod(0.5+r2StepY*float64(index), 1)
+	return mgl32.Vec2{float32(x), float32(y)}
+}
+
+// FrameJitter gets a sub-pixel offset, from -0.5 to 0.5, for frame, cycling
+// through samples points of Halton2D(), such as for TAA. (0, 0) is skipped.
+func FrameJitter(frame uint64, samples int) mgl32.Vec2 {
+	if samples < 1 {
+		samples = 1
+	}
+	p := Halton2D(int(frame%uint64(samples)) + 1)
+	return p.Sub(mgl32.Vec2{0.5, 0.5})
+}
+
+// RandomSeed is the seed of the RNGs from FrameRNG(). Changing it gives a
+// different, but still repeatable, run.
+var RandomSeed uint64 = 1
+
+// FrameRNG gets an RNG for frame, such as Clock.TotalFrames, which gives
+// the same numbers for the same frame and RandomSeed, so effects are
+// repeatable, such as when replaying input or comparing captures.
+func FrameRNG(frame uint64) *RNG {
+	return NewRNG(RandomSeed ^ splitMix(&frame))
+}
+
+// RNG is a small, fast pseudo-random number generator (splitmix64). Unlike
+// math/rand, its sequence for a seed is fixed, so it's safe to depend on.
+// It isn't safe for concurrent use.
+type RNG struct {
+	state uint64
+}
+
+// NewRNG creates an RNG with seed.
+func NewRNG(seed uint64) *RNG { return &RNG{state: seed} }
+
+// splitMix advances state and gets the next number from it.
+func splitMix(state *uint64) uint64 {
+	*state += 0x9e3779b97f4a7c15
+	z := *state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Uint64 gets a random number.
+func (r *RNG) Uint64() uint64 { return splitMix(&r.state) }
+
+// Intn gets a random number from 0 to n-1. n must be greater than 0.
+func (r *RNG) Intn(n int) int { return int(r.Uint64() % uint64(n)) }
+
+// Float32 gets a random number from 0 up to 1.
+func (r *RNG) Float32() float32 { return float32(r.Uint64()>>40) / (1 << 24) }
+
+// Float64 gets a random number from 0 up to 1.
+func (r *RNG) Float64() float64 { return float64(r.Uint64()>>11) / (1 << 53) }
+
+// Perm gets a random permutation of the numbers 0 to n-1.
+func (r *RNG) Perm(n int) []int {
+	p := make([]int, n)
+	for i := range p {
+		j := r.Intn(i + 1)
+		p[i], p[j] = p[j], i
+	}
+	return p
+}
+
+// Range gets a random number from min up to max.
+func (r *RNG) Range(min, max float32) float32 { return min + r.Float32()*(max-min) }
+
+// Vec2 gets a random point from 0 up to 1.
+func (r *RNG) Vec2() mgl32.Vec2 { return mgl32.Vec2{r.Float32(), r.Float32()} }
+
+// InUnitDisk gets a random point evenly distributed in the circle of
+// radius 1, such as for depth of field or soft shadow samples.
+func (r *RNG) InUnitDisk() mgl32.Vec2 {
+	angle := r.Float64() * 2 * math.Pi
+	radius := math.Sqrt(r.Float64())
+	return mgl32.Vec2{float32(radius * math.Cos(angle)), float32(radius * math.Sin(angle))}
+}
+
+// UnitVec3 gets a random direction, evenly distributed over the sphere.
+func (r *RNG) UnitVec3() mgl32.Vec3 {
+	z := 2*r.Float64() - 1
+	angle := r.Float64() * 2 * math.Pi
+	radius := math.Sqrt(1 - z*z)
+	return mgl32.Vec3{float32(radius * math.Cos(angle)), float32(radius * math.Sin(angle)), float32(z)}
+}
@@ -0,0 +1,102 @@
+package sgl
+
+import (
+	"image"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// asyncRead is a texture being read into a pixel buffer.
+type asyncRead struct {
+	pbo      uint32
+	fence    *Fence
+	img      *image.RGBA
+	callback func(*image.RGBA)
+}
+
+// reads started by ReadImageAsync(), oldest first.
+var asyncReads []*asyncRead
+
+// ReadImageAsync starts reading the texture into a Go image without waiting
+// for the GPU, like the frames read by Window.CaptureEveryNthFrame(). The
+// image is passed to callback, on the render thread, once the GPU is done,
+// usually a frame or two later. Window.EndFrame() checks for finished reads;
+// without a Window, call PollAsyncReads() each frame.
+func (tex *Texture2D) ReadImageAsync(callback func(img *image.RGBA)) {
+	read := &asyncRead{
+		img:      image.NewRGBA(image.Rect(0, 0, int(tex.Width), int(tex.Height))),
+		callback: callback,
+	}
+	gl.GenBuffers(1, &read.pbo)
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, read.pbo)
+	gl.BufferData(gl.PIXEL_PACK_BUFFER, len(read.img.Pix), nil, gl.STREAM_READ)
+	gl.BindTexture(gl.TEXTURE_2D, tex.ID)
+	gl.GetTexImage(gl.TEXTURE_2D, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.PtrOffset(0))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+	read.fence = NewFence()
+
+	asyncReads = append(asyncReads, read)
+}
+
+// readBufferAsync starts reading the region of the default framebuffer's
+// buffer, such as gl.BACK, with (0, 0) at the bottom left, like
+// ReadImageAsync(). The image passed to callback has (0, 0) at the top left.
+// The bound read framebuffer and its read buffer are left as they were.
+func readBufferAsync(buffer uint32, region image.Rectangle, callback func(img *image.RGBA)) {
+	read := &asyncRead{
+		img:      image.NewRGBA(image.Rect(0, 0, region.Dx(), region.Dy())),
+		callback: callback,
+	}
+	gl.GenBuffers(1, &read.pbo)
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, read.pbo)
+	gl.BufferData(gl.PIXEL_PACK_BUFFER, len(read.img.Pix), nil, gl.STREAM_READ)
+	var readFbo, readBuffer int32
+	gl.GetIntegerv(gl.READ_FRAMEBUFFER_BINDING, &readFbo)
+	gl.GetIntegerv(gl.READ_BUFFER, &readBuffer)
+	backend.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	gl.ReadBuffer(buffer)
+	gl.ReadPixels(int32(region.Min.X), int32(region.Min.Y), int32(region.Dx()), int32(region.Dy()),
+		gl.RGBA, gl.UNSIGNED_BYTE, gl.PtrOffset(0))
+	backend.BindFramebuffer(gl.READ_FRAMEBUFFER, uint32(readFbo))
+	gl.ReadBuffer(uint32(readBuffer))
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+	read.fence = NewFence()
+
+	asyncReads = append(asyncReads, read)
+}
+
+// PollAsyncReads calls the callbacks of reads started by ReadImageAsync()
+// which the GPU has finished, and returns the number still pending.
+func PollAsyncReads() int {
+	// callbacks may start new reads, so they go into a fresh list
+	reads := asyncReads
+	asyncReads = nil
+	var pending []*asyncRead
+	for _, read := range reads {
+		if !read.fence.Done() {
+			pending = append(pending, read)
+			continue
+		}
+		read.finish()
+	}
+	asyncReads = append(pending, asyncReads...)
+	return len(asyncReads)
+}
+
+// finish copies the pixels from the pbo, releases it, and calls the callback.
+func (read *asyncRead) finish() {
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, read.pbo)
+	ptr := gl.MapBufferRange(gl.PIXEL_PACK_BUFFER, 0, len(read.img.Pix), gl.MAP_READ_BIT)
+	if ptr != nil {
+		copy(read.img.Pix, unsafe.Slice((*byte)(ptr), len(read.img.Pix)))
+		gl.UnmapBuffer(gl.PIXEL_PACK_BUFFER)
+	}
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+	gl.DeleteBuffers(1, &read.pbo)
+	read.fence.Delete()
+
+	FlipVertical(read.img)
+	read.callback(read.img)
+}
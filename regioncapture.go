@@ -0,0 +1,215 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// RegionCapture saves a rectangle of the screen chosen by the user to a png
+// file. Once started, such as by its Chord(), dragging with the left mouse
+// button selects the rectangle, and releasing saves it. Escape cancels.
+// The frame is read asynchronously and encoded on another goroutine, so
+// rendering isn't stalled.
+//
+// Draw() should be called last each frame, after imgui, to show the
+// selection, and so the selection isn't in the capture:
+//
+//	chords := ChordSet{region.Chord(glfw.KeyLeftControl, glfw.KeyF12)}
+//	...
+//	platform.RenderImgui(gui)
+//	region.Draw(width, height)
+//	platform.EndFrame()
+type RegionCapture struct {
+	Dir   string // where files are saved
+	Color Color  // of the selection's outline
+	Shade Color  // over the screen outside the selection
+
+	// Optional function called with the path of each file saved, or the
+	// error saving it. It's called from another goroutine.
+	OnSaved func(path string, err error)
+
+	platform   *Window
+	shapes     *ShapeBatch
+	active     bool
+	dragging   bool
+	start, end mgl32.Vec2       // of the selection, in screen coordinates
+	pending    *image.Rectangle // to read in the next Draw(), in framebuffer pixels
+	deleted    bool
+}
+
+// NewRegionCapture creates a region capture for platform, saving into dir.
+func NewRegionCapture(platform *Window, dir string) (*RegionCapture, error) {
+	shapes, err := NewShapeBatch()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create region capture: %w", err)
+	}
+	rc := &RegionCapture{
+		Dir:      dir,
+		Color:    Color{1, 1, 1, 1},
+		Shade:    Color{0, 0, 0, 0.4},
+		platform: platform,
+		shapes:   shapes,
+	}
+	platform.AddMouseButtonCallback(rc.mouseButton)
+	platform.AddKeyCallback(rc.key)
+	return rc, nil
+}
+
+// Chord makes a chord that starts selecting a region when keys are pressed.
+func (rc *RegionCapture) Chord(keys ...glfw.Key) Chord {
+	return Chord{
+		Name:    "capture region",
+		Keys:    keys,
+		Wait:    0.5,
+		Execute: rc.Start,
+	}
+}
+
+// Start shows the selection overlay, waiting for the user to drag.
+func (rc *RegionCapture) Start() {
+	if rc.active || rc.deleted {
+		return
+	}
+	rc.active, rc.dragging = true, false
+	rc.platform.SetCursorShape(CursorCrosshair)
+}
+
+// Cancel stops selecting without saving.
+func (rc *RegionCapture) Cancel() {
+	if !rc.active {
+		return
+	}
+	rc.active, rc.dragging = false, false
+	rc.platform.SetCursorShape(CursorArrow)
+}
+
+// Active returns true while a region is being selected.
+func (rc *RegionCapture) Active() bool { return rc.active }
+
+// selection gets the selected rectangle, in screen coordinates.
+func (rc *RegionCapture) selection() (min, max mgl32.Vec2) {
+	min = mgl32.Vec2{minFloat(rc.start[0], rc.end[0]), minFloat(rc.start[1], rc.end[1])}
+	max = mgl32.Vec2{maxFloat(rc.start[0], rc.end[0]), maxFloat(rc.start[1], rc.end[1])}
+	return
+}
+
+// cursor gets the cursor position in screen coordinates.
+func (rc *RegionCapture) cursor() mgl32.Vec2 {
+	x, y := rc.platform.GlfwWindow.GetCursorPos()
+	return mgl32.Vec2{float32(x), float32(y)}
+}
+
+// mouseButton is the window's mouse button callback.
+func (rc *RegionCapture) mouseButton(_ *glfw.Window, button glfw.MouseButton, action glfw.Action, _ glfw.ModifierKey) {
+	if !rc.active || button != glfw.MouseButtonLeft {
+		return
+	}
+	switch {
+	case action == glfw.Press:
+		rc.start, rc.end = rc.cursor(), rc.cursor()
+		rc.dragging = true
+	case action == glfw.Release && rc.dragging:
+		rc.end = rc.cursor()
+		rc.Cancel()
+		min, max := rc.selection()
+		scale := rc.platform.FramebufferScale()
+		_, fbHeight := rc.platform.GlfwWindow.GetFramebufferSize()
+		// opengl's origin is the bottom left
+		region := image.Rect(
+			int(min[0]*scale[0]), fbHeight-int(max[1]*scale[1]),
+			int(max[0]*scale[0]), fbHeight-int(min[1]*scale[1]))
+		if !region.Empty() {
+			rc.pending = &region
+		}
+	}
+}
+
+// key is the window's key callback.
+func (rc *RegionCapture) key(_ *glfw.Window, key glfw.Key, _ int, action glfw.Action, _ glfw.ModifierKey) {
+	if key == glfw.KeyEscape && action == glfw.Press {
+		rc.Cancel()
+	}
+}
+
+// Draw the selection overlay, in pixels with (0, 0) at the top left of a
+// screen of size (width, height), which should be the window's screen
+// coordinates. A region selected since the last Draw() is read from the back
+// buffer first, so it has what was drawn this frame without the overlay.
+// Blending should be enabled.
+func (rc *RegionCapture) Draw(width, height float32) {
+	if rc.pending != nil {
+		fbWidth, fbHeight := rc.platform.GlfwWindow.GetFramebufferSize()
+		region := rc.pending.Intersect(image.Rect(0, 0, fbWidth, fbHeight))
+		rc.pending = nil
+		if !region.Empty() {
+			readBufferAsync(gl.BACK, region, rc.save)
+		}
+	}
+	if !rc.active {
+		return
+	}
+
+	rc.shapes.Clear()
+	if !rc.dragging {
+		rc.shapes.RoundedRect(mgl32.Vec2{0, 0}, mgl32.Vec2{width, height}, 0, rc.Shade)
+	} else {
+		rc.end = rc.cursor()
+		min, max := rc.selection()
+		// shade around the selection
+		rc.shapes.RoundedRect(mgl32.Vec2{0, 0}, mgl32.Vec2{width, min[1]}, 0, rc.Shade)
+		rc.shapes.RoundedRect(mgl32.Vec2{0, max[1]}, mgl32.Vec2{width, height}, 0, rc.Shade)
+		rc.shapes.RoundedRect(mgl32.Vec2{0, min[1]}, mgl32.Vec2{min[0], max[1]}, 0, rc.Shade)
+		rc.shapes.RoundedRect(mgl32.Vec2{max[0], min[1]}, mgl32.Vec2{width, max[1]}, 0, rc.Shade)
+		rc.shapes.RoundedRectOutline(min, max, 0, 1, rc.Color)
+	}
+	rc.shapes.Draw(width, height)
+}
+
+// save encodes img to a new file on another goroutine.
+func (rc *RegionCapture) save(img *image.RGBA) {
+	path := filepath.Join(rc.Dir, fmt.Sprintf("region-%s.png", time.Now().Format("20060102-150405.000")))
+	onSaved := rc.OnSaved
+	go func() {
+		err := savePNG(path, img)
+		if onSaved != nil {
+			onSaved(path, err)
+		} else if err != nil {
+			log.Printf("sgl: capture region: %v", err)
+		}
+	}()
+}
+
+// savePNG writes img to a png file at path, creating its directory.
+func savePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create capture dir: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(file, img); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// Delete resources. The capture stops taking input.
+func (rc *RegionCapture) Delete() {
+	rc.Cancel()
+	rc.deleted = true
+	rc.shapes.Delete()
+}
@@ -0,0 +1,67 @@
+package sgl
+
+import "github.com/go-gl/gl/v3.3-core/gl"
+
+// RenderState is the fixed function state which drawing commonly changes:
+// blending, the depth and scissor tests, the stencil test, and which
+// buffers are written. PushRenderState() sets it, and PopRenderState() puts
+// back what was in effect before, so changes can be nested without knowing
+// about each other. Start from CurrentRenderState() to change only some of
+// it.
+type RenderState struct {
+	Blend       bool
+	DepthTest   bool
+	DepthWrite  bool
+	ColorWrite  [4]bool // red, green, blue, alpha
+	ScissorTest bool
+	Scissor     [4]int32 // x, y, width, height, used if ScissorTest
+	StencilTest bool
+	Stencil     Stencil // used if StencilTest
+}
+
+// CurrentRenderState gets the state in effect from opengl.
+func CurrentRenderState() (s RenderState) {
+	s.Blend = backend.IsEnabled(gl.BLEND)
+	s.DepthTest = backend.IsEnabled(gl.DEPTH_TEST)
+	backend.GetBooleanv(gl.DEPTH_WRITEMASK, &s.DepthWrite)
+	backend.GetBooleanv(gl.COLOR_WRITEMASK, &s.ColorWrite[0])
+	s.ScissorTest = backend.IsEnabled(gl.SCISSOR_TEST)
+	backend.GetIntegerv(gl.SCISSOR_BOX, &s.Scissor[0])
+	s.StencilTest = backend.IsEnabled(gl.STENCIL_TEST)
+	s.Stencil = currentStencil()
+	return
+}
+
+// apply sets the state in opengl.
+func (s RenderState) apply() {
+	setEnabled(gl.BLEND, s.Blend)
+	setEnabled(gl.DEPTH_TEST, s.DepthTest)
+	backend.DepthMask(s.DepthWrite)
+	backend.ColorMask(s.ColorWrite[0], s.ColorWrite[1], s.ColorWrite[2], s.ColorWrite[3])
+	setEnabled(gl.SCISSOR_TEST, s.ScissorTest)
+	backend.Scissor(s.Scissor[0], s.Scissor[1], s.Scissor[2], s.Scissor[3])
+	setEnabled(gl.STENCIL_TEST, s.StencilTest)
+	s.Stencil.apply()
+}
+
+// states replaced by PushRenderState(). opengl state is global, so this is
+// too.
+var renderStateStack []RenderState
+
+// PushRenderState sets s in opengl. Every push must be matched by
+// PopRenderState().
+func PushRenderState(s RenderState) {
+	renderStateStack = append(renderStateStack, CurrentRenderState())
+	s.apply()
+}
+
+// PopRenderState restores the state in effect before the matching
+// PushRenderState().
+func PopRenderState() {
+	if len(renderStateStack) == 0 {
+		return
+	}
+	last := len(renderStateStack) - 1
+	renderStateStack[last].apply()
+	renderStateStack = renderStateStack[:last]
+}
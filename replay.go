@@ -0,0 +1,211 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// InputEventKind is the kind of an InputEvent.
+type InputEventKind int
+
+// Kinds of InputEvent, one for each window callback plus cursor movement.
+const (
+	KeyEvent InputEventKind = iota
+	MouseButtonEvent
+	ScrollEvent
+	CharEvent
+	CursorEvent
+)
+
+// InputEvent is a single input received by the window.
+type InputEvent struct {
+	Frame    uint64 // frame received in, counted from the start of recording
+	Kind     InputEventKind
+	Key      glfw.Key         `json:",omitempty"`
+	Scancode int              `json:",omitempty"`
+	Button   glfw.MouseButton `json:",omitempty"`
+	Action   glfw.Action      `json:",omitempty"`
+	Mods     glfw.ModifierKey `json:",omitempty"`
+	X, Y     float64          `json:",omitempty"` // scroll offset or cursor position
+	Char     rune             `json:",omitempty"`
+}
+
+// InputRecording is the input received by a window over a number of frames,
+// which can be saved and replayed later, such as for reproducible bug
+// reports or automated soak tests. Frames are recorded and replayed with a
+// fixed time step so the app sees exactly the same sequence of input and
+// Clock.DeltaT.
+type InputRecording struct {
+	DeltaT float64 // seconds per frame
+	Frames uint64  // frames recorded
+	Events []InputEvent
+}
+
+// Save writes the recording as json.
+func (rec *InputRecording) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	return enc.Encode(rec)
+}
+
+// LoadInputRecording reads a recording written by Save().
+func LoadInputRecording(r io.Reader) (*InputRecording, error) {
+	var rec InputRecording
+	if err := json.NewDecoder(r).Decode(&rec); err != nil {
+		return nil, fmt.Errorf("couldn't read input recording: %w", err)
+	}
+	return &rec, nil
+}
+
+// inputReplay is the state of recording or replaying input.
+type inputReplay struct {
+	rec         *InputRecording
+	playing     bool
+	startFrame  uint64 // Clock.TotalFrames before the first frame
+	next        int    // index of the next event to replay
+	onDone      func()
+	fixedDeltaT float64 // Clock.FixedDeltaT to restore when done
+
+	cursorX, cursorY float64                   // replayed cursor position
+	buttonsDown      map[glfw.MouseButton]bool // replayed buttons held
+}
+
+// frame gets the frame number relative to the start of recording or replay.
+func (r *inputReplay) frame(clock *Timer) uint64 { return clock.TotalFrames - r.startFrame }
+
+// RecordInput starts recording input, with the Clock fixed at deltaT seconds
+// per frame. Anything which should replay identically must depend only on
+// Clock.DeltaT and input from Input() or the callbacks (not eg Chords, which
+// read the live keyboard), and must start in the same state.
+func (platform *Window) RecordInput(deltaT float64) {
+	platform.replay = &inputReplay{
+		rec:         &InputRecording{DeltaT: deltaT},
+		startFrame:  platform.Clock.TotalFrames,
+		fixedDeltaT: platform.Clock.FixedDeltaT,
+	}
+	platform.Clock.FixedDeltaT = deltaT
+	platform.replay.cursorX, platform.replay.cursorY = platform.GlfwWindow.GetCursorPos()
+	platform.record(InputEvent{Kind: CursorEvent, X: platform.replay.cursorX, Y: platform.replay.cursorY})
+}
+
+// StopRecording stops recording input and returns the recording, or nil if
+// input wasn't being recorded.
+func (platform *Window) StopRecording() *InputRecording {
+	r := platform.replay
+	if r == nil || r.playing {
+		return nil
+	}
+	r.rec.Frames = r.frame(&platform.Clock) + 1
+	platform.Clock.FixedDeltaT = r.fixedDeltaT
+	platform.replay = nil
+	return r.rec
+}
+
+// ReplayInput replays rec, starting with the next frame. Real input is
+// ignored until the replay is done, then onDone (which may be nil) is
+// called.
+func (platform *Window) ReplayInput(rec *InputRecording, onDone func()) {
+	platform.StopRecording()
+	platform.replay = &inputReplay{
+		rec:         rec,
+		playing:     true,
+		startFrame:  platform.Clock.TotalFrames + 1,
+		onDone:      onDone,
+		fixedDeltaT: platform.Clock.FixedDeltaT,
+		buttonsDown: make(map[glfw.MouseButton]bool),
+	}
+	platform.Clock.FixedDeltaT = rec.DeltaT
+	platform.replay.cursorX, platform.replay.cursorY = platform.GlfwWindow.GetCursorPos()
+}
+
+// Recording returns true if input is being recorded.
+func (platform *Window) Recording() bool { return platform.replay != nil && !platform.replay.playing }
+
+// Replaying returns true if input is being replayed.
+func (platform *Window) Replaying() bool { return platform.replay != nil && platform.replay.playing }
+
+// record adds e to the recording, if recording.
+func (platform *Window) record(e InputEvent) {
+	if r := platform.replay; r != nil && !r.playing {
+		e.Frame = r.frame(&platform.Clock)
+		r.rec.Events = append(r.rec.Events, e)
+	}
+}
+
+// ignoreInput is true if real input should be ignored because it's being
+// replayed.
+func (platform *Window) ignoreInput() bool { return platform.Replaying() }
+
+// cursorPos gets the cursor position, or the replayed position.
+func (platform *Window) cursorPos() (x, y float64) {
+	r := platform.replay
+	if r == nil {
+		return platform.GlfwWindow.GetCursorPos()
+	}
+	if !r.playing {
+		x, y = platform.GlfwWindow.GetCursorPos()
+		if x != r.cursorX || y != r.cursorY {
+			r.cursorX, r.cursorY = x, y
+			platform.record(InputEvent{Kind: CursorEvent, X: x, Y: y})
+		}
+	}
+	return r.cursorX, r.cursorY
+}
+
+// mouseButtonDown returns true if button is held, or held in the replay.
+func (platform *Window) mouseButtonDown(button glfw.MouseButton) bool {
+	if platform.Replaying() {
+		return platform.replay.buttonsDown[button]
+	}
+	return platform.GlfwWindow.GetMouseButton(button) == glfw.Press
+}
+
+// replayInput sends the replayed events for the current frame to the
+// callbacks, and ends the replay after its last frame. It must be called
+// after events are polled.
+func (platform *Window) replayInput() {
+	r := platform.replay
+	if r == nil || !r.playing {
+		return
+	}
+	frame := r.frame(&platform.Clock)
+	if frame >= r.rec.Frames {
+		platform.Clock.FixedDeltaT = r.fixedDeltaT
+		platform.replay = nil
+		if r.onDone != nil {
+			r.onDone()
+		}
+		return
+	}
+
+	w := platform.GlfwWindow
+	for ; r.next < len(r.rec.Events) && r.rec.Events[r.next].Frame <= frame; r.next++ {
+		e := r.rec.Events[r.next]
+		switch e.Kind {
+		case KeyEvent:
+			for _, cb := range platform.keyCallbacks {
+				cb(w, e.Key, e.Scancode, e.Action, e.Mods)
+			}
+		case MouseButtonEvent:
+			r.buttonsDown[e.Button] = e.Action == glfw.Press
+			for _, cb := range platform.mouseCallbacks {
+				cb(w, e.Button, e.Action, e.Mods)
+			}
+		case ScrollEvent:
+			for _, cb := range platform.scrollCallbacks {
+				cb(w, e.X, e.Y)
+			}
+		case CharEvent:
+			for _, cb := range platform.charCallbacks {
+				cb(w, e.Char)
+			}
+		case CursorEvent:
+			r.cursorX, r.cursorY = e.X, e.Y
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/inkyblackness/imgui-go/v4"
+)
+
+// FrameHistory keeps downscaled copies of the last few frames drawn, with
+// their stats, so a transient glitch such as a single frame of flicker can
+// be found afterwards by stepping back through them with Gui(). Frames are
+// copied on the GPU, so keeping them is cheap, but they use
+// size*Scale*Scale of the framebuffer's memory.
+type FrameHistory struct {
+	Scale  float32 // size of kept frames relative to the framebuffer
+	Paused bool    // frames aren't kept while paused
+
+	frames []historyFrame // ring, with the newest at latest
+	latest int
+	count  int
+	back   int32 // frames back from latest shown by Gui()
+
+	keptFrame uint64 // Clock.TotalFrames when last kept, to keep each once
+}
+
+// historyFrame is one frame kept by FrameHistory.
+type historyFrame struct {
+	target    *Fbo
+	frame     uint64
+	frameTime float64
+	stats     RenderStats
+}
+
+// NewFrameHistory creates an empty history of size frames.
+func NewFrameHistory(size int, scale float32) *FrameHistory {
+	return &FrameHistory{
+		Scale:  scale,
+		frames: make([]historyFrame, size),
+		latest: -1,
+	}
+}
+
+// Keep copies the framebuffer bound for reading (width x height) into the
+// history as the current frame. It does nothing if the frame was already
+// kept or the history is paused. Window calls it in RenderImgui(), or in
+// EndFrame() without imgui.
+func (h *FrameHistory) Keep(clock *Timer, width, height int) {
+	if h.Paused || h.keptFrame == clock.TotalFrames || width <= 0 || height <= 0 {
+		return
+	}
+	h.keptFrame = clock.TotalFrames
+
+	w, ht := int(float32(width)*h.Scale), int(float32(height)*h.Scale)
+	if w < 1 {
+		w = 1
+	}
+	if ht < 1 {
+		ht = 1
+	}
+	slot := (h.latest + 1) % len(h.frames)
+	f := &h.frames[slot]
+	if f.target == nil || int(f.target.Width) != w || int(f.target.Height) != ht {
+		if f.target != nil {
+			f.target.Delete()
+		}
+		var err error
+		if f.target, err = NewFbo(w, ht); err != nil {
+			f.target = nil
+			return
+		}
+	}
+
+	var drawFbo int32
+	gl.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &drawFbo)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, f.target.ID)
+	gl.BlitFramebuffer(0, 0, int32(width), int32(height), 0, 0, int32(w), int32(ht), gl.COLOR_BUFFER_BIT, gl.LINEAR)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, uint32(drawFbo))
+
+	f.frame = clock.TotalFrames
+	f.frameTime = clock.UnscaledDeltaT
+	f.stats = RenderStats{}
+	h.latest = slot
+	if h.count < len(h.frames) {
+		h.count++
+	}
+}
+
+// setStats sets the stats of the frame kept at frame, once they're complete.
+func (h *FrameHistory) setStats(frame uint64, stats RenderStats) {
+	if h.latest >= 0 && h.frames[h.latest].frame == frame {
+		h.frames[h.latest].stats = stats
+	}
+}
+
+// Len gets the number of frames kept.
+func (h *FrameHistory) Len() int { return h.count }
+
+// Frame gets the texture, frame number (Clock.TotalFrames), frame time, and
+// stats of the frame kept back frames before the newest. tex is nil if there
+// is no such frame.
+func (h *FrameHistory) Frame(back int) (tex *Texture2D, frame uint64, frameTime float64, stats RenderStats) {
+	if back < 0 || back >= h.count {
+		return nil, 0, 0, RenderStats{}
+	}
+	f := h.frames[(h.latest-back+len(h.frames))%len(h.frames)]
+	return f.target.ColorBuffer, f.frame, f.frameTime, f.stats
+}
+
+// Clear forgets all the frames kept.
+func (h *FrameHistory) Clear() {
+	h.count, h.latest, h.back = 0, -1, 0
+}
+
+// Delete the kept frames.
+func (h *FrameHistory) Delete() {
+	for i := range h.frames {
+		if h.frames[i].target != nil {
+			h.frames[i].target.Delete()
+			h.frames[i].target = nil
+		}
+	}
+	h.Clear()
+}
+
+// Gui shows a window for stepping back through the kept frames, with a plot
+// of their frame times. Moving back from the newest frame pauses the
+// history so the frames stay put. Call it inside the func of
+// Window.RenderImgui().
+func (h *FrameHistory) Gui(title string) {
+	imgui.SetNextWindowSizeV(imgui.Vec2{X: 420, Y: 420}, imgui.ConditionFirstUseEver)
+	if imgui.Begin(title + "##history") {
+		h.gui()
+	}
+	imgui.End()
+}
+
+func (h *FrameHistory) gui() {
+	live := !h.Paused
+	if imgui.Checkbox("live", &live) {
+		h.Paused = !live
+		if live {
+			h.back = 0
+		}
+	}
+	imgui.SameLine()
+	if imgui.Button("<") && h.back < int32(h.count)-1 {
+		h.back++
+		h.Paused = true
+	}
+	imgui.SameLine()
+	if imgui.Button(">") && h.back > 0 {
+		h.back--
+	}
+	imgui.SameLine()
+	if imgui.Button("clear") {
+		h.Clear()
+	}
+	if h.count == 0 {
+		imgui.Text("no frames kept")
+		return
+	}
+	if h.back >= int32(h.count) {
+		h.back = int32(h.count) - 1
+	}
+
+	// frame times, oldest first
+	times := make([]float32, h.count)
+	for i := range times {
+		_, _, t, _ := h.Frame(h.count - 1 - i)
+		times[i] = float32(t * 1000)
+	}
+	width := imgui.ContentRegionAvail().X
+	imgui.PlotLinesV("##frametimes", times, 0, "frame time (ms)", 0, 2*maxFloat32(times)+1, imgui.Vec2{X: width, Y: 40})
+
+	// slider runs oldest to newest, like the plot
+	pos := int32(h.count) - 1 - h.back
+	if imgui.SliderIntV("##frame", &pos, 0, int32(h.count)-1, "", imgui.SliderFlagsNone) {
+		h.back = int32(h.count) - 1 - pos
+		h.Paused = h.Paused || h.back > 0
+	}
+
+	tex, frame, frameTime, stats := h.Frame(int(h.back))
+	imgui.Text(fmt.Sprintf("frame %d (-%d)  %.2f ms", frame, h.back, frameTime*1000))
+	imgui.Text(fmt.Sprintf("%d draws  %d tris  %d tex binds  %d programs  %.1f KiB up",
+		stats.DrawCalls, stats.Triangles, stats.TextureBinds, stats.ProgramSwitches, float64(stats.UploadBytes)/1024))
+	height := width * float32(tex.Height) / float32(tex.Width)
+	imgui.ImageV(imgui.TextureID(tex.ID), imgui.Vec2{X: width, Y: height},
+		imgui.Vec2{X: 0, Y: 1}, imgui.Vec2{X: 1, Y: 0}, // textures are upside down
+		imgui.Vec4{X: 1, Y: 1, Z: 1, W: 1}, imgui.Vec4{})
+}
+
+// maxFloat32 gets the largest of values, or 0.
+func maxFloat32(values []float32) (max float32) {
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	return
+}
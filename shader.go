@@ -2,7 +2,6 @@ package sgl
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
@@ -70,19 +69,21 @@ bvec4         gl.uniform4i gl.uniform4f gl.uniform4iv gl.uniform4fv
 */
 
 type Attribute struct {
-	ID     uint32 // index or location of attribute
-	Name   string // name of attribute in the shader GLSL
-	Size   int32  // 'numbers' in a single attribute
-	Type   uint32 // sgl.Float32 (gl.FLOAT), etc
-	Stride int32  // bytes
-	Offset int    // bytes
-	// Normalized bool // if added, goes after Type
+	ID         uint32 // index or location of attribute
+	Name       string // name of attribute in the shader GLSL
+	Size       int32  // 'numbers' in a single attribute
+	Type       uint32 // sgl.Float32 (gl.FLOAT), etc
+	Stride     int32  // bytes
+	Offset     int    // bytes
+	Divisor    uint32 // 0 advances per vertex, n per n instances
+	Normalized bool   // integer types are read as 0 to 1 (or -1 to 1) floats
 }
 
 // Enable (associate) attribute with "current" VAO/VBO.
 func (a *Attribute) Enable() {
-	gl.EnableVertexAttribArray(a.ID)
-	gl.VertexAttribPointer(a.ID, a.Size, a.Type, false, a.Stride, gl.PtrOffset(a.Offset))
+	backend.EnableVertexAttribArray(a.ID)
+	backend.VertexAttribPointer(a.ID, a.Size, a.Type, a.Normalized, a.Stride, a.Offset)
+	backend.VertexAttribDivisor(a.ID, a.Divisor)
 }
 
 // func (a *Attribute) String() string { return fmt.Sprintf("%+v", *a) }
@@ -112,27 +113,27 @@ func (s *Shader) Attributes() (copy []Attribute) {
 }
 
 func (s *Shader) SetInt(uniformName string, count int32, val *int32) {
-	gl.Uniform1iv(s.Uniforms[uniformName], count, val)
+	backend.Uniform1iv(s.Uniforms[uniformName], count, val)
 }
 
 func (s *Shader) SetFloat(uniformName string, count int32, val *float32) {
-	gl.Uniform1fv(s.Uniforms[uniformName], count, val)
+	backend.Uniform1fv(s.Uniforms[uniformName], count, val)
 }
 
 func (s *Shader) SetVec2(uniformName string, count int32, val *mgl32.Vec2) {
-	gl.Uniform2fv(s.Uniforms[uniformName], count, &(*val)[0])
+	backend.Uniform2fv(s.Uniforms[uniformName], count, &(*val)[0])
 }
 
 func (s *Shader) SetVec3(uniformName string, count int32, val *mgl32.Vec3) {
-	gl.Uniform3fv(s.Uniforms[uniformName], count, &(*val)[0])
+	backend.Uniform3fv(s.Uniforms[uniformName], count, &(*val)[0])
 }
 
 func (s *Shader) SetVec4(uniformName string, count int32, val *mgl32.Vec4) {
-	gl.Uniform4fv(s.Uniforms[uniformName], count, &(*val)[0])
+	backend.Uniform4fv(s.Uniforms[uniformName], count, &(*val)[0])
 }
 
 func (s *Shader) SetMat4(uniformName string, count int32, val *mgl32.Mat4) {
-	gl.UniformMatrix4fv(s.Uniforms[uniformName], count, false, &(*val)[0])
+	backend.UniformMatrix4fv(s.Uniforms[uniformName], count, false, &(*val)[0])
 }
 
 // func (s *Shader) String() string { return fmt.Sprintf("%+v", *s) }
@@ -174,11 +175,16 @@ func (prog *Program) Compute() *Shader {
 // }
 
 func (prog *Program) Use() {
-	gl.UseProgram(prog.ID)
+	if prog.ID != usedProgram {
+		frameStats.ProgramSwitches++
+		usedProgram = prog.ID
+	}
+	backend.UseProgram(prog.ID)
 }
 
 func (prog *Program) Delete() {
-	gl.DeleteProgram(prog.ID)
+	unwatchLeak(prog, "Program", prog.ID)
+	backend.DeleteProgram(prog.ID)
 }
 
 // AddShader creates and associates a shader with this program.
@@ -213,27 +219,27 @@ func (prog *Program) Compile() error {
 }
 
 func (prog *Program) Link() error {
-	prog.ID = gl.CreateProgram()
+	prog.ID = backend.CreateProgram()
+	watchLeak(prog, "Program", prog.ID)
 
 	for _, shader := range prog.Shaders {
-		gl.AttachShader(prog.ID, shader.ID)
-		defer gl.DeleteShader(shader.ID) // should this really be called if linking fails?
+		backend.AttachShader(prog.ID, shader.ID)
+		defer backend.DeleteShader(shader.ID) // should this really be called if linking fails?
 	}
 
-	gl.LinkProgram(prog.ID)
-	if err := prog.checkLinkStatus(); err != nil {
-		return err
+	if ok, log := backend.LinkProgram(prog.ID); !ok {
+		return fmt.Errorf("failed to link program: %v", log)
 	}
 
 	prog.Use()
 	for _, shader := range prog.Shaders {
 		for name, attrib := range shader.Attribs {
-			id := uint32(gl.GetAttribLocation(prog.ID, gl.Str(name+"\x00")))
+			id := uint32(backend.GetAttribLocation(prog.ID, name))
 			attrib.ID = id
 			shader.Attribs[name] = attrib // set modied value
 		}
 		for name := range shader.Uniforms {
-			id := gl.GetUniformLocation(prog.ID, gl.Str(name+"\x00"))
+			id := backend.GetUniformLocation(prog.ID, name)
 			shader.Uniforms[name] = id
 		}
 	}
@@ -254,41 +260,10 @@ func (prog *Program) Build() error {
 	return nil
 }
 
-func (prog *Program) checkLinkStatus() error {
-	var status int32
-	gl.GetProgramiv(prog.ID, gl.LINK_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(prog.ID, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(prog.ID, logLength, nil, gl.Str(log))
-
-		return fmt.Errorf("failed to link program: %v", log)
-	}
-	return nil
-}
-
 func compileShader(source string, shaderType uint32) (uint32, error) {
-	shader := gl.CreateShader(shaderType)
-
-	csources, free := gl.Strs(source + "\x00") // gl.Strs() lies about null termination
-	defer free()
-	gl.ShaderSource(shader, 1, csources, nil)
-	gl.CompileShader(shader)
-
-	// this just checks compilation status
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
-
+	shader := backend.CreateShader(shaderType)
+	if ok, log := backend.CompileShader(shader, source); !ok {
 		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
 	}
-
 	return shader, nil
 }
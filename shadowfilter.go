@@ -0,0 +1,337 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ShadowFilterMode is how a shadow map is filtered, to soften the edges of
+// shadows.
+type ShadowFilterMode int32
+
+// Shadow filtering modes.
+const (
+	ShadowHard    ShadowFilterMode = iota // one hardware PCF sample
+	ShadowPCF                             // a square kernel of PCF samples
+	ShadowPoisson                         // a rotated poisson disk of PCF samples; soft but noisy
+	ShadowVSM                             // variance shadow map; smooth, but light bleeds through overlaps
+	ShadowEVSM                            // exponential variance shadow map; less bleeding
+)
+
+// ShadowFilter configures filtering of a shadow map in shaders using
+// ShadowFilterShaderSource. The shadow map is a depth texture from
+// NewDepthTexture(), drawn from the light's point of view.
+type ShadowFilter struct {
+	Mode       ShadowFilterMode
+	KernelSize int            // width of the ShadowPCF kernel in texels: 3, 5, or 7
+	Radius     float32        // of the ShadowPoisson disk, in texels
+	LightBleed float32        // for ShadowVSM and ShadowEVSM, cuts off light bleeding, from 0 to 1
+	Moments    *ShadowMoments // for ShadowVSM and ShadowEVSM
+}
+
+// ShadowFilterUniforms are the uniforms declared by ShadowFilterShaderSource.
+// Add them to the fragment shader's uniforms in Program.AddShader().
+var ShadowFilterUniforms = []string{"shadowMap", "shadowMoments", "shadowFilterMode", "shadowKernel",
+	"shadowRadius", "shadowBleed", "shadowExponents"}
+
+// ShadowFilterShaderSource declares the shadow map uniforms and a function to
+// sample them. Put it directly after the #version line of a fragment shader,
+// then use
+//
+//	float lit = shadowFactor(shadowCoord);
+//
+// where shadowCoord is the fragment's position in the light's clip space,
+// mapped to 0 to 1, and call Shader.SetShadowFilter() after Program.Use().
+const ShadowFilterShaderSource = `
+uniform sampler2DShadow shadowMap;
+uniform sampler2D shadowMoments;
+uniform int shadowFilterMode;
+uniform int shadowKernel;
+uniform float shadowRadius;
+uniform float shadowBleed;
+uniform vec2 shadowExponents;
+
+const vec2 shadowPoissonDisk[16] = vec2[](
+    vec2(-0.94201624, -0.39906216), vec2(0.94558609, -0.76890725),
+    vec2(-0.09418410, -0.92938870), vec2(0.34495938, 0.29387760),
+    vec2(-0.91588581, 0.45771432), vec2(-0.81544232, -0.87912464),
+    vec2(-0.38277543, 0.27676845), vec2(0.97484398, 0.75648379),
+    vec2(0.44323325, -0.97511554), vec2(0.53742981, -0.47373420),
+    vec2(-0.26496911, -0.41893023), vec2(0.79197514, 0.19090188),
+    vec2(-0.24188840, 0.99706507), vec2(-0.81409955, 0.91437590),
+    vec2(0.19984126, 0.78641367), vec2(0.14383161, -0.14100790));
+
+// shadowChebyshev gets the upper bound on the light reaching depth from the
+// moments of the depths around it.
+float shadowChebyshev(vec2 moments, float depth, float minVariance)
+{
+    if (depth <= moments.x) {
+        return 1.0;
+    }
+    float variance = max(moments.y - moments.x * moments.x, minVariance);
+    float d = depth - moments.x;
+    float p = variance / (variance + d * d);
+    return clamp((p - shadowBleed) / (1.0 - shadowBleed), 0.0, 1.0);
+}
+
+// shadowFactor gets the light reaching coord, in the light's clip space
+// mapped to 0 to 1, from 0 (in shadow) to 1 (lit).
+float shadowFactor(vec3 coord)
+{
+    if (any(lessThan(coord, vec3(0.0))) || any(greaterThan(coord, vec3(1.0)))) {
+        return 1.0;
+    }
+    vec2 texel = 1.0 / vec2(textureSize(shadowMap, 0));
+
+    if (shadowFilterMode == 1) {
+        int r = shadowKernel / 2;
+        float sum = 0.0;
+        for (int y = -r; y <= r; y++) {
+            for (int x = -r; x <= r; x++) {
+                sum += texture(shadowMap, vec3(coord.xy + vec2(x, y) * texel, coord.z));
+            }
+        }
+        return sum / float((2 * r + 1) * (2 * r + 1));
+    }
+    if (shadowFilterMode == 2) {
+        // rotated per pixel, trading banding for noise
+        float angle = 6.2831853 * fract(sin(dot(gl_FragCoord.xy, vec2(12.9898, 78.233))) * 43758.5453);
+        mat2 rotation = mat2(cos(angle), sin(angle), -sin(angle), cos(angle));
+        float sum = 0.0;
+        for (int i = 0; i < 16; i++) {
+            vec2 offset = rotation * shadowPoissonDisk[i] * shadowRadius * texel;
+            sum += texture(shadowMap, vec3(coord.xy + offset, coord.z));
+        }
+        return sum / 16.0;
+    }
+    if (shadowFilterMode == 3) {
+        return shadowChebyshev(texture(shadowMoments, coord.xy).xy, coord.z, 0.00002);
+    }
+    if (shadowFilterMode == 4) {
+        vec4 moments = texture(shadowMoments, coord.xy);
+        float d = coord.z * 2.0 - 1.0;
+        float pos = exp(shadowExponents.x * d);
+        float neg = -exp(-shadowExponents.y * d);
+        float lit = shadowChebyshev(moments.xy, pos, 0.0001 * shadowExponents.x * shadowExponents.x);
+        return min(lit, shadowChebyshev(moments.zw, neg, 0.0001 * shadowExponents.y * shadowExponents.y));
+    }
+    return texture(shadowMap, coord);
+}
+`
+
+// SetShadowFilter sets the uniforms declared by ShadowFilterShaderSource
+// for f, and binds shadowMap to texture unit mapUnit and f.Moments, if any,
+// to momentsUnit. The shader's program must be in use.
+func (s *Shader) SetShadowFilter(f ShadowFilter, shadowMap *Texture2D, mapUnit, momentsUnit uint32) {
+	mode := f.Mode
+	if (mode == ShadowVSM || mode == ShadowEVSM) && f.Moments == nil {
+		mode = ShadowPCF
+	}
+	if mode == ShadowEVSM && !f.Moments.EVSM {
+		mode = ShadowVSM
+	} else if mode == ShadowVSM && f.Moments.EVSM {
+		mode = ShadowEVSM
+	}
+	kernel := f.KernelSize
+	if kernel < 1 {
+		kernel = 3
+	}
+	bleed := mgl32.Clamp(f.LightBleed, 0, 0.99)
+
+	set := func(name string, apply func(loc int32)) {
+		if loc, ok := s.Uniforms[name]; ok {
+			apply(loc)
+		}
+	}
+	set("shadowMap", func(loc int32) { gl.Uniform1i(loc, int32(mapUnit)) })
+	set("shadowMoments", func(loc int32) { gl.Uniform1i(loc, int32(momentsUnit)) })
+	set("shadowFilterMode", func(loc int32) { gl.Uniform1i(loc, int32(mode)) })
+	set("shadowKernel", func(loc int32) { gl.Uniform1i(loc, int32(kernel)) })
+	set("shadowRadius", func(loc int32) { gl.Uniform1f(loc, f.Radius) })
+	set("shadowBleed", func(loc int32) { gl.Uniform1f(loc, bleed) })
+	if f.Moments != nil {
+		set("shadowExponents", func(loc int32) { gl.Uniform2f(loc, f.Moments.Exponents[0], f.Moments.Exponents[1]) })
+		f.Moments.Texture.Bind(momentsUnit)
+	}
+	shadowMap.Bind(mapUnit)
+}
+
+// only need these once in the package
+var shadowMomentsProgram, shadowBlurProgram *Program
+
+// called to create and build the shadow moment programs.
+func initShadowMomentsPrograms() error {
+	shadowMomentsProgram = NewProgram()
+	shadowMomentsProgram.AddShader(VertexShader, quadVertexShader,
+		[]string{"projection", "model"},
+		Attribute{Name: "vertex", Type: gl.FLOAT, Size: 4, Stride: 4 * SizeOfFloat, Offset: 0})
+	shadowMomentsProgram.AddShader(FragmentShader, shadowMomentsFragmentShader,
+		[]string{"depth", "evsm", "exponents"})
+	if err := shadowMomentsProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build shadow moments program: %w", err)
+	}
+
+	shadowBlurProgram = NewProgram()
+	shadowBlurProgram.AddShader(VertexShader, quadVertexShader,
+		[]string{"projection", "model"},
+		Attribute{Name: "vertex", Type: gl.FLOAT, Size: 4, Stride: 4 * SizeOfFloat, Offset: 0})
+	shadowBlurProgram.AddShader(FragmentShader, shadowBlurFragmentShader,
+		[]string{"source", "direction", "radius"})
+	if err := shadowBlurProgram.Build(); err != nil {
+		shadowMomentsProgram.Delete()
+		shadowMomentsProgram = nil
+		return fmt.Errorf("couldn't build shadow blur program: %w", err)
+	}
+	return nil
+}
+
+// ShadowMoments are the depth moments of a shadow map, blurred, for
+// ShadowVSM and ShadowEVSM filtering. Unlike depth, moments can be blurred
+// and filtered linearly, so shadows are smooth even at low resolution.
+type ShadowMoments struct {
+	EVSM       bool       // exponential moments, rather than plain
+	Exponents  mgl32.Vec2 // for EVSM, of the positive and negative moments
+	BlurRadius int        // in texels; 0 for none
+	Texture    *Texture2D // RGBA32F moments
+
+	fbo         uint32
+	blurTexture *Texture2D // between blur passes
+	blurFbo     uint32
+}
+
+// NewShadowMoments creates moments for a width x height shadow map.
+func NewShadowMoments(width, height int, evsm bool) (*ShadowMoments, error) {
+	if shadowMomentsProgram == nil {
+		if err := initShadowMomentsPrograms(); err != nil {
+			return nil, err
+		}
+	}
+	m := &ShadowMoments{EVSM: evsm, Exponents: mgl32.Vec2{40, 5}, BlurRadius: 2}
+	var status uint32
+	for _, target := range []struct {
+		tex **Texture2D
+		fbo *uint32
+	}{{&m.Texture, &m.fbo}, {&m.blurTexture, &m.blurFbo}} {
+		tex := newAttachment(width, height, TexRGBA32F, gl.LINEAR)
+		gl.GenFramebuffers(1, target.fbo)
+		gl.BindFramebuffer(gl.FRAMEBUFFER, *target.fbo)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, tex.ID, 0)
+		*target.tex = tex
+		if status = gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+			break
+		}
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		m.Delete()
+		return nil, fmt.Errorf("shadow moments framebuffer is not complete")
+	}
+	return m, nil
+}
+
+// Update computes the moments of shadowMap, after it's drawn, and blurs
+// them.
+func (m *ShadowMoments) Update(shadowMap *Texture2D) {
+	saved := saveTargetState()
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.BLEND)
+	w, h := float32(m.Texture.Width), float32(m.Texture.Height)
+	gl.Viewport(0, 0, m.Texture.Width, m.Texture.Height)
+	projection, model := quadTransform(0, 0, w, h, w, h)
+
+	// read raw depth
+	shadowMap.SetCompare(false)
+	evsm := boolToInt32(m.EVSM)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, m.fbo)
+	shadowMomentsProgram.Use()
+	shadowMomentsProgram.Vertex().SetMat4("projection", 1, &projection)
+	shadowMomentsProgram.Vertex().SetMat4("model", 1, &model)
+	shadowMomentsProgram.Fragment().SetInt("evsm", 1, &evsm)
+	shadowMomentsProgram.Fragment().SetVec2("exponents", 1, &m.Exponents)
+	shadowMap.Bind(0)
+	drawQuad()
+	shadowMap.SetCompare(true)
+
+	// separable blur, across into blurTexture then down back into Texture
+	if m.BlurRadius > 0 {
+		radius := int32(m.BlurRadius)
+		shadowBlurProgram.Use()
+		shadowBlurProgram.Vertex().SetMat4("projection", 1, &projection)
+		shadowBlurProgram.Vertex().SetMat4("model", 1, &model)
+		shadowBlurProgram.Fragment().SetInt("radius", 1, &radius)
+		passes := []struct {
+			fbo       uint32
+			source    *Texture2D
+			direction mgl32.Vec2
+		}{
+			{m.blurFbo, m.Texture, mgl32.Vec2{1 / w, 0}},
+			{m.fbo, m.blurTexture, mgl32.Vec2{0, 1 / h}},
+		}
+		for _, pass := range passes {
+			gl.BindFramebuffer(gl.FRAMEBUFFER, pass.fbo)
+			shadowBlurProgram.Fragment().SetVec2("direction", 1, &pass.direction)
+			pass.source.Bind(0)
+			drawQuad()
+		}
+	}
+	saved.restore()
+}
+
+// Delete resources.
+func (m *ShadowMoments) Delete() {
+	for _, tex := range []*Texture2D{m.Texture, m.blurTexture} {
+		if tex != nil {
+			tex.Delete()
+		}
+	}
+	gl.DeleteFramebuffers(1, &m.fbo)
+	gl.DeleteFramebuffers(1, &m.blurFbo)
+}
+
+const shadowMomentsFragmentShader = `#version 330 core
+in vec2 TexCoords;
+
+uniform sampler2D depth;
+uniform bool evsm;
+uniform vec2 exponents;
+
+out vec4 FragColor;
+
+void main()
+{
+    // TexCoords are y down, so flip to sample the same row
+    float d = texture(depth, vec2(TexCoords.x, 1.0 - TexCoords.y)).r;
+    if (evsm) {
+        float pos = exp(exponents.x * (d * 2.0 - 1.0));
+        float neg = -exp(-exponents.y * (d * 2.0 - 1.0));
+        FragColor = vec4(pos, pos * pos, neg, neg * neg);
+    } else {
+        FragColor = vec4(d, d * d, 0.0, 0.0);
+    }
+}`
+
+const shadowBlurFragmentShader = `#version 330 core
+in vec2 TexCoords;
+
+uniform sampler2D source;
+uniform vec2 direction; // one texel along the blur
+uniform int radius;
+
+out vec4 FragColor;
+
+void main()
+{
+    // TexCoords are y down, so flip to sample the same row
+    vec2 uv = vec2(TexCoords.x, 1.0 - TexCoords.y);
+    float sigma = max(float(radius) / 2.0, 0.5);
+    vec4 sum = vec4(0.0);
+    float total = 0.0;
+    for (int i = -radius; i <= radius; i++) {
+        float w = exp(-float(i * i) / (2.0 * sigma * sigma));
+        sum += texture(source, uv + direction * float(i)) * w;
+        total += w;
+    }
+    FragColor = sum / total;
+}`
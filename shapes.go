@@ -0,0 +1,263 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// only need this once in the package
+var shapeProgram *Program
+
+// called to create and build the shape program.
+func initShapeProgram() error {
+	shapeProgram = NewProgram()
+	shapeProgram.AddShader(VertexShader, shapeVertexShader, []string{"projection"})
+	shapeProgram.AddShader(FragmentShader, shapeFragmentShader, nil)
+	if err := shapeProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build shape program: %w", err)
+	}
+	return nil
+}
+
+// kinds of shape, matching the shader
+const (
+	shapeCircle float32 = iota
+	shapeRoundedRect
+	shapeCapsule
+)
+
+// shapeInstance is the per instance data of a shape.
+type shapeInstance struct {
+	bounds mgl32.Vec4 // min x, y, max x, y of the quad
+	shape  mgl32.Vec4 // circle center; rect center, half size; capsule ends
+	params mgl32.Vec4 // kind, radius, outline thickness (0 fills), unused
+	color  mgl32.Vec4
+}
+
+// ShapeBatch draws 2D circles, rings, rounded rectangles, and capsules,
+// all in one instanced draw call with one quad per shape. Edges are found
+// from a signed distance function in the fragment shader, so they're
+// smoothly anti-aliased at any size or scale.
+//
+// Add shapes every frame, then Draw() and Clear(). Positions are in pixels
+// with (0, 0) at the top left, like quadTransform() and
+// CharacterDict.DrawString().
+type ShapeBatch struct {
+	shapes []shapeInstance
+
+	vao, quadVbo, instanceVbo uint32
+	capacity                  int // instances the buffer can hold
+}
+
+// NewShapeBatch creates a batch with no shapes.
+func NewShapeBatch() (*ShapeBatch, error) {
+	if shapeProgram == nil {
+		if err := initShapeProgram(); err != nil {
+			return nil, err
+		}
+	}
+	b := &ShapeBatch{}
+	b.vao = backend.GenVertexArray()
+	backend.BindVertexArray(b.vao)
+
+	b.quadVbo = backend.GenBuffer()
+	backend.BindBuffer(gl.ARRAY_BUFFER, b.quadVbo)
+	backend.BufferData(gl.ARRAY_BUFFER, len(quadVertices)*SizeOfFloat, gl.Ptr(quadVertices), gl.STATIC_DRAW)
+	backend.VertexAttribPointer(0, 4, gl.FLOAT, false, 4*SizeOfFloat, 0)
+	backend.EnableVertexAttribArray(0)
+	trackBuffer(b.quadVbo, len(quadVertices)*SizeOfFloat)
+
+	b.instanceVbo = backend.GenBuffer()
+	backend.BindBuffer(gl.ARRAY_BUFFER, b.instanceVbo)
+	for i := uint32(1); i <= 4; i++ {
+		backend.VertexAttribPointer(i, 4, gl.FLOAT, false, 4*SizeOfV4, int(i-1)*SizeOfV4)
+		backend.EnableVertexAttribArray(i)
+		backend.VertexAttribDivisor(i, 1)
+	}
+
+	backend.BindVertexArray(0)
+	backend.BindBuffer(gl.ARRAY_BUFFER, 0)
+	return b, nil
+}
+
+// Len gets the number of shapes in the batch.
+func (b *ShapeBatch) Len() int { return len(b.shapes) }
+
+// Clear removes all shapes.
+func (b *ShapeBatch) Clear() { b.shapes = b.shapes[:0] }
+
+// add a shape covering min to max, grown by a pixel for anti-aliasing.
+func (b *ShapeBatch) add(kind float32, min, max mgl32.Vec2, shape mgl32.Vec4, radius, thickness float32, color Color) {
+	b.shapes = append(b.shapes, shapeInstance{
+		bounds: mgl32.Vec4{min[0] - 1, min[1] - 1, max[0] + 1, max[1] + 1},
+		shape:  shape,
+		params: mgl32.Vec4{kind, radius, thickness, 0},
+		color:  color.Vec4(),
+	})
+}
+
+// Circle adds a filled circle.
+func (b *ShapeBatch) Circle(center mgl32.Vec2, radius float32, color Color) {
+	b.Ring(center, radius, 0, color)
+}
+
+// Ring adds a circle outline thickness wide, centered on radius. A
+// thickness of 0 fills the circle.
+func (b *ShapeBatch) Ring(center mgl32.Vec2, radius, thickness float32, color Color) {
+	r := mgl32.Vec2{radius + thickness/2, radius + thickness/2}
+	b.add(shapeCircle, center.Sub(r), center.Add(r),
+		mgl32.Vec4{center[0], center[1], 0, 0}, radius, thickness, color)
+}
+
+// RoundedRect adds a filled rectangle from min to max, with corners
+// rounded by radius. A radius of 0 gives square corners.
+func (b *ShapeBatch) RoundedRect(min, max mgl32.Vec2, radius float32, color Color) {
+	b.RoundedRectOutline(min, max, radius, 0, color)
+}
+
+// RoundedRectOutline adds the outline of a rounded rectangle, thickness
+// wide and centered on its edge. A thickness of 0 fills the rectangle.
+func (b *ShapeBatch) RoundedRectOutline(min, max mgl32.Vec2, radius, thickness float32, color Color) {
+	center := min.Add(max).Mul(0.5)
+	half := max.Sub(min).Mul(0.5)
+	radius = mgl32.Clamp(radius, 0, minFloat(half[0], half[1]))
+	grow := mgl32.Vec2{thickness / 2, thickness / 2}
+	b.add(shapeRoundedRect, min.Sub(grow), max.Add(grow),
+		mgl32.Vec4{center[0], center[1], half[0], half[1]}, radius, thickness, color)
+}
+
+// Capsule adds a line from p to q with round ends, radius wide on each
+// side.
+func (b *ShapeBatch) Capsule(p, q mgl32.Vec2, radius float32, color Color) {
+	min := mgl32.Vec2{minFloat(p[0], q[0]) - radius, minFloat(p[1], q[1]) - radius}
+	max := mgl32.Vec2{maxFloat(p[0], q[0]) + radius, maxFloat(p[1], q[1]) + radius}
+	b.add(shapeCapsule, min, max, mgl32.Vec4{p[0], p[1], q[0], q[1]}, radius, 0, color)
+}
+
+// minFloat gets the smaller of a and b.
+func minFloat(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxFloat gets the larger of a and b.
+func maxFloat(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Draw the shapes on a screen of size (width, height). Blending should be
+// enabled.
+func (b *ShapeBatch) Draw(width, height float32) {
+	n := len(b.shapes)
+	if n == 0 {
+		return
+	}
+
+	backend.BindBuffer(gl.ARRAY_BUFFER, b.instanceVbo)
+	size := n * 4 * SizeOfV4
+	if n > b.capacity {
+		b.capacity = n
+		backend.BufferData(gl.ARRAY_BUFFER, size, nil, gl.DYNAMIC_DRAW)
+		trackBuffer(b.instanceVbo, size)
+	}
+	backend.BufferSubData(gl.ARRAY_BUFFER, 0, size, gl.Ptr(b.shapes))
+	countUpload(size)
+	backend.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	projection := mgl32.Ortho2D(0, width, height, 0)
+	shapeProgram.Use()
+	shapeProgram.Vertex().SetMat4("projection", 1, &projection)
+	backend.BindVertexArray(b.vao)
+	backend.DrawArraysInstanced(gl.TRIANGLE_STRIP, 0, 4, int32(n))
+	backend.BindVertexArray(0)
+	countDraw(gl.TRIANGLE_STRIP, 4*int32(n))
+}
+
+// Delete resources.
+func (b *ShapeBatch) Delete() {
+	trackBuffer(b.quadVbo, 0)
+	trackBuffer(b.instanceVbo, 0)
+	backend.DeleteBuffer(b.quadVbo)
+	backend.DeleteBuffer(b.instanceVbo)
+	backend.DeleteVertexArray(b.vao)
+}
+
+const shapeVertexShader = `#version 330 core
+layout (location = 0) in vec4 vertex; // <vec2 pos, vec2 tex>
+layout (location = 1) in vec4 aBounds;
+layout (location = 2) in vec4 aShape;
+layout (location = 3) in vec4 aParams;
+layout (location = 4) in vec4 aInstanceColor;
+
+uniform mat4 projection;
+
+out vec2 Pos; // pixels
+flat out vec4 Shape;
+flat out vec4 Params;
+flat out vec4 Color;
+
+void main()
+{
+    Pos = mix(aBounds.xy, aBounds.zw, vertex.xy);
+    Shape = aShape;
+    Params = aParams;
+    Color = aInstanceColor;
+    gl_Position = projection * vec4(Pos, 0.0, 1.0);
+}`
+
+const shapeFragmentShader = `#version 330 core
+in vec2 Pos;
+flat in vec4 Shape;
+flat in vec4 Params;
+flat in vec4 Color;
+
+out vec4 FragColor;
+
+float circle(vec2 p, vec2 center, float radius)
+{
+    return length(p - center) - radius;
+}
+
+float roundedRect(vec2 p, vec2 center, vec2 halfSize, float radius)
+{
+    vec2 q = abs(p - center) - halfSize + radius;
+    return length(max(q, 0.0)) + min(max(q.x, q.y), 0.0) - radius;
+}
+
+float capsule(vec2 p, vec2 a, vec2 b, float radius)
+{
+    vec2 pa = p - a, ba = b - a;
+    float h = clamp(dot(pa, ba) / max(dot(ba, ba), 1e-6), 0.0, 1.0);
+    return length(pa - ba * h) - radius;
+}
+
+void main()
+{
+    int kind = int(Params.x + 0.5);
+    float d;
+    if (kind == 0) {
+        d = circle(Pos, Shape.xy, Params.y);
+    } else if (kind == 1) {
+        d = roundedRect(Pos, Shape.xy, Shape.zw, Params.y);
+    } else {
+        d = capsule(Pos, Shape.xy, Shape.zw, Params.y);
+    }
+    if (Params.z > 0.0) {
+        d = abs(d) - Params.z * 0.5; // outline
+    }
+
+    // cover a pixel's width of distance either side of the edge
+    float aa = max(fwidth(d), 1e-4);
+    float alpha = clamp(0.5 - d / aa, 0.0, 1.0);
+    if (alpha <= 0.0) {
+        discard;
+    }
+    FragColor = vec4(Color.rgb, Color.a * alpha);
+}`
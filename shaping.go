@@ -0,0 +1,278 @@
+package sgl
+
+import "unicode"
+
+// ShapeText prepares text for drawing with fonts that map each rune to one
+// glyph, such as a CharacterDict. Right-to-left runs (Hebrew, Arabic) are
+// reordered into the order they're displayed, using a simplified form of
+// the unicode bidirectional algorithm (no explicit embeddings), and Arabic
+// letters are replaced by their joined contextual forms (from the Arabic
+// Presentation Forms-B block), including lam-alef ligatures. Text without
+// right-to-left characters is returned as is.
+//
+// Indic scripts (Devanagari, Bengali, Tamil, and so on) are not shaped:
+// vowel signs aren't reordered and conjuncts aren't formed, so they're drawn
+// one glyph per rune in the order they're stored, which is wrong for most
+// words. Nor are Arabic diacritics placed, or fonts' own ligature and
+// substitution tables used. That needs a shaper such as HarfBuzz and a font
+// renderer drawing glyphs instead of runes, which this package doesn't have.
+//
+// CharacterDict.DrawString() and Width() call this automatically.
+func ShapeText(text string) string {
+	rtl := false
+	for _, r := range text {
+		if isRTL(r) {
+			rtl = true
+			break
+		}
+	}
+	if !rtl {
+		return text
+	}
+	return string(reorderBidi(joinArabic([]rune(text))))
+}
+
+// isRTL is true for strong right-to-left runes.
+func isRTL(r rune) bool {
+	return (r >= 0x0590 && r <= 0x08ff) || (r >= 0xfb1d && r <= 0xfdff) || (r >= 0xfe70 && r <= 0xfeff)
+}
+
+// number of presentation forms of each letter from U+0621 to U+063A, then
+// U+0641 to U+064A, in order starting from U+FE80. 1 is non-joining, 2 is
+// right-joining (joins only to the letter before it), and 4 is dual-joining.
+var arabicFormCounts = [...]int{
+	1, 2, 2, 2, 2, 4, 2, 4, 2, 4, 4, 4, 4, 4, 2, 2, 2, 2, 4, 4, 4, 4, 4, 4, 4, 4, // U+0621
+	4, 4, 4, 4, 4, 4, 4, 2, 2, 4, // U+0641
+}
+
+// arabicLetter is the presentation forms of a letter.
+type arabicLetter struct {
+	isolated rune // final, initial, and medial follow
+	forms    int
+}
+
+var arabicLetters = func() map[rune]arabicLetter {
+	letters := make(map[rune]arabicLetter, len(arabicFormCounts))
+	form := rune(0xfe80)
+	for i, count := range arabicFormCounts {
+		r := rune(0x0621 + i)
+		if i >= 26 {
+			r = rune(0x0641 + i - 26)
+		}
+		letters[r] = arabicLetter{isolated: form, forms: count}
+		form += rune(count)
+	}
+	return letters
+}()
+
+// isolated form of lam-alef ligatures, by alef. the final form follows.
+var lamAlef = map[rune]rune{
+	0x0622: 0xfef5, // alef with madda above
+	0x0623: 0xfef7, // alef with hamza above
+	0x0625: 0xfef9, // alef with hamza below
+	0x0627: 0xfefb, // alef
+}
+
+const (
+	arabicLam     = 0x0644
+	arabicTatweel = 0x0640
+)
+
+// joinsAfter is true if r connects to the letter following it.
+func joinsAfter(r rune) bool {
+	return r == arabicTatweel || arabicLetters[r].forms == 4
+}
+
+// joinsBefore is true if r connects to the letter preceding it.
+func joinsBefore(r rune) bool {
+	return r == arabicTatweel || arabicLetters[r].forms >= 2
+}
+
+// joinArabic replaces Arabic letters with their contextual forms, in
+// logical order. Combining marks (harakat) don't break joins.
+func joinArabic(text []rune) []rune {
+	// neighboring letters, skipping marks
+	neighbor := func(i, step int) rune {
+		for i += step; i >= 0 && i < len(text); i += step {
+			if !unicode.Is(unicode.Mn, text[i]) {
+				return text[i]
+			}
+		}
+		return 0
+	}
+
+	shaped := make([]rune, 0, len(text))
+	for i := 0; i < len(text); i++ {
+		r := text[i]
+		letter, ok := arabicLetters[r]
+		if !ok {
+			shaped = append(shaped, r)
+			continue
+		}
+		prev, next := neighbor(i, -1), neighbor(i, 1)
+		joinPrev := joinsAfter(prev) && letter.forms >= 2
+
+		if lig, ok := lamAlef[next]; r == arabicLam && ok {
+			// skip to the alef, dropping any marks between
+			for i++; text[i] != next; i++ {
+			}
+			if joinPrev {
+				lig++ // final
+			}
+			shaped = append(shaped, lig)
+			continue
+		}
+
+		joinNext := letter.forms == 4 && joinsBefore(next)
+		form := letter.isolated
+		switch {
+		case joinPrev && joinNext:
+			form += 3 // medial
+		case joinNext:
+			form += 2 // initial
+		case joinPrev:
+			form++ // final
+		}
+		shaped = append(shaped, form)
+	}
+	return shaped
+}
+
+// bidi classes, simplified
+const (
+	bidiNeutral = iota
+	bidiL
+	bidiR
+	bidiNumber
+)
+
+func bidiClass(r rune) int {
+	switch {
+	case isRTL(r) && !unicode.IsDigit(r):
+		return bidiR
+	case unicode.IsDigit(r):
+		return bidiNumber
+	case unicode.IsLetter(r):
+		return bidiL
+	}
+	return bidiNeutral
+}
+
+// mirrored pairs of brackets, which swap in right-to-left runs.
+var bidiMirror = map[rune]rune{
+	'(': ')', ')': '(', '[': ']', ']': '[', '{': '}', '}': '{', '<': '>', '>': '<',
+	'«': '»', '»': '«',
+}
+
+// reorderBidi reorders text from logical to display order.
+func reorderBidi(text []rune) []rune {
+	classes := make([]int, len(text))
+	base := bidiNeutral
+	for i, r := range text {
+		classes[i] = bidiClass(r)
+		if base == bidiNeutral && (classes[i] == bidiL || classes[i] == bidiR) {
+			base = classes[i]
+		}
+	}
+	baseLevel := 0
+	if base == bidiR {
+		baseLevel = 1
+	}
+
+	// numbers take the direction of the preceding strong letter (W7)
+	prevStrong := base
+	if base == bidiNeutral {
+		prevStrong = bidiL
+	}
+	for i, c := range classes {
+		switch c {
+		case bidiL, bidiR:
+			prevStrong = c
+		case bidiNumber:
+			if prevStrong == bidiL {
+				classes[i] = bidiL
+			}
+		}
+	}
+
+	// neutrals between letters of the same direction take that direction,
+	// otherwise the paragraph's (N1, N2). numbers count as right-to-left.
+	direction := func(c int) int {
+		if c == bidiNumber {
+			return bidiR
+		}
+		return c
+	}
+	for i := 0; i < len(classes); {
+		if classes[i] != bidiNeutral {
+			i++
+			continue
+		}
+		end := i
+		for end < len(classes) && classes[end] == bidiNeutral {
+			end++
+		}
+		before, after := base, base
+		if i > 0 {
+			before = direction(classes[i-1])
+		}
+		if end < len(classes) {
+			after = direction(classes[end])
+		}
+		resolved := base
+		if before == after {
+			resolved = before
+		}
+		if resolved == bidiNeutral {
+			resolved = bidiL
+		}
+		for ; i < end; i++ {
+			classes[i] = resolved
+		}
+	}
+
+	// embedding levels (I1, I2)
+	levels := make([]int, len(text))
+	maxLevel := 0
+	for i, c := range classes {
+		switch {
+		case baseLevel == 0 && c == bidiR:
+			levels[i] = 1
+		case baseLevel == 0 && c == bidiNumber:
+			levels[i] = 2
+		case baseLevel == 1 && c != bidiR:
+			levels[i] = 2
+		default:
+			levels[i] = baseLevel
+		}
+		if levels[i] > maxLevel {
+			maxLevel = levels[i]
+		}
+	}
+
+	// reverse each run at or above each level, from the highest (L2)
+	display := append([]rune(nil), text...)
+	for i, r := range display {
+		if m, ok := bidiMirror[r]; ok && levels[i]%2 == 1 {
+			display[i] = m
+		}
+	}
+	for level := maxLevel; level >= 1; level-- {
+		for i := 0; i < len(display); {
+			if levels[i] < level {
+				i++
+				continue
+			}
+			end := i
+			for end < len(display) && levels[end] >= level {
+				end++
+			}
+			for l, r := i, end-1; l < r; l, r = l+1, r-1 {
+				display[l], display[r] = display[r], display[l]
+				levels[l], levels[r] = levels[r], levels[l]
+			}
+			i = end
+		}
+	}
+	return display
+}
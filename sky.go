@@ -0,0 +1,372 @@
+package sgl
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// only need these once in the package
+var gradientSkyProgram, atmosphereSkyProgram, proceduralSkyProgram *Program
+
+// called to create and build a procedural sky program, which uses the skybox
+// vertex shader with its own fragment shader.
+func initSkyProgram(name, fragmentShader string, uniforms []string) (*Program, error) {
+	prog := NewProgram()
+	prog.AddShader(VertexShader, skyboxVertexShader,
+		[]string{"projection", "view"},
+		Attribute{Name: "aPos", Type: gl.FLOAT, Size: 3, Stride: 3 * SizeOfFloat, Offset: 0})
+	prog.AddShader(FragmentShader, fragmentShader, uniforms)
+
+	if err := prog.Build(); err != nil {
+		return nil, fmt.Errorf("couldn't build %s sky program: %w", name, err)
+	}
+	BindFog(prog)
+	return prog, nil
+}
+
+// newSkyVao makes the cube drawn by a sky using prog.
+func newSkyVao(prog *Program) *Vao {
+	vao := NewVao(Triangles, NewVbo("vbo", prog.Vertex().Attributes()...))
+	vao.Vbo["vbo"].Initalize(skyboxVertices)
+	return vao
+}
+
+// drawSky draws the cube with prog, which must already be in use, behind
+// everything else.
+func drawSky(prog *Program, vao *Vao, view, projection mgl32.Mat4) {
+	view = view.Mat3().Mat4() // remove translation from the view matrix
+	prog.Vertex().SetMat4("view", 1, &view)
+	prog.Vertex().SetMat4("projection", 1, &projection)
+	gl.DepthFunc(gl.LEQUAL)
+	gl.BindVertexArray(vao.ID)
+	gl.DrawArrays(gl.TRIANGLES, 0, 36)
+	countDraw(gl.TRIANGLES, 36)
+	gl.BindVertexArray(0)
+	gl.DepthFunc(gl.LESS)
+}
+
+// GradientSky is a procedural sky which fades from Horizon to Zenith above
+// the horizon, and from Horizon to Ground below it. It needs no textures.
+type GradientSky struct {
+	Zenith, Horizon, Ground Color
+	Exponent                float32 // larger values make a thinner band of horizon color
+	vao                     *Vao
+}
+
+// NewGradientSky creates a gradient sky with an Exponent of 0.5.
+func NewGradientSky(zenith, horizon, ground Color) (*GradientSky, error) {
+	if gradientSkyProgram == nil {
+		prog, err := initSkyProgram("gradient", gradientSkyFragmentShader, []string{"zenith", "horizon", "ground", "exponent"})
+		if err != nil {
+			return nil, err
+		}
+		gradientSkyProgram = prog
+	}
+	return &GradientSky{
+		Zenith:   zenith,
+		Horizon:  horizon,
+		Ground:   ground,
+		Exponent: 0.5,
+		vao:      newSkyVao(gradientSkyProgram),
+	}, nil
+}
+
+// NewDaySky creates a gradient sky of blue above a hazy horizon.
+func NewDaySky() (*GradientSky, error) {
+	return NewGradientSky(
+		Color{0.22, 0.45, 0.85, 1},
+		Color{0.75, 0.85, 0.95, 1},
+		Color{0.35, 0.33, 0.3, 1})
+}
+
+// NewDuskSky creates a gradient sky of deep blue above an orange horizon.
+func NewDuskSky() (*GradientSky, error) {
+	return NewGradientSky(
+		Color{0.08, 0.1, 0.3, 1},
+		Color{0.95, 0.55, 0.3, 1},
+		Color{0.1, 0.08, 0.08, 1})
+}
+
+// Delete resources.
+func (sky *GradientSky) Delete() {
+	sky.vao.Delete()
+}
+
+// Draw should be called after other objects.
+func (sky *GradientSky) Draw(view, projection mgl32.Mat4) {
+	zenith, horizon, ground := sky.Zenith.Vec4(), sky.Horizon.Vec4(), sky.Ground.Vec4()
+	gradientSkyProgram.Use()
+	gradientSkyProgram.Fragment().SetVec4("zenith", 1, &zenith)
+	gradientSkyProgram.Fragment().SetVec4("horizon", 1, &horizon)
+	gradientSkyProgram.Fragment().SetVec4("ground", 1, &ground)
+	gradientSkyProgram.Fragment().SetFloat("exponent", 1, &sky.Exponent)
+	drawSky(gradientSkyProgram, sky.vao, view, projection)
+}
+
+// AtmosphereSky is a procedural sky using a simple approximation of
+// atmospheric scattering, so its color changes with the sun's height: blue
+// at midday, red and orange at sunset, and dark at night. It needs no
+// textures.
+type AtmosphereSky struct {
+	SunDirection mgl32.Vec3 // direction towards the sun; need not be normalized
+	Exposure     float32    // brightness
+	vao          *Vao
+}
+
+// NewAtmosphereSky creates an atmospheric sky lit from sunDirection, with an
+// Exposure of 1.
+func NewAtmosphereSky(sunDirection mgl32.Vec3) (*AtmosphereSky, error) {
+	if atmosphereSkyProgram == nil {
+		prog, err := initSkyProgram("atmosphere", atmosphereSkyFragmentShader, []string{"sunDirection", "exposure"})
+		if err != nil {
+			return nil, err
+		}
+		atmosphereSkyProgram = prog
+	}
+	return &AtmosphereSky{
+		SunDirection: sunDirection,
+		Exposure:     1,
+		vao:          newSkyVao(atmosphereSkyProgram),
+	}, nil
+}
+
+// Delete resources.
+func (sky *AtmosphereSky) Delete() {
+	sky.vao.Delete()
+}
+
+// Draw should be called after other objects.
+func (sky *AtmosphereSky) Draw(view, projection mgl32.Mat4) {
+	sun := sky.SunDirection.Normalize()
+	atmosphereSkyProgram.Use()
+	atmosphereSkyProgram.Fragment().SetVec3("sunDirection", 1, &sun)
+	atmosphereSkyProgram.Fragment().SetFloat("exposure", 1, &sky.Exposure)
+	drawSky(atmosphereSkyProgram, sky.vao, view, projection)
+}
+
+// ProceduralSky is a physically based day-night sky (the Preetham model),
+// with the sun placed by the time of day. Use GetSunLight() to light the scene
+// to match. It needs no textures.
+type ProceduralSky struct {
+	TimeOfDay float32 // hours, from 0 to 24, where 12 is noon
+	Latitude  float32 // degrees north; tilts the sun's path towards the south
+	Turbidity float32 // haziness, from 2 (clear) to 10 (hazy)
+	Exposure  float32 // brightness
+	vao       *Vao
+}
+
+// NewProceduralSky creates a sky at midday on a clear day, at latitude 40.
+func NewProceduralSky() (*ProceduralSky, error) {
+	if proceduralSkyProgram == nil {
+		prog, err := initSkyProgram("procedural", proceduralSkyFragmentShader, []string{"sunDirection", "turbidity", "exposure"})
+		if err != nil {
+			return nil, err
+		}
+		proceduralSkyProgram = prog
+	}
+	return &ProceduralSky{
+		TimeOfDay: 12,
+		Latitude:  40,
+		Turbidity: 3,
+		Exposure:  1,
+		vao:       newSkyVao(proceduralSkyProgram),
+	}, nil
+}
+
+// Delete resources.
+func (sky *ProceduralSky) Delete() {
+	sky.vao.Delete()
+}
+
+// SunDirection gets the normalized direction towards the sun, with +Y up,
+// +X east, and -Z north. The sun follows its path at an equinox, rising at 6
+// and setting at 18.
+func (sky *ProceduralSky) SunDirection() mgl32.Vec3 {
+	hourAngle := float64(sky.TimeOfDay-12) / 24 * 2 * math.Pi
+	lat := float64(mgl32.DegToRad(sky.Latitude))
+	return mgl32.Vec3{
+		float32(-math.Sin(hourAngle)),
+		float32(math.Cos(hourAngle) * math.Cos(lat)),
+		float32(math.Cos(hourAngle) * math.Sin(lat)),
+	}.Normalize()
+}
+
+// GetSunLight gets a directional light matching the sky: the sun is reddened
+// and dimmed as it nears the horizon, and is off at night, leaving a dim
+// ambient light.
+func (sky *ProceduralSky) GetSunLight() DirectionalLight {
+	sun := sky.SunDirection()
+	height := float64(sun.Y())
+	// amount of air the sunlight passes through, and how much of the
+	// sun is above the horizon
+	airMass := 1 / (math.Max(height, 0) + 0.15)
+	up := smoothstep(-0.1, 0.05, height)
+	haze := float64(sky.Turbidity) / 3
+
+	var color [3]float32
+	for i, beta := range [3]float64{0.58, 1.35, 3.31} {
+		color[i] = float32(math.Exp(-(beta+0.4*haze)*0.25*airMass) * up)
+	}
+	ambient := float32(0.05 + 0.25*up)
+
+	return DirectionalLight{
+		Direction: sun.Mul(-1),
+		Color:     Color{color[0], color[1], color[2], 1},
+		Ambient:   Color{ambient * 0.8, ambient * 0.9, ambient, 1},
+	}
+}
+
+// Draw should be called after other objects.
+func (sky *ProceduralSky) Draw(view, projection mgl32.Mat4) {
+	sun := sky.SunDirection()
+	proceduralSkyProgram.Use()
+	proceduralSkyProgram.Fragment().SetVec3("sunDirection", 1, &sun)
+	proceduralSkyProgram.Fragment().SetFloat("turbidity", 1, &sky.Turbidity)
+	proceduralSkyProgram.Fragment().SetFloat("exposure", 1, &sky.Exposure)
+	drawSky(proceduralSkyProgram, sky.vao, view, projection)
+}
+
+func smoothstep(edge0, edge1, x float64) float64 {
+	t := math.Max(0, math.Min(1, (x-edge0)/(edge1-edge0)))
+	return t * t * (3 - 2*t)
+}
+
+const gradientSkyFragmentShader = `#version 330 core
+` + FogShaderSource + `
+uniform vec4 zenith;
+uniform vec4 horizon;
+uniform vec4 ground;
+uniform float exponent;
+
+in vec3 TexCoords;
+
+out vec4 FragColor;
+
+void main()
+{
+    float h = normalize(TexCoords).y;
+    if (h >= 0.0) {
+        FragColor = mix(horizon, zenith, pow(h, exponent));
+    } else {
+        FragColor = mix(horizon, ground, pow(-h, exponent));
+    }
+    FragColor.rgb = applySkyFog(FragColor.rgb, TexCoords);
+}`
+
+const atmosphereSkyFragmentShader = `#version 330 core
+` + FogShaderSource + `
+uniform vec3 sunDirection;
+uniform float exposure;
+
+in vec3 TexCoords;
+
+out vec4 FragColor;
+
+const float PI = 3.14159265;
+const vec3 betaRayleigh = vec3(0.58, 1.35, 3.31); // scatters blue most
+const vec3 betaMie = vec3(0.4);                  // haze, scatters evenly
+const float g = 0.76;                            // mie forward scattering
+
+// relative amount of air along a ray at height h (sine of elevation)
+float airMass(float h)
+{
+    return 1.0 / (max(h, 0.0) + 0.15);
+}
+
+void main()
+{
+    vec3 dir = normalize(TexCoords);
+    float mu = dot(dir, sunDirection);
+
+    // sunlight reaching the sky, reddened when the sun is low
+    vec3 sunLight = exp(-(betaRayleigh + betaMie) * 0.25 * airMass(sunDirection.y));
+    sunLight *= smoothstep(-0.1, 0.05, sunDirection.y);
+
+    float phaseR = 0.75 * (1.0 + mu * mu);
+    float phaseM = (1.0 - g * g) / (4.0 * PI * pow(1.0 + g * g - 2.0 * g * mu, 1.5));
+    vec3 beta = betaRayleigh + betaMie;
+    vec3 extinction = exp(-beta * 0.25 * airMass(dir.y));
+    vec3 color = sunLight * (betaRayleigh * phaseR + betaMie * phaseM) / beta * (1.0 - extinction);
+
+    // sun disk
+    color += sunLight * extinction * 20.0 * smoothstep(0.9995, 0.9998, mu);
+    // darker ground below the horizon
+    if (dir.y < 0.0) {
+        color *= mix(1.0, 0.3, smoothstep(0.0, 0.1, -dir.y));
+    }
+
+    FragColor = vec4(applySkyFog(1.0 - exp(-color * exposure * 2.0), dir), 1.0);
+}`
+
+const proceduralSkyFragmentShader = `#version 330 core
+` + FogShaderSource + `
+uniform vec3 sunDirection;
+uniform float turbidity;
+uniform float exposure;
+
+in vec3 TexCoords;
+
+out vec4 FragColor;
+
+// perez sky luminance distribution, for Y, x, and y at once
+vec3 perez(float cosTheta, float gamma, float cosGamma, vec3 A, vec3 B, vec3 C, vec3 D, vec3 E)
+{
+    return (1.0 + A * exp(B / cosTheta)) * (1.0 + C * exp(D * gamma) + E * cosGamma * cosGamma);
+}
+
+void main()
+{
+    vec3 dir = normalize(TexCoords);
+    float T = turbidity;
+
+    // distribution coefficients for Y, x, and y
+    vec3 A = vec3(0.1787 * T - 1.4630, -0.0193 * T - 0.2592, -0.0167 * T - 0.2608);
+    vec3 B = vec3(-0.3554 * T + 0.4275, -0.0665 * T + 0.0008, -0.0950 * T + 0.0092);
+    vec3 C = vec3(-0.0227 * T + 5.3251, -0.0004 * T + 0.2125, -0.0079 * T + 0.2102);
+    vec3 D = vec3(0.1206 * T - 2.5771, -0.0641 * T - 0.8989, -0.0441 * T - 1.6537);
+    vec3 E = vec3(-0.0670 * T + 0.3703, -0.0033 * T + 0.0452, -0.0109 * T + 0.0529);
+
+    // the model is only valid with the sun above the horizon
+    float thetaS = acos(clamp(sunDirection.y, 0.0, 1.0));
+    float t = thetaS, t2 = t * t, t3 = t2 * t, T2 = T * T;
+
+    // zenith luminance and chromaticity
+    float chi = (4.0 / 9.0 - T / 120.0) * (3.14159265 - 2.0 * thetaS);
+    float Yz = (4.0453 * T - 4.9710) * tan(chi) - 0.2155 * T + 2.4192;
+    float xz = (0.00166 * t3 - 0.00375 * t2 + 0.00209 * t) * T2
+        + (-0.02903 * t3 + 0.06377 * t2 - 0.03202 * t + 0.00394) * T
+        + (0.11693 * t3 - 0.21196 * t2 + 0.06052 * t + 0.25886);
+    float yz = (0.00275 * t3 - 0.00610 * t2 + 0.00317 * t) * T2
+        + (-0.04214 * t3 + 0.08970 * t2 - 0.04153 * t + 0.00516) * T
+        + (0.15346 * t3 - 0.26756 * t2 + 0.06670 * t + 0.26688);
+
+    float cosTheta = max(dir.y, 0.01);
+    float cosGamma = clamp(dot(dir, sunDirection), -1.0, 1.0);
+    float gamma = acos(cosGamma);
+    vec3 Yxy = vec3(Yz, xz, yz)
+        * perez(cosTheta, gamma, cosGamma, A, B, C, D, E)
+        / perez(1.0, thetaS, cos(thetaS), A, B, C, D, E);
+
+    // Yxy to XYZ to linear rgb
+    vec3 XYZ = vec3(Yxy.y / Yxy.z * Yxy.x, Yxy.x, (1.0 - Yxy.y - Yxy.z) / Yxy.z * Yxy.x);
+    vec3 color = mat3(
+        3.2406, -0.9689, 0.0557,
+        -1.5372, 1.8758, -0.2040,
+        -0.4986, 0.0415, 1.0570) * XYZ;
+    color = max(color, 0.0) * 0.05;
+
+    // sun disk
+    color += vec3(1.0, 0.9, 0.7) * 5.0 * smoothstep(0.9995, 0.9998, cosGamma);
+
+    // fade to night as the sun sets, and darken the ground
+    float day = smoothstep(-0.1, 0.05, sunDirection.y);
+    color = mix(vec3(0.002, 0.004, 0.01), color, day);
+    if (dir.y < 0.0) {
+        color *= mix(1.0, 0.3, smoothstep(0.0, 0.1, -dir.y));
+    }
+
+    FragColor = vec4(applySkyFog(1.0 - exp(-color * exposure), dir), 1.0);
+}`
@@ -23,6 +23,7 @@ func initSkyboxProgram() error {
 	if errBuild != nil {
 		return fmt.Errorf("couldn't build skybox program: %w", errBuild)
 	}
+	BindFog(skyboxProgram)
 	return nil
 }
 
@@ -62,6 +63,7 @@ func NewSkybox(faces []*image.RGBA) (*Skybox, error) {
 // Delete resources.
 func (sky *Skybox) Delete() {
 	sky.Vao.Delete()
+	trackTexture(sky.TextureID, 0)
 	gl.DeleteTextures(1, &sky.TextureID)
 }
 
@@ -77,6 +79,7 @@ func (sky *Skybox) Draw(view, projection mgl32.Mat4) {
 	gl.ActiveTexture(gl.TEXTURE0)
 	gl.BindTexture(gl.TEXTURE_CUBE_MAP, sky.TextureID)
 	gl.DrawArrays(gl.TRIANGLES, 0, 36) // actually draws skybox
+	countDraw(gl.TRIANGLES, 36)
 	gl.BindVertexArray(0)
 	gl.DepthFunc(gl.LESS) // set depth function back to default
 }
@@ -94,7 +97,10 @@ func loadCubemap(faces []*image.RGBA) uint32 {
 	gl.GenTextures(1, &textureID)
 	gl.BindTexture(gl.TEXTURE_CUBE_MAP, textureID)
 
+	var bytes int
 	for i, face := range faces {
+		bytes += face.Bounds().Dx() * face.Bounds().Dy() * 4 // RGB is usually padded
+		restore := unpackRows(face.Stride, 4)
 		gl.TexImage2D(
 			uint32(gl.TEXTURE_CUBE_MAP_POSITIVE_X+i),
 			0,
@@ -104,6 +110,7 @@ func loadCubemap(faces []*image.RGBA) uint32 {
 			gl.RGBA, // image format
 			gl.UNSIGNED_BYTE,
 			gl.Ptr(face.Pix))
+		restore()
 	}
 	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
 	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
@@ -111,6 +118,7 @@ func loadCubemap(faces []*image.RGBA) uint32 {
 	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
 	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
 
+	trackTexture(textureID, bytes)
 	return textureID
 }
 
@@ -130,6 +138,7 @@ void main()
 }`
 
 const skyboxFragmentShader = `#version 330 core
+` + FogShaderSource + `
 uniform samplerCube skybox;
 
 in vec3 TexCoords;
@@ -139,6 +148,7 @@ out vec4 FragColor;
 void main()
 {    
     FragColor = texture(skybox, TexCoords);
+    FragColor.rgb = applySkyFog(FragColor.rgb, TexCoords);
 }`
 
 // positions of vertices each face (2 triangles per face).
@@ -0,0 +1,298 @@
+package sgl
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// PageLoader gets the pixels of rect (in texels of mip level) of a
+// PagedTexture, as tightly packed data in the texture's format: a []byte, or
+// a []float32 for float formats.
+type PageLoader func(level int, rect image.Rectangle) (data interface{}, err error)
+
+// PagedTexture is a prototype of a sparse (virtual) texture, which can be
+// much larger than video memory, such as for large terrain or gigapixel
+// images. Only the pages (tiles) in use are resident: each frame, the
+// visible regions are requested with Request() or RequestRegion(), then
+// Update() loads missing pages with Load and evicts the least recently
+// requested pages beyond MaxPages. It needs GL_ARB_sparse_texture.
+//
+// The small mip levels at the end of the chain (the "tail") are always
+// resident. Sampling a page which isn't resident gives undefined values
+// (usually 0), so a shader that must never see holes can clamp its lod:
+//
+//	textureLod(tex, uv, max(lod, float(tailLevel)))
+type PagedTexture struct {
+	Texture               *Texture2D
+	PageWidth, PageHeight int // in texels
+	Levels                int // mip levels
+	MaxPages              int // resident pages, not counting the tail
+	Load                  PageLoader
+
+	tailLevel int                // first level of the mip tail
+	resident  map[pageKey]uint64 // frame last requested, by page
+	requested map[pageKey]bool   // since the last Update()
+	frame     uint64
+}
+
+// pageKey identifies a page of a PagedTexture.
+type pageKey struct {
+	level, x, y int
+}
+
+// NewPagedTexture creates a sparse width x height texture with mipmaps in
+// format, which must be a sparse-capable (usually uncompressed) format. The
+// mip tail is loaded with load right away.
+func NewPagedTexture(width, height int, format TextureFormat, maxPages int, load PageLoader) (*PagedTexture, error) {
+	if !HasExtension("GL_ARB_sparse_texture") {
+		return nil, fmt.Errorf("paged textures need GL_ARB_sparse_texture")
+	}
+	var pageSizes, pageW, pageH int32
+	gl.GetInternalformativ(gl.TEXTURE_2D, uint32(format.Internal), gl.NUM_VIRTUAL_PAGE_SIZES_ARB, 1, &pageSizes)
+	if pageSizes == 0 {
+		return nil, fmt.Errorf("format 0x%x can't be used for a sparse texture", format.Internal)
+	}
+	gl.GetInternalformativ(gl.TEXTURE_2D, uint32(format.Internal), gl.VIRTUAL_PAGE_SIZE_X_ARB, 1, &pageW)
+	gl.GetInternalformativ(gl.TEXTURE_2D, uint32(format.Internal), gl.VIRTUAL_PAGE_SIZE_Y_ARB, 1, &pageH)
+	if width%int(pageW) != 0 || height%int(pageH) != 0 {
+		return nil, fmt.Errorf("paged texture size %dx%d isn't a multiple of the %dx%d page size", width, height, pageW, pageH)
+	}
+
+	pt := &PagedTexture{
+		Texture:    &Texture2D{Width: int32(width), Height: int32(height), Format: format},
+		PageWidth:  int(pageW),
+		PageHeight: int(pageH),
+		Levels:     mipLevels(width, height),
+		MaxPages:   maxPages,
+		Load:       load,
+		resident:   make(map[pageKey]uint64),
+		requested:  make(map[pageKey]bool),
+	}
+	pt.Texture.ID = backend.GenTexture()
+	backend.BindTexture(gl.TEXTURE_2D, pt.Texture.ID)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_SPARSE_ARB, gl.TRUE)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.VIRTUAL_PAGE_SIZE_INDEX_ARB, 0)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexStorage2D(gl.TEXTURE_2D, int32(pt.Levels), uint32(format.Internal), int32(width), int32(height))
+	var sparseLevels int32
+	gl.GetTexParameteriv(gl.TEXTURE_2D, gl.NUM_SPARSE_LEVELS_ARB, &sparseLevels)
+	pt.tailLevel = int(sparseLevels)
+	backend.BindTexture(gl.TEXTURE_2D, 0)
+	if err := CheckError(); err != nil {
+		pt.Delete()
+		return nil, fmt.Errorf("failed to create paged texture: %w", err)
+	}
+
+	// committing any of the tail commits all of it
+	if pt.tailLevel < pt.Levels {
+		backend.BindTexture(gl.TEXTURE_2D, pt.Texture.ID)
+		w, h := pt.levelSize(pt.tailLevel)
+		gl.TexPageCommitmentARB(gl.TEXTURE_2D, int32(pt.tailLevel), 0, 0, 0, int32(w), int32(h), 1, true)
+		backend.BindTexture(gl.TEXTURE_2D, 0)
+		for level := pt.tailLevel; level < pt.Levels; level++ {
+			w, h := pt.levelSize(level)
+			if err := pt.upload(level, image.Rect(0, 0, w, h)); err != nil {
+				pt.Delete()
+				return nil, err
+			}
+		}
+	}
+	pt.track()
+	return pt, nil
+}
+
+// mipLevels gets the number of levels in a full mip chain.
+func mipLevels(width, height int) int {
+	levels := 1
+	for width > 1 || height > 1 {
+		width, height = width/2, height/2
+		levels++
+	}
+	return levels
+}
+
+// levelSize gets the size of a mip level in texels.
+func (pt *PagedTexture) levelSize(level int) (width, height int) {
+	width, height = int(pt.Texture.Width)>>level, int(pt.Texture.Height)>>level
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	return
+}
+
+// TailLevel gets the first level of the mip tail, which is always resident.
+func (pt *PagedTexture) TailLevel() int { return pt.tailLevel }
+
+// Resident gets the number of resident pages, not counting the tail.
+func (pt *PagedTexture) Resident() int { return len(pt.resident) }
+
+// Request marks page (x, y) of level as needed this frame. Levels in the
+// tail and pages outside the texture are ignored.
+func (pt *PagedTexture) Request(level, x, y int) {
+	if level < 0 || level >= pt.tailLevel || x < 0 || y < 0 {
+		return
+	}
+	w, h := pt.levelSize(level)
+	if x*pt.PageWidth >= w || y*pt.PageHeight >= h {
+		return
+	}
+	pt.requested[pageKey{level, x, y}] = true
+}
+
+// RequestRegion requests the pages covering the region of the texture from
+// min to max (texture coordinates, 0 to 1) at the level of detail needed to
+// draw it screenPixels wide, such as a terrain tile's bounds projected with
+// the camera.
+func (pt *PagedTexture) RequestRegion(min, max mgl32.Vec2, screenPixels float32) {
+	if screenPixels <= 0 || max.X() <= min.X() || max.Y() <= min.Y() {
+		return
+	}
+	texels := (max.X() - min.X()) * float32(pt.Texture.Width)
+	level := int(math.Floor(math.Log2(float64(texels / screenPixels))))
+	if level < 0 {
+		level = 0
+	}
+	if level >= pt.tailLevel {
+		return
+	}
+	w, h := pt.levelSize(level)
+	x0 := int(mgl32.Clamp(min.X(), 0, 1) * float32(w) / float32(pt.PageWidth))
+	y0 := int(mgl32.Clamp(min.Y(), 0, 1) * float32(h) / float32(pt.PageHeight))
+	x1 := int(math.Ceil(float64(mgl32.Clamp(max.X(), 0, 1) * float32(w) / float32(pt.PageWidth))))
+	y1 := int(math.Ceil(float64(mgl32.Clamp(max.Y(), 0, 1) * float32(h) / float32(pt.PageHeight))))
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			pt.Request(level, x, y)
+		}
+	}
+}
+
+// Update loads up to maxLoads of the pages requested since the last Update()
+// which aren't resident, coarsest first, then evicts the least recently
+// requested pages while there are more than MaxPages. It returns the number
+// of requested pages still missing, and the first error from Load.
+func (pt *PagedTexture) Update(maxLoads int) (missing int, err error) {
+	pt.frame++
+	var load []pageKey
+	for page := range pt.requested {
+		if _, ok := pt.resident[page]; ok {
+			pt.resident[page] = pt.frame
+		} else {
+			load = append(load, page)
+		}
+	}
+	for page := range pt.requested {
+		delete(pt.requested, page)
+	}
+
+	sort.Slice(load, func(i, j int) bool { return load[i].level > load[j].level })
+	if len(load) > maxLoads {
+		missing = len(load) - maxLoads
+		load = load[:maxLoads]
+	}
+	for _, page := range load {
+		rect := pt.pageRect(page)
+		pt.commit(page, rect, true)
+		if loadErr := pt.upload(page.level, rect); loadErr != nil {
+			pt.commit(page, rect, false)
+			missing++
+			if err == nil {
+				err = loadErr
+			}
+			continue
+		}
+		pt.resident[page] = pt.frame
+	}
+
+	pt.evict()
+	pt.track()
+	return missing, err
+}
+
+// evict removes the least recently requested pages beyond MaxPages, except
+// those requested this frame.
+func (pt *PagedTexture) evict() {
+	extra := len(pt.resident) - pt.MaxPages
+	if extra <= 0 {
+		return
+	}
+	pages := make([]pageKey, 0, len(pt.resident))
+	for page, frame := range pt.resident {
+		if frame < pt.frame {
+			pages = append(pages, page)
+		}
+	}
+	sort.Slice(pages, func(i, j int) bool { return pt.resident[pages[i]] < pt.resident[pages[j]] })
+	for i := 0; i < extra && i < len(pages); i++ {
+		pt.commit(pages[i], pt.pageRect(pages[i]), false)
+		delete(pt.resident, pages[i])
+	}
+}
+
+// pageRect gets the texels of page, which may be cut off by the edge of its
+// level.
+func (pt *PagedTexture) pageRect(page pageKey) image.Rectangle {
+	w, h := pt.levelSize(page.level)
+	rect := image.Rect(0, 0, pt.PageWidth, pt.PageHeight).Add(image.Pt(page.x*pt.PageWidth, page.y*pt.PageHeight))
+	return rect.Intersect(image.Rect(0, 0, w, h))
+}
+
+// commit makes the memory of a page resident or not.
+func (pt *PagedTexture) commit(page pageKey, rect image.Rectangle, resident bool) {
+	backend.BindTexture(gl.TEXTURE_2D, pt.Texture.ID)
+	gl.TexPageCommitmentARB(gl.TEXTURE_2D, int32(page.level),
+		int32(rect.Min.X), int32(rect.Min.Y), 0,
+		int32(rect.Dx()), int32(rect.Dy()), 1, resident)
+	backend.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+// upload loads rect of level with Load and copies it into the texture.
+func (pt *PagedTexture) upload(level int, rect image.Rectangle) error {
+	data, err := pt.Load(level, rect)
+	if err != nil {
+		return fmt.Errorf("couldn't load page %v of level %d: %w", rect, level, err)
+	}
+	format := pt.Texture.Format
+	stride := rect.Dx() * format.Size
+	if err := checkTextureData(format, rect.Dx(), rect.Dy(), stride, data); err != nil {
+		return fmt.Errorf("bad data for page %v of level %d: %w", rect, level, err)
+	}
+	backend.BindTexture(gl.TEXTURE_2D, pt.Texture.ID)
+	restore := unpackRows(stride, format.Size)
+	backend.TexSubImage2D(gl.TEXTURE_2D, int32(level),
+		int32(rect.Min.X), int32(rect.Min.Y),
+		int32(rect.Dx()), int32(rect.Dy()),
+		format.Format, format.Type, gl.Ptr(data))
+	restore()
+	backend.BindTexture(gl.TEXTURE_2D, 0)
+	countUpload(rect.Dx() * rect.Dy() * format.Size)
+	return nil
+}
+
+// track updates the estimated memory of the resident pages and tail.
+func (pt *PagedTexture) track() {
+	pageBytes := pt.PageWidth * pt.PageHeight * pt.Texture.Format.Size
+	tail := 0
+	for level := pt.tailLevel; level < pt.Levels; level++ {
+		w, h := pt.levelSize(level)
+		tail += w * h * pt.Texture.Format.Size
+	}
+	trackTexture(pt.Texture.ID, len(pt.resident)*pageBytes+tail)
+}
+
+// Delete the texture, freeing all its pages.
+func (pt *PagedTexture) Delete() {
+	pt.Texture.Delete()
+	pt.resident = make(map[pageKey]uint64)
+}
@@ -0,0 +1,211 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/inkyblackness/imgui-go/v4"
+)
+
+// only need this once in the package
+var ssrProgram *Program
+
+// called to create and build the ssr program.
+func initSSRProgram() error {
+	ssrProgram = NewProgram()
+	ssrProgram.AddShader(VertexShader, quadVertexShader,
+		[]string{"projection", "model"},
+		Attribute{Name: "vertex", Type: gl.FLOAT, Size: 4, Stride: 4 * SizeOfFloat, Offset: 0})
+	ssrProgram.AddShader(FragmentShader, ssrFragmentShader,
+		[]string{"color", "normals", "depth", "environment", "useEnvironment", "view",
+			"cameraProjection", "invProjection", "maxDistance", "steps", "thickness", "strength"})
+	if err := ssrProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build ssr program: %w", err)
+	}
+	var color, normals, depth, environment int32 = 0, 1, 2, 3
+	ssrProgram.Fragment().SetInt("color", 1, &color)
+	ssrProgram.Fragment().SetInt("normals", 1, &normals)
+	ssrProgram.Fragment().SetInt("depth", 1, &depth)
+	ssrProgram.Fragment().SetInt("environment", 1, &environment)
+	return nil
+}
+
+// SSR is screen space reflections: a post-processing pass which adds
+// reflections to the lit scene in a GBuffer, in proportion to each pixel's
+// reflectivity and the fresnel effect. Rays are marched through the depth
+// buffer, so only things on screen are reflected; rays which miss, or
+// leave the screen, reflect Environment instead, if set.
+type SSR struct {
+	MaxDistance float32 // longest ray, in world units
+	Steps       int     // along each ray, before refining a hit
+	Thickness   float32 // of surfaces behind the depth buffer, in world units
+	Strength    float32 // scales all reflections
+	Environment *Skybox // reflected where rays miss; may be nil
+	Output      *Texture2D
+
+	fbo uint32
+}
+
+// NewSSR creates a reflection pass.
+func NewSSR() (*SSR, error) {
+	if ssrProgram == nil {
+		if err := initSSRProgram(); err != nil {
+			return nil, err
+		}
+	}
+	return &SSR{MaxDistance: 20, Steps: 48, Thickness: 0.5, Strength: 1}, nil
+}
+
+// Apply composites reflections into the color of g, into Output.
+// view and projection are those used to draw the scene.
+func (ssr *SSR) Apply(g *GBuffer, view, projection mgl32.Mat4) error {
+	if err := resizeColorTarget(&ssr.Output, &ssr.fbo, int(g.Width), int(g.Height)); err != nil {
+		return fmt.Errorf("couldn't create ssr target: %w", err)
+	}
+
+	saved := saveTargetState()
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.BLEND)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, ssr.fbo)
+	gl.Viewport(0, 0, g.Width, g.Height)
+
+	w, h := float32(g.Width), float32(g.Height)
+	invProjection := projection.Inv()
+	steps := int32(ssr.Steps)
+	useEnvironment := boolToInt32(ssr.Environment != nil)
+	quadProjection, model := quadTransform(0, 0, w, h, w, h)
+	ssrProgram.Use()
+	ssrProgram.Vertex().SetMat4("projection", 1, &quadProjection)
+	ssrProgram.Vertex().SetMat4("model", 1, &model)
+	ssrProgram.Fragment().SetMat4("view", 1, &view)
+	ssrProgram.Fragment().SetMat4("cameraProjection", 1, &projection)
+	ssrProgram.Fragment().SetMat4("invProjection", 1, &invProjection)
+	ssrProgram.Fragment().SetFloat("maxDistance", 1, &ssr.MaxDistance)
+	ssrProgram.Fragment().SetInt("steps", 1, &steps)
+	ssrProgram.Fragment().SetFloat("thickness", 1, &ssr.Thickness)
+	ssrProgram.Fragment().SetFloat("strength", 1, &ssr.Strength)
+	ssrProgram.Fragment().SetInt("useEnvironment", 1, &useEnvironment)
+	g.Color.Bind(0)
+	g.Normal.Bind(1)
+	g.Depth.Bind(2)
+	if ssr.Environment != nil {
+		gl.ActiveTexture(gl.TEXTURE3)
+		gl.BindTexture(gl.TEXTURE_CUBE_MAP, ssr.Environment.TextureID)
+	}
+	gl.ActiveTexture(gl.TEXTURE0)
+	drawQuad()
+	saved.restore()
+	return nil
+}
+
+// Gui shows controls for the reflections in a window. Call it inside the
+// func of Window.RenderImgui().
+func (ssr *SSR) Gui(title string) {
+	if imgui.Begin(title + "##ssr") {
+		steps := int32(ssr.Steps)
+		imgui.SliderFloat("strength", &ssr.Strength, 0, 1)
+		imgui.SliderFloat("max distance", &ssr.MaxDistance, 1, 200)
+		imgui.SliderFloat("thickness", &ssr.Thickness, 0.01, 5)
+		if imgui.SliderInt("steps", &steps, 8, 256) {
+			ssr.Steps = int(steps)
+		}
+	}
+	imgui.End()
+}
+
+// Delete resources. The Environment isn't deleted.
+func (ssr *SSR) Delete() {
+	if ssr.Output != nil {
+		ssr.Output.Delete()
+		gl.DeleteFramebuffers(1, &ssr.fbo)
+	}
+}
+
+const ssrFragmentShader = `#version 330 core
+in vec2 TexCoords;
+
+uniform sampler2D color;
+uniform sampler2D normals; // world normal, reflectivity
+uniform sampler2D depth;
+uniform samplerCube environment;
+uniform bool useEnvironment;
+uniform mat4 view;
+uniform mat4 cameraProjection;
+uniform mat4 invProjection;
+uniform float maxDistance;
+uniform int steps;
+uniform float thickness;
+uniform float strength;
+
+out vec4 FragColor;
+
+// viewPosition gets the view space position of the depth buffer at uv.
+vec3 viewPosition(vec2 uv)
+{
+    vec4 p = invProjection * vec4(uv * 2.0 - 1.0, texture(depth, uv).r * 2.0 - 1.0, 1.0);
+    return p.xyz / p.w;
+}
+
+// toScreen gets the texture coords of view space point p.
+vec2 toScreen(vec3 p)
+{
+    vec4 clip = cameraProjection * vec4(p, 1.0);
+    return clip.xy / clip.w * 0.5 + 0.5;
+}
+
+void main()
+{
+    // TexCoords are y down, so flip to sample the same row
+    vec2 uv = vec2(TexCoords.x, 1.0 - TexCoords.y);
+    vec4 lit = texture(color, uv);
+    vec4 surface = texture(normals, uv);
+    if (surface.a <= 0.0 || strength <= 0.0 || texture(depth, uv).r >= 1.0) {
+        FragColor = lit;
+        return;
+    }
+
+    vec3 p = viewPosition(uv);
+    vec3 n = normalize(mat3(view) * surface.xyz);
+    vec3 eye = normalize(p);
+    vec3 r = reflect(eye, n);
+
+    // march, then halve back and forth to find where the ray crosses the
+    // surface it hit
+    vec3 stepVec = r * (maxDistance / float(steps));
+    vec3 ray = p;
+    float hit = 0.0;
+    vec2 hitUV = uv;
+    for (int i = 0; i < steps; i++) {
+        ray += stepVec;
+        hitUV = toScreen(ray);
+        if (any(lessThan(hitUV, vec2(0.0))) || any(greaterThan(hitUV, vec2(1.0))) || ray.z > 0.0) {
+            break;
+        }
+        float behind = viewPosition(hitUV).z - ray.z;
+        if (behind > 0.0 && behind < thickness) {
+            for (int j = 0; j < 6; j++) {
+                stepVec *= 0.5;
+                ray += (viewPosition(hitUV).z - ray.z > 0.0) ? -stepVec : stepVec;
+                hitUV = toScreen(ray);
+            }
+            // fade out towards the screen's edges and the ray's end
+            vec2 edge = smoothstep(0.0, 0.1, hitUV) * (1.0 - smoothstep(0.9, 1.0, hitUV));
+            hit = edge.x * edge.y * (1.0 - float(i) / float(steps));
+            break;
+        }
+    }
+
+    // schlick's fresnel, with reflectivity as the reflectance head on
+    float amount = surface.a + (1.0 - surface.a) * pow(1.0 - max(dot(-eye, n), 0.0), 5.0);
+    amount *= strength;
+
+    vec3 reflected = texture(color, hitUV).rgb;
+    if (useEnvironment) {
+        vec3 worldDir = transpose(mat3(view)) * r;
+        reflected = mix(texture(environment, worldDir).rgb, reflected, hit);
+    } else {
+        amount *= hit;
+    }
+    FragColor = vec4(mix(lit.rgb, reflected, amount), lit.a);
+}`
@@ -0,0 +1,79 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/inkyblackness/imgui-go/v4"
+)
+
+// RenderStats are counts of work done through sgl's wrappers (Vao,
+// Texture2D, Program, Buffer, and the stock drawables) in one frame. Calls
+// made directly to opengl aren't counted.
+type RenderStats struct {
+	DrawCalls       int
+	Triangles       int
+	TextureBinds    int
+	ProgramSwitches int
+	UploadBytes     int // to buffers and textures
+}
+
+var (
+	frameStats  RenderStats // being counted
+	lastStats   RenderStats // last complete frame
+	usedProgram uint32      // to count program switches
+)
+
+// Stats gets the counts for the last complete frame. Frames end at
+// Window.EndFrame().
+func Stats() RenderStats { return lastStats }
+
+// endStatsFrame finishes counting a frame. Called by Window.EndFrame().
+func endStatsFrame() {
+	lastStats = frameStats
+	frameStats = RenderStats{}
+}
+
+// countDraw counts a draw call of count vertices.
+func countDraw(mode uint32, count int32) {
+	frameStats.DrawCalls++
+	switch mode {
+	case gl.TRIANGLES:
+		frameStats.Triangles += int(count / 3)
+	case gl.TRIANGLE_STRIP, gl.TRIANGLE_FAN:
+		if count > 2 {
+			frameStats.Triangles += int(count - 2)
+		}
+	}
+}
+
+// countUpload counts bytes sent to the GPU.
+func countUpload(bytes int) {
+	frameStats.UploadBytes += bytes
+}
+
+// StatsGui shows the frame rate and Stats() in a small window in the top
+// left corner. extras are called inside the window to add more, such as
+// LuminanceAnalyzer.DrawImgui. Call it inside the func of
+// Window.RenderImgui().
+func StatsGui(title string, extras ...func()) {
+	stats := Stats()
+	imgui.SetNextWindowPosV(imgui.Vec2{X: 10, Y: 10}, imgui.ConditionFirstUseEver, imgui.Vec2{})
+	imgui.SetNextWindowBgAlpha(0.6)
+	flags := imgui.WindowFlagsNoDecoration | imgui.WindowFlagsAlwaysAutoResize | imgui.WindowFlagsNoFocusOnAppearing | imgui.WindowFlagsNoNav
+	if imgui.BeginV(title+"##stats", nil, flags) {
+		fps := imgui.CurrentIO().Framerate()
+		imgui.Text(fmt.Sprintf("%.1f fps (%.2f ms)", fps, 1000/fps))
+		imgui.Separator()
+		imgui.Text(fmt.Sprintf("draw calls   %d", stats.DrawCalls))
+		imgui.Text(fmt.Sprintf("triangles    %d", stats.Triangles))
+		imgui.Text(fmt.Sprintf("tex binds    %d", stats.TextureBinds))
+		imgui.Text(fmt.Sprintf("programs     %d", stats.ProgramSwitches))
+		imgui.Text(fmt.Sprintf("uploads      %.1f KiB", float64(stats.UploadBytes)/1024))
+		for _, extra := range extras {
+			imgui.Separator()
+			extra()
+		}
+	}
+	imgui.End()
+}
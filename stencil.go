@@ -0,0 +1,132 @@
+package sgl
+
+import "github.com/go-gl/gl/v3.3-core/gl"
+
+// StencilFunc compares a Stencil's Ref to the stencil buffer.
+type StencilFunc uint32
+
+// Stencil test functions. Each passes if Ref (masked) compares to the
+// stored value (masked) as named, eg StencilLess passes if Ref < stored.
+const (
+	StencilAlways       StencilFunc = gl.ALWAYS
+	StencilNever        StencilFunc = gl.NEVER
+	StencilEqual        StencilFunc = gl.EQUAL
+	StencilNotEqual     StencilFunc = gl.NOTEQUAL
+	StencilLess         StencilFunc = gl.LESS
+	StencilLessEqual    StencilFunc = gl.LEQUAL
+	StencilGreater      StencilFunc = gl.GREATER
+	StencilGreaterEqual StencilFunc = gl.GEQUAL
+)
+
+// StencilOp is what happens to the stencil buffer after a test.
+type StencilOp uint32
+
+// Stencil buffer operations.
+const (
+	StencilKeep     StencilOp = gl.KEEP      // leave it
+	StencilZero     StencilOp = gl.ZERO      // set it to 0
+	StencilReplace  StencilOp = gl.REPLACE   // set it to Ref
+	StencilIncr     StencilOp = gl.INCR      // add 1, up to the max
+	StencilIncrWrap StencilOp = gl.INCR_WRAP // add 1, wrapping to 0
+	StencilDecr     StencilOp = gl.DECR      // subtract 1, down to 0
+	StencilDecrWrap StencilOp = gl.DECR_WRAP // subtract 1, wrapping to the max
+	StencilInvert   StencilOp = gl.INVERT    // flip its bits
+)
+
+// Stencil is the state of the stencil test.
+type Stencil struct {
+	Func      StencilFunc
+	Ref       int32
+	ReadMask  uint32    // bits compared by Func
+	WriteMask uint32    // bits changed by the ops
+	Fail      StencilOp // when the stencil test fails
+	DepthFail StencilOp // when the stencil test passes but the depth test fails
+	Pass      StencilOp // when both pass
+}
+
+// DefaultStencil passes everything and changes nothing, like opengl's
+// initial state.
+var DefaultStencil = Stencil{
+	Func:      StencilAlways,
+	ReadMask:  0xFF,
+	WriteMask: 0xFF,
+	Fail:      StencilKeep,
+	DepthFail: StencilKeep,
+	Pass:      StencilKeep,
+}
+
+// apply sets the stencil test to s, without enabling it.
+func (s Stencil) apply() {
+	backend.StencilFunc(uint32(s.Func), s.Ref, s.ReadMask)
+	backend.StencilMask(s.WriteMask)
+	backend.StencilOp(uint32(s.Fail), uint32(s.DepthFail), uint32(s.Pass))
+}
+
+// currentStencil gets the stencil test set in opengl, whether or not it's
+// enabled.
+func currentStencil() Stencil {
+	var fn, ref, readMask, writeMask, fail, depthFail, pass int32
+	backend.GetIntegerv(gl.STENCIL_FUNC, &fn)
+	backend.GetIntegerv(gl.STENCIL_REF, &ref)
+	backend.GetIntegerv(gl.STENCIL_VALUE_MASK, &readMask)
+	backend.GetIntegerv(gl.STENCIL_WRITEMASK, &writeMask)
+	backend.GetIntegerv(gl.STENCIL_FAIL, &fail)
+	backend.GetIntegerv(gl.STENCIL_PASS_DEPTH_FAIL, &depthFail)
+	backend.GetIntegerv(gl.STENCIL_PASS_DEPTH_PASS, &pass)
+	return Stencil{
+		Func:      StencilFunc(fn),
+		Ref:       ref,
+		ReadMask:  uint32(readMask),
+		WriteMask: uint32(writeMask),
+		Fail:      StencilOp(fail),
+		DepthFail: StencilOp(depthFail),
+		Pass:      StencilOp(pass),
+	}
+}
+
+// PushStencil enables the stencil test with s, leaving the rest of the
+// RenderState as it is. Every push must be matched by PopStencil().
+func PushStencil(s Stencil) {
+	state := CurrentRenderState()
+	state.StencilTest, state.Stencil = true, s
+	PushRenderState(state)
+}
+
+// PopStencil restores the stencil test in effect before the matching
+// PushStencil(). It's the same as PopRenderState().
+func PopStencil() {
+	PopRenderState()
+}
+
+// CurrentStencil gets the stencil test in effect, and false if the test is
+// disabled.
+func CurrentStencil() (Stencil, bool) {
+	return currentStencil(), backend.IsEnabled(gl.STENCIL_TEST)
+}
+
+// DrawMasked draws through a mask, such as for outlines, portals, or
+// clipping UI to a shape. First drawMask() is called to write ref to the
+// stencil buffer, without changing color or depth, then draw() is called
+// with only pixels where the mask was drawn changed, or if inside is false,
+// only those where it wasn't. The framebuffer needs a stencil buffer, which
+// should be cleared first, such as by Window.ClearBuffers().
+func DrawMasked(ref int32, inside bool, drawMask, draw func()) {
+	state := CurrentRenderState()
+	mask := state
+	mask.ColorWrite, mask.DepthWrite = [4]bool{}, false
+	mask.StencilTest, mask.Stencil = true, DefaultStencil
+	mask.Stencil.Ref, mask.Stencil.Pass = ref, StencilReplace
+	PushRenderState(mask)
+	drawMask()
+	PopRenderState()
+
+	masked := state
+	masked.StencilTest, masked.Stencil = true, DefaultStencil
+	masked.Stencil.Ref, masked.Stencil.Func, masked.Stencil.WriteMask = ref, StencilEqual, 0
+	if !inside {
+		masked.Stencil.Func = StencilNotEqual
+	}
+	PushRenderState(masked)
+	draw()
+	PopRenderState()
+}
@@ -0,0 +1,212 @@
+package sgl
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// FrameStream serves frames over HTTP as an MJPEG stream, which browsers
+// show like a video, such as to view a headless app on a remote machine (see
+// ListenToken()) or in CI. Frames are given to it with Publish(); Window does this for the
+// stream in Window.Stream. Adding "?snapshot" to the url gets just the
+// latest frame as a jpeg.
+//
+//	stream := sgl.NewFrameStream(80, 15)
+//	http.Handle("/stream", stream)
+//	go http.ListenAndServe("localhost:8080", nil)
+//	for ... {
+//		draw()
+//		if stream.Wants() {
+//			stream.Publish(headless.ReadImage())
+//		}
+//	}
+type FrameStream struct {
+	Quality int     // jpeg quality, 1 to 100
+	MaxFps  float64 // limit on frames published. 0 is unlimited.
+
+	mu          sync.Mutex
+	frame       []byte        // latest jpeg
+	updated     chan struct{} // closed when frame changes, then replaced
+	clients     int
+	encoding    bool
+	lastPublish time.Time
+	closed      bool
+	server      *http.Server
+}
+
+// NewFrameStream creates a stream with no frames.
+func NewFrameStream(quality int, maxFps float64) *FrameStream {
+	return &FrameStream{
+		Quality: quality,
+		MaxFps:  maxFps,
+		updated: make(chan struct{}),
+	}
+}
+
+// Listen serves the stream at the root of address, in the background. It's
+// an error for address not to be a loopback address, and only requests for
+// localhost, 127.0.0.1, or ::1 are answered, as with DebugServer. Use
+// ListenToken() to serve other machines.
+func (s *FrameStream) Listen(address string) error {
+	return s.ListenToken(address, "")
+}
+
+// ListenToken serves the stream like Listen(), except that if token isn't
+// empty, address may be any address, and requests must send the token,
+// either as "Authorization: Bearer <token>" or a "token" query parameter
+// (eg "http://host:8080/?token=..." in a browser).
+func (s *FrameStream) ListenToken(address, token string) error {
+	if token == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return fmt.Errorf("couldn't start frame stream: %w", err)
+		}
+		if !loopbackHost(host) {
+			return fmt.Errorf("couldn't start frame stream: %q isn't a loopback address; use a token", address)
+		}
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("couldn't start frame stream: %w", err)
+	}
+	s.mu.Lock()
+	s.server = &http.Server{Handler: guardRequests(token, true, s)}
+	s.mu.Unlock()
+	go s.server.Serve(listener)
+	return nil
+}
+
+// Wants returns true if a frame should be published now: someone is
+// watching, the previous frame has been encoded, and MaxFps allows it.
+func (s *FrameStream) Wants() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clients == 0 || s.encoding || s.closed {
+		return false
+	}
+	return s.MaxFps <= 0 || time.Since(s.lastPublish).Seconds() >= 1/s.MaxFps
+}
+
+// Publish encodes img and sends it to everyone watching. Encoding is done in
+// the background, so img must not be changed afterwards. Frames published
+// while the previous is still being encoded are dropped.
+func (s *FrameStream) Publish(img image.Image) {
+	s.mu.Lock()
+	if s.encoding || s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.encoding = true
+	s.lastPublish = time.Now()
+	s.mu.Unlock()
+
+	go func() {
+		var buf bytes.Buffer
+		err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: s.Quality})
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.encoding = false
+		if err != nil || s.closed {
+			return
+		}
+		s.frame = buf.Bytes()
+		close(s.updated)
+		s.updated = make(chan struct{})
+	}()
+}
+
+// next gets the latest frame, first waiting on wait (the channel it last
+// returned, or nil) for a newer one. It waits until a frame is published if
+// there are none. It returns a nil frame if the stream or request ended.
+func (s *FrameStream) next(r *http.Request, wait <-chan struct{}) (frame []byte, nextWait <-chan struct{}) {
+	for {
+		if wait != nil {
+			select {
+			case <-wait:
+			case <-r.Context().Done():
+				return nil, nil
+			}
+		}
+		s.mu.Lock()
+		frame, updated, closed := s.frame, s.updated, s.closed
+		s.mu.Unlock()
+		if closed {
+			return nil, nil
+		}
+		if frame != nil {
+			return frame, updated
+		}
+		wait = updated // nothing published yet
+	}
+}
+
+// ServeHTTP serves the stream, or the latest frame if the url has a
+// "snapshot" parameter.
+func (s *FrameStream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.clients++
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.clients--
+		s.mu.Unlock()
+	}()
+
+	if _, ok := r.URL.Query()["snapshot"]; ok {
+		frame, _ := s.next(r, nil)
+		if frame == nil {
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Content-Length", strconv.Itoa(len(frame)))
+		w.Write(frame)
+		return
+	}
+
+	const boundary = "sglframe"
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+boundary)
+	w.Header().Set("Cache-Control", "no-cache")
+	var wait <-chan struct{}
+	for {
+		var frame []byte
+		if frame, wait = s.next(r, wait); frame == nil {
+			return
+		}
+		_, err := fmt.Fprintf(w, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n", boundary, len(frame))
+		if err == nil {
+			_, err = w.Write(frame)
+		}
+		if err == nil {
+			_, err = w.Write([]byte("\r\n"))
+		}
+		if err != nil {
+			return
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+}
+
+// Close ends all streams, and stops the server started by Listen().
+func (s *FrameStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	close(s.updated)
+	if s.server != nil {
+		return s.server.Close()
+	}
+	return nil
+}
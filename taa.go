@@ -0,0 +1,229 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// only need this once in the package
+var taaProgram *Program
+
+// called to create and build the taa program.
+func initTAAProgram() error {
+	taaProgram = NewProgram()
+	taaProgram.AddShader(VertexShader, quadVertexShader,
+		[]string{"projection", "model"},
+		Attribute{Name: "vertex", Type: gl.FLOAT, Size: 4, Stride: 4 * SizeOfFloat, Offset: 0})
+	taaProgram.AddShader(FragmentShader, taaFragmentShader,
+		[]string{"current", "velocity", "depth", "history", "invViewProj", "prevViewProj",
+			"feedback", "historyValid"})
+	if err := taaProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build taa program: %w", err)
+	}
+	var current, velocity, depth, history int32 = 0, 1, 2, 3
+	taaProgram.Fragment().SetInt("current", 1, &current)
+	taaProgram.Fragment().SetInt("velocity", 1, &velocity)
+	taaProgram.Fragment().SetInt("depth", 1, &depth)
+	taaProgram.Fragment().SetInt("history", 1, &history)
+	return nil
+}
+
+// JitterProjection moves projection by jitter pixels on a width x height
+// screen, to sample a different spot within each pixel.
+func JitterProjection(projection mgl32.Mat4, jitter mgl32.Vec2, width, height int) mgl32.Mat4 {
+	return mgl32.Translate3D(2*jitter.X()/float32(width), 2*jitter.Y()/float32(height), 0).Mul4(projection)
+}
+
+// TAA is temporal anti-aliasing. Each frame the scene is drawn into a
+// GBuffer with the projection jittered by a fraction of a pixel, then
+// Resolve() blends it with the previous frames, reprojected by velocity, so
+// edges are smoothed over several frames. Previous frames are clamped to the
+// colors around each pixel, so moving things don't leave ghosts.
+//
+// Each frame:
+//
+//	gbuffer.Use()
+//	gbuffer.Clear(color)
+//	// draw the scene with taa.Jittered(projection)
+//	taa.Resolve(gbuffer, view, projection)
+//	// post-process or draw taa.Output
+type TAA struct {
+	Feedback float32    // weight of the previous frames, from 0 to 1
+	Samples  int        // jitter positions before repeating
+	Output   *Texture2D // the anti-aliased frame, RGBA16F
+
+	width, height int
+	history       [2]*Texture2D
+	fbos          [2]uint32
+	frame         int
+	prevViewProj  mgl32.Mat4
+	historyValid  bool
+}
+
+// NewTAA creates TAA for a width x height GBuffer.
+func NewTAA(width, height int) (*TAA, error) {
+	if taaProgram == nil {
+		if err := initTAAProgram(); err != nil {
+			return nil, err
+		}
+	}
+	taa := &TAA{Feedback: 0.9, Samples: 8}
+	if err := taa.Resize(width, height); err != nil {
+		return nil, err
+	}
+	return taa, nil
+}
+
+// Resize the history to width x height, such as when the window is
+// resized, which resets it.
+func (taa *TAA) Resize(width, height int) error {
+	taa.deleteHistory()
+	taa.width, taa.height = width, height
+	for i := range taa.history {
+		var err error
+		if taa.history[i], taa.fbos[i], err = newColorTarget(width, height); err != nil {
+			taa.deleteHistory()
+			return fmt.Errorf("couldn't create taa history: %w", err)
+		}
+	}
+	taa.Output = taa.history[0]
+	taa.Reset()
+	return nil
+}
+
+// Reset forgets the previous frames, such as after a camera cut, so they
+// don't smear into the next frame.
+func (taa *TAA) Reset() {
+	taa.historyValid = false
+}
+
+// Jitter gets this frame's offset in pixels, from -0.5 to 0.5.
+func (taa *TAA) Jitter() mgl32.Vec2 {
+	return FrameJitter(uint64(taa.frame), taa.Samples)
+}
+
+// Jittered gets projection jittered for this frame.
+func (taa *TAA) Jittered(projection mgl32.Mat4) mgl32.Mat4 {
+	return JitterProjection(projection, taa.Jitter(), taa.width, taa.height)
+}
+
+// Resolve blends the frame drawn in g with the previous frames into Output.
+// view and projection are this frame's, without jitter.
+func (taa *TAA) Resolve(g *GBuffer, view, projection mgl32.Mat4) {
+	viewProj := projection.Mul4(view)
+	invViewProj := viewProj.Inv()
+	valid := boolToInt32(taa.historyValid)
+	read, write := taa.frame%2, (taa.frame+1)%2
+
+	saved := saveTargetState()
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.BLEND)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, taa.fbos[write])
+	gl.Viewport(0, 0, int32(taa.width), int32(taa.height))
+
+	w, h := float32(taa.width), float32(taa.height)
+	quadProjection, model := quadTransform(0, 0, w, h, w, h)
+	taaProgram.Use()
+	taaProgram.Vertex().SetMat4("projection", 1, &quadProjection)
+	taaProgram.Vertex().SetMat4("model", 1, &model)
+	taaProgram.Fragment().SetMat4("invViewProj", 1, &invViewProj)
+	taaProgram.Fragment().SetMat4("prevViewProj", 1, &taa.prevViewProj)
+	taaProgram.Fragment().SetFloat("feedback", 1, &taa.Feedback)
+	taaProgram.Fragment().SetInt("historyValid", 1, &valid)
+	g.Color.Bind(0)
+	g.Velocity.Bind(1)
+	g.Depth.Bind(2)
+	taa.history[read].Bind(3)
+	gl.ActiveTexture(gl.TEXTURE0)
+	drawQuad()
+	saved.restore()
+
+	taa.Output = taa.history[write]
+	taa.frame++
+	taa.prevViewProj = viewProj
+	taa.historyValid = true
+}
+
+// deleteHistory deletes the history textures and framebuffers.
+func (taa *TAA) deleteHistory() {
+	for i := range taa.history {
+		if taa.history[i] != nil {
+			taa.history[i].Delete()
+			taa.history[i] = nil
+			gl.DeleteFramebuffers(1, &taa.fbos[i])
+		}
+	}
+}
+
+// Delete resources.
+func (taa *TAA) Delete() {
+	taa.deleteHistory()
+}
+
+const taaFragmentShader = `#version 330 core
+` + cameraMotionShaderSource + `
+in vec2 TexCoords;
+
+uniform sampler2D current;
+uniform sampler2D velocity;
+uniform sampler2D depth;
+uniform sampler2D history;
+uniform mat4 invViewProj;  // this frame's
+uniform mat4 prevViewProj; // last frame's
+uniform float feedback;
+uniform bool historyValid;
+
+out vec4 FragColor;
+
+float luma(vec3 c)
+{
+    return dot(c, vec3(0.2126, 0.7152, 0.0722));
+}
+
+void main()
+{
+    // TexCoords are y down, so flip to sample the same row
+    vec2 uv = vec2(TexCoords.x, 1.0 - TexCoords.y);
+    vec2 texel = 1.0 / vec2(textureSize(current, 0));
+
+    // the range of colors around the pixel, and the nearest depth, whose
+    // velocity keeps the edges of moving things sharp
+    vec3 lo = vec3(1e9), hi = vec3(-1e9), center = vec3(0.0);
+    float nearest = 1.0;
+    vec2 nearestUV = uv;
+    for (int y = -1; y <= 1; y++) {
+        for (int x = -1; x <= 1; x++) {
+            vec2 p = uv + vec2(x, y) * texel;
+            vec3 c = texture(current, p).rgb;
+            lo = min(lo, c);
+            hi = max(hi, c);
+            if (x == 0 && y == 0) {
+                center = c;
+            }
+            float d = texture(depth, p).r;
+            if (d < nearest) {
+                nearest = d;
+                nearestUV = p;
+            }
+        }
+    }
+
+    vec2 motion = texture(velocity, nearestUV).xy;
+    if (motion == vec2(0.0)) {
+        // static, so reproject with the camera
+        motion = cameraMotion(uv, nearest, invViewProj, prevViewProj);
+    }
+    vec2 prevUV = uv - motion;
+    if (!historyValid || any(lessThan(prevUV, vec2(0.0))) || any(greaterThan(prevUV, vec2(1.0)))) {
+        FragColor = vec4(center, 1.0);
+        return;
+    }
+
+    vec3 previous = clamp(texture(history, prevUV).rgb, lo, hi);
+    // weigh by inverse luminance so bright pixels don't flicker
+    float wc = (1.0 - feedback) / (1.0 + luma(center));
+    float wp = feedback / (1.0 + luma(previous));
+    FragColor = vec4((center * wc + previous * wp) / (wc + wp), 1.0);
+}`
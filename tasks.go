@@ -0,0 +1,154 @@
+package sgl
+
+import (
+	"log"
+	"runtime"
+	"runtime/debug"
+)
+
+// Tasks runs script-like functions alongside the render loop. Each task
+// runs in its own goroutine, but only while the main loop waits for it in
+// Update(), so tasks run one at a time and in a deterministic order. This
+// allows sequential "cutscene" style code without manual state machines:
+//
+//	tasks.Start(func(t *Task) {
+//		fadeIn()
+//		t.WaitSeconds(2)
+//		for !player.ReachedDoor() {
+//			t.Yield()
+//		}
+//		openDoor()
+//	})
+//
+// Tasks are not run on the main (OpenGL) thread, so they must not make
+// OpenGL calls.
+type Tasks struct {
+	running []*Task
+	started []*Task // started since the last Update()
+}
+
+// Task is a single function being run by Tasks. The methods that wait
+// may only be called from the task's own function.
+type Task struct {
+	resume chan bool     // true to continue, false to cancel
+	yield  chan struct{} // task paused or finished
+
+	waitSeconds float64
+	waitFrames  int
+	cancelled   bool
+	done        bool
+	panicked    interface{} // value of a panic in the task function
+	stack       []byte      // of the task when it panicked
+}
+
+// Start creates a task running fn. It first runs during the next Update().
+func (ts *Tasks) Start(fn func(t *Task)) *Task {
+	t := &Task{
+		resume: make(chan bool),
+		yield:  make(chan struct{}),
+	}
+	go t.run(fn)
+	ts.started = append(ts.started, t)
+	return t
+}
+
+func (t *Task) run(fn func(t *Task)) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.panicked, t.stack = r, debug.Stack()
+		}
+		t.done = true
+		t.yield <- struct{}{}
+	}()
+
+	if !<-t.resume {
+		return // cancelled before it ever ran
+	}
+	fn(t)
+}
+
+// Yield pauses the task until the next frame.
+func (t *Task) Yield() {
+	t.yield <- struct{}{}
+	if !<-t.resume {
+		runtime.Goexit() // cancelled. deferred funcs in the task still run.
+	}
+}
+
+// WaitSeconds pauses the task for at least the given number of seconds, as
+// measured by the dt passed to Tasks.Update().
+func (t *Task) WaitSeconds(seconds float64) {
+	t.waitSeconds = seconds
+	t.Yield()
+}
+
+// WaitFrames pauses the task for n frames. WaitFrames(1) is the same as Yield().
+func (t *Task) WaitFrames(n int) {
+	t.waitFrames = n - 1
+	t.Yield()
+}
+
+// Cancel stops the task at the point it is waiting. It will not be resumed,
+// though functions it deferred are run during the next Update().
+func (t *Task) Cancel() { t.cancelled = true }
+
+// Done returns true if the task has finished or was cancelled.
+func (t *Task) Done() bool { return t.done }
+
+// Update resumes every task that is ready to continue, one at a time in the
+// order they were started. Call once each frame with the frame's time delta.
+// If a task panics, Update logs the task's stack and panics with the same
+// value.
+func (ts *Tasks) Update(dt float64) {
+	ts.running = append(ts.running, ts.started...)
+	ts.started = ts.started[:0]
+
+	for _, t := range ts.running {
+		if t.done {
+			continue
+		}
+		if !t.cancelled {
+			if t.waitSeconds > 0 {
+				t.waitSeconds -= dt
+				if t.waitSeconds > 0 {
+					continue
+				}
+			}
+			if t.waitFrames > 0 {
+				t.waitFrames--
+				continue
+			}
+		}
+
+		t.resume <- !t.cancelled
+		<-t.yield // wait for task to yield or finish
+
+		if t.panicked != nil {
+			log.Printf("sgl: task panicked: %v\n%s", t.panicked, t.stack)
+			panic(t.panicked)
+		}
+	}
+
+	active := ts.running[:0]
+	for _, t := range ts.running {
+		if !t.done {
+			active = append(active, t)
+		}
+	}
+	ts.running = active
+}
+
+// Len gets the number of unfinished tasks.
+func (ts *Tasks) Len() int {
+	return len(ts.running) + len(ts.started)
+}
+
+// CancelAll cancels every task.
+func (ts *Tasks) CancelAll() {
+	for _, t := range ts.running {
+		t.Cancel()
+	}
+	for _, t := range ts.started {
+		t.Cancel()
+	}
+}
@@ -0,0 +1,104 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// TextChord executes when a sequence of characters is typed, such as ":wq"
+// or a cheat code. Unlike Chord, it follows the characters typed rather than
+// which keys are down, so it respects the keyboard layout, shift, and input
+// methods.
+type TextChord struct {
+	Name          string  // Optional name, used to identify the chord
+	Text          string  // The characters to type
+	Execute       func()  // The function to execute
+	Timeout       float64 // Max time (seconds) between characters. 0 for no limit.
+	CaseSensitive bool    // When set, "ABC" doesn't match "abc"
+	Context       string  // Context in which the chord is active. Empty means all contexts.
+
+	typed     []rune    // the last characters typed, at most as many as Text
+	lastTyped time.Time // when the last character was typed
+}
+
+// Typed adds a typed character to the sequence and returns true if the
+// sequence now ends with Text. The sequence starts over after a match, or
+// if more than Timeout seconds have passed since the previous character.
+func (c *TextChord) Typed(char rune, now time.Time) bool {
+	if c.Timeout > 0 && now.Sub(c.lastTyped).Seconds() > c.Timeout {
+		c.typed = c.typed[:0]
+	}
+	c.lastTyped = now
+
+	n := len([]rune(c.Text))
+	if n == 0 {
+		return false
+	}
+	c.typed = append(c.typed, char)
+	if len(c.typed) > n {
+		c.typed = append(c.typed[:0], c.typed[len(c.typed)-n:]...)
+	}
+	typed := string(c.typed)
+	matched := typed == c.Text || (!c.CaseSensitive && strings.EqualFold(typed, c.Text))
+	if matched {
+		c.typed = c.typed[:0]
+	}
+	return matched
+}
+
+// Reset forgets the characters typed so far.
+func (c *TextChord) Reset() { c.typed = c.typed[:0] }
+
+// TextChordSet is a logical grouping of (related) TextChords.
+type TextChordSet []TextChord
+
+// Typed adds a typed character to each chord's sequence and runs the
+// function of each chord it completes.
+func (cs TextChordSet) Typed(char rune) {
+	cs.typed(char, nil)
+}
+
+// typed adds char to the chords for which active returns true, or all
+// chords if active is nil, and runs those completed.
+func (cs TextChordSet) typed(char rune, active func(*TextChord) bool) {
+	now := time.Now()
+	for i := range cs {
+		if active != nil && !active(&cs[i]) {
+			continue
+		}
+		if cs[i].Typed(char, now) && cs[i].Execute != nil {
+			cs[i].Execute()
+		}
+	}
+}
+
+// Find gets the first TextChord in the set with the given name, or nil if
+// there is none.
+func (cs TextChordSet) Find(name string) *TextChord {
+	for i := range cs {
+		if cs[i].Name == name {
+			return &cs[i]
+		}
+	}
+	return nil
+}
+
+// AddTextChords runs the chords in the set as characters are typed into
+// the window. Like ExecuteChords(), only chords active in the Window's
+// current Contexts get characters, and none do while imgui is capturing the
+// keyboard, such as while typing into a text box.
+func (platform *Window) AddTextChords(set TextChordSet) {
+	active := func(c *TextChord) bool {
+		return platform.Contexts.Active(c.Context)
+	}
+	platform.AddCharCallback(func(_ *glfw.Window, char rune) {
+		if platform.CapturesKeyboard() {
+			return
+		}
+		set.typed(char, active)
+	})
+}
@@ -0,0 +1,329 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// TextField is a single line text input drawn with a CharacterDict, for apps
+// which don't use imgui. It takes keys and characters from the window's
+// callbacks while focused, so held keys repeat at the system's rate. Clicking
+// the field focuses it and places the cursor, and clicking elsewhere or
+// pressing Escape unfocuses it. Shift with the arrows, Home, and End selects,
+// Ctrl moves by word, and Ctrl+A, C, X, and V select all, copy, cut, and
+// paste.
+//
+// Each frame, something like
+//
+//	field.Draw(x, y, width, height)
+type TextField struct {
+	Width     float32 // of the box, in pixels
+	Scale     float32 // of the text
+	MaxLength int     // in characters; 0 for no limit
+	Focused   bool
+
+	TextColor      Color
+	SelectionColor Color
+	BoxColor       Color
+	BorderColor    Color // while focused
+
+	// Called when Enter is pressed while focused.
+	OnEnter func(text string)
+
+	dict     *CharacterDict
+	platform *Window
+	shapes   *ShapeBatch
+	text     []rune
+	cursor   int     // index in text
+	anchor   int     // other end of the selection; cursor if none
+	scroll   float32 // pixels of text hidden at the left
+	x, y     float32 // of the last draw
+	blink    float64 // Clock.RealTime the cursor was last moved
+	deleted  bool
+}
+
+// NewTextField creates an empty, unfocused field width pixels wide, taking
+// input from platform.
+func NewTextField(platform *Window, dict *CharacterDict, width float32) (*TextField, error) {
+	shapes, err := NewShapeBatch()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create text field: %w", err)
+	}
+	f := &TextField{
+		Width:          width,
+		Scale:          1,
+		TextColor:      Color{1, 1, 1, 1},
+		SelectionColor: Color{0.25, 0.45, 0.8, 1},
+		BoxColor:       Color{0.1, 0.1, 0.1, 0.9},
+		BorderColor:    Color{0.4, 0.6, 1, 1},
+		dict:           dict,
+		platform:       platform,
+		shapes:         shapes,
+	}
+	platform.AddKeyCallback(f.key)
+	platform.AddCharCallback(f.char)
+	platform.AddMouseButtonCallback(f.mouseButton)
+	return f, nil
+}
+
+// Text gets the field's text.
+func (f *TextField) Text() string { return string(f.text) }
+
+// SetText replaces the text, moving the cursor to the end.
+func (f *TextField) SetText(text string) {
+	f.text = []rune(text)
+	if f.MaxLength > 0 && len(f.text) > f.MaxLength {
+		f.text = f.text[:f.MaxLength]
+	}
+	f.cursor, f.anchor = len(f.text), len(f.text)
+	f.moved()
+}
+
+// Selection gets the selected text.
+func (f *TextField) Selection() string {
+	start, end := f.selected()
+	return string(f.text[start:end])
+}
+
+// SelectAll selects all the text.
+func (f *TextField) SelectAll() {
+	f.anchor, f.cursor = 0, len(f.text)
+	f.moved()
+}
+
+// Height gets the height of the box in pixels.
+func (f *TextField) Height() float32 { return (f.dict.fh + 4) * f.Scale }
+
+// selected gets the start and end of the selection.
+func (f *TextField) selected() (start, end int) {
+	if f.anchor < f.cursor {
+		return f.anchor, f.cursor
+	}
+	return f.cursor, f.anchor
+}
+
+// moved restarts the cursor's blink, so it's visible while typing.
+func (f *TextField) moved() { f.blink = f.platform.Clock.RealTime }
+
+// insert replaces the selection with s, as much as fits in MaxLength.
+func (f *TextField) insert(s []rune) {
+	start, end := f.selected()
+	if f.MaxLength > 0 {
+		room := f.MaxLength - (len(f.text) - (end - start))
+		if room < len(s) {
+			s = s[:maxInt(room, 0)]
+		}
+	}
+	text := make([]rune, 0, len(f.text)-(end-start)+len(s))
+	text = append(text, f.text[:start]...)
+	text = append(text, s...)
+	f.text = append(text, f.text[end:]...)
+	f.cursor = start + len(s)
+	f.anchor = f.cursor
+	f.moved()
+}
+
+// wordBoundary gets the start of the word before (dir -1) or the end of the
+// word after (dir 1) the cursor.
+func (f *TextField) wordBoundary(dir int) int {
+	i := f.cursor
+	if dir < 0 {
+		for i > 0 && unicode.IsSpace(f.text[i-1]) {
+			i--
+		}
+		for i > 0 && !unicode.IsSpace(f.text[i-1]) {
+			i--
+		}
+		return i
+	}
+	for i < len(f.text) && unicode.IsSpace(f.text[i]) {
+		i++
+	}
+	for i < len(f.text) && !unicode.IsSpace(f.text[i]) {
+		i++
+	}
+	return i
+}
+
+// moveTo moves the cursor to i, extending the selection if selecting.
+func (f *TextField) moveTo(i int, selecting bool) {
+	f.cursor = i
+	if !selecting {
+		f.anchor = i
+	}
+	f.moved()
+}
+
+// key is the window's key callback.
+func (f *TextField) key(_ *glfw.Window, key glfw.Key, _ int, action glfw.Action, mods glfw.ModifierKey) {
+	if f.deleted || !f.Focused || action == glfw.Release {
+		return
+	}
+	shift := mods&glfw.ModShift != 0
+	ctrl := mods&(glfw.ModControl|glfw.ModSuper) != 0
+	start, end := f.selected()
+	switch key {
+	case glfw.KeyLeft:
+		switch {
+		case ctrl:
+			f.moveTo(f.wordBoundary(-1), shift)
+		case start != end && !shift:
+			f.moveTo(start, false)
+		case f.cursor > 0:
+			f.moveTo(f.cursor-1, shift)
+		}
+	case glfw.KeyRight:
+		switch {
+		case ctrl:
+			f.moveTo(f.wordBoundary(1), shift)
+		case start != end && !shift:
+			f.moveTo(end, false)
+		case f.cursor < len(f.text):
+			f.moveTo(f.cursor+1, shift)
+		}
+	case glfw.KeyHome:
+		f.moveTo(0, shift)
+	case glfw.KeyEnd:
+		f.moveTo(len(f.text), shift)
+	case glfw.KeyBackspace:
+		if start == end && f.cursor > 0 {
+			f.anchor = f.cursor - 1
+			if ctrl {
+				f.anchor = f.wordBoundary(-1)
+			}
+		}
+		f.insert(nil)
+	case glfw.KeyDelete:
+		if start == end && f.cursor < len(f.text) {
+			f.anchor = f.cursor + 1
+			if ctrl {
+				f.anchor = f.wordBoundary(1)
+			}
+		}
+		f.insert(nil)
+	case glfw.KeyA:
+		if ctrl {
+			f.SelectAll()
+		}
+	case glfw.KeyC, glfw.KeyX:
+		if ctrl && start != end {
+			f.platform.SetClipboardText(f.Selection())
+			if key == glfw.KeyX {
+				f.insert(nil)
+			}
+		}
+	case glfw.KeyV:
+		if ctrl {
+			var pasted []rune
+			for _, r := range f.platform.ClipboardText() {
+				if unicode.IsPrint(r) {
+					pasted = append(pasted, r)
+				}
+			}
+			f.insert(pasted)
+		}
+	case glfw.KeyEnter, glfw.KeyKPEnter:
+		if f.OnEnter != nil && action == glfw.Press {
+			f.OnEnter(f.Text())
+		}
+	case glfw.KeyEscape:
+		f.Focused = false
+	}
+}
+
+// char is the window's character callback.
+func (f *TextField) char(_ *glfw.Window, char rune) {
+	if f.deleted || !f.Focused {
+		return
+	}
+	f.insert([]rune{char})
+}
+
+// mouseButton is the window's mouse button callback.
+func (f *TextField) mouseButton(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+	if f.deleted || button != glfw.MouseButtonLeft || action != glfw.Press {
+		return
+	}
+	mx, my := w.GetCursorPos()
+	x, y := float32(mx), float32(my)
+	if x < f.x || x > f.x+f.Width || y < f.y || y > f.y+f.Height() {
+		f.Focused = false
+		return
+	}
+	f.Focused = true
+	f.moveTo(f.indexAt(x), mods&glfw.ModShift != 0)
+}
+
+// indexAt gets the index in text nearest to x, in pixels.
+func (f *TextField) indexAt(x float32) int {
+	pad := 2 * f.Scale
+	x -= f.x + pad - f.scroll
+	for i := range f.text {
+		left := f.dict.Width(string(f.text[:i]), f.Scale)
+		right := f.dict.Width(string(f.text[:i+1]), f.Scale)
+		if x < (left+right)/2 {
+			return i
+		}
+	}
+	return len(f.text)
+}
+
+// Draw the field with its top left at (x, y), in pixels with (0, 0) at the
+// top left of a screen of size (width, height), like
+// CharacterDict.DrawString(). Clicks are tested in the window's screen
+// coordinates, so these should be the same. Blending should be enabled.
+func (f *TextField) Draw(x, y, width, height float32) {
+	f.x, f.y = x, y
+	pad := 2 * f.Scale
+	h := f.Height()
+	inner := f.Width - 2*pad
+
+	// scroll to keep the cursor in view
+	cursorX := f.dict.Width(string(f.text[:f.cursor]), f.Scale)
+	if cursorX-f.scroll > inner {
+		f.scroll = cursorX - inner
+	}
+	if cursorX < f.scroll {
+		f.scroll = cursorX
+	}
+	f.scroll = maxFloat(0, minFloat(f.scroll, f.dict.Width(string(f.text), f.Scale)-inner))
+
+	f.shapes.Clear()
+	min, max := mgl32.Vec2{x, y}, mgl32.Vec2{x + f.Width, y + h}
+	f.shapes.RoundedRect(min, max, pad, f.BoxColor)
+	if f.Focused {
+		f.shapes.RoundedRectOutline(min, max, pad, 1, f.BorderColor)
+	}
+	textX, textY := x+pad-f.scroll, y+pad
+	if start, end := f.selected(); f.Focused && start != end {
+		left := f.dict.Width(string(f.text[:start]), f.Scale)
+		right := f.dict.Width(string(f.text[:end]), f.Scale)
+		f.shapes.RoundedRect(mgl32.Vec2{textX + left, textY},
+			mgl32.Vec2{textX + right, textY + f.dict.fh*f.Scale}, 0, f.SelectionColor)
+	}
+	// the cursor is on for half a second, then off for half
+	if elapsed := f.platform.Clock.RealTime - f.blink; f.Focused && int(elapsed*2)%2 == 0 {
+		f.shapes.RoundedRect(mgl32.Vec2{textX + cursorX, textY},
+			mgl32.Vec2{textX + cursorX + f.Scale, textY + f.dict.fh*f.Scale}, 0, f.TextColor)
+	}
+
+	PushClipRect(int32(x), int32(y), int32(f.Width), int32(h))
+	f.shapes.Draw(width, height)
+	// DrawString() scales y along with the text
+	color := mgl32.Vec3{f.TextColor.R, f.TextColor.G, f.TextColor.B}
+	f.dict.DrawString(string(f.text), textX, textY/f.Scale, f.Scale, color, width, height)
+	PopClipRect()
+}
+
+// Delete resources. The field stops taking input, and the CharacterDict is
+// not deleted.
+func (f *TextField) Delete() {
+	f.deleted = true
+	f.Focused = false
+	f.shapes.Delete()
+}
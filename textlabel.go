@@ -0,0 +1,168 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// only need this once in the package
+var labelProgram *Program
+
+// called to create and build the text label program.
+func initLabelProgram() error {
+	labelProgram = NewProgram()
+	labelProgram.AddShader(VertexShader, quadVertexShader,
+		[]string{"projection", "model"},
+		Attribute{Name: "vertex", Type: gl.FLOAT, Size: 4, Stride: 4 * SizeOfFloat, Offset: 0})
+	labelProgram.AddShader(FragmentShader, labelFragmentShader, []string{"text", "textColor"})
+
+	if err := labelProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build text label program: %w", err)
+	}
+	return nil
+}
+
+// TextLabel is text which is drawn once into a texture, then drawn as a
+// single quad until it changes. It's much cheaper than
+// CharacterDict.DrawString() for text that changes rarely, such as HUD
+// labels. The color can be changed freely without drawing the text again.
+type TextLabel struct {
+	dict  *CharacterDict
+	text  string
+	scale float32
+	fbo   *Fbo
+	dirty bool
+}
+
+// NewTextLabel creates a label of text drawn with dict at the given scale.
+// The text is drawn into the texture on the first Draw().
+func NewTextLabel(dict *CharacterDict, text string, scale float32) *TextLabel {
+	return &TextLabel{
+		dict:  dict,
+		text:  text,
+		scale: scale,
+		dirty: true,
+	}
+}
+
+// Delete resources. The CharacterDict is not deleted.
+func (l *TextLabel) Delete() {
+	if l.fbo != nil {
+		l.fbo.Delete()
+		l.fbo = nil
+	}
+}
+
+// Text gets the label's text.
+func (l *TextLabel) Text() string { return l.text }
+
+// SetText changes the label's text. It's drawn again only if changed.
+func (l *TextLabel) SetText(text string) {
+	if text != l.text {
+		l.text = text
+		l.dirty = true
+	}
+}
+
+// Localize sets the label's text to key translated by loc, and changes it
+// again whenever loc's language changes.
+func (l *TextLabel) Localize(loc *Localizer, key string) {
+	l.SetText(loc.T(key))
+	loc.OnLanguageChange(func(string) { l.SetText(loc.T(key)) })
+}
+
+// SetScale changes the size of the text.
+func (l *TextLabel) SetScale(scale float32) {
+	if scale != l.scale {
+		l.scale = scale
+		l.dirty = true
+	}
+}
+
+// Invalidate causes the text to be drawn again on the next Draw(), such as
+// after the CharacterDict is changed.
+func (l *TextLabel) Invalidate() { l.dirty = true }
+
+// Size gets the size of the label in pixels.
+func (l *TextLabel) Size() (width, height float32) {
+	return l.dict.Width(l.text, l.scale), l.dict.fh * l.scale
+}
+
+// render draws the text into the fbo, resizing it if needed.
+func (l *TextLabel) render() error {
+	w, h := l.Size()
+	fw, fh := int32(w+0.5), int32(h+0.5)
+	if l.fbo == nil || l.fbo.Width != fw || l.fbo.Height != fh {
+		l.Delete()
+		fbo, err := NewFbo(int(fw), int(fh))
+		if err != nil {
+			return fmt.Errorf("couldn't create text label: %w", err)
+		}
+		l.fbo = fbo
+	}
+
+	saved := saveTargetState()
+
+	// white text on black, so the red channel is the text's coverage
+	l.fbo.Use()
+	gl.Viewport(0, 0, fw, fh)
+	gl.ClearColor(0, 0, 0, 0)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	gl.Enable(gl.BLEND)
+	gl.Disable(gl.DEPTH_TEST)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	l.dict.DrawString(l.text, 0, 0, l.scale, mgl32.Vec3{1, 1, 1}, float32(fw), float32(fh))
+
+	saved.restore()
+
+	l.dirty = false
+	return nil
+}
+
+// Draw the label with its top left at (x, y), in pixels with (0, 0) at the
+// top left of a screen of size (width, height), like
+// CharacterDict.DrawString(). Blending should be enabled.
+func (l *TextLabel) Draw(x, y float32, color mgl32.Vec3, width, height float32) error {
+	if l.text == "" {
+		return nil
+	}
+	if labelProgram == nil {
+		if err := initLabelProgram(); err != nil {
+			return err
+		}
+	}
+	if l.dirty {
+		if err := l.render(); err != nil {
+			return err
+		}
+	}
+
+	w, h := l.Size()
+	projection, model := quadTransform(x, y, w, h, width, height)
+	labelProgram.Use()
+	labelProgram.Vertex().SetMat4("projection", 1, &projection)
+	labelProgram.Vertex().SetMat4("model", 1, &model)
+	labelProgram.Fragment().SetVec3("textColor", 1, &color)
+
+	l.fbo.ColorBuffer.Bind(0)
+	drawQuad()
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return nil
+}
+
+const labelFragmentShader = `#version 330 core
+uniform sampler2D text;
+uniform vec3 textColor;
+
+in vec2 TexCoords;
+
+out vec4 FragColor;
+
+void main()
+{
+    // the fbo's origin is the bottom left, but the quad's is the top left
+    float alpha = texture(text, vec2(TexCoords.x, 1.0 - TexCoords.y)).r;
+    FragColor = vec4(textColor, alpha);
+}`
@@ -5,6 +5,7 @@ import (
 	"image"
 	"image/draw"
 	"os"
+	"unsafe"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 )
@@ -47,6 +48,38 @@ func OpenImages(filenames ...string) ([]*image.RGBA, error) {
 type Texture2D struct {
 	ID            uint32
 	Width, Height int32
+	Format        TextureFormat // zero value is treated as TexRGBA8
+}
+
+// TextureFormat describes how texture data is stored in opengl and in memory.
+type TextureFormat struct {
+	Internal int32  // internal format, eg gl.R8
+	Format   uint32 // format of the source data, eg gl.RED
+	Type     uint32 // type of each channel of the source data, eg gl.UNSIGNED_BYTE
+	Size     int    // bytes per pixel of the source data
+}
+
+// Common texture formats.
+var (
+	TexRGBA8   = TextureFormat{gl.RGBA8, gl.RGBA, gl.UNSIGNED_BYTE, 4}
+	TexRG8     = TextureFormat{gl.RG8, gl.RG, gl.UNSIGNED_BYTE, 2}
+	TexR8      = TextureFormat{gl.R8, gl.RED, gl.UNSIGNED_BYTE, 1}
+	TexRGBA16F = TextureFormat{gl.RGBA16F, gl.RGBA, gl.FLOAT, 16}
+	TexRGBA32F = TextureFormat{gl.RGBA32F, gl.RGBA, gl.FLOAT, 16}
+	TexRG32F   = TextureFormat{gl.RG32F, gl.RG, gl.FLOAT, 8}
+	TexR32F    = TextureFormat{gl.R32F, gl.RED, gl.FLOAT, 4}
+
+	TexDepth16  = TextureFormat{gl.DEPTH_COMPONENT16, gl.DEPTH_COMPONENT, gl.FLOAT, 4}
+	TexDepth24  = TextureFormat{gl.DEPTH_COMPONENT24, gl.DEPTH_COMPONENT, gl.FLOAT, 4}
+	TexDepth32F = TextureFormat{gl.DEPTH_COMPONENT32F, gl.DEPTH_COMPONENT, gl.FLOAT, 4}
+)
+
+// format gets the texture's format, defaulting to TexRGBA8.
+func (tex *Texture2D) format() TextureFormat {
+	if tex.Format == (TextureFormat{}) {
+		return TexRGBA8
+	}
+	return tex.Format
 }
 
 /*
@@ -114,52 +147,290 @@ func NewTexture2D(rgba *image.RGBA) (*Texture2D, error) {
 		Height: int32(rgba.Bounds().Dy()),
 	}
 
-	gl.GenTextures(1, &texture.ID)
-	gl.ActiveTexture(gl.TEXTURE0)
-	gl.BindTexture(gl.TEXTURE_2D, texture.ID)
+	texture.ID = backend.GenTexture()
+	backend.ActiveTexture(gl.TEXTURE0)
+	backend.BindTexture(gl.TEXTURE_2D, texture.ID)
 	// TODO: update to sampler object?
 	// https://stackoverflow.com/questions/30759028/changing-texture-parameters-at-runtime
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-	gl.TexImage2D(
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	restore := unpackRows(rgba.Stride, 4)
+	backend.TexImage2D(
 		gl.TEXTURE_2D,
 		0,
 		gl.RGBA, // internal texture format
 		texture.Width,
 		texture.Height,
-		0,
 		gl.RGBA, // image format
 		gl.UNSIGNED_BYTE,
 		gl.Ptr(rgba.Pix))
+	restore()
+
+	backend.BindTexture(gl.TEXTURE_2D, 0) // unbind texture
+
+	trackTexture(texture.ID, int(texture.Width*texture.Height*4))
+	watchLeak(texture, "Texture2D", texture.ID)
+	return texture, nil
+}
+
+// NewTextureImage creates a texture from img, choosing a format that matches
+// the image type: *image.Gray and *image.Alpha use a single channel (R8), and
+// *image.RGBA and *image.NRGBA use RGBA8. Other image types are converted to
+// RGBA. Gray textures sample as gray, and Alpha textures as white with alpha.
+func NewTextureImage(img image.Image) (*Texture2D, error) {
+	var tex *Texture2D
+	var err error
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	switch img := img.(type) {
+	case *image.RGBA:
+		return NewTexture2D(img)
+	case *image.NRGBA:
+		// same layout as RGBA, just without premultiplied alpha
+		tex, err = newTexture(w, h, TexRGBA8, img.Pix, img.Stride)
+	case *image.Gray:
+		tex, err = newTexture(w, h, TexR8, img.Pix, img.Stride)
+		if err == nil {
+			tex.swizzle(gl.RED, gl.RED, gl.RED, gl.ONE)
+		}
+	case *image.Alpha:
+		tex, err = newTexture(w, h, TexR8, img.Pix, img.Stride)
+		if err == nil {
+			tex.swizzle(gl.ONE, gl.ONE, gl.ONE, gl.RED)
+		}
+	default:
+		return NewTexture2D(imageToRGBA(img))
+	}
+	return tex, err
+}
 
-	gl.BindTexture(gl.TEXTURE_2D, 0) // unbind texture
+// NewTextureData creates a texture of the given size and format from raw
+// data, which is a []byte, or a []float32 for float formats. The data is
+// tightly packed pixels with no padding between rows. data may be nil to
+// allocate an uninitialized texture.
+func NewTextureData(width, height int, format TextureFormat, data interface{}) (*Texture2D, error) {
+	return newTexture(width, height, format, data, width*format.Size)
+}
 
+// newTexture creates a texture from data with rows stride bytes apart.
+func newTexture(width, height int, format TextureFormat, data interface{}, stride int) (*Texture2D, error) {
+	if data != nil {
+		if err := checkTextureData(format, width, height, stride, data); err != nil {
+			return nil, err
+		}
+	}
+	texture := &Texture2D{
+		Width:  int32(width),
+		Height: int32(height),
+		Format: format,
+	}
+
+	var ptr unsafe.Pointer
+	if data != nil {
+		ptr = gl.Ptr(data)
+	}
+
+	texture.ID = backend.GenTexture()
+	backend.ActiveTexture(gl.TEXTURE0)
+	backend.BindTexture(gl.TEXTURE_2D, texture.ID)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	restore := unpackRows(stride, format.Size)
+	backend.TexImage2D(gl.TEXTURE_2D, 0, format.Internal, texture.Width, texture.Height,
+		format.Format, format.Type, ptr)
+	restore()
+	if data != nil {
+		countUpload(width * height * format.Size)
+	}
+	backend.BindTexture(gl.TEXTURE_2D, 0)
+
+	if err := CheckError(); err != nil {
+		texture.Delete()
+		return nil, fmt.Errorf("failed to create texture: %w", err)
+	}
+	trackTexture(texture.ID, width*height*format.Size)
+	watchLeak(texture, "Texture2D", texture.ID)
 	return texture, nil
 }
 
+// checkTextureData makes sure data is the right type and length for a
+// width x height region in the format, with rows stride bytes apart.
+func checkTextureData(format TextureFormat, width, height, stride int, data interface{}) error {
+	var bytes int
+	switch d := data.(type) {
+	case []byte:
+		if format.Type == gl.FLOAT {
+			return fmt.Errorf("texture data for a float format must be []float32")
+		}
+		bytes = len(d)
+	case []float32:
+		if format.Type != gl.FLOAT {
+			return fmt.Errorf("texture data for a non-float format must be []byte")
+		}
+		bytes = len(d) * SizeOfFloat
+	default:
+		return fmt.Errorf("texture data must be []byte or []float32, not %T", data)
+	}
+	if stride < width*format.Size {
+		return fmt.Errorf("stride %d is too small for %d pixels", stride, width)
+	}
+	if height == 0 {
+		return nil
+	}
+	if need := stride*(height-1) + width*format.Size; bytes < need {
+		return fmt.Errorf("%d bytes is too few for a %dx%d region (need %d)", bytes, width, height, need)
+	}
+	return nil
+}
+
+// unpackRows sets the pixel store parameters for uploading rows of pixels
+// that are stride bytes apart, and returns a func that restores the defaults.
+// Without this, rows that aren't a multiple of 4 bytes (eg odd width R8 or
+// RGB images) or that have padding (eg from SubImage()) are sheared.
+func unpackRows(stride, pixelSize int) (restore func()) {
+	backend.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	if stride%pixelSize == 0 {
+		backend.PixelStorei(gl.UNPACK_ROW_LENGTH, int32(stride/pixelSize))
+	}
+	return func() {
+		backend.PixelStorei(gl.UNPACK_ROW_LENGTH, 0)
+		backend.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+	}
+}
+
+// NewDepthTexture creates a depth texture for use as a framebuffer's depth
+// attachment, such as for shadow mapping. format should be one of TexDepth16,
+// TexDepth24, or TexDepth32F. Comparison mode is enabled so the texture can be
+// used with a sampler2DShadow in shaders, with linear filtering for hardware
+// PCF. Samples outside the texture compare as fully lit (depth 1).
+func NewDepthTexture(width, height int, format TextureFormat) (*Texture2D, error) {
+	if format.Format != gl.DEPTH_COMPONENT {
+		return nil, fmt.Errorf("format 0x%x is not a depth format", format.Internal)
+	}
+	tex, err := NewTextureData(width, height, format, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	border := [4]float32{1, 1, 1, 1}
+	backend.BindTexture(gl.TEXTURE_2D, tex.ID)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_BORDER)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_BORDER)
+	backend.TexParameterfv(gl.TEXTURE_2D, gl.TEXTURE_BORDER_COLOR, &border[0])
+	backend.BindTexture(gl.TEXTURE_2D, 0)
+	tex.SetCompare(true)
+	return tex, nil
+}
+
+// SetCompare turns depth comparison on or off for a depth texture. It must be
+// on to sample with sampler2DShadow, and off to read raw depth with sampler2D
+// (eg to display the depth for debugging).
+func (tex *Texture2D) SetCompare(enabled bool) {
+	backend.BindTexture(gl.TEXTURE_2D, tex.ID)
+	if enabled {
+		backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_MODE, gl.COMPARE_REF_TO_TEXTURE)
+		backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_FUNC, gl.LEQUAL)
+	} else {
+		backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_MODE, gl.NONE)
+	}
+	backend.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+// swizzle sets which channels are returned when the texture is sampled.
+func (tex *Texture2D) swizzle(r, g, b, a int32) {
+	mask := [4]int32{r, g, b, a}
+	backend.BindTexture(gl.TEXTURE_2D, tex.ID)
+	backend.TexParameteriv(gl.TEXTURE_2D, gl.TEXTURE_SWIZZLE_RGBA, &mask[0])
+	backend.BindTexture(gl.TEXTURE_2D, 0)
+}
+
+// Bind the texture to texture unit (0 for gl.TEXTURE0, etc) for drawing,
+// leaving unit active.
+func (tex *Texture2D) Bind(unit uint32) {
+	backend.ActiveTexture(gl.TEXTURE0 + unit)
+	backend.BindTexture(gl.TEXTURE_2D, tex.ID)
+	frameStats.TextureBinds++
+}
+
 func (tex *Texture2D) Delete() {
-	gl.DeleteTextures(1, &tex.ID)
+	unwatchLeak(tex, "Texture2D", tex.ID)
+	trackTexture(tex.ID, 0)
+	backend.DeleteTexture(tex.ID)
 }
 
+// Reload replaces the entire texture with img. If img is a different size
+// than the texture, the texture is resized.
 func (tex *Texture2D) Reload(img *image.RGBA) {
-	gl.BindTexture(gl.TEXTURE_2D, tex.ID)
-	gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0,
-		tex.Width,
-		tex.Height,
-		gl.RGBA, gl.UNSIGNED_BYTE,
-		gl.Ptr(img.Pix))
-	gl.BindTexture(gl.TEXTURE_2D, 0)
+	w, h := int32(img.Bounds().Dx()), int32(img.Bounds().Dy())
+	backend.BindTexture(gl.TEXTURE_2D, tex.ID)
+	defer unpackRows(img.Stride, 4)()
+	if w != tex.Width || h != tex.Height {
+		tex.Width, tex.Height = w, h
+		backend.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, w, h, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+		trackTexture(tex.ID, int(w*h*4))
+	} else {
+		backend.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0,
+			tex.Width,
+			tex.Height,
+			gl.RGBA, gl.UNSIGNED_BYTE,
+			gl.Ptr(img.Pix))
+	}
+	backend.BindTexture(gl.TEXTURE_2D, 0)
+	countUpload(int(w * h * 4))
+}
+
+// SetSubImage replaces the region of the texture with its top left corner
+// at (x, y) with img. The region must be within the texture.
+func (tex *Texture2D) SetSubImage(x, y int, img *image.RGBA) error {
+	if tex.format() != TexRGBA8 {
+		return fmt.Errorf("can't set RGBA image in texture with internal format 0x%x", tex.Format.Internal)
+	}
+	rect := image.Rect(x, y, x+img.Bounds().Dx(), y+img.Bounds().Dy())
+	return tex.setPixels(rect, img.Pix, img.Stride)
+}
+
+// SetPixels replaces the rect region of the texture with data, which is tightly
+// packed pixels in the texture's Format with no padding between rows. data is
+// a []byte, or a []float32 for float formats.
+func (tex *Texture2D) SetPixels(rect image.Rectangle, data interface{}) error {
+	return tex.setPixels(rect, data, rect.Dx()*tex.format().Size)
+}
+
+// setPixels replaces the rect region with data with rows stride bytes apart.
+func (tex *Texture2D) setPixels(rect image.Rectangle, data interface{}, stride int) error {
+	if !rect.In(image.Rect(0, 0, int(tex.Width), int(tex.Height))) {
+		return fmt.Errorf("region %v is outside the %dx%d texture", rect, tex.Width, tex.Height)
+	}
+	format := tex.format()
+	if err := checkTextureData(format, rect.Dx(), rect.Dy(), stride, data); err != nil {
+		return err
+	}
+	if rect.Empty() {
+		return nil
+	}
+
+	backend.BindTexture(gl.TEXTURE_2D, tex.ID)
+	defer unpackRows(stride, format.Size)()
+	backend.TexSubImage2D(gl.TEXTURE_2D, 0,
+		int32(rect.Min.X), int32(rect.Min.Y),
+		int32(rect.Dx()), int32(rect.Dy()),
+		format.Format, format.Type,
+		gl.Ptr(data))
+	backend.BindTexture(gl.TEXTURE_2D, 0)
+	countUpload(rect.Dx() * rect.Dy() * format.Size)
+	return nil
 }
 
 // ReadImage gets a Go image from the texture.
 func (tex *Texture2D) ReadImage() *image.RGBA {
 	img := image.NewRGBA(image.Rect(0, 0, int(tex.Width), int(tex.Height)))
-	gl.BindTexture(gl.TEXTURE_2D, tex.ID)
-	gl.GetTexImage(gl.TEXTURE_2D, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
-	gl.BindTexture(gl.TEXTURE_2D, 0)
+	backend.BindTexture(gl.TEXTURE_2D, tex.ID)
+	backend.GetTexImage(gl.TEXTURE_2D, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+	backend.BindTexture(gl.TEXTURE_2D, 0)
 
-	flipVertically(img)
+	FlipVertical(img)
 	return img
 }
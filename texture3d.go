@@ -0,0 +1,104 @@
+package sgl
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Texture3D is a 3D texture, such as a volume of scan data or a color
+// grading lookup table.
+type Texture3D struct {
+	ID                   uint32
+	Width, Height, Depth int32
+	Format               TextureFormat
+}
+
+// NewTexture3D creates a width x height x depth texture in format from data,
+// which is tightly packed pixels, slice by slice, in a []byte, or a
+// []float32 for float formats. data may be nil to allocate an uninitialized
+// texture. It's filtered linearly and clamped at the edges.
+func NewTexture3D(width, height, depth int, format TextureFormat, data interface{}) (*Texture3D, error) {
+	stride := width * format.Size
+	if data != nil {
+		// slices are just more rows
+		if err := checkTextureData(format, width, height*depth, stride, data); err != nil {
+			return nil, err
+		}
+	}
+	tex := &Texture3D{
+		Width:  int32(width),
+		Height: int32(height),
+		Depth:  int32(depth),
+		Format: format,
+	}
+
+	var ptr unsafe.Pointer
+	if data != nil {
+		ptr = gl.Ptr(data)
+	}
+
+	tex.ID = backend.GenTexture()
+	backend.ActiveTexture(gl.TEXTURE0)
+	backend.BindTexture(gl.TEXTURE_3D, tex.ID)
+	backend.TexParameteri(gl.TEXTURE_3D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	backend.TexParameteri(gl.TEXTURE_3D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	backend.TexParameteri(gl.TEXTURE_3D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	backend.TexParameteri(gl.TEXTURE_3D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	backend.TexParameteri(gl.TEXTURE_3D, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+	restore := unpackRows(stride, format.Size)
+	backend.TexImage3D(gl.TEXTURE_3D, 0, format.Internal, tex.Width, tex.Height, tex.Depth,
+		format.Format, format.Type, ptr)
+	restore()
+	backend.BindTexture(gl.TEXTURE_3D, 0)
+	if data != nil {
+		countUpload(width * height * depth * format.Size)
+	}
+
+	if err := CheckError(); err != nil {
+		tex.Delete()
+		return nil, fmt.Errorf("failed to create 3d texture: %w", err)
+	}
+	trackTexture(tex.ID, width*height*depth*format.Size)
+	watchLeak(tex, "Texture3D", tex.ID)
+	return tex, nil
+}
+
+// SetSlices replaces depth slices starting at slice z with data, in the same
+// layout as for NewTexture3D().
+func (tex *Texture3D) SetSlices(z, depth int, data interface{}) error {
+	if z < 0 || depth < 0 || z+depth > int(tex.Depth) {
+		return fmt.Errorf("slices %d to %d are outside the texture's %d", z, z+depth, tex.Depth)
+	}
+	stride := int(tex.Width) * tex.Format.Size
+	if err := checkTextureData(tex.Format, int(tex.Width), int(tex.Height)*depth, stride, data); err != nil {
+		return err
+	}
+	if depth == 0 {
+		return nil
+	}
+	backend.BindTexture(gl.TEXTURE_3D, tex.ID)
+	restore := unpackRows(stride, tex.Format.Size)
+	backend.TexSubImage3D(gl.TEXTURE_3D, 0, 0, 0, int32(z), tex.Width, tex.Height, int32(depth),
+		tex.Format.Format, tex.Format.Type, gl.Ptr(data))
+	restore()
+	backend.BindTexture(gl.TEXTURE_3D, 0)
+	countUpload(int(tex.Width*tex.Height) * depth * tex.Format.Size)
+	return nil
+}
+
+// Bind the texture to texture unit (0 for gl.TEXTURE0, etc) for drawing,
+// leaving unit active.
+func (tex *Texture3D) Bind(unit uint32) {
+	backend.ActiveTexture(gl.TEXTURE0 + unit)
+	backend.BindTexture(gl.TEXTURE_3D, tex.ID)
+	frameStats.TextureBinds++
+}
+
+// Delete the texture.
+func (tex *Texture3D) Delete() {
+	unwatchLeak(tex, "Texture3D", tex.ID)
+	trackTexture(tex.ID, 0)
+	backend.DeleteTexture(tex.ID)
+}
@@ -0,0 +1,329 @@
+package sgl
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"unsafe"
+)
+
+// glGoroutine is the id of the goroutine which owns the opengl context,
+// recorded by Init().
+var glGoroutine int64
+
+// threadChecking is set by SetThreadCheck().
+var threadChecking bool
+
+// SetThreadCheck turns on or off a debug mode in which sgl panics, with a
+// clear message, when its opengl calls are made from any goroutine other
+// than the one that called Init() (or NewHeadlessContext()). Opengl
+// contexts belong to a single thread, and calls from another usually fail
+// with confusing driver errors or crashes instead. All calls made through
+// the Backend (see its doc) are checked, as are the frame methods of
+// Window, the imgui renderer, CharacterDict.DrawString(), and PickPoint().
+// It's slow, so it's meant for debugging. It wraps the backend, so it
+// should be called after any SetBackend().
+func SetThreadCheck(enabled bool) {
+	checked, wrapped := backend.(threadChecked)
+	switch {
+	case enabled && !wrapped:
+		backend = threadChecked{backend}
+	case !enabled && wrapped:
+		backend = checked.Backend
+	}
+	threadChecking = enabled
+}
+
+// recordGLThread records the current goroutine as the one owning the
+// opengl context. It must be locked to its OS thread.
+func recordGLThread() {
+	glGoroutine = goroutineID()
+}
+
+// assertGLThread panics if thread checking is on and this isn't the gl
+// goroutine. It's for methods which call gl directly rather than through
+// the backend.
+func assertGLThread() {
+	if threadChecking {
+		checkThread()
+	}
+}
+
+// checkThread panics if this isn't the gl goroutine.
+func checkThread() {
+	if id := goroutineID(); id != glGoroutine {
+		panic(fmt.Sprintf("sgl: opengl called from goroutine %d, but the context belongs to goroutine %d, "+
+			"which called sgl.Init(). Opengl must only be used from that goroutine, "+
+			"so send work to it with a channel instead.", id, glGoroutine))
+	}
+}
+
+// goroutineID gets the id of the current goroutine from its stack trace,
+// which starts "goroutine 1 [running]:".
+func goroutineID() int64 {
+	buf := make([]byte, 32)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i >= 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseInt(string(buf), 10, 64)
+	return id
+}
+
+// threadChecked is a Backend which calls checkThread() before each call.
+type threadChecked struct{ Backend }
+
+func (b threadChecked) GetError() uint32 { checkThread(); return b.Backend.GetError() }
+
+// state
+func (b threadChecked) Enable(capability uint32)  { checkThread(); b.Backend.Enable(capability) }
+func (b threadChecked) Disable(capability uint32) { checkThread(); b.Backend.Disable(capability) }
+func (b threadChecked) IsEnabled(capability uint32) bool {
+	checkThread()
+	return b.Backend.IsEnabled(capability)
+}
+func (b threadChecked) GetIntegerv(pname uint32, data *int32) {
+	checkThread()
+	b.Backend.GetIntegerv(pname, data)
+}
+func (b threadChecked) GetFloatv(pname uint32, data *float32) {
+	checkThread()
+	b.Backend.GetFloatv(pname, data)
+}
+func (b threadChecked) GetBooleanv(pname uint32, data *bool) {
+	checkThread()
+	b.Backend.GetBooleanv(pname, data)
+}
+func (b threadChecked) Viewport(x, y, width, height int32) {
+	checkThread()
+	b.Backend.Viewport(x, y, width, height)
+}
+func (b threadChecked) Scissor(x, y, width, height int32) {
+	checkThread()
+	b.Backend.Scissor(x, y, width, height)
+}
+func (b threadChecked) ClearColor(red, green, blue, alpha float32) {
+	checkThread()
+	b.Backend.ClearColor(red, green, blue, alpha)
+}
+func (b threadChecked) BlendFuncSeparate(srcRGB, dstRGB, srcAlpha, dstAlpha uint32) {
+	checkThread()
+	b.Backend.BlendFuncSeparate(srcRGB, dstRGB, srcAlpha, dstAlpha)
+}
+func (b threadChecked) DepthMask(flag bool) { checkThread(); b.Backend.DepthMask(flag) }
+func (b threadChecked) ColorMask(red, green, blue, alpha bool) {
+	checkThread()
+	b.Backend.ColorMask(red, green, blue, alpha)
+}
+func (b threadChecked) StencilFunc(fn uint32, ref int32, mask uint32) {
+	checkThread()
+	b.Backend.StencilFunc(fn, ref, mask)
+}
+func (b threadChecked) StencilMask(mask uint32) { checkThread(); b.Backend.StencilMask(mask) }
+func (b threadChecked) StencilOp(fail, depthFail, pass uint32) {
+	checkThread()
+	b.Backend.StencilOp(fail, depthFail, pass)
+}
+
+// buffers and vertex arrays
+func (b threadChecked) GenBuffer() uint32          { checkThread(); return b.Backend.GenBuffer() }
+func (b threadChecked) DeleteBuffer(buffer uint32) { checkThread(); b.Backend.DeleteBuffer(buffer) }
+func (b threadChecked) BindBuffer(target, buffer uint32) {
+	checkThread()
+	b.Backend.BindBuffer(target, buffer)
+}
+func (b threadChecked) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	checkThread()
+	b.Backend.BufferData(target, size, data, usage)
+}
+func (b threadChecked) BufferSubData(target uint32, offset, size int, data unsafe.Pointer) {
+	checkThread()
+	b.Backend.BufferSubData(target, offset, size, data)
+}
+func (b threadChecked) GetBufferSubData(target uint32, offset, size int, data unsafe.Pointer) {
+	checkThread()
+	b.Backend.GetBufferSubData(target, offset, size, data)
+}
+func (b threadChecked) GenVertexArray() uint32 { checkThread(); return b.Backend.GenVertexArray() }
+func (b threadChecked) DeleteVertexArray(array uint32) {
+	checkThread()
+	b.Backend.DeleteVertexArray(array)
+}
+func (b threadChecked) BindVertexArray(array uint32) { checkThread(); b.Backend.BindVertexArray(array) }
+func (b threadChecked) EnableVertexAttribArray(index uint32) {
+	checkThread()
+	b.Backend.EnableVertexAttribArray(index)
+}
+func (b threadChecked) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset int) {
+	checkThread()
+	b.Backend.VertexAttribPointer(index, size, xtype, normalized, stride, offset)
+}
+func (b threadChecked) VertexAttribDivisor(index, divisor uint32) {
+	checkThread()
+	b.Backend.VertexAttribDivisor(index, divisor)
+}
+func (b threadChecked) DrawArrays(mode uint32, first, count int32) {
+	checkThread()
+	b.Backend.DrawArrays(mode, first, count)
+}
+func (b threadChecked) DrawArraysInstanced(mode uint32, first, count, instances int32) {
+	checkThread()
+	b.Backend.DrawArraysInstanced(mode, first, count, instances)
+}
+func (b threadChecked) DrawElements(mode uint32, count int32, xtype uint32, offset int) {
+	checkThread()
+	b.Backend.DrawElements(mode, count, xtype, offset)
+}
+
+// textures
+func (b threadChecked) GenTexture() uint32           { checkThread(); return b.Backend.GenTexture() }
+func (b threadChecked) DeleteTexture(texture uint32) { checkThread(); b.Backend.DeleteTexture(texture) }
+func (b threadChecked) ActiveTexture(unit uint32)    { checkThread(); b.Backend.ActiveTexture(unit) }
+func (b threadChecked) BindTexture(target, texture uint32) {
+	checkThread()
+	b.Backend.BindTexture(target, texture)
+}
+func (b threadChecked) TexParameteri(target, pname uint32, param int32) {
+	checkThread()
+	b.Backend.TexParameteri(target, pname, param)
+}
+func (b threadChecked) TexParameteriv(target, pname uint32, params *int32) {
+	checkThread()
+	b.Backend.TexParameteriv(target, pname, params)
+}
+func (b threadChecked) TexParameterfv(target, pname uint32, params *float32) {
+	checkThread()
+	b.Backend.TexParameterfv(target, pname, params)
+}
+func (b threadChecked) PixelStorei(pname uint32, param int32) {
+	checkThread()
+	b.Backend.PixelStorei(pname, param)
+}
+func (b threadChecked) TexImage2D(target uint32, level, internalFormat, width, height int32, format, xtype uint32, pixels unsafe.Pointer) {
+	checkThread()
+	b.Backend.TexImage2D(target, level, internalFormat, width, height, format, xtype, pixels)
+}
+func (b threadChecked) TexSubImage2D(target uint32, level, x, y, width, height int32, format, xtype uint32, pixels unsafe.Pointer) {
+	checkThread()
+	b.Backend.TexSubImage2D(target, level, x, y, width, height, format, xtype, pixels)
+}
+func (b threadChecked) GetTexImage(target uint32, level int32, format, xtype uint32, pixels unsafe.Pointer) {
+	checkThread()
+	b.Backend.GetTexImage(target, level, format, xtype, pixels)
+}
+func (b threadChecked) TexImage3D(target uint32, level, internalFormat, width, height, depth int32, format, xtype uint32, pixels unsafe.Pointer) {
+	checkThread()
+	b.Backend.TexImage3D(target, level, internalFormat, width, height, depth, format, xtype, pixels)
+}
+func (b threadChecked) TexSubImage3D(target uint32, level, x, y, z, width, height, depth int32, format, xtype uint32, pixels unsafe.Pointer) {
+	checkThread()
+	b.Backend.TexSubImage3D(target, level, x, y, z, width, height, depth, format, xtype, pixels)
+}
+func (b threadChecked) TexBuffer(target, internalFormat, buffer uint32) {
+	checkThread()
+	b.Backend.TexBuffer(target, internalFormat, buffer)
+}
+
+// shaders and programs
+func (b threadChecked) CreateShader(shaderType uint32) uint32 {
+	checkThread()
+	return b.Backend.CreateShader(shaderType)
+}
+func (b threadChecked) CompileShader(shader uint32, source string) (ok bool, log string) {
+	checkThread()
+	return b.Backend.CompileShader(shader, source)
+}
+func (b threadChecked) DeleteShader(shader uint32) { checkThread(); b.Backend.DeleteShader(shader) }
+func (b threadChecked) CreateProgram() uint32      { checkThread(); return b.Backend.CreateProgram() }
+func (b threadChecked) AttachShader(program, shader uint32) {
+	checkThread()
+	b.Backend.AttachShader(program, shader)
+}
+func (b threadChecked) LinkProgram(program uint32) (ok bool, log string) {
+	checkThread()
+	return b.Backend.LinkProgram(program)
+}
+func (b threadChecked) UseProgram(program uint32)    { checkThread(); b.Backend.UseProgram(program) }
+func (b threadChecked) DeleteProgram(program uint32) { checkThread(); b.Backend.DeleteProgram(program) }
+func (b threadChecked) GetAttribLocation(program uint32, name string) int32 {
+	checkThread()
+	return b.Backend.GetAttribLocation(program, name)
+}
+func (b threadChecked) GetUniformLocation(program uint32, name string) int32 {
+	checkThread()
+	return b.Backend.GetUniformLocation(program, name)
+}
+func (b threadChecked) Uniform1iv(location, count int32, value *int32) {
+	checkThread()
+	b.Backend.Uniform1iv(location, count, value)
+}
+func (b threadChecked) Uniform3iv(location, count int32, value *int32) {
+	checkThread()
+	b.Backend.Uniform3iv(location, count, value)
+}
+func (b threadChecked) Uniform1fv(location, count int32, value *float32) {
+	checkThread()
+	b.Backend.Uniform1fv(location, count, value)
+}
+func (b threadChecked) Uniform2fv(location, count int32, value *float32) {
+	checkThread()
+	b.Backend.Uniform2fv(location, count, value)
+}
+func (b threadChecked) Uniform3fv(location, count int32, value *float32) {
+	checkThread()
+	b.Backend.Uniform3fv(location, count, value)
+}
+func (b threadChecked) Uniform4fv(location, count int32, value *float32) {
+	checkThread()
+	b.Backend.Uniform4fv(location, count, value)
+}
+func (b threadChecked) UniformMatrix4fv(location, count int32, transpose bool, value *float32) {
+	checkThread()
+	b.Backend.UniformMatrix4fv(location, count, transpose, value)
+}
+
+// framebuffers
+func (b threadChecked) GenFramebuffer() uint32 { checkThread(); return b.Backend.GenFramebuffer() }
+func (b threadChecked) DeleteFramebuffer(framebuffer uint32) {
+	checkThread()
+	b.Backend.DeleteFramebuffer(framebuffer)
+}
+func (b threadChecked) BindFramebuffer(target, framebuffer uint32) {
+	checkThread()
+	b.Backend.BindFramebuffer(target, framebuffer)
+}
+func (b threadChecked) FramebufferTexture2D(target, attachment, textarget, texture uint32, level int32) {
+	checkThread()
+	b.Backend.FramebufferTexture2D(target, attachment, textarget, texture, level)
+}
+func (b threadChecked) CheckFramebufferStatus(target uint32) uint32 {
+	checkThread()
+	return b.Backend.CheckFramebufferStatus(target)
+}
+func (b threadChecked) DrawBuffers(buffers []uint32) {
+	checkThread()
+	b.Backend.DrawBuffers(buffers)
+}
+func (b threadChecked) ClearBufferfv(buffer uint32, drawBuffer int32, value *float32) {
+	checkThread()
+	b.Backend.ClearBufferfv(buffer, drawBuffer, value)
+}
+func (b threadChecked) GenRenderbuffer() uint32 { checkThread(); return b.Backend.GenRenderbuffer() }
+func (b threadChecked) DeleteRenderbuffer(renderbuffer uint32) {
+	checkThread()
+	b.Backend.DeleteRenderbuffer(renderbuffer)
+}
+func (b threadChecked) BindRenderbuffer(target, renderbuffer uint32) {
+	checkThread()
+	b.Backend.BindRenderbuffer(target, renderbuffer)
+}
+func (b threadChecked) RenderbufferStorage(target, internalFormat uint32, width, height int32) {
+	checkThread()
+	b.Backend.RenderbufferStorage(target, internalFormat, width, height)
+}
+func (b threadChecked) FramebufferRenderbuffer(target, attachment, renderbufferTarget, renderbuffer uint32) {
+	checkThread()
+	b.Backend.FramebufferRenderbuffer(target, attachment, renderbufferTarget, renderbuffer)
+}
@@ -0,0 +1,261 @@
+package sgl
+
+import (
+	"fmt"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/inkyblackness/imgui-go/v4"
+)
+
+// ToastLevel is the severity of a Toast, which determines its color.
+type ToastLevel int
+
+const (
+	ToastInfo ToastLevel = iota
+	ToastWarn
+	ToastError
+)
+
+// Color gets the text color used for toasts of the level.
+func (l ToastLevel) Color() Color {
+	switch l {
+	case ToastWarn:
+		return Color{R: 1, G: 0.8, B: 0.2, A: 1}
+	case ToastError:
+		return Color{R: 1, G: 0.3, B: 0.3, A: 1}
+	default:
+		return Color{R: 1, G: 1, B: 1, A: 1}
+	}
+}
+
+// Corner of the screen.
+type Corner int
+
+const (
+	TopLeft Corner = iota
+	TopRight
+	BottomLeft
+	BottomRight
+)
+
+// Toast is a short message shown for a few seconds.
+type Toast struct {
+	Message string
+	Level   ToastLevel
+	Alpha   float32 // opacity, animated as the toast fades in and out
+
+	id   int
+	done bool
+	key  string        // see Toasts.ShowKey()
+	args []interface{} // formatting key
+}
+
+// Toasts shows transient messages such as "screenshot saved" in a corner of
+// the screen. Call Update() each frame, then draw them with DrawImgui(),
+// DrawText(), or by using Visible() directly.
+type Toasts struct {
+	Corner   Corner
+	Duration float32 // seconds each toast is fully visible
+	FadeTime float32 // seconds to fade in or out
+	Max      int     // max number shown at once. the oldest are removed first.
+
+	list      []*Toast
+	anim      *Animator
+	nextID    int
+	localizer *Localizer // see Localize()
+}
+
+// NewToasts creates a Toasts shown in the corner with some default timing.
+func NewToasts(corner Corner) *Toasts {
+	return &Toasts{
+		Corner:   corner,
+		Duration: 3,
+		FadeTime: 0.3,
+		Max:      5,
+		anim:     NewAnimator(),
+	}
+}
+
+// Show adds a toast with the message.
+func (ts *Toasts) Show(level ToastLevel, message string) {
+	t := &Toast{Message: message, Level: level, id: ts.nextID}
+	ts.nextID++
+	ts.list = append(ts.list, t)
+	if ts.Max > 0 && len(ts.list) > ts.Max {
+		ts.dismiss(ts.list[0])
+	}
+
+	name := ts.animName(t)
+	fadeOut := func() {
+		AnimateValue(ts.anim, name, &t.Alpha, ts.FadeTime, t.Alpha, 0, func() { t.done = true })
+	}
+	wait := func() {
+		var elapsed float32
+		ts.anim.Add(name, func(dt float32) bool {
+			elapsed += dt
+			return elapsed >= ts.Duration
+		}, ts.Duration, fadeOut)
+	}
+	AnimateValue(ts.anim, name, &t.Alpha, ts.FadeTime, 0, 1, wait)
+}
+
+// Info shows an info toast, formatted as with fmt.Sprintf().
+func (ts *Toasts) Info(format string, args ...interface{}) {
+	ts.Show(ToastInfo, fmt.Sprintf(format, args...))
+}
+
+// Warn shows a warning toast, formatted as with fmt.Sprintf().
+func (ts *Toasts) Warn(format string, args ...interface{}) {
+	ts.Show(ToastWarn, fmt.Sprintf(format, args...))
+}
+
+// Error shows an error toast, formatted as with fmt.Sprintf().
+func (ts *Toasts) Error(format string, args ...interface{}) {
+	ts.Show(ToastError, fmt.Sprintf(format, args...))
+}
+
+// Localize uses loc to translate the messages of toasts shown with
+// ShowKey(), and translates those shown again whenever loc's language
+// changes.
+func (ts *Toasts) Localize(loc *Localizer) {
+	ts.localizer = loc
+	loc.OnLanguageChange(func(string) {
+		if ts.localizer != loc {
+			return
+		}
+		for _, t := range ts.list {
+			if t.key != "" {
+				t.Message = loc.Tf(t.key, t.args...)
+			}
+		}
+	})
+}
+
+// ShowKey adds a toast with the message for key, formatted with args as
+// with fmt.Sprintf(), translated by the Localizer given to Localize(). key
+// is used as the format if there's no Localizer.
+func (ts *Toasts) ShowKey(level ToastLevel, key string, args ...interface{}) {
+	message := fmt.Sprintf(key, args...)
+	if ts.localizer != nil {
+		message = ts.localizer.Tf(key, args...)
+	}
+	ts.Show(level, message)
+	t := ts.list[len(ts.list)-1]
+	t.key, t.args = key, args
+}
+
+// dismiss removes a toast immediately.
+func (ts *Toasts) dismiss(t *Toast) {
+	ts.anim.Cancel(ts.animName(t))
+	t.done = true
+	ts.removeDone()
+}
+
+func (ts *Toasts) animName(t *Toast) string { return fmt.Sprintf("toast%d", t.id) }
+
+// Clear removes all toasts.
+func (ts *Toasts) Clear() {
+	for _, t := range ts.list {
+		ts.anim.Cancel(ts.animName(t))
+	}
+	ts.list = ts.list[:0]
+}
+
+// Update animates the toasts. dt is in seconds.
+func (ts *Toasts) Update(dt float32) {
+	ts.anim.Update(dt)
+	ts.removeDone()
+}
+
+func (ts *Toasts) removeDone() {
+	active := ts.list[:0]
+	for _, t := range ts.list {
+		if !t.done {
+			active = append(active, t)
+		}
+	}
+	ts.list = active
+}
+
+// Visible gets the toasts currently shown, oldest first.
+func (ts *Toasts) Visible() []*Toast {
+	return ts.list
+}
+
+// DrawImgui draws the toasts as small imgui windows. Call it in the gui
+// func of Window.RenderImgui().
+func (ts *Toasts) DrawImgui() {
+	const margin = 10
+	flags := imgui.WindowFlagsNoDecoration | imgui.WindowFlagsAlwaysAutoResize |
+		imgui.WindowFlagsNoSavedSettings | imgui.WindowFlagsNoFocusOnAppearing |
+		imgui.WindowFlagsNoNav | imgui.WindowFlagsNoInputs
+
+	viewport := imgui.MainViewport()
+	pos, size := viewport.WorkPos(), viewport.WorkSize()
+	right := ts.Corner == TopRight || ts.Corner == BottomRight
+	bottom := ts.Corner == BottomLeft || ts.Corner == BottomRight
+
+	var pivot imgui.Vec2
+	x, y := pos.X+margin, pos.Y+margin
+	if right {
+		x, pivot.X = pos.X+size.X-margin, 1
+	}
+	if bottom {
+		y, pivot.Y = pos.Y+size.Y-margin, 1
+	}
+
+	// newest toast nearest the corner
+	for i := len(ts.list) - 1; i >= 0; i-- {
+		t := ts.list[i]
+		imgui.SetNextWindowPosV(imgui.Vec2{X: x, Y: y}, imgui.ConditionAlways, pivot)
+		imgui.SetNextWindowBgAlpha(0.8)
+		imgui.PushStyleVarFloat(imgui.StyleVarAlpha, t.Alpha)
+		if imgui.BeginV(fmt.Sprintf("##%s", ts.animName(t)), nil, flags) {
+			c := t.Level.Color()
+			imgui.PushStyleColor(imgui.StyleColorText, imgui.Vec4{X: c.R, Y: c.G, Z: c.B, W: c.A})
+			imgui.Text(t.Message)
+			imgui.PopStyleColor()
+
+			step := imgui.WindowHeight() + margin/2
+			if bottom {
+				y -= step
+			} else {
+				y += step
+			}
+		}
+		imgui.End()
+		imgui.PopStyleVar()
+	}
+}
+
+// DrawText draws the toasts with a CharacterDict, for programs not using
+// imgui. width and height are the size of the screen. Since DrawString()
+// doesn't support transparency, toasts fade to black instead.
+func (ts *Toasts) DrawText(cd *CharacterDict, scale, width, height float32) {
+	const margin = 10
+	lineHeight := cd.fh*scale + margin/2
+
+	bottom := ts.Corner == BottomLeft || ts.Corner == BottomRight
+	right := ts.Corner == TopRight || ts.Corner == BottomRight
+	y := float32(margin)
+	if bottom {
+		y = height - margin - cd.fh*scale
+	}
+
+	for i := len(ts.list) - 1; i >= 0; i-- {
+		t := ts.list[i]
+		x := float32(margin)
+		if right {
+			x = width - margin - float32(len([]rune(t.Message)))*cd.fw*scale
+		}
+		c := t.Level.Color()
+		color := mgl32.Vec3{c.R, c.G, c.B}.Mul(t.Alpha)
+		cd.DrawString(t.Message, x, y/scale, scale, color, width, height)
+
+		if bottom {
+			y -= lineHeight
+		} else {
+			y += lineHeight
+		}
+	}
+}
@@ -17,18 +17,41 @@ func invLerp(x, min, max float32) float32 {
 }
 
 // Timer keeps time and other similar info useful for an opengl render loop.
+// DeltaT and TotalTime are affected by Scale and Pause(), while
+// UnscaledDeltaT and RealTime always follow the wall clock, so things like
+// UI animations can keep running while the rest of the program is paused.
 type Timer struct {
-	TotalFrames uint64
-	TotalTime   float64
-	DeltaT      float64 // Seconds
-	Start       time.Time
-	Now         time.Time
+	TotalFrames    uint64
+	TotalTime      float64 // Seconds, scaled
+	DeltaT         float64 // Seconds, scaled
+	UnscaledDeltaT float64 // Seconds
+	RealTime       float64 // Seconds
+	Scale          float64 // Multiplier for DeltaT. 1 is normal speed, and 0 freezes time.
+	FixedDeltaT    float64 // If > 0, used as UnscaledDeltaT instead of the wall clock.
+	Start          time.Time
+	Now            time.Time
+
+	paused    bool
+	scheduled []*scheduledCall
 }
 
-// Reset the timer to an initial state. Should call once before the render loop.
+// NewTimer creates a reset Timer running at normal speed.
+func NewTimer() *Timer {
+	t := &Timer{}
+	t.Reset()
+	return t
+}
+
+// Reset the timer to an initial state, with Scale 1. Should call once before
+// the render loop. FixedDeltaT is kept.
 func (t *Timer) Reset() {
+	t.Scale = 1
 	t.TotalFrames = 0
+	t.TotalTime = 0
+	t.RealTime = 0
 	t.DeltaT = 0
+	t.UnscaledDeltaT = 0
+	t.paused = false
 	t.Now = time.Now()
 	t.Start = t.Now
 }
@@ -37,20 +60,112 @@ func (t *Timer) Reset() {
 func (t *Timer) Update() {
 	t.TotalFrames++
 	current := time.Now()
-	t.DeltaT = current.Sub(t.Now).Seconds()
+	t.UnscaledDeltaT = current.Sub(t.Now).Seconds()
+	if t.FixedDeltaT > 0 {
+		t.UnscaledDeltaT = t.FixedDeltaT
+	}
 	t.Now = current
+	t.RealTime += t.UnscaledDeltaT
+
+	t.DeltaT = t.UnscaledDeltaT * t.Scale
+	if t.paused {
+		t.DeltaT = 0
+	}
 	t.TotalTime += t.DeltaT
+
+	t.runScheduled()
+}
+
+// Pause makes DeltaT 0 until Resume() is called.
+func (t *Timer) Pause() { t.paused = true }
+
+// Resume undoes Pause().
+func (t *Timer) Resume() { t.paused = false }
+
+// Paused returns true if the timer is paused.
+func (t *Timer) Paused() bool { return t.paused }
+
+// scheduledCall is a function to be called by Timer.Update() at some time.
+type scheduledCall struct {
+	fn        func()
+	remaining float64 // seconds until the next call
+	interval  float64 // seconds between calls, 0 to call once
+	done      bool
+}
+
+// TimerHandle refers to a function scheduled with Timer.After() or Timer.Every().
+type TimerHandle struct {
+	call *scheduledCall
+}
+
+// Cancel stops the function from being called again.
+func (h TimerHandle) Cancel() {
+	if h.call != nil {
+		h.call.done = true
+	}
+}
+
+// Active returns true if the function will be called again.
+func (h TimerHandle) Active() bool {
+	return h.call != nil && !h.call.done
+}
+
+// After schedules fn to be called once, during Update(), after the given number
+// of (scaled) seconds. Example:
+//
+//	timer.After(2.5, func() { fmt.Println("2.5 seconds later") })
+func (t *Timer) After(seconds float64, fn func()) TimerHandle {
+	call := &scheduledCall{fn: fn, remaining: seconds}
+	t.scheduled = append(t.scheduled, call)
+	return TimerHandle{call}
+}
+
+// Every schedules fn to be called repeatedly, during Update(), every given
+// number of (scaled) seconds until cancelled.
+func (t *Timer) Every(seconds float64, fn func()) TimerHandle {
+	call := &scheduledCall{fn: fn, remaining: seconds, interval: seconds}
+	t.scheduled = append(t.scheduled, call)
+	return TimerHandle{call}
+}
+
+// runScheduled calls scheduled functions that are due and removes finished ones.
+func (t *Timer) runScheduled() {
+	// functions scheduled by the calls below are first checked next Update()
+	n := len(t.scheduled)
+	for i := 0; i < n; i++ {
+		call := t.scheduled[i]
+		call.remaining -= t.DeltaT
+		for !call.done && call.remaining <= 0 {
+			call.fn()
+			if call.interval > 0 {
+				call.remaining += call.interval
+			} else {
+				call.done = true
+			}
+		}
+	}
+
+	active := t.scheduled[:0]
+	for _, call := range t.scheduled {
+		if !call.done {
+			active = append(active, call)
+		}
+	}
+	for i := len(active); i < len(t.scheduled); i++ {
+		t.scheduled[i] = nil
+	}
+	t.scheduled = active
 }
 
 // AvgFps gets the average framerate over the total program runtime (or
 // since Reset() was called).
 func (t *Timer) AvgFps() float64 {
-	return float64(t.TotalFrames) / t.TotalTime
+	return float64(t.TotalFrames) / t.RealTime
 }
 
 // Fps gets the instantaneous framerate of this render loop.
 func (t *Timer) Fps() float64 {
-	return 1.0 / t.DeltaT
+	return 1.0 / t.UnscaledDeltaT
 }
 
 // IsNthFrame returns true if the current frame number is on the "nth" since
@@ -238,57 +353,10 @@ func (s *Selecter) Previous() {
 	}
 }
 
-// Animation is a function that takes a time delta and returns whether or
-// not the animation has completed.
-type Animation func(float32) bool
-
-// AnimationMap holds animations keyed by some name.
-type AnimationMap map[string]Animation
-
-// Update every animation in the map, deleting those that are completed.
-func (am AnimationMap) Update(dt float32) {
-	for name, ani := range am {
-		done := ani(dt)
-		if done {
-			delete(am, name)
-		}
-	}
-}
-
-// Has checks the animation map for an animation of the given name.
-func (am AnimationMap) Has(name string) bool {
-	_, has := am[name]
-	return has
+// Color is an RGBA color with components typically in the range 0 to 1.
+type Color struct {
+	R, G, B, A float32
 }
 
-// Float32 inserts a new animation with "name" which animates the value from "from" to "to" over
-// "durationSec" seconds.
-func (am AnimationMap) Float32(name string, value *float32, durationSec, from, to float32) {
-	var elapsed float32
-	am[name] = func(dt float32) (done bool) {
-		elapsed += dt
-		t := mgl32.Clamp(elapsed/durationSec, 0, 1)
-		*value = lerp(t, from, to)
-		if elapsed > durationSec {
-			return true
-		}
-		return false
-	}
-}
-
-// Vec3f inserts a new animation with "name" which animates the value from "from" to "to" over
-// "durationSec" seconds.
-func (am AnimationMap) Vec3f(name string, value *mgl32.Vec3, durationSec float32, from, to mgl32.Vec3) {
-	var elapsed float32
-	am[name] = func(dt float32) (done bool) {
-		elapsed += dt
-		t := mgl32.Clamp(elapsed/durationSec, 0, 1)
-		(*value)[0] = lerp(t, from[0], to[0])
-		(*value)[1] = lerp(t, from[1], to[1])
-		(*value)[2] = lerp(t, from[2], to[2])
-		if elapsed > durationSec {
-			return true
-		}
-		return false
-	}
-}
+// Vec4 gets the color as a vector, such as for use as a shader uniform.
+func (c Color) Vec4() mgl32.Vec4 { return mgl32.Vec4{c.R, c.G, c.B, c.A} }
@@ -38,11 +38,11 @@ func (b *Buffer) Size() int       { return b.size }             // size of buffe
 func (b *Buffer) Bytes(n int) int { return n * b.bytesPerItem } // calculates the number of bytes in n vertices
 
 func (b *Buffer) Bind() {
-	gl.BindBuffer(b.target, b.ID)
+	backend.BindBuffer(b.target, b.ID)
 }
 
 func (b *Buffer) UnBind() {
-	gl.BindBuffer(b.target, 0)
+	backend.BindBuffer(b.target, 0)
 }
 
 // used with VBOs (not EBOs)
@@ -69,7 +69,8 @@ func (b *Buffer) Allocate(vertexCount int, usage uint32) {
 	b.size = b.Bytes(vertexCount)
 	b.usage = usage
 	b.Bind()
-	gl.BufferData(b.target, b.size, gl.Ptr(nil), b.usage)
+	backend.BufferData(b.target, b.size, nil, b.usage)
+	trackBuffer(b.ID, b.size)
 	b.UnBind()
 }
 
@@ -95,7 +96,9 @@ func (b *Buffer) Initalize(data interface{}) {
 		b.usage = StaticDraw // set to static draw if not yet set (by Allocate())
 	}
 	b.Bind()
-	gl.BufferData(b.target, b.size, gl.Ptr(data), b.usage)
+	backend.BufferData(b.target, b.size, gl.Ptr(data), b.usage)
+	countUpload(b.size)
+	trackBuffer(b.ID, b.size)
 	if err := CheckError(); err != nil {
 		fmt.Println("Buffer.Set()", err)
 	}
@@ -108,19 +111,22 @@ func (b *Buffer) Set(startVertex, countVertices int, data interface{}) {
 	// bytesPerVertex already determined elsewhere
 	b.count = countVertices
 	b.Bind()
-	gl.BufferSubData(b.target, b.Bytes(startVertex), b.Bytes(countVertices), gl.Ptr(data))
+	backend.BufferSubData(b.target, b.Bytes(startVertex), b.Bytes(countVertices), gl.Ptr(data))
+	countUpload(b.Bytes(countVertices))
 	b.UnBind()
 }
 
 // data MUST be a slice with length >= b.Bytes(countVertices)
 func (b *Buffer) Get(startVertex, countVertices int, data interface{}) {
 	b.Bind()
-	gl.GetBufferSubData(b.target, b.Bytes(startVertex), b.Bytes(countVertices), gl.Ptr(data))
+	backend.GetBufferSubData(b.target, b.Bytes(startVertex), b.Bytes(countVertices), gl.Ptr(data))
 	b.UnBind()
 }
 
 func (b *Buffer) Delete() {
-	gl.DeleteBuffers(1, &b.ID)
+	unwatchLeak(b, "Buffer", b.ID)
+	trackBuffer(b.ID, 0)
+	backend.DeleteBuffer(b.ID)
 }
 
 func NewVbo(name string, attribs ...Attribute) *Buffer {
@@ -129,7 +135,8 @@ func NewVbo(name string, attribs ...Attribute) *Buffer {
 		Attributes: attribs,
 		target:     gl.ARRAY_BUFFER,
 	}
-	gl.GenBuffers(1, &b.ID)
+	b.ID = backend.GenBuffer()
+	watchLeak(b, "Buffer", b.ID)
 	return b
 }
 
@@ -138,7 +145,8 @@ func NewEbo() *Buffer {
 		Name:   "EBO",
 		target: gl.ELEMENT_ARRAY_BUFFER,
 	}
-	gl.GenBuffers(1, &b.ID)
+	b.ID = backend.GenBuffer()
+	watchLeak(b, "Buffer", b.ID)
 	return b
 }
 
@@ -164,8 +172,8 @@ func NewVao(drawMode uint32, vbos ...*Buffer) *Vao {
 		Vbo: make(map[string]*Buffer),
 	}
 
-	gl.GenVertexArrays(1, &v.ID)
-	gl.BindVertexArray(v.ID)
+	v.ID = backend.GenVertexArray()
+	backend.BindVertexArray(v.ID)
 
 	// if i wanted to make the vao use separate vbos for each vertex attribute,
 	// (eg VVVNNN instead of interlaced VNVNVN), i would have do for each vbo
@@ -184,7 +192,7 @@ func NewVao(drawMode uint32, vbos ...*Buffer) *Vao {
 	v.Ebo = NewEbo() // just gets id, doesn't set up ebo or allocate
 	v.Ebo.Bind()     // necessary?
 
-	gl.BindVertexArray(0)
+	backend.BindVertexArray(0)
 	v.Ebo.UnBind()
 
 	return v
@@ -205,7 +213,7 @@ func NewVao(drawMode uint32, vbos ...*Buffer) *Vao {
 // }
 
 func (v *Vao) Delete() {
-	gl.DeleteVertexArrays(1, &v.ID)
+	backend.DeleteVertexArray(v.ID)
 	for _, vbo := range v.Vbo {
 		vbo.Delete()
 	}
@@ -341,12 +349,13 @@ func (v *Vao) DrawOptions(mode uint32, first, count int32) {
 	// }
 	// gl.ActiveTexture(gl.TEXTURE0) // reset to 0th texture
 
-	gl.BindVertexArray(v.ID)
+	countDraw(mode, count)
+	backend.BindVertexArray(v.ID)
 	if v.Ebo.Count() > 0 {
-		gl.DrawElements(mode, count, Uint32, gl.PtrOffset(int(first)))
+		backend.DrawElements(mode, count, Uint32, int(first))
 	} else {
-		gl.DrawArrays(mode, first, count)
+		backend.DrawArrays(mode, first, count)
 	}
 
-	gl.BindVertexArray(0) // unbind vao
+	backend.BindVertexArray(0) // unbind vao
 }
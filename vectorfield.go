@@ -0,0 +1,623 @@
+package sgl
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// VectorField is a regular grid of vectors, such as velocities from a
+// simulation, for visualizing with ArrowGlyphs, TraceStreamline(), and LIC.
+// A 2D field has Depth 1 and lies in the xy plane.
+type VectorField struct {
+	Width, Height, Depth int
+	Origin               mgl32.Vec3 // position of the vector at (0, 0, 0)
+	Spacing              float32    // distance between grid points
+	Vectors              []mgl32.Vec3
+}
+
+// NewVectorField creates a field of zero vectors.
+func NewVectorField(width, height, depth int, origin mgl32.Vec3, spacing float32) *VectorField {
+	return &VectorField{
+		Width:   width,
+		Height:  height,
+		Depth:   depth,
+		Origin:  origin,
+		Spacing: spacing,
+		Vectors: make([]mgl32.Vec3, width*height*depth),
+	}
+}
+
+// At gets the vector at grid point (x, y, z).
+func (f *VectorField) At(x, y, z int) mgl32.Vec3 {
+	return f.Vectors[(z*f.Height+y)*f.Width+x]
+}
+
+// Set the vector at grid point (x, y, z).
+func (f *VectorField) Set(x, y, z int, v mgl32.Vec3) {
+	f.Vectors[(z*f.Height+y)*f.Width+x] = v
+}
+
+// Position gets the position of grid point (x, y, z).
+func (f *VectorField) Position(x, y, z int) mgl32.Vec3 {
+	return f.Origin.Add(mgl32.Vec3{float32(x), float32(y), float32(z)}.Mul(f.Spacing))
+}
+
+// MaxMagnitude gets the length of the longest vector.
+func (f *VectorField) MaxMagnitude() (max float32) {
+	for _, v := range f.Vectors {
+		if l := v.Len(); l > max {
+			max = l
+		}
+	}
+	return
+}
+
+// Sample interpolates the field at p. ok is false if p is outside the
+// field. The z of p is ignored for 2D fields.
+func (f *VectorField) Sample(p mgl32.Vec3) (v mgl32.Vec3, ok bool) {
+	g := p.Sub(f.Origin).Mul(1 / f.Spacing)
+	if f.Depth == 1 {
+		g[2] = 0
+	}
+	size := [3]int{f.Width, f.Height, f.Depth}
+	var i0, i1 [3]int
+	var t [3]float32
+	for axis := range g {
+		if g[axis] < 0 || g[axis] > float32(size[axis]-1) {
+			return mgl32.Vec3{}, false
+		}
+		i0[axis] = int(g[axis])
+		i1[axis] = i0[axis] + 1
+		if i1[axis] > size[axis]-1 {
+			i1[axis] = size[axis] - 1
+		}
+		t[axis] = g[axis] - float32(i0[axis])
+	}
+	lerp := func(a, b mgl32.Vec3, t float32) mgl32.Vec3 { return a.Add(b.Sub(a).Mul(t)) }
+	corner := func(x, y, z [3]int) mgl32.Vec3 { return f.At(x[0], y[1], z[2]) }
+	bottom := lerp(
+		lerp(corner(i0, i0, i0), corner(i1, i0, i0), t[0]),
+		lerp(corner(i0, i1, i0), corner(i1, i1, i0), t[0]), t[1])
+	top := lerp(
+		lerp(corner(i0, i0, i1), corner(i1, i0, i1), t[0]),
+		lerp(corner(i0, i1, i1), corner(i1, i1, i1), t[0]), t[1])
+	return lerp(bottom, top, t[2]), true
+}
+
+// FieldFunc gets the vector of a field at p, and false if p is outside the
+// field. VectorField.Sample is a FieldFunc, but analytic fields work too.
+type FieldFunc func(p mgl32.Vec3) (v mgl32.Vec3, ok bool)
+
+// TraceStreamline follows the field from seed, in steps of length step, with
+// 4th order Runge-Kutta integration, and returns the points along the way.
+// A negative step traces backwards. It stops after maxSteps, on leaving the
+// field, or where the field is zero.
+func TraceStreamline(field FieldFunc, seed mgl32.Vec3, step float32, maxSteps int) []mgl32.Vec3 {
+	// direction of the field, so steps are the same length at any speed
+	dir := func(p mgl32.Vec3) (mgl32.Vec3, bool) {
+		v, ok := field(p)
+		if !ok || v.Len() < 1e-9 {
+			return mgl32.Vec3{}, false
+		}
+		return v.Normalize(), true
+	}
+
+	points := []mgl32.Vec3{seed}
+	p := seed
+	for i := 0; i < maxSteps; i++ {
+		k1, ok1 := dir(p)
+		k2, ok2 := dir(p.Add(k1.Mul(step / 2)))
+		k3, ok3 := dir(p.Add(k2.Mul(step / 2)))
+		k4, ok4 := dir(p.Add(k3.Mul(step)))
+		if !(ok1 && ok2 && ok3 && ok4) {
+			break
+		}
+		p = p.Add(k1.Add(k2.Mul(2)).Add(k3.Mul(2)).Add(k4).Mul(step / 6))
+		points = append(points, p)
+	}
+	return points
+}
+
+// only need these once in the package
+var arrowProgram, streamlineProgram, licProgram *Program
+
+// called to create and build the arrow glyph program.
+func initArrowProgram() error {
+	arrowProgram = NewProgram()
+	arrowProgram.AddShader(VertexShader, arrowVertexShader,
+		[]string{"projection", "view", "cameraPos", "scale", "maxMagnitude"})
+	arrowProgram.AddShader(FragmentShader, arrowFragmentShader,
+		[]string{"colorLow", "colorHigh", "maxMagnitude"})
+	if err := arrowProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build arrow program: %w", err)
+	}
+	return nil
+}
+
+// arrowShape is a flat arrow pointing along +x, from 0 to 1, as triangles.
+var arrowShape = []float32{
+	// shaft
+	0, -0.04, 0.7, -0.04, 0.7, 0.04,
+	0, -0.04, 0.7, 0.04, 0, 0.04,
+	// head
+	0.7, -0.15, 1, 0, 0.7, 0.15,
+}
+
+// ArrowGlyphs draws an arrow for each vector of a field, all in one
+// instanced draw call. Arrows turn about their axis to face the camera, are
+// Scale long at MaxMagnitude, and are colored from ColorLow to ColorHigh by
+// magnitude.
+type ArrowGlyphs struct {
+	Scale               float32
+	MaxMagnitude        float32
+	ColorLow, ColorHigh Color
+
+	vao, shapeVbo, instanceVbo uint32
+	count                      int32
+	capacity                   int // instances the buffer can hold
+}
+
+// NewArrowGlyphs creates glyphs with no arrows.
+func NewArrowGlyphs() (*ArrowGlyphs, error) {
+	if arrowProgram == nil {
+		if err := initArrowProgram(); err != nil {
+			return nil, err
+		}
+	}
+	a := &ArrowGlyphs{
+		Scale:        1,
+		MaxMagnitude: 1,
+		ColorLow:     Color{0.2, 0.3, 1, 1},
+		ColorHigh:    Color{1, 0.3, 0.2, 1},
+	}
+	a.vao = backend.GenVertexArray()
+	backend.BindVertexArray(a.vao)
+
+	a.shapeVbo = backend.GenBuffer()
+	backend.BindBuffer(gl.ARRAY_BUFFER, a.shapeVbo)
+	backend.BufferData(gl.ARRAY_BUFFER, len(arrowShape)*SizeOfFloat, gl.Ptr(arrowShape), gl.STATIC_DRAW)
+	backend.VertexAttribPointer(0, 2, gl.FLOAT, false, 2*SizeOfFloat, 0)
+	backend.EnableVertexAttribArray(0)
+	trackBuffer(a.shapeVbo, len(arrowShape)*SizeOfFloat)
+
+	// position and vector of each arrow
+	a.instanceVbo = backend.GenBuffer()
+	backend.BindBuffer(gl.ARRAY_BUFFER, a.instanceVbo)
+	backend.VertexAttribPointer(1, 3, gl.FLOAT, false, 2*SizeOfV3, 0)
+	backend.VertexAttribPointer(2, 3, gl.FLOAT, false, 2*SizeOfV3, SizeOfV3)
+	backend.EnableVertexAttribArray(1)
+	backend.EnableVertexAttribArray(2)
+	backend.VertexAttribDivisor(1, 1)
+	backend.VertexAttribDivisor(2, 1)
+
+	backend.BindVertexArray(0)
+	backend.BindBuffer(gl.ARRAY_BUFFER, 0)
+	return a, nil
+}
+
+// SetArrows replaces the arrows with one at each position, pointing along
+// the vector of the same index.
+func (a *ArrowGlyphs) SetArrows(positions, vectors []mgl32.Vec3) {
+	n := len(positions)
+	if len(vectors) < n {
+		n = len(vectors)
+	}
+	data := make([]mgl32.Vec3, 0, 2*n)
+	for i := 0; i < n; i++ {
+		data = append(data, positions[i], vectors[i])
+	}
+	a.count = int32(n)
+
+	backend.BindBuffer(gl.ARRAY_BUFFER, a.instanceVbo)
+	size := len(data) * SizeOfV3
+	if n > a.capacity {
+		a.capacity = n
+		backend.BufferData(gl.ARRAY_BUFFER, size, nil, gl.DYNAMIC_DRAW)
+		trackBuffer(a.instanceVbo, size)
+	}
+	if n > 0 {
+		backend.BufferSubData(gl.ARRAY_BUFFER, 0, size, gl.Ptr(data))
+		countUpload(size)
+	}
+	backend.BindBuffer(gl.ARRAY_BUFFER, 0)
+}
+
+// SetField replaces the arrows with one at every nth grid point of field,
+// and sets MaxMagnitude to the field's.
+func (a *ArrowGlyphs) SetField(field *VectorField, every int) {
+	if every < 1 {
+		every = 1
+	}
+	var positions, vectors []mgl32.Vec3
+	for z := 0; z < field.Depth; z += every {
+		for y := 0; y < field.Height; y += every {
+			for x := 0; x < field.Width; x += every {
+				positions = append(positions, field.Position(x, y, z))
+				vectors = append(vectors, field.At(x, y, z))
+			}
+		}
+	}
+	a.SetArrows(positions, vectors)
+	if max := field.MaxMagnitude(); max > 0 {
+		a.MaxMagnitude = max
+	}
+}
+
+// Draw the arrows.
+func (a *ArrowGlyphs) Draw(view, projection mgl32.Mat4, cameraPos mgl32.Vec3) {
+	if a.count == 0 {
+		return
+	}
+	low, high := a.ColorLow.Vec4(), a.ColorHigh.Vec4()
+	arrowProgram.Use()
+	arrowProgram.Vertex().SetMat4("projection", 1, &projection)
+	arrowProgram.Vertex().SetMat4("view", 1, &view)
+	arrowProgram.Vertex().SetVec3("cameraPos", 1, &cameraPos)
+	arrowProgram.Vertex().SetFloat("scale", 1, &a.Scale)
+	arrowProgram.Vertex().SetFloat("maxMagnitude", 1, &a.MaxMagnitude)
+	arrowProgram.Fragment().SetVec4("colorLow", 1, &low)
+	arrowProgram.Fragment().SetVec4("colorHigh", 1, &high)
+	arrowProgram.Fragment().SetFloat("maxMagnitude", 1, &a.MaxMagnitude)
+
+	vertices := int32(len(arrowShape) / 2)
+	backend.BindVertexArray(a.vao)
+	backend.DrawArraysInstanced(gl.TRIANGLES, 0, vertices, a.count)
+	backend.BindVertexArray(0)
+	countDraw(gl.TRIANGLES, vertices*a.count)
+}
+
+// Delete resources.
+func (a *ArrowGlyphs) Delete() {
+	trackBuffer(a.shapeVbo, 0)
+	trackBuffer(a.instanceVbo, 0)
+	backend.DeleteBuffer(a.shapeVbo)
+	backend.DeleteBuffer(a.instanceVbo)
+	backend.DeleteVertexArray(a.vao)
+}
+
+// called to create and build the streamline program.
+func initStreamlineProgram() error {
+	streamlineProgram = NewProgram()
+	streamlineProgram.AddShader(VertexShader, streamlineVertexShader,
+		append([]string{"projection", "view"}, VertexColorUniforms...),
+		Attribute{Name: "aPos", Type: gl.FLOAT, Size: 3, Stride: SizeOfV3 + SizeOfV4, Offset: 0},
+		ColorAttribute(Float32, SizeOfV3+SizeOfV4, SizeOfV3))
+	streamlineProgram.AddShader(FragmentShader, streamlineFragmentShader,
+		[]string{"color"})
+	if err := streamlineProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build streamline program: %w", err)
+	}
+	return nil
+}
+
+// Streamlines draws lines, such as those from TraceStreamline(), in a single
+// draw call. Color tints the color of each point.
+type Streamlines struct {
+	Color Color
+	vao   *Vao
+}
+
+// NewStreamlines creates a drawable of lines, each a sequence of points.
+func NewStreamlines(lines [][]mgl32.Vec3) (*Streamlines, error) {
+	return NewColoredStreamlines(lines, nil)
+}
+
+// NewColoredStreamlines is like NewStreamlines, with a color for each point,
+// such as from its speed, blended along the lines. colors may be nil, or
+// shorter than lines, and points without a color are white.
+func NewColoredStreamlines(lines [][]mgl32.Vec3, colors [][]Color) (*Streamlines, error) {
+	if streamlineProgram == nil {
+		if err := initStreamlineProgram(); err != nil {
+			return nil, err
+		}
+	}
+	// as separate segments, so the lines aren't joined
+	var vertices []float32
+	for n, line := range lines {
+		color := func(i int) Color {
+			if n < len(colors) && i < len(colors[n]) {
+				return colors[n][i]
+			}
+			return Color{1, 1, 1, 1}
+		}
+		for i := 1; i < len(line); i++ {
+			a, b := line[i-1], line[i]
+			ca, cb := color(i-1), color(i)
+			vertices = append(vertices, a[0], a[1], a[2], ca.R, ca.G, ca.B, ca.A)
+			vertices = append(vertices, b[0], b[1], b[2], cb.R, cb.G, cb.B, cb.A)
+		}
+	}
+	vao := NewVao(Lines, NewVbo("vbo", streamlineProgram.Vertex().Attributes()...))
+	if len(vertices) > 0 {
+		vao.Vbo["vbo"].Initalize(vertices)
+	}
+	return &Streamlines{Color: Color{1, 1, 1, 1}, vao: vao}, nil
+}
+
+// Draw the lines.
+func (s *Streamlines) Draw(view, projection mgl32.Mat4) {
+	if s.vao.count() == 0 {
+		return
+	}
+	color := s.Color.Vec4()
+	streamlineProgram.Use()
+	streamlineProgram.Vertex().SetMat4("projection", 1, &projection)
+	streamlineProgram.Vertex().SetMat4("view", 1, &view)
+	streamlineProgram.Vertex().SetVertexColors(VertexColors{PerVertex: true})
+	streamlineProgram.Fragment().SetVec4("color", 1, &color)
+	s.vao.Draw()
+}
+
+// Delete resources.
+func (s *Streamlines) Delete() {
+	s.vao.Delete()
+}
+
+// called to create and build the line integral convolution program.
+func initLICProgram() error {
+	licProgram = NewProgram()
+	licProgram.AddShader(VertexShader, quadVertexShader,
+		[]string{"projection", "model"},
+		Attribute{Name: "vertex", Type: gl.FLOAT, Size: 4, Stride: 4 * SizeOfFloat, Offset: 0})
+	licProgram.AddShader(FragmentShader, licFragmentShader,
+		[]string{"field", "noise", "size", "fieldSize", "steps", "contrast",
+			"colorLow", "colorHigh", "maxMagnitude"})
+	if err := licProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build lic program: %w", err)
+	}
+	var field, noise int32 = 0, 1
+	licProgram.Fragment().SetInt("field", 1, &field)
+	licProgram.Fragment().SetInt("noise", 1, &noise)
+	return nil
+}
+
+// LIC renders a 2D vector field with line integral convolution: white noise
+// smeared along the field's streamlines, so the whole flow is visible at
+// once. Render() draws into Output, which can then be drawn like any
+// texture. Colors are from ColorLow to ColorHigh by magnitude.
+type LIC struct {
+	Length              int     // pixels followed each way along the flow
+	Contrast            float32 // 1 keeps the contrast of the noise
+	MaxMagnitude        float32
+	ColorLow, ColorHigh Color
+	Output              *Fbo
+
+	field, noise *Texture2D
+	fieldSize    mgl32.Vec2
+}
+
+// NewLIC creates a width x height LIC image of field, which must be 2D.
+func NewLIC(width, height int, field *VectorField) (*LIC, error) {
+	if field.Depth != 1 {
+		return nil, fmt.Errorf("lic needs a 2D field, not depth %d", field.Depth)
+	}
+	if licProgram == nil {
+		if err := initLICProgram(); err != nil {
+			return nil, err
+		}
+	}
+
+	rng := NewRNG(RandomSeed)
+	noiseData := make([]byte, width*height)
+	for i := range noiseData {
+		noiseData[i] = byte(rng.Intn(256))
+	}
+	noise, err := NewTextureData(width, height, TexR8, noiseData)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create lic noise: %w", err)
+	}
+	backend.BindTexture(gl.TEXTURE_2D, noise.ID)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	backend.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	backend.BindTexture(gl.TEXTURE_2D, 0)
+
+	output, err := NewFbo(width, height)
+	if err != nil {
+		noise.Delete()
+		return nil, fmt.Errorf("couldn't create lic output: %w", err)
+	}
+
+	lic := &LIC{
+		Length:    20,
+		Contrast:  1,
+		ColorLow:  Color{1, 1, 1, 1},
+		ColorHigh: Color{1, 1, 1, 1},
+		Output:    output,
+		noise:     noise,
+	}
+	if err := lic.SetField(field); err != nil {
+		lic.Delete()
+		return nil, err
+	}
+	return lic, nil
+}
+
+// SetField replaces the field, such as with the next step of a simulation,
+// and sets MaxMagnitude to the field's.
+func (lic *LIC) SetField(field *VectorField) error {
+	data := make([]float32, 0, 2*len(field.Vectors))
+	for _, v := range field.Vectors {
+		data = append(data, v[0], v[1])
+	}
+	if lic.field != nil && int(lic.field.Width) == field.Width && int(lic.field.Height) == field.Height {
+		if err := lic.field.SetPixels(image.Rect(0, 0, field.Width, field.Height), data); err != nil {
+			return err
+		}
+	} else {
+		tex, err := NewTextureData(field.Width, field.Height, TexRG32F, data)
+		if err != nil {
+			return fmt.Errorf("couldn't create lic field: %w", err)
+		}
+		if lic.field != nil {
+			lic.field.Delete()
+		}
+		lic.field = tex
+	}
+	lic.fieldSize = mgl32.Vec2{float32(field.Width), float32(field.Height)}
+	lic.MaxMagnitude = float32(math.Max(float64(field.MaxMagnitude()), 1e-6))
+	return nil
+}
+
+// Render draws the LIC image into Output. Pixel (x, y) of Output is at
+// the same relative position as grid point (x, y) of the field.
+func (lic *LIC) Render() {
+	saved := saveTargetState()
+	backend.Disable(gl.DEPTH_TEST)
+	backend.Disable(gl.BLEND)
+	lic.Output.Use()
+	w, h := float32(lic.Output.Width), float32(lic.Output.Height)
+	backend.Viewport(0, 0, lic.Output.Width, lic.Output.Height)
+
+	size := mgl32.Vec2{w, h}
+	steps := int32(lic.Length)
+	low, high := lic.ColorLow.Vec4(), lic.ColorHigh.Vec4()
+	projection, model := quadTransform(0, 0, w, h, w, h)
+	licProgram.Use()
+	licProgram.Vertex().SetMat4("projection", 1, &projection)
+	licProgram.Vertex().SetMat4("model", 1, &model)
+	licProgram.Fragment().SetVec2("size", 1, &size)
+	licProgram.Fragment().SetVec2("fieldSize", 1, &lic.fieldSize)
+	licProgram.Fragment().SetInt("steps", 1, &steps)
+	licProgram.Fragment().SetFloat("contrast", 1, &lic.Contrast)
+	licProgram.Fragment().SetVec4("colorLow", 1, &low)
+	licProgram.Fragment().SetVec4("colorHigh", 1, &high)
+	licProgram.Fragment().SetFloat("maxMagnitude", 1, &lic.MaxMagnitude)
+	lic.field.Bind(0)
+	lic.noise.Bind(1)
+	backend.ActiveTexture(gl.TEXTURE0)
+	drawQuad()
+	saved.restore()
+}
+
+// Delete resources.
+func (lic *LIC) Delete() {
+	lic.Output.Delete()
+	lic.noise.Delete()
+	if lic.field != nil {
+		lic.field.Delete()
+	}
+}
+
+const arrowVertexShader = `#version 330 core
+layout (location = 0) in vec2 aShape;
+layout (location = 1) in vec3 aPos;
+layout (location = 2) in vec3 aVector;
+
+uniform mat4 projection;
+uniform mat4 view;
+uniform vec3 cameraPos;
+uniform float scale;
+uniform float maxMagnitude;
+
+out float Magnitude;
+
+void main()
+{
+    Magnitude = length(aVector);
+    vec3 dir = Magnitude > 0.0 ? aVector / Magnitude : vec3(1.0, 0.0, 0.0);
+    float len = scale * min(Magnitude / maxMagnitude, 1.0);
+
+    // turn the flat arrow about its axis to face the camera
+    vec3 side = cross(dir, cameraPos - aPos);
+    if (length(side) < 1e-6) {
+        side = cross(dir, abs(dir.y) < 0.9 ? vec3(0.0, 1.0, 0.0) : vec3(1.0, 0.0, 0.0));
+    }
+    side = normalize(side);
+
+    vec3 world = aPos + (dir * aShape.x + side * aShape.y) * len;
+    gl_Position = projection * view * vec4(world, 1.0);
+}`
+
+const arrowFragmentShader = `#version 330 core
+uniform vec4 colorLow;
+uniform vec4 colorHigh;
+uniform float maxMagnitude;
+
+in float Magnitude;
+
+out vec4 FragColor;
+
+void main()
+{
+    FragColor = mix(colorLow, colorHigh, clamp(Magnitude / maxMagnitude, 0.0, 1.0));
+}`
+
+const streamlineVertexShader = `#version 330 core
+` + VertexColorShaderSource + `
+in vec3 aPos;
+
+uniform mat4 projection;
+uniform mat4 view;
+
+void main()
+{
+    VertexColor = vertexColor();
+    gl_Position = projection * view * vec4(aPos, 1.0);
+}`
+
+const streamlineFragmentShader = `#version 330 core
+uniform vec4 color;
+
+in vec4 VertexColor;
+
+out vec4 FragColor;
+
+void main()
+{
+    FragColor = color * VertexColor;
+}`
+
+const licFragmentShader = `#version 330 core
+uniform sampler2D field; // rg is the vector, in grid units
+uniform sampler2D noise;
+uniform vec2 size;      // of the output, in pixels
+uniform vec2 fieldSize; // in grid points
+uniform int steps;
+uniform float contrast;
+uniform vec4 colorLow;
+uniform vec4 colorHigh;
+uniform float maxMagnitude;
+
+out vec4 FragColor;
+
+// direction of the field at uv, as a step of 1 output pixel in uv units
+vec2 direction(vec2 uv)
+{
+    vec2 v = texture(field, uv).xy / fieldSize * size;
+    float len = length(v);
+    return len > 1e-9 ? v / len / size : vec2(0.0);
+}
+
+void main()
+{
+    vec2 start = gl_FragCoord.xy / size;
+    float sum = texture(noise, start).r;
+    float count = 1.0;
+
+    // follow the streamline forwards, then backwards
+    for (int sign = -1; sign <= 1; sign += 2) {
+        vec2 uv = start;
+        for (int i = 0; i < steps; i++) {
+            vec2 dir = direction(uv);
+            if (dir == vec2(0.0)) {
+                break;
+            }
+            uv += float(sign) * dir;
+            if (any(lessThan(uv, vec2(0.0))) || any(greaterThan(uv, vec2(1.0)))) {
+                break;
+            }
+            sum += texture(noise, uv).r;
+            count += 1.0;
+        }
+    }
+
+    // averaging flattens the noise, by sqrt(count)
+    float value = clamp((sum / count - 0.5) * sqrt(count) * contrast + 0.5, 0.0, 1.0);
+    float magnitude = length(texture(field, start).xy);
+    vec4 color = mix(colorLow, colorHigh, clamp(magnitude / maxMagnitude, 0.0, 1.0));
+    FragColor = vec4(color.rgb * value, color.a);
+}`
@@ -0,0 +1,91 @@
+package sgl
+
+// Names of the optional color attributes read by VertexColorShaderSource.
+const (
+	ColorAttributeName         = "aColor"
+	InstanceColorAttributeName = "aInstanceColor"
+)
+
+// ColorAttribute gets the per vertex "aColor" attribute, an rgba color at
+// offset bytes into each vertex of stride bytes. xtype is Float32 for
+// 0 to 1 floats, or Uint8 for 0 to 255 bytes, which are normalized.
+func ColorAttribute(xtype uint32, stride int32, offset int) Attribute {
+	return Attribute{
+		Name:       ColorAttributeName,
+		Type:       xtype,
+		Size:       4,
+		Normalized: xtype != Float32,
+		Stride:     stride,
+		Offset:     offset,
+	}
+}
+
+// InstanceColorAttribute gets the per instance "aInstanceColor" attribute,
+// like ColorAttribute but advancing once per instance. Put it in its own
+// Vbo, since attributes in a Vbo share a stride.
+func InstanceColorAttribute(xtype uint32, stride int32, offset int) Attribute {
+	a := ColorAttribute(xtype, stride, offset)
+	a.Name = InstanceColorAttributeName
+	a.Divisor = 1
+	return a
+}
+
+// VertexColors toggles which color attributes tint a shader using
+// VertexColorShaderSource. Both are multiplied with the shader's own color.
+type VertexColors struct {
+	PerVertex   bool // use aColor
+	PerInstance bool // use aInstanceColor
+}
+
+// VertexColorUniforms are the uniforms declared by VertexColorShaderSource.
+// Add them to the vertex shader's uniforms in Program.AddShader().
+var VertexColorUniforms = []string{"useVertexColor", "useInstanceColor"}
+
+// VertexColorShaderSource declares the color attributes and their toggles,
+// and a function to get the color of a vertex. Put it directly after the
+// #version line of a vertex shader, then use
+//
+//	VertexColor = vertexColor();
+//
+// and in the fragment shader
+//
+//	in vec4 VertexColor;
+//	FragColor = color * VertexColor;
+//
+// and call Shader.SetVertexColors() after Program.Use(). Attributes which
+// aren't in the bound Vao read as black, so only toggle on those it has.
+const VertexColorShaderSource = `
+in vec4 aColor;
+in vec4 aInstanceColor;
+
+uniform bool useVertexColor;
+uniform bool useInstanceColor;
+
+out vec4 VertexColor;
+
+// vertexColor gets the product of the enabled color attributes.
+vec4 vertexColor()
+{
+    vec4 c = vec4(1.0);
+    if (useVertexColor) {
+        c *= aColor;
+    }
+    if (useInstanceColor) {
+        c *= aInstanceColor;
+    }
+    return c;
+}
+`
+
+// SetVertexColors sets the uniforms declared by VertexColorShaderSource.
+// The shader's program must be in use.
+func (s *Shader) SetVertexColors(v VertexColors) {
+	if loc, ok := s.Uniforms["useVertexColor"]; ok {
+		perVertex := boolToInt32(v.PerVertex)
+		backend.Uniform1iv(loc, 1, &perVertex)
+	}
+	if loc, ok := s.Uniforms["useInstanceColor"]; ok {
+		perInstance := boolToInt32(v.PerInstance)
+		backend.Uniform1iv(loc, 1, &perInstance)
+	}
+}
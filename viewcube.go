@@ -0,0 +1,248 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// only need this once in the package
+var viewCubeProgram *Program
+
+// called to create and build the view cube program.
+func initViewCubeProgram() error {
+	viewCubeProgram = NewProgram()
+	viewCubeProgram.AddShader(VertexShader, viewCubeVertexShader,
+		append([]string{"projection", "view"}, VertexColorUniforms...),
+		Attribute{Name: "aPos", Type: gl.FLOAT, Size: 3, Stride: 7 * SizeOfFloat, Offset: 0},
+		ColorAttribute(Float32, 7*SizeOfFloat, 3*SizeOfFloat))
+	viewCubeProgram.AddShader(FragmentShader, viewCubeFragmentShader, []string{"hovered"})
+	if err := viewCubeProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build view cube program: %w", err)
+	}
+	return nil
+}
+
+// viewCubeBand is how far from the center of a face, from 0 to 1, the
+// edge and corner regions start. It matches band in the shader.
+const viewCubeBand = 0.6
+
+// ViewCube is an overlay, like those in CAD apps, showing the camera's
+// orientation as a cube in a corner of the screen. Clicking a face, edge,
+// or corner of the cube gives a direction to snap the camera to with
+// ViewCubeSnap(). Faces are colored by axis: x red, y green, and z blue,
+// darker on the negative side.
+//
+// Each frame, something like
+//
+//	region, ok := cube.Pick(mouseX, mouseY)
+//	cube.Hovered = region
+//	if ok && in.ButtonPressed(glfw.MouseButtonLeft) {
+//		view = ViewCubeSnap(region, target, distance)
+//	}
+//	cube.Draw(view, x, y, width, height)
+//
+// where x, y, width, and height are in the window's screen coordinates, as
+// are the mouse's.
+type ViewCube struct {
+	Size    float32    // of the overlay, in screen coordinates
+	Hovered mgl32.Vec3 // region highlighted; see Pick()
+
+	platform         *Window
+	vao              *Vao
+	x, y             float32    // of the last draw
+	view, projection mgl32.Mat4 // of the last draw
+}
+
+// NewViewCube creates a view cube size pixels square, for platform.
+func NewViewCube(platform *Window, size float32) (*ViewCube, error) {
+	if viewCubeProgram == nil {
+		if err := initViewCubeProgram(); err != nil {
+			return nil, err
+		}
+	}
+	vao := NewVao(Triangles, NewVbo("vbo", viewCubeProgram.Vertex().Attributes()...))
+	vao.Vbo["vbo"].Initalize(viewCubeVertices())
+	return &ViewCube{Size: size, platform: platform, vao: vao}, nil
+}
+
+// viewCubeVertices gets the triangles of a cube from -1 to 1, with
+// position and color per vertex.
+func viewCubeVertices() []float32 {
+	var data []float32
+	for axis := 0; axis < 3; axis++ {
+		u, v := (axis+1)%3, (axis+2)%3
+		for _, side := range []float32{1, -1} {
+			color := [4]float32{0.25, 0.25, 0.25, 1}
+			color[axis] = 0.85
+			if side < 0 {
+				color[axis] = 0.5
+			}
+			corner := func(a, b float32) {
+				var p [3]float32
+				p[axis], p[u], p[v] = side, a*side, b
+				data = append(data, p[0], p[1], p[2])
+				data = append(data, color[:]...)
+			}
+			// wound counter clockwise seen from outside
+			corner(-1, -1)
+			corner(1, -1)
+			corner(1, 1)
+			corner(-1, -1)
+			corner(1, 1)
+			corner(-1, 1)
+		}
+	}
+	return data
+}
+
+// cubeCamera gets the view and projection for the cube, turned like view.
+func (c *ViewCube) cubeCamera(view mgl32.Mat4) (cubeView, projection mgl32.Mat4) {
+	rotation := view.Mat3().Mat4()
+	cubeView = mgl32.Translate3D(0, 0, -5).Mul4(rotation)
+	projection = mgl32.Ortho(-1.8, 1.8, -1.8, 1.8, 0.1, 10)
+	return
+}
+
+// Draw the cube, turned like view, with its top left at (x, y), in pixels
+// with (0, 0) at the top left of a screen of size (width, height), which
+// should be the window's screen coordinates. The depth buffer is cleared
+// where the cube is drawn.
+func (c *ViewCube) Draw(view mgl32.Mat4, x, y, width, height float32) {
+	c.x, c.y = x, y
+	c.view, c.projection = c.cubeCamera(view)
+
+	saved := saveTargetState()
+	scale := c.platform.FramebufferScale()
+	box := [4]int32{
+		int32(x * scale[0]), int32((height - y - c.Size) * scale[1]),
+		int32(c.Size * scale[0]), int32(c.Size * scale[1]),
+	}
+	gl.Viewport(box[0], box[1], box[2], box[3])
+	state := CurrentRenderState()
+	state.ScissorTest, state.Scissor = true, box
+	state.DepthTest, state.DepthWrite, state.Blend = true, true, false
+	PushRenderState(state)
+	gl.Clear(gl.DEPTH_BUFFER_BIT)
+
+	viewCubeProgram.Use()
+	viewCubeProgram.Vertex().SetMat4("projection", 1, &c.projection)
+	viewCubeProgram.Vertex().SetMat4("view", 1, &c.view)
+	viewCubeProgram.Vertex().SetVertexColors(VertexColors{PerVertex: true})
+	viewCubeProgram.Fragment().SetVec3("hovered", 1, &c.Hovered)
+	c.vao.Draw()
+	PopRenderState()
+	saved.restore()
+}
+
+// Pick gets the region of the cube under (x, y), in the same pixels as
+// Draw(), as of the last Draw(). Each component of the region is -1, 0, or
+// 1, so a face such as {0, 1, 0} has one non-zero component, an edge two,
+// and a corner three. ok is false if (x, y) isn't on the cube.
+func (c *ViewCube) Pick(x, y float64) (region mgl32.Vec3, ok bool) {
+	// the ray through (x, y) in the cube's space, from the ortho camera
+	ndcX := (float32(x)-c.x)/c.Size*2 - 1
+	ndcY := 1 - (float32(y)-c.y)/c.Size*2
+	if ndcX < -1 || ndcX > 1 || ndcY < -1 || ndcY > 1 {
+		return region, false
+	}
+	inv := c.projection.Mul4(c.view).Inv()
+	near := mgl32.TransformCoordinate(mgl32.Vec3{ndcX, ndcY, -1}, inv)
+	far := mgl32.TransformCoordinate(mgl32.Vec3{ndcX, ndcY, 1}, inv)
+	dir := far.Sub(near)
+
+	// slab intersection with the cube from -1 to 1
+	tMin, tMax := float32(math.Inf(-1)), float32(math.Inf(1))
+	for i := 0; i < 3; i++ {
+		if dir[i] == 0 {
+			if near[i] < -1 || near[i] > 1 {
+				return region, false
+			}
+			continue
+		}
+		t0, t1 := (-1-near[i])/dir[i], (1-near[i])/dir[i]
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		tMin, tMax = maxFloat(tMin, t0), minFloat(tMax, t1)
+	}
+	if tMin > tMax {
+		return region, false
+	}
+	hit := near.Add(dir.Mul(tMin))
+	for i := 0; i < 3; i++ {
+		switch {
+		case hit[i] > viewCubeBand:
+			region[i] = 1
+		case hit[i] < -viewCubeBand:
+			region[i] = -1
+		}
+	}
+	return region, true
+}
+
+// ViewCubeSnap gets the view looking at target from distance away in the
+// direction of region, such as one from ViewCube.Pick(). +y is up, except
+// looking straight down or up, when -z or +z is up.
+func ViewCubeSnap(region, target mgl32.Vec3, distance float32) mgl32.Mat4 {
+	if region.Len() == 0 {
+		region = mgl32.Vec3{0, 0, 1}
+	}
+	dir := region.Normalize()
+	up := mgl32.Vec3{0, 1, 0}
+	if region[0] == 0 && region[2] == 0 {
+		up = mgl32.Vec3{0, 0, -region[1]}
+	}
+	return mgl32.LookAtV(target.Add(dir.Mul(distance)), target, up)
+}
+
+// Delete resources.
+func (c *ViewCube) Delete() {
+	c.vao.Delete()
+}
+
+const viewCubeVertexShader = `#version 330 core
+` + VertexColorShaderSource + `
+in vec3 aPos;
+
+uniform mat4 projection;
+uniform mat4 view;
+
+out vec3 CubePos;
+
+void main()
+{
+    CubePos = aPos;
+    VertexColor = vertexColor();
+    gl_Position = projection * view * vec4(aPos, 1.0);
+}`
+
+const viewCubeFragmentShader = `#version 330 core
+in vec3 CubePos;
+in vec4 VertexColor;
+
+uniform vec3 hovered; // region, or zero
+
+out vec4 FragColor;
+
+const float band = 0.6;
+
+void main()
+{
+    vec3 region = step(band, CubePos) - step(band, -CubePos);
+    vec3 color = VertexColor.rgb;
+    if (hovered != vec3(0.0) && region == hovered) {
+        color = mix(color, vec3(1.0), 0.5);
+    }
+    // darken the border between regions
+    vec3 d = abs(abs(CubePos) - band);
+    float onEdge = float(abs(CubePos.x) < 0.999 && d.x < 0.02) +
+                   float(abs(CubePos.y) < 0.999 && d.y < 0.02) +
+                   float(abs(CubePos.z) < 0.999 && d.z < 0.02);
+    color *= onEdge > 0.0 ? 0.6 : 1.0;
+    FragColor = vec4(color, 1.0);
+}`
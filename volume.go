@@ -0,0 +1,293 @@
+package sgl
+
+import (
+	"fmt"
+	"image"
+	"sort"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// only need this once in the package
+var volumeProgram *Program
+
+// MaxVolumeSlicePlanes is the number of planes a VolumeRenderer can be
+// sliced with.
+const MaxVolumeSlicePlanes = 4
+
+// called to create and build the volume program.
+func initVolumeProgram() error {
+	volumeProgram = NewProgram()
+	volumeProgram.AddShader(VertexShader, volumeVertexShader,
+		[]string{"projection", "view", "model"},
+		Attribute{Name: "aPos", Type: gl.FLOAT, Size: 3, Stride: SizeOfV3, Offset: 0})
+	volumeProgram.AddShader(FragmentShader, volumeFragmentShader,
+		[]string{"volume", "transfer", "cameraModel", "steps", "opacity", "valueMin", "valueMax",
+			"planes", "planeCount"})
+
+	if err := volumeProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build volume program: %w", err)
+	}
+	var volume, transfer int32 = 0, 1
+	volumeProgram.Fragment().SetInt("volume", 1, &volume)
+	volumeProgram.Fragment().SetInt("transfer", 1, &transfer)
+	return nil
+}
+
+// TransferPoint is a control point of a transfer function: the color and
+// opacity (alpha) given to a value of the volume.
+type TransferPoint struct {
+	Value float32 // 0 to 1, after mapping with ValueMin and ValueMax
+	Color Color
+}
+
+// transferSize is the number of entries in the transfer function lookup
+// table.
+const transferSize = 256
+
+// VolumeRenderer draws a Texture3D, such as a CT scan or simulation, by
+// ray marching through it. Each value of the volume's first channel is
+// mapped from ValueMin..ValueMax to 0..1 and then to a color and opacity by
+// the transfer function. The volume fills the unit cube (0 to 1 on each
+// axis), placed in the world with Model.
+//
+// It should be drawn after opaque objects, and doesn't write depth. Objects
+// inside the volume aren't blended into it.
+type VolumeRenderer struct {
+	Volume             *Texture3D
+	Model              mgl32.Mat4
+	Steps              int     // samples along the diagonal of the volume
+	Opacity            float32 // scales the transfer function's alpha
+	ValueMin, ValueMax float32
+	Planes             []mgl32.Vec4 // world space (normal, distance); the negative side is cut away
+
+	transfer *Texture2D
+	vao      *Vao
+}
+
+// NewVolumeRenderer creates a renderer for volume, with a grayscale ramp for
+// the transfer function. The volume isn't deleted with the renderer.
+func NewVolumeRenderer(volume *Texture3D) (*VolumeRenderer, error) {
+	if volumeProgram == nil {
+		if err := initVolumeProgram(); err != nil {
+			return nil, err
+		}
+	}
+	transfer, err := NewTextureData(transferSize, 1, TexRGBA8, nil)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create transfer function: %w", err)
+	}
+
+	vao := NewVao(Triangles, NewVbo("vbo", volumeProgram.Vertex().Attributes()...))
+	vao.Vbo["vbo"].Initalize(unitCubeTriangles())
+
+	v := &VolumeRenderer{
+		Volume:   volume,
+		Model:    mgl32.Ident4(),
+		Steps:    256,
+		Opacity:  1,
+		ValueMin: 0,
+		ValueMax: 1,
+		transfer: transfer,
+		vao:      vao,
+	}
+	v.SetTransferFunction(
+		TransferPoint{0, Color{0, 0, 0, 0}},
+		TransferPoint{1, Color{1, 1, 1, 0.1}})
+	return v, nil
+}
+
+// unitCubeTriangles gets the faces of the cube from 0 to 1, wound counter
+// clockwise seen from outside.
+func unitCubeTriangles() []float32 {
+	var vertices []float32
+	for axis := 0; axis < 3; axis++ {
+		u, v := (axis+1)%3, (axis+2)%3 // u x v is along +axis
+		for side := 0; side < 2; side++ {
+			corners := [4][2]float32{{0, 0}, {1, 0}, {1, 1}, {0, 1}}
+			if side == 0 {
+				corners[1], corners[3] = corners[3], corners[1] // facing -axis
+			}
+			for _, i := range []int{0, 1, 2, 0, 2, 3} {
+				var p [3]float32
+				p[axis], p[u], p[v] = float32(side), corners[i][0], corners[i][1]
+				vertices = append(vertices, p[:]...)
+			}
+		}
+	}
+	return vertices
+}
+
+// SetTransferFunction sets the transfer function by linear interpolation
+// between points, which needn't be sorted. Values beyond the first and last
+// points get their colors. A color's alpha is the opacity of 1/256 of the
+// width of the volume.
+func (v *VolumeRenderer) SetTransferFunction(points ...TransferPoint) {
+	if len(points) == 0 {
+		return
+	}
+	sorted := append([]TransferPoint(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Value < sorted[j].Value })
+
+	lut := make([]byte, 0, transferSize*4)
+	next := 0
+	for i := 0; i < transferSize; i++ {
+		x := float32(i) / (transferSize - 1)
+		for next < len(sorted) && sorted[next].Value < x {
+			next++
+		}
+		var c Color
+		switch {
+		case next == 0:
+			c = sorted[0].Color
+		case next == len(sorted):
+			c = sorted[len(sorted)-1].Color
+		default:
+			a, b := sorted[next-1], sorted[next]
+			t := (x - a.Value) / (b.Value - a.Value)
+			c = Color{
+				a.Color.R + (b.Color.R-a.Color.R)*t,
+				a.Color.G + (b.Color.G-a.Color.G)*t,
+				a.Color.B + (b.Color.B-a.Color.B)*t,
+				a.Color.A + (b.Color.A-a.Color.A)*t,
+			}
+		}
+		lut = append(lut, unitToByte(c.R), unitToByte(c.G), unitToByte(c.B), unitToByte(c.A))
+	}
+	v.transfer.SetPixels(image.Rect(0, 0, transferSize, 1), lut)
+}
+
+// unitToByte converts 0..1 to 0..255.
+func unitToByte(x float32) byte {
+	return byte(mgl32.Clamp(x, 0, 1)*255 + 0.5)
+}
+
+// Draw the volume.
+func (v *VolumeRenderer) Draw(view, projection mgl32.Mat4, cameraPos mgl32.Vec3) {
+	cameraModel := v.Model.Inv().Mul4x1(cameraPos.Vec4(1)).Vec3()
+	steps := int32(v.Steps)
+
+	// planes in model space: dot(plane, model * p) = dot(transpose(model) * plane, p)
+	var planes [MaxVolumeSlicePlanes]mgl32.Vec4
+	count := int32(len(v.Planes))
+	if count > MaxVolumeSlicePlanes {
+		count = MaxVolumeSlicePlanes
+	}
+	for i := 0; i < int(count); i++ {
+		planes[i] = v.Model.Transpose().Mul4x1(v.Planes[i])
+	}
+
+	var cull, depthMask bool
+	var cullMode int32
+	cull = gl.IsEnabled(gl.CULL_FACE)
+	gl.GetIntegerv(gl.CULL_FACE_MODE, &cullMode)
+	gl.GetBooleanv(gl.DEPTH_WRITEMASK, &depthMask)
+	saved := saveTargetState()
+
+	// back faces, so the camera can be inside the volume
+	gl.Enable(gl.CULL_FACE)
+	gl.CullFace(gl.FRONT)
+	gl.DepthMask(false)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA) // premultiplied
+
+	volumeProgram.Use()
+	volumeProgram.Vertex().SetMat4("projection", 1, &projection)
+	volumeProgram.Vertex().SetMat4("view", 1, &view)
+	volumeProgram.Vertex().SetMat4("model", 1, &v.Model)
+	volumeProgram.Fragment().SetVec3("cameraModel", 1, &cameraModel)
+	volumeProgram.Fragment().SetInt("steps", 1, &steps)
+	volumeProgram.Fragment().SetFloat("opacity", 1, &v.Opacity)
+	volumeProgram.Fragment().SetFloat("valueMin", 1, &v.ValueMin)
+	volumeProgram.Fragment().SetFloat("valueMax", 1, &v.ValueMax)
+	volumeProgram.Fragment().SetVec4("planes", MaxVolumeSlicePlanes, &planes[0])
+	volumeProgram.Fragment().SetInt("planeCount", 1, &count)
+	v.Volume.Bind(0)
+	v.transfer.Bind(1)
+	gl.ActiveTexture(gl.TEXTURE0)
+	v.vao.Draw()
+
+	saved.restore()
+	gl.DepthMask(depthMask)
+	gl.CullFace(uint32(cullMode))
+	setEnabled(gl.CULL_FACE, cull)
+}
+
+// Delete resources. The Volume isn't deleted.
+func (v *VolumeRenderer) Delete() {
+	v.transfer.Delete()
+	v.vao.Delete()
+}
+
+const volumeVertexShader = `#version 330 core
+in vec3 aPos;
+
+uniform mat4 projection;
+uniform mat4 view;
+uniform mat4 model;
+
+out vec3 ModelPos;
+
+void main()
+{
+    ModelPos = aPos;
+    gl_Position = projection * view * model * vec4(aPos, 1.0);
+}`
+
+const volumeFragmentShader = `#version 330 core
+uniform sampler3D volume;
+uniform sampler2D transfer;
+uniform vec3 cameraModel; // camera position in model space
+uniform int steps;
+uniform float opacity;
+uniform float valueMin;
+uniform float valueMax;
+uniform vec4 planes[4];
+uniform int planeCount;
+
+in vec3 ModelPos; // on a back face, where the ray leaves the volume
+
+out vec4 FragColor;
+
+void main()
+{
+    vec3 dir = normalize(ModelPos - cameraModel);
+
+    // where the ray enters the cube, or the camera if it's inside
+    vec3 t0 = (vec3(0.0) - cameraModel) / dir;
+    vec3 t1 = (vec3(1.0) - cameraModel) / dir;
+    vec3 tmin = min(t0, t1);
+    float tNear = max(max(max(tmin.x, tmin.y), tmin.z), 0.0);
+    float tFar = length(ModelPos - cameraModel);
+
+    float stepLength = sqrt(3.0) / float(steps);
+    // jitter the start to turn banding into noise
+    float jitter = fract(sin(dot(gl_FragCoord.xy, vec2(12.9898, 78.233))) * 43758.5453);
+    float exponent = opacity * stepLength * 256.0;
+
+    vec4 color = vec4(0.0);
+    for (float t = tNear + jitter * stepLength; t < tFar; t += stepLength) {
+        vec3 p = cameraModel + dir * t;
+        bool cut = false;
+        for (int i = 0; i < planeCount; i++) {
+            cut = cut || dot(vec4(p, 1.0), planes[i]) < 0.0;
+        }
+        if (cut) {
+            continue;
+        }
+
+        float value = (texture(volume, p).r - valueMin) / (valueMax - valueMin);
+        vec4 sampled = texture(transfer, vec2(clamp(value, 0.0, 1.0), 0.5));
+        // the transfer alpha is for 1/256 of the volume
+        float alpha = 1.0 - pow(1.0 - min(sampled.a, 0.999), exponent);
+
+        // front to back
+        color.rgb += (1.0 - color.a) * alpha * sampled.rgb;
+        color.a += (1.0 - color.a) * alpha;
+        if (color.a > 0.99) {
+            break;
+        }
+    }
+    FragColor = color;
+}`
@@ -0,0 +1,262 @@
+package sgl
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// only need this once in the package
+var waterProgram *Program
+
+// called to create and build the water program.
+func initWaterProgram() error {
+	waterProgram = NewProgram()
+	waterProgram.AddShader(VertexShader, waterVertexShader,
+		[]string{"projection", "view", "model", "tiling"},
+		Attribute{Name: "aPos", Type: gl.FLOAT, Size: 3, Stride: 3 * SizeOfFloat, Offset: 0})
+	waterProgram.AddShader(FragmentShader, waterFragmentShader,
+		[]string{"reflection", "refraction", "dudvMap", "normalMap", "moveFactor", "waveStrength",
+			"tint", "lightDirection", "lightColor", "cameraPos"})
+
+	if err := waterProgram.Build(); err != nil {
+		return fmt.Errorf("couldn't build water program: %w", err)
+	}
+	BindFog(waterProgram)
+	var reflection, refraction, dudv, normal int32 = 0, 1, 2, 3
+	waterProgram.Fragment().SetInt("reflection", 1, &reflection)
+	waterProgram.Fragment().SetInt("refraction", 1, &refraction)
+	waterProgram.Fragment().SetInt("dudvMap", 1, &dudv)
+	waterProgram.Fragment().SetInt("normalMap", 1, &normal)
+	return nil
+}
+
+// unit square in the xz plane, centered on the origin.
+var waterVertices = []float32{
+	-0.5, 0, -0.5,
+	-0.5, 0, 0.5,
+	0.5, 0, -0.5,
+	0.5, 0, -0.5,
+	-0.5, 0, 0.5,
+	0.5, 0, 0.5,
+}
+
+// WaterPlane is a horizontal square of water which reflects and refracts the
+// scene. Each frame, call Update(), then RenderTextures() to draw the scene
+// into the reflection and refraction FBOs, then draw the scene normally and
+// finally Draw() the water.
+type WaterPlane struct {
+	Height       float32    // y of the water's surface
+	Center       mgl32.Vec2 // x and z of the center of the water
+	Size         float32    // width and depth
+	Tiling       float32    // repeats of the dudv and normal maps per unit of distance
+	WaveStrength float32    // amount of distortion
+	WaveSpeed    float32    // movement of the dudv map per second
+	Tint         Color      // alpha is the amount of tint
+
+	DuDv, Normal           *Texture2D // distortion and normal maps; should repeat
+	Reflection, Refraction *Fbo
+
+	moveFactor float32
+	vao        *Vao
+}
+
+// NewWaterPlane creates water with reflection and refraction FBOs of the
+// given size, which can be smaller than the window to save time.
+func NewWaterPlane(height, size float32, fboWidth, fboHeight int, dudv, normal *Texture2D) (*WaterPlane, error) {
+	if waterProgram == nil {
+		if err := initWaterProgram(); err != nil {
+			return nil, err
+		}
+	}
+
+	reflection, err := NewFbo(fboWidth, fboHeight)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create water reflection: %w", err)
+	}
+	refraction, err := NewFbo(fboWidth, fboHeight)
+	if err != nil {
+		reflection.Delete()
+		return nil, fmt.Errorf("couldn't create water refraction: %w", err)
+	}
+
+	vao := NewVao(Triangles, NewVbo("vbo", waterProgram.Vertex().Attributes()...))
+	vao.Vbo["vbo"].Initalize(waterVertices)
+
+	return &WaterPlane{
+		Height:       height,
+		Size:         size,
+		Tiling:       0.1,
+		WaveStrength: 0.02,
+		WaveSpeed:    0.03,
+		Tint:         Color{0, 0.3, 0.5, 0.2},
+		DuDv:         dudv,
+		Normal:       normal,
+		Reflection:   reflection,
+		Refraction:   refraction,
+		vao:          vao,
+	}, nil
+}
+
+// Delete resources. The DuDv and Normal textures are not deleted.
+func (water *WaterPlane) Delete() {
+	water.Reflection.Delete()
+	water.Refraction.Delete()
+	water.vao.Delete()
+}
+
+// Update animates the waves.
+func (water *WaterPlane) Update(clock *Timer) {
+	water.moveFactor += water.WaveSpeed * float32(clock.DeltaT)
+	water.moveFactor = float32(math.Mod(float64(water.moveFactor), 1))
+}
+
+// ReflectedView gets the view matrix of a camera mirrored below the water.
+func (water *WaterPlane) ReflectedView(view mgl32.Mat4) mgl32.Mat4 {
+	mirror := mgl32.Translate3D(0, 2*water.Height, 0).Mul4(mgl32.Scale3D(1, -1, 1))
+	return view.Mul4(mirror)
+}
+
+// RenderTextures draws the scene into the Reflection and Refraction FBOs.
+// drawScene is called once for each, and must draw everything except the
+// water with the given view and projection. clipPlane, a plane (normal,
+// distance) in world space, is already set with SetClipPlanes(), so shaders
+// using ClipPlaneShaderSource need only call Shader.SetClipPlanes(). The
+// framebuffer, viewport, and clip planes in use are restored afterwards.
+func (water *WaterPlane) RenderTextures(view, projection mgl32.Mat4, drawScene func(view, projection mgl32.Mat4, clipPlane mgl32.Vec4)) {
+	var prevFbo int32
+	var viewport [4]int32
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &prevFbo)
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	prevPlanes := ClipPlanes()
+
+	// a little overlap hides seams at the water's edge
+	const overlap = 0.05
+
+	// reflection: everything above the water, seen from below. mirroring
+	// reverses the winding of triangles.
+	water.Reflection.Use()
+	gl.Viewport(0, 0, water.Reflection.Width, water.Reflection.Height)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	gl.FrontFace(gl.CW)
+	above := mgl32.Vec4{0, 1, 0, -water.Height + overlap}
+	SetClipPlanes(above)
+	drawScene(water.ReflectedView(view), projection, above)
+	gl.FrontFace(gl.CCW)
+
+	// refraction: everything below the water
+	water.Refraction.Use()
+	gl.Viewport(0, 0, water.Refraction.Width, water.Refraction.Height)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	below := mgl32.Vec4{0, -1, 0, water.Height + overlap}
+	SetClipPlanes(below)
+	drawScene(view, projection, below)
+
+	SetClipPlanes(prevPlanes...)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(prevFbo))
+	gl.Viewport(viewport[0], viewport[1], viewport[2], viewport[3])
+}
+
+// Draw the water, lit by sun, after the rest of the scene.
+func (water *WaterPlane) Draw(view, projection mgl32.Mat4, cameraPos mgl32.Vec3, sun DirectionalLight) {
+	model := mgl32.Translate3D(water.Center.X(), water.Height, water.Center.Y()).
+		Mul4(mgl32.Scale3D(water.Size, 1, water.Size))
+	tint := water.Tint.Vec4()
+	lightDir := sun.Direction.Normalize()
+	lightColor := mgl32.Vec3{sun.Color.R, sun.Color.G, sun.Color.B}
+
+	waterProgram.Use()
+	waterProgram.Vertex().SetMat4("projection", 1, &projection)
+	waterProgram.Vertex().SetMat4("view", 1, &view)
+	waterProgram.Vertex().SetMat4("model", 1, &model)
+	waterProgram.Vertex().SetFloat("tiling", 1, &water.Tiling)
+	waterProgram.Fragment().SetFloat("moveFactor", 1, &water.moveFactor)
+	waterProgram.Fragment().SetFloat("waveStrength", 1, &water.WaveStrength)
+	waterProgram.Fragment().SetVec4("tint", 1, &tint)
+	waterProgram.Fragment().SetVec3("lightDirection", 1, &lightDir)
+	waterProgram.Fragment().SetVec3("lightColor", 1, &lightColor)
+	waterProgram.Fragment().SetVec3("cameraPos", 1, &cameraPos)
+
+	water.Reflection.ColorBuffer.Bind(0)
+	water.Refraction.ColorBuffer.Bind(1)
+	water.DuDv.Bind(2)
+	water.Normal.Bind(3)
+	gl.ActiveTexture(gl.TEXTURE0)
+
+	gl.BindVertexArray(water.vao.ID)
+	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(waterVertices)/3))
+	countDraw(gl.TRIANGLES, int32(len(waterVertices)/3))
+	gl.BindVertexArray(0)
+}
+
+const waterVertexShader = `#version 330 core
+in vec3 aPos;
+
+uniform mat4 projection;
+uniform mat4 view;
+uniform mat4 model;
+uniform float tiling;
+
+out vec4 ClipSpace;
+out vec2 TexCoords;
+out vec3 WorldPos;
+
+void main()
+{
+    vec4 world = model * vec4(aPos, 1.0);
+    WorldPos = world.xyz;
+    TexCoords = world.xz * tiling;
+    ClipSpace = projection * view * world;
+    gl_Position = ClipSpace;
+}`
+
+const waterFragmentShader = `#version 330 core
+` + FogShaderSource + `
+uniform sampler2D reflection;
+uniform sampler2D refraction;
+uniform sampler2D dudvMap;
+uniform sampler2D normalMap;
+uniform float moveFactor;
+uniform float waveStrength;
+uniform vec4 tint;
+uniform vec3 lightDirection;
+uniform vec3 lightColor;
+uniform vec3 cameraPos;
+
+in vec4 ClipSpace;
+in vec2 TexCoords;
+in vec3 WorldPos;
+
+out vec4 FragColor;
+
+void main()
+{
+    // screen position, where the reflection and refraction were drawn
+    vec2 ndc = ClipSpace.xy / ClipSpace.w * 0.5 + 0.5;
+
+    // two scrolling samples of the dudv map make the ripples
+    vec2 distorted = texture(dudvMap, vec2(TexCoords.x + moveFactor, TexCoords.y)).rg * 0.1;
+    distorted = TexCoords + vec2(distorted.x, distorted.y + moveFactor);
+    vec2 distortion = (texture(dudvMap, distorted).rg * 2.0 - 1.0) * waveStrength;
+
+    vec2 coords = clamp(ndc + distortion, 0.001, 0.999);
+    vec3 reflected = texture(reflection, coords).rgb;
+    vec3 refracted = texture(refraction, coords).rgb;
+
+    vec4 n = texture(normalMap, distorted);
+    vec3 normal = normalize(vec3(n.r * 2.0 - 1.0, n.b * 3.0, n.g * 2.0 - 1.0));
+
+    // more reflective when seen at a low angle
+    vec3 toCamera = normalize(cameraPos - WorldPos);
+    float fresnel = pow(max(dot(toCamera, normal), 0.0), 0.8);
+    vec3 color = mix(reflected, refracted, fresnel);
+
+    vec3 halfway = normalize(toCamera - lightDirection);
+    float specular = pow(max(dot(normal, halfway), 0.0), 64.0);
+    color += lightColor * specular * 0.5;
+
+    color = mix(color, tint.rgb, tint.a);
+    FragColor = vec4(applyFog(color, WorldPos, cameraPos), 1.0);
+}`
@@ -0,0 +1,459 @@
+//go:build !egl
+
+package sgl
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// SetAspectRatio constrains the aspect ratio of the window's content area
+// (in windowed mode) to num:den, eg 16:9. Values of 0 remove the constraint.
+// The window is resized immediately if necessary.
+func (platform *Window) SetAspectRatio(num, den int) {
+	if num <= 0 || den <= 0 {
+		num, den = glfw.DontCare, glfw.DontCare
+	}
+	platform.GlfwWindow.SetAspectRatio(num, den)
+	platform.syncDimensions()
+}
+
+// SetSizeLimits constrains the size of the window's content area (in windowed
+// mode). Values of 0 mean no limit. The window is resized immediately if
+// necessary.
+func (platform *Window) SetSizeLimits(minW, minH, maxW, maxH int) {
+	limit := func(v int) int {
+		if v <= 0 {
+			return glfw.DontCare
+		}
+		return v
+	}
+	platform.GlfwWindow.SetSizeLimits(limit(minW), limit(minH), limit(maxW), limit(maxH))
+	platform.syncDimensions()
+}
+
+// WindowHint sets glfw hints for creating the window, such as for a newer
+// opengl context or a multisampled framebuffer. Hints are reset once the
+// window is created, so they only apply to the window they're passed to.
+type WindowHint func(*windowHints) error
+
+// WindowSetting is a WindowOption or a WindowHint, for NewWindow().
+type WindowSetting interface {
+	isWindowSetting()
+}
+
+func (WindowOption) isWindowSetting() {}
+func (WindowHint) isWindowSetting()   {}
+
+// windowHint is a glfw hint for the next window created, with the value to
+// reset it to afterwards.
+type windowHint struct {
+	hint         glfw.Hint
+	value, reset int
+}
+
+// windowHints are the hints collected for one NewWindow() call.
+type windowHints struct {
+	hints []windowHint
+	setup []WindowOption // called once the window is created, before options
+}
+
+// set sets hint to value for creating the window.
+func (h *windowHints) set(hint glfw.Hint, value, reset int) {
+	h.hints = append(h.hints, windowHint{hint, value, reset})
+}
+
+// after adds setup to be done once the window is created, such as enabling
+// what was hinted for.
+func (h *windowHints) after(setup WindowOption) {
+	h.setup = append(h.setup, setup)
+}
+
+// apply sets the hints in glfw.
+func (h *windowHints) apply() {
+	for _, wh := range h.hints {
+		glfw.WindowHint(wh.hint, wh.value)
+	}
+}
+
+// reset resets the hints in glfw, so they only apply to this window.
+func (h *windowHints) reset() {
+	for _, wh := range h.hints {
+		glfw.WindowHint(wh.hint, wh.reset)
+	}
+}
+
+// UseAspectRatio is an option to constrain the window's aspect ratio.
+// See Window.SetAspectRatio().
+func UseAspectRatio(num, den int) WindowOption {
+	return func(win *Window) error {
+		if num < 0 || den < 0 {
+			return fmt.Errorf("invalid aspect ratio %d:%d", num, den)
+		}
+		win.SetAspectRatio(num, den)
+		return nil
+	}
+}
+
+// UseGLVersion is an option to ask for an opengl context of at least
+// major.minor, such as 4.3 for compute shaders and storage buffers, rather
+// than the default 3.3. Window creation fails if the driver can't provide
+// it. The gl package bound is still 3.3, so newer functions need bindings
+// of their own. Use Window.ContextVersion() to see what was given.
+func UseGLVersion(major, minor int) WindowHint {
+	return func(h *windowHints) error {
+		if major < 3 || (major == 3 && minor < 3) {
+			return fmt.Errorf("opengl %d.%d is older than the 3.3 sgl needs", major, minor)
+		}
+		h.set(glfw.ContextVersionMajor, major, 3)
+		h.set(glfw.ContextVersionMinor, minor, 3)
+		return nil
+	}
+}
+
+// UseCompatProfile is an option to ask for a compatibility profile context,
+// which keeps deprecated functions, rather than the default core profile.
+// macOS only supports the core profile for versions after 2.1.
+func UseCompatProfile() WindowHint {
+	return func(h *windowHints) error {
+		h.set(glfw.OpenGLProfile, glfw.OpenGLCompatProfile, glfw.OpenGLCoreProfile)
+		h.set(glfw.OpenGLForwardCompatible, glfw.False, glfw.True)
+		return nil
+	}
+}
+
+// UseDebugContext is an option to ask for a debug context, in which the
+// driver checks and reports more errors, at some cost in speed.
+func UseDebugContext() WindowHint {
+	return func(h *windowHints) error {
+		h.set(glfw.OpenGLDebugContext, glfw.True, glfw.False)
+		return nil
+	}
+}
+
+// ContextVersion gets the version of the window's opengl context, which may
+// be newer than the one asked for.
+func (platform *Window) ContextVersion() (major, minor int) {
+	return platform.GlfwWindow.GetAttrib(glfw.ContextVersionMajor),
+		platform.GlfwWindow.GetAttrib(glfw.ContextVersionMinor)
+}
+
+// DebugContext returns true if the window has a debug context.
+func (platform *Window) DebugContext() bool {
+	return platform.GlfwWindow.GetAttrib(glfw.OpenGLDebugContext) == glfw.True
+}
+
+// UseMSAA is an option to give the window's framebuffer samples per pixel
+// for multisample anti-aliasing, and turn it on. The driver may give a
+// different number, or none; use Window.Samples() to see what was given.
+func UseMSAA(samples int) WindowHint {
+	return func(h *windowHints) error {
+		if samples < 0 {
+			return fmt.Errorf("invalid msaa samples %d", samples)
+		}
+		h.set(glfw.Samples, samples, 0)
+		h.after(func(win *Window) error {
+			setEnabled(gl.MULTISAMPLE, win.Samples() > 0)
+			return nil
+		})
+		return nil
+	}
+}
+
+// Samples gets the number of samples per pixel of the window's framebuffer,
+// or 0 if it isn't multisampled.
+func (platform *Window) Samples() int {
+	var fbo, samples int32
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &fbo)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.GetIntegerv(gl.SAMPLES, &samples)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(fbo))
+	return int(samples)
+}
+
+// UseSRGB is an option to give the window an sRGB capable framebuffer and
+// turn on sRGB encoding, so shaders can output linear colors, which are
+// converted to sRGB when written. Blending is then done in linear space,
+// too. Textures of sRGB images should then be stored as gl.SRGB8_ALPHA8 so
+// they're read as linear. imgui isn't encoded; see imguiData.EncodeSRGB.
+func UseSRGB() WindowHint {
+	return func(h *windowHints) error {
+		h.set(glfw.SRGBCapable, glfw.True, glfw.False)
+		h.after(func(win *Window) error {
+			win.SetSRGB(true)
+			return nil
+		})
+		return nil
+	}
+}
+
+// SetSRGB turns sRGB encoding of the framebuffer on or off. Without
+// UseSRGB(), the window's framebuffer may not support it.
+func (platform *Window) SetSRGB(enabled bool) {
+	setEnabled(gl.FRAMEBUFFER_SRGB, enabled)
+}
+
+// SRGB returns true if sRGB encoding is on.
+func (platform *Window) SRGB() bool {
+	return gl.IsEnabled(gl.FRAMEBUFFER_SRGB)
+}
+
+// UseSizeLimits is an option to constrain the window's size.
+// See Window.SetSizeLimits().
+func UseSizeLimits(minW, minH, maxW, maxH int) WindowOption {
+	return func(win *Window) error {
+		if (maxW > 0 && minW > maxW) || (maxH > 0 && minH > maxH) {
+			return fmt.Errorf("invalid size limits: min %dx%d, max %dx%d", minW, minH, maxW, maxH)
+		}
+		win.SetSizeLimits(minW, minH, maxW, maxH)
+		return nil
+	}
+}
+
+// syncDimensions updates Dimensions with the window's actual size, since the
+// size callback isn't called until events are next polled.
+func (platform *Window) syncDimensions() {
+	if !platform.Dimensions.Fullscreen {
+		platform.Dimensions.W, platform.Dimensions.H = platform.GlfwWindow.GetSize()
+	}
+}
+
+// UseIdleThrottle is an option to limit the frame rate to idleFPS while the
+// window is minimized and, if unfocused is true, while it doesn't have the
+// input focus. This keeps background tools from using a whole cpu core.
+// See Window.IdleFPS.
+func UseIdleThrottle(idleFPS float64, unfocused bool) WindowOption {
+	return func(win *Window) error {
+		if idleFPS < 0 {
+			return fmt.Errorf("invalid idle fps %f", idleFPS)
+		}
+		win.IdleFPS = idleFPS
+		win.ThrottleUnfocused = unfocused
+		return nil
+	}
+}
+
+// Minimized returns true if the window is minimized (iconified). Nothing
+// drawn is visible, so drawing may be skipped.
+func (platform *Window) Minimized() bool {
+	return platform.GlfwWindow.GetAttrib(glfw.Iconified) == glfw.True
+}
+
+// Focused returns true if the window has the input focus.
+func (platform *Window) Focused() bool {
+	return platform.GlfwWindow.GetAttrib(glfw.Focused) == glfw.True
+}
+
+// idle returns true if the frame rate should be limited to IdleFPS.
+func (platform *Window) idle() bool {
+	if platform.IdleFPS <= 0 {
+		return false
+	}
+	return platform.Minimized() || (platform.ThrottleUnfocused && !platform.Focused())
+}
+
+// throttle waits until 1/IdleFPS seconds have passed since the previous
+// frame began, while the window is idle. Events are processed while waiting,
+// and waiting stops early if the window is no longer idle.
+func (platform *Window) throttle() {
+	if !platform.idle() {
+		return
+	}
+	deadline := platform.Clock.Now.Add(time.Duration(float64(time.Second) / platform.IdleFPS))
+	for remaining := time.Until(deadline); remaining > 0; remaining = time.Until(deadline) {
+		glfw.WaitEventsTimeout(remaining.Seconds())
+		if !platform.idle() || platform.ShouldClose() {
+			return
+		}
+	}
+}
+
+// UseVSync is an option to set the swap interval. See Window.SetVSync().
+func UseVSync(interval int) WindowOption {
+	return func(win *Window) error {
+		win.SetVSync(interval)
+		return nil
+	}
+}
+
+// SetVSync sets the number of screen refreshes to wait for before swapping
+// buffers. 1, the default, syncs to the monitor's refresh rate, and 0 turns
+// vsync off, so frames are drawn as fast as possible, or at the rate set by
+// SetTargetFPS(). -1 requests adaptive vsync, where late frames are swapped
+// immediately rather than waiting for the next refresh, if the driver
+// supports it.
+func (platform *Window) SetVSync(interval int) {
+	assertGLThread()
+	glfw.SwapInterval(interval)
+	platform.swapInterval = interval
+}
+
+// VSync gets the swap interval set by SetVSync().
+func (platform *Window) VSync() int { return platform.swapInterval }
+
+// UseTargetFPS is an option to limit the frame rate. See
+// Window.SetTargetFPS().
+func UseTargetFPS(fps float64) WindowOption {
+	return func(win *Window) error {
+		if fps < 0 {
+			return fmt.Errorf("invalid target fps %f", fps)
+		}
+		win.SetTargetFPS(fps)
+		return nil
+	}
+}
+
+// SetTargetFPS limits the frame rate to fps while vsync is off (see
+// SetVSync()). BeginFrame() waits until 1/fps seconds after the previous
+// frame began, before updating the Clock, so Clock.DeltaT includes the wait.
+// 0 removes the limit.
+func (platform *Window) SetTargetFPS(fps float64) {
+	if fps < 0 {
+		fps = 0
+	}
+	platform.targetFPS = fps
+}
+
+// TargetFPS gets the frame rate limit set by SetTargetFPS().
+func (platform *Window) TargetFPS() float64 { return platform.targetFPS }
+
+// frameLimitSpin is how long before a frame is due that limitFrameRate()
+// stops sleeping and spins, since sleeps can overshoot by a millisecond or
+// more.
+const frameLimitSpin = 2 * time.Millisecond
+
+// limitFrameRate waits until 1/targetFPS seconds have passed since the
+// previous frame began, if vsync is off and there's a target. It sleeps for
+// most of the wait, then spins for the rest to be precise.
+func (platform *Window) limitFrameRate() {
+	if platform.targetFPS <= 0 || platform.swapInterval != 0 {
+		return
+	}
+	deadline := platform.Clock.Now.Add(time.Duration(float64(time.Second) / platform.targetFPS))
+	if remaining := time.Until(deadline) - frameLimitSpin; remaining > 0 {
+		time.Sleep(remaining)
+	}
+	for time.Now().Before(deadline) {
+		runtime.Gosched()
+	}
+}
+
+// number of frames drawn after an event or Invalidate() in event driven mode.
+// imgui needs a couple of frames to settle after input (eg for hover state).
+const eventDrivenSettleFrames = 3
+
+// UseEventDriven is an option to start the window in event driven mode.
+// See Window.SetEventDriven().
+func UseEventDriven(timeout float64) WindowOption {
+	return func(win *Window) error {
+		win.SetEventDriven(true, timeout)
+		return nil
+	}
+}
+
+// SetEventDriven enables or disables event driven mode. In event driven mode,
+// BeginFrame() waits for input or a call to Invalidate() before starting a
+// new frame, rather than running continuously. This greatly reduces cpu and gpu
+// use for tools that only change in response to the user. If timeout (seconds)
+// is greater than 0, a frame is drawn at least that often anyway.
+//
+// Anything animating on its own must call Invalidate() each frame until it
+// finishes. Clock.DeltaT may be large after waiting.
+func (platform *Window) SetEventDriven(enabled bool, timeout float64) {
+	platform.eventDriven = enabled
+	platform.eventTimeout = timeout
+	platform.Invalidate()
+}
+
+// Invalidate requests that a new frame be drawn when in event driven mode.
+// It may be called from any goroutine.
+func (platform *Window) Invalidate() {
+	atomic.StoreInt32(&platform.redrawFrames, eventDrivenSettleFrames)
+	glfw.PostEmptyEvent()
+}
+
+// pollEvents processes events, first waiting for one if the window is in event
+// driven mode and no frames have been requested.
+func (platform *Window) pollEvents() {
+	if !platform.eventDriven || atomic.AddInt32(&platform.redrawFrames, -1) >= 0 {
+		platform.PollEvents()
+		return
+	}
+
+	if platform.eventTimeout > 0 {
+		glfw.WaitEventsTimeout(platform.eventTimeout)
+	} else {
+		glfw.WaitEvents()
+	}
+	atomic.StoreInt32(&platform.redrawFrames, eventDrivenSettleFrames-1)
+}
+
+// UseProfiler is an option that creates a Profiler for the window. Frames
+// exceeding budgetSec seconds are passed to hook, or logged if hook is nil.
+func UseProfiler(budgetSec float64, hook func(FrameReport)) WindowOption {
+	return func(win *Window) error {
+		win.Profiler = NewProfiler(budgetSec)
+		win.Profiler.Hook = hook
+		return nil
+	}
+}
+
+// UseFrameHistory is an option to keep the last size frames at scale of the
+// framebuffer's size, available as Window.History. Frames are kept before
+// imgui is rendered, so the gui isn't in them.
+func UseFrameHistory(size int, scale float32) WindowOption {
+	return func(win *Window) error {
+		if size <= 0 || scale <= 0 || scale > 1 {
+			return fmt.Errorf("invalid frame history size %d or scale %g", size, scale)
+		}
+		win.History = NewFrameHistory(size, scale)
+		return nil
+	}
+}
+
+// UseFrameStream is an option to serve the window's frames at address, a
+// loopback address such as "localhost:8080", available as Window.Stream.
+// Frames are read back only while someone is watching, but then each one
+// stalls the gpu briefly.
+func UseFrameStream(address string, quality int, maxFps float64) WindowOption {
+	return UseFrameStreamToken(address, "", quality, maxFps)
+}
+
+// UseFrameStreamToken is an option like UseFrameStream(), except that the
+// stream requires token. See FrameStream.ListenToken().
+func UseFrameStreamToken(address, token string, quality int, maxFps float64) WindowOption {
+	return func(win *Window) error {
+		win.Stream = NewFrameStream(quality, maxFps)
+		return win.Stream.ListenToken(address, token)
+	}
+}
+
+// WaitForLoad runs a render loop showing a loading screen until p is
+// complete, keeping the window responsive. draw is called each frame to draw
+// the screen. If draw is nil, the window must use imgui, and ProgressGui() is
+// shown. Returns p.Err(), or an error if the window was closed first.
+func (platform *Window) WaitForLoad(p *LoadProgress, draw func(p *LoadProgress)) error {
+	if draw == nil {
+		if !platform.CanUseGui() {
+			return fmt.Errorf("no loading screen: window doesn't use imgui")
+		}
+		draw = func(p *LoadProgress) {
+			platform.RenderImgui(func() { ProgressGui("Loading", p) })
+		}
+	}
+
+	for !p.Complete() {
+		if !platform.BeginFrame() {
+			return fmt.Errorf("window closed while loading")
+		}
+		platform.ClearBuffers()
+		draw(p)
+		platform.EndFrame()
+	}
+	return p.Err()
+}